@@ -3,6 +3,9 @@
 package main
 
 import (
+	"flag"               // 导入命令行参数解析包，用于子命令参数
+	"fmt"                // 导入格式化输出包，用于输出迁移结果
+	"os"                 // 导入系统包，读取命令行参数
 	Init "vulnmain/Init" // 导入项目初始化包，包含配置和数据库初始化功能
 	"vulnmain/models"    // 导入数据模型包，包含数据表结构定义
 	"vulnmain/routers"   // 导入路由包，包含API路由配置
@@ -37,6 +40,32 @@ func main() {
 		g.Log().Fatalf("初始化默认数据失败: %v", err)
 	}
 
+	// 迁移旧版本通过静态目录引用的漏洞图片，迁移失败不影响系统启动
+	if err := services.MigrateLegacyVulnImages(); err != nil {
+		g.Log().Errorf("迁移漏洞图片失败: %v", err)
+	}
+
+	// 为历史漏洞补算指纹，用于重复和回归检测
+	if err := services.BackfillVulnFingerprints(); err != nil {
+		g.Log().Errorf("补算漏洞指纹失败: %v", err)
+	}
+
+	// 为历史漏洞补算风险优先级评分
+	if err := services.BackfillVulnRiskScores(); err != nil {
+		g.Log().Errorf("补算漏洞风险优先级失败: %v", err)
+	}
+
+	// 为历史漏洞按漏洞类型和CWE编号匹配漏洞分类
+	if err := services.BackfillVulnCategories(); err != nil {
+		g.Log().Errorf("匹配漏洞分类失败: %v", err)
+	}
+
+	// 存储迁移子命令：vulnmain migrate-storage -to s3 [-delete-source]
+	if len(os.Args) > 1 && os.Args[1] == "migrate-storage" {
+		runStorageMigration(os.Args[2:])
+		return
+	}
+
 	// 创建Gin框架的默认路由引擎实例
 	r := gin.Default()
 
@@ -64,3 +93,30 @@ func main() {
 	//如果配置文件中没有指定端口，则使用默认的8080端口启动HTTP服务器
 	panic(r.Run())
 }
+
+// runStorageMigration函数执行存储迁移子命令
+// 将FileStorage中记录的文件迁移到目标存储后端，完成后退出程序
+func runStorageMigration(args []string) {
+	flags := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	target := flags.String("to", "", "目标存储后端：local、s3、webdav")
+	deleteSource := flags.Bool("delete-source", false, "迁移成功后删除源文件")
+	flags.Parse(args)
+
+	if *target == "" {
+		fmt.Println("用法: vulnmain migrate-storage -to <local|s3|webdav> [-delete-source]")
+		os.Exit(2)
+	}
+
+	result, err := services.MigrateStorage(*target, *deleteSource)
+	if err != nil {
+		g.Log().Fatalf("存储迁移失败: %v", err)
+	}
+
+	fmt.Printf("存储迁移完成：共%d个文件，成功%d个，失败%d个\n", result.Total, result.Migrated, result.Failed)
+	for _, msg := range result.Errors {
+		fmt.Println("  " + msg)
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
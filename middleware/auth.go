@@ -88,6 +88,50 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// StreamAuthMiddleware函数创建实时推送接口的认证中间件
+// 浏览器的EventSource无法设置请求头，因此允许通过查询参数ticket传递短时效推送票据，
+// 票据由 POST /api/stream/ticket 签发；长期有效的JWT令牌只能放在请求头中，避免写入访问日志和浏览器历史
+func StreamAuthMiddleware() gin.HandlerFunc {
+	jwtAuth := JWTAuthMiddleware()
+	return func(c *gin.Context) {
+		// 请求头中有令牌时按JWT认证
+		if c.GetHeader("Authorization") != "" {
+			jwtAuth(c)
+			return
+		}
+
+		userID, err := utils.NewResourceSigner().VerifyStreamTicket(c.Query("ticket"))
+		if err != nil {
+			// 票据缺失、过期或签名无效，返回401未授权错误
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": 401,
+				"msg":  "无效的推送票据",
+			})
+			c.Abort() // 终止请求处理
+			return
+		}
+
+		// 与JWTAuthMiddleware相同，校验用户存在且处于启用状态
+		db := Init.GetDB()
+		var user models.User
+		if err := db.Preload("Role.Permissions").Where("id = ?", userID).First(&user).Error; err != nil || user.Status != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": 401,
+				"msg":  "用户不存在或已被禁用",
+			})
+			c.Abort() // 终止请求处理
+			return
+		}
+
+		c.Set("user", &user)
+		c.Set("user_id", user.ID)
+		c.Set("username", user.Username)
+		c.Set("role_code", user.Role.Code)
+
+		c.Next()
+	}
+}
+
 // PermissionMiddleware函数创建权限验证中间件
 // 该中间件检查当前用户是否拥有指定的权限，如果没有则拒绝访问
 func PermissionMiddleware(permissionCode string) gin.HandlerFunc {
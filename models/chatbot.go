@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ProjectChatBot 项目群机器人配置表
+// 项目配置了某个平台的群机器人后，该项目的通知发送到项目专属群，不再发送到系统设置中的全局群机器人
+type ProjectChatBot struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	ProjectID  uint      `gorm:"not null;unique_index:idx_project_platform" json:"project_id"`       // 项目ID
+	Platform   string    `gorm:"size:20;not null;unique_index:idx_project_platform" json:"platform"` // 平台：dingtalk、wecom、feishu、slack
+	WebhookURL string    `gorm:"size:500;not null" json:"-"`                                         // 机器人Webhook地址，包含访问令牌，不在接口中返回
+	Secret     string    `gorm:"size:255" json:"-"`                                                  // 加签密钥（钉钉、飞书），不在接口中返回
+	IsActive   bool      `gorm:"default:true" json:"is_active"`                                      // 是否启用，停用后该项目在此平台不发送任何通知
+	CreatedBy  uint      `json:"created_by"`                                                         // 创建人ID
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
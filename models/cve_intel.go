@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+)
+
+// CVERecord 本地CVE情报表，数据来自NVD JSON 2.0数据源的离线镜像
+type CVERecord struct {
+	ID             uint       `gorm:"primary_key" json:"id"`
+	CVEID          string     `gorm:"size:30;not null;unique_index" json:"cve_id"` // CVE编号
+	Description    string     `gorm:"type:text" json:"description"`                // 漏洞描述，取NVD英文描述
+	CWEIDs         string     `gorm:"size:255" json:"cwe_ids"`                     // CWE编号，多个用逗号分隔
+	CVSSVector     string     `gorm:"size:255" json:"cvss_vector"`                 // CVSS向量，优先取NVD给出的CVSS 3.1评分
+	CVSSScore      float64    `json:"cvss_score"`                                  // CVSS基础评分
+	CVSSSeverity   string     `gorm:"size:20" json:"cvss_severity"`                // NVD给出的严重程度
+	References     string     `gorm:"type:text" json:"references"`                 // 参考链接，多个链接用换行分隔
+	PublishedAt    *time.Time `json:"published_at"`                                // NVD发布时间
+	LastModifiedAt *time.Time `json:"last_modified_at"`                            // NVD最后修改时间
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CVERecord) TableName() string {
+	return "cve_records"
+}
+
+// KEVEntry CISA已知被利用漏洞目录(KEV)条目
+type KEVEntry struct {
+	ID                uint       `gorm:"primary_key" json:"id"`
+	CVEID             string     `gorm:"size:30;not null;unique_index" json:"cve_id"` // CVE编号
+	VendorProject     string     `gorm:"size:255" json:"vendor_project"`              // 厂商
+	Product           string     `gorm:"size:255" json:"product"`                     // 产品
+	VulnerabilityName string     `gorm:"size:500" json:"vulnerability_name"`          // 漏洞名称
+	ShortDescription  string     `gorm:"type:text" json:"short_description"`          // 简要描述
+	RequiredAction    string     `gorm:"type:text" json:"required_action"`            // CISA要求的处置措施
+	DateAdded         *time.Time `json:"date_added"`                                  // 列入目录的日期
+	DueDate           *time.Time `json:"due_date"`                                    // CISA要求的修复期限
+	KnownRansomware   bool       `gorm:"default:false" json:"known_ransomware"`       // 是否已知被勒索软件利用
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (KEVEntry) TableName() string {
+	return "kev_entries"
+}
+
+// EPSSScore FIRST EPSS漏洞利用预测评分
+type EPSSScore struct {
+	ID         uint       `gorm:"primary_key" json:"id"`
+	CVEID      string     `gorm:"size:30;not null;unique_index" json:"cve_id"` // CVE编号
+	Score      float64    `json:"score"`                                       // 未来30天内被利用的概率，0-1
+	Percentile float64    `json:"percentile"`                                  // 评分在全部CVE中的百分位，0-1
+	ScoreDate  *time.Time `json:"score_date"`                                  // 评分日期
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (EPSSScore) TableName() string {
+	return "epss_scores"
+}
+
+// CVEFeedImport CVE情报数据源导入记录，文件大小和修改时间未变化时跳过重复导入
+type CVEFeedImport struct {
+	ID          uint       `gorm:"primary_key" json:"id"`
+	Source      string     `gorm:"size:20;index" json:"source"`     // 数据源：nvd、kev、epss
+	FileName    string     `gorm:"size:255;index" json:"file_name"` // 数据源文件名
+	FileSize    int64      `json:"file_size"`                       // 文件大小
+	FileModTime time.Time  `json:"file_mod_time"`                   // 文件修改时间
+	Status      string     `gorm:"size:20" json:"status"`           // 导入状态：success成功、failed失败
+	Records     int        `json:"records"`                         // 导入的记录数
+	Error       string     `gorm:"type:text" json:"error"`          // 失败原因
+	StartedAt   time.Time  `json:"started_at"`                      // 开始导入时间
+	FinishedAt  *time.Time `json:"finished_at"`                     // 完成导入时间
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (CVEFeedImport) TableName() string {
+	return "cve_feed_imports"
+}
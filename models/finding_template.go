@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// 漏洞模板可见范围
+const (
+	FindingTemplateScopePersonal = "personal" // 个人模板，仅创建人可见
+	FindingTemplateScopeShared   = "shared"   // 共享模板，所有可以提交漏洞的用户可见
+)
+
+// FindingTemplate 漏洞模板，用于常见漏洞（如缺少安全响应头、弱TLS配置）的快速提交。
+// 文本字段支持{{asset.name}}、{{vuln_url}}等占位符，提交漏洞时按所选资产和漏洞地址替换
+type FindingTemplate struct {
+	ID            uint          `gorm:"primary_key" json:"id"`
+	Name          string        `gorm:"size:100;not null" json:"name"`                                           // 模板名称
+	Scope         string        `gorm:"size:20;default:'personal';index" json:"scope"`                           // 可见范围：personal个人、shared共享
+	OwnerID       uint          `gorm:"index" json:"owner_id"`                                                   // 创建人ID，个人模板仅创建人可见
+	Owner         User          `gorm:"foreignkey:OwnerID;save_associations:false" json:"owner"`                 // 创建人
+	Title         string        `gorm:"size:255" json:"title"`                                                   // 漏洞标题
+	VulnType      string        `gorm:"size:50" json:"vuln_type"`                                                // 漏洞类型
+	CategoryID    *uint         `json:"category_id"`                                                             // 漏洞分类，为空时提交漏洞按漏洞类型自动归类
+	Category      *VulnCategory `gorm:"foreignkey:CategoryID;save_associations:false" json:"category,omitempty"` // 漏洞分类对象
+	Severity      string        `gorm:"size:20" json:"severity"`                                                 // 严重程度
+	CVSSVector    string        `gorm:"size:255" json:"cvss_vector"`                                             // CVSS向量
+	CVSSScore     float64       `json:"cvss_score"`                                                              // CVSS评分
+	Description   string        `gorm:"type:text" json:"description"`                                            // 漏洞描述
+	POC           string        `gorm:"type:text" json:"poc"`                                                    // 复现步骤
+	Solution      string        `gorm:"type:text" json:"solution"`                                               // 解决方案
+	FixSuggestion string        `gorm:"type:text" json:"fix_suggestion"`                                         // 修复建议
+	References    string        `gorm:"type:text" json:"references"`                                             // 参考链接
+	Tags          string        `gorm:"size:500" json:"tags"`                                                    // 漏洞标签
+	SourceVulnID  *uint         `json:"source_vuln_id"`                                                          // 由漏洞转换而来时的原漏洞ID
+	UsageCount    int           `gorm:"default:0" json:"usage_count"`                                            // 使用次数
+	LastUsedAt    *time.Time    `json:"last_used_at"`                                                            // 最近使用时间
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	DeletedAt     *time.Time    `sql:"index" json:"deleted_at"`
+}
+
+// TableName 指定表名
+func (FindingTemplate) TableName() string {
+	return "finding_templates"
+}
+
+// FindingTemplateUsage 漏洞模板使用记录，每次使用模板提交漏洞记录一条
+type FindingTemplateUsage struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	TemplateID uint      `gorm:"index" json:"template_id"` // 模板ID
+	VulnID     uint      `gorm:"index" json:"vuln_id"`     // 使用模板提交的漏洞ID
+	UserID     uint      `gorm:"index" json:"user_id"`     // 提交人ID
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (FindingTemplateUsage) TableName() string {
+	return "finding_template_usages"
+}
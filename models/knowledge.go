@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 知识库文章状态
+const (
+	KnowledgeStatusDraft     = "draft"     // 草稿，只有编辑者可见，不用于预填修复建议
+	KnowledgeStatusPublished = "published" // 已发布
+)
+
+// KnowledgeArticle 知识库文章，按漏洞分类或CWE编写的修复指南，正文各部分均为Markdown格式
+type KnowledgeArticle struct {
+	ID          uint          `gorm:"primary_key" json:"id"`
+	Title       string        `gorm:"size:255;not null" json:"title"`                                          // 标题
+	CategoryID  *uint         `gorm:"index" json:"category_id"`                                                // 适用的漏洞分类，下级分类没有文章时沿用上级分类的文章
+	Category    *VulnCategory `gorm:"foreignkey:CategoryID;save_associations:false" json:"category,omitempty"` // 漏洞分类对象
+	CWEID       string        `gorm:"size:20;index" json:"cwe_id"`                                             // 适用的CWE编号
+	VulnTypes   string        `gorm:"size:500" json:"vuln_types"`                                              // 适用的漏洞类型，多个用逗号分隔，优先于分类匹配
+	Description string        `gorm:"type:text" json:"description"`                                            // 漏洞描述
+	Impact      string        `gorm:"type:text" json:"impact"`                                                 // 危害影响
+	Remediation string        `gorm:"type:text" json:"remediation"`                                            // 修复建议，创建漏洞未填写修复建议时预填
+	FixExamples string        `gorm:"type:text" json:"fix_examples"`                                           // 分语言的修复示例代码
+	References  string        `gorm:"type:text" json:"references"`                                             // 参考链接，多个链接用换行分隔
+	Tags        string        `gorm:"size:500" json:"tags"`                                                    // 标签，用逗号分隔
+	Status      string        `gorm:"size:20;default:'published';index" json:"status"`                         // 状态：draft草稿、published已发布
+	Version     int           `gorm:"default:1" json:"version"`                                                // 当前版本号，每次修改递增
+	AuthorID    uint          `json:"author_id"`                                                               // 创建人ID
+	Author      User          `gorm:"foreignkey:AuthorID;save_associations:false" json:"author"`               // 创建人
+	UpdatedBy   uint          `json:"updated_by"`                                                              // 最后修改人ID
+	Editor      User          `gorm:"foreignkey:UpdatedBy;save_associations:false" json:"editor"`              // 最后修改人
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	DeletedAt   *time.Time    `sql:"index" json:"deleted_at"`
+}
+
+// TableName 指定表名
+func (KnowledgeArticle) TableName() string {
+	return "knowledge_articles"
+}
+
+// KnowledgeArticleVersion 知识库文章的历史版本，每次创建、修改或恢复文章时保存一份完整内容
+type KnowledgeArticleVersion struct {
+	ID          uint      `gorm:"primary_key" json:"id"`
+	ArticleID   uint      `gorm:"index" json:"article_id"`           // 文章ID
+	Version     int       `json:"version"`                           // 版本号
+	Title       string    `gorm:"size:255" json:"title"`             // 标题
+	CategoryID  *uint     `json:"category_id"`                       // 漏洞分类
+	CWEID       string    `gorm:"size:20" json:"cwe_id"`             // CWE编号
+	VulnTypes   string    `gorm:"size:500" json:"vuln_types"`        // 适用的漏洞类型
+	Description string    `gorm:"type:text" json:"description"`      // 漏洞描述
+	Impact      string    `gorm:"type:text" json:"impact"`           // 危害影响
+	Remediation string    `gorm:"type:text" json:"remediation"`      // 修复建议
+	FixExamples string    `gorm:"type:text" json:"fix_examples"`     // 修复示例
+	References  string    `gorm:"type:text" json:"references"`       // 参考链接
+	Tags        string    `gorm:"size:500" json:"tags"`              // 标签
+	Status      string    `gorm:"size:20" json:"status"`             // 保存该版本时的状态
+	ChangeNote  string    `gorm:"size:255" json:"change_note"`       // 修改说明
+	EditorID    uint      `json:"editor_id"`                         // 修改人ID
+	Editor      User      `gorm:"foreignkey:EditorID" json:"editor"` // 修改人
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (KnowledgeArticleVersion) TableName() string {
+	return "knowledge_article_versions"
+}
+
+// KnowledgeArticleRef 漏洞详情中关联的知识库文章
+type KnowledgeArticleRef struct {
+	ID      uint   `json:"id"`
+	Title   string `json:"title"`
+	Version int    `json:"version"`
+}
+
+// KnowledgeFullTextColumns 知识库文章全文索引包含的列，MATCH查询的列必须与索引一致
+const KnowledgeFullTextColumns = "title, description, impact, remediation, fix_examples, tags"
+
+// knowledgeFullTextIndex 知识库文章全文索引名称
+const knowledgeFullTextIndex = "ft_knowledge_articles"
+
+// ensureKnowledgeFullTextIndex 为知识库文章创建使用ngram分词的全文索引，支持中文检索
+func ensureKnowledgeFullTextIndex(db *gorm.DB) error {
+	var count int
+	if err := db.Raw("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+		KnowledgeArticle{}.TableName(), knowledgeFullTextIndex).Row().Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Exec("ALTER TABLE " + KnowledgeArticle{}.TableName() + " ADD FULLTEXT INDEX " + knowledgeFullTextIndex +
+		" (" + KnowledgeFullTextColumns + ") WITH PARSER ngram").Error
+}
@@ -27,9 +27,10 @@ func AutoMigrate() error {
 		&User{},           // 用户表，存储系统用户信息
 
 		// 项目管理相关表
-		&Project{},       // 项目表，存储安全项目信息
-		&ProjectMember{}, // 项目成员关联表，管理项目与用户的关系
-		&ProjectStats{},  // 项目统计表，缓存项目统计数据
+		&Project{},        // 项目表，存储安全项目信息
+		&ProjectMember{},  // 项目成员关联表，管理项目与用户的关系
+		&ProjectStats{},   // 项目统计表，缓存项目统计数据
+		&ProjectChatBot{}, // 项目群机器人表，存储项目专属的群机器人配置
 
 		// 资产管理相关表
 		&AssetGroup{},    // 资产组表，用于资产分组管理
@@ -44,19 +45,51 @@ func AutoMigrate() error {
 		&VulnComment{},          // 漏洞评论表，记录漏洞处理过程中的评论
 		&VulnTimeline{},         // 漏洞时间线表，记录漏洞处理的时间节点
 		&VulnDeadlineReminder{}, // 漏洞截止时间提醒记录表，避免重复发送提醒
+		&VulnEscalation{},       // 漏洞超期升级记录表，避免同一级别重复升级
+		&VulnRelation{},         // 漏洞关系表，记录重复、引起、阻塞和父子关系
+		&Workflow{},             // 漏洞工作流表，按项目类型定义漏洞状态流转
+		&WorkflowState{},        // 工作流状态表
+		&WorkflowTransition{},   // 工作流状态流转表
+		&WorkflowApproval{},     // 状态流转审批记录表，记录多人审批的进度
+		&RiskAcceptance{},       // 风险接受申请表，即风险例外登记册
+		&SLAPolicy{},            // 漏洞修复SLA策略表，按严重程度和资产属性定义修复时限
+
+		// CVE情报相关表，数据来自NVD、CISA KEV和FIRST EPSS的离线镜像
+		&CVERecord{},     // CVE情报表
+		&KEVEntry{},      // CISA已知被利用漏洞目录表
+		&EPSSScore{},     // EPSS漏洞利用预测评分表
+		&CVEFeedImport{}, // CVE情报数据源导入记录表
+
+		// 知识库相关表
+		&KnowledgeArticle{},        // 知识库文章表，按漏洞分类或CWE编写的修复指南
+		&KnowledgeArticleVersion{}, // 知识库文章历史版本表
+
+		// 漏洞模板相关表
+		&FindingTemplate{},      // 漏洞模板表，用于常见漏洞的快速提交
+		&FindingTemplateUsage{}, // 漏洞模板使用记录表
 
 		// 系统管理相关表
-		&SystemConfig{}, // 系统配置表，存储系统配置参数
-		&OperationLog{}, // 操作日志表，记录用户操作行为
-		&Notification{}, // 通知表，存储系统通知信息
-		&FileStorage{},  // 文件存储表，记录上传文件信息
-		&Dictionary{},   // 字典表，存储系统字典数据
-		&WeeklyReport{}, // 周报记录表，存储周报生成和发送记录
+		&SystemConfig{},           // 系统配置表，存储系统配置参数
+		&OperationLog{},           // 操作日志表，记录用户操作行为
+		&Notification{},           // 通知表，存储系统通知信息
+		&NotificationPreference{}, // 通知偏好表，存储用户按事件选择的通知渠道
+		&NotificationSetting{},    // 通知设置表，存储用户的汇总投递方式和个人Webhook
+		&NotificationDigest{},     // 待汇总通知表，存储等待合并发送的邮件和Webhook通知
+		&FileStorage{},            // 文件存储表，记录上传文件信息
+		&Dictionary{},             // 字典表，存储系统字典数据
+		&WeeklyReport{},           // 周报记录表，存储周报生成和发送记录
+		&Webhook{},                // Webhook订阅表，存储出站Webhook配置
+		&WebhookDelivery{},        // Webhook投递记录表，存储推送结果和重试状态
 	).Error; err != nil {
 		// 如果迁移过程中出现错误，返回格式化的错误信息
 		return fmt.Errorf("数据库迁移失败: %v", err)
 	}
 
+	// 知识库全文索引，MySQL不支持ngram分词时搜索退化为模糊匹配，不影响系统启动
+	if err := ensureKnowledgeFullTextIndex(db); err != nil {
+		fmt.Printf("创建知识库全文索引失败，搜索将使用模糊匹配: %v\n", err)
+	}
+
 	// 迁移成功，返回nil
 	return nil
 }
@@ -115,8 +148,14 @@ func InitDefaultData() error {
 		{Name: "分配漏洞", Code: "vuln:assign", Module: "vuln", Action: "assign", Description: "分配漏洞给处理人"},
 		{Name: "复测漏洞", Code: "vuln:retest", Module: "vuln", Action: "retest", Description: "复测已修复的漏洞"},
 		{Name: "修复漏洞", Code: "vuln:fix", Module: "vuln", Action: "fix", Description: "标记漏洞为已修复"},
-		{Name: "忽略漏洞", Code: "vuln:ignore", Module: "vuln", Action: "ignore", Description: "忽略漏洞"},
 		{Name: "修改漏洞状态", Code: "vuln:change_status", Module: "vuln", Action: "change_status", Description: "修改漏洞状态"},
+		{Name: "审核漏洞", Code: "vuln:audit", Module: "vuln", Action: "audit", Description: "审核待确认的漏洞"},
+		{Name: "审批风险接受", Code: "risk:approve", Module: "risk", Action: "approve", Description: "作为审批人批准或拒绝漏洞风险接受申请"},
+		{Name: "查看风险例外", Code: "risk:view", Module: "risk", Action: "view", Description: "查看和导出风险例外登记册"},
+
+		// 知识库模块权限，管理漏洞修复指南
+		{Name: "查看知识库", Code: "knowledge:view", Module: "knowledge", Action: "view", Description: "查看和搜索知识库文章"},
+		{Name: "编辑知识库", Code: "knowledge:edit", Module: "knowledge", Action: "edit", Description: "创建、修改、删除知识库文章和恢复历史版本"},
 
 		// 资产管理模块权限，包含网络资产的管理操作
 		{Name: "查看资产", Code: "asset:view", Module: "asset", Action: "view", Description: "查看资产列表和详情"},
@@ -201,6 +240,8 @@ func InitDefaultData() error {
 		"user:view",                                                                                 // 用户查看权限（查看研发工程师列表等）
 		"vuln:view", "vuln:create", "vuln:edit", "vuln:assign", "vuln:retest", "vuln:change_status", // 漏洞管理权限
 		"asset:view", "asset:create", "asset:edit", "asset:delete", // 资产管理权限（只能管理自己名下的资产）
+		"risk:view", "risk:approve", // 风险例外登记册查看和风险接受审批权限
+		"knowledge:view", "knowledge:edit", // 知识库查看和编辑权限
 	}
 	assignRolePermissions("security_engineer", securityEngineerPermissions)
 
@@ -210,6 +251,7 @@ func InitDefaultData() error {
 		"dashboard:view",                                           // 首页查看权限
 		"project:view",                                             // 项目查看权限（查看自己名下的项目）
 		"vuln:view", "vuln:edit", "vuln:fix", "vuln:change_status", // 漏洞查看、编辑、修复权限（只能处理分配给自己的漏洞）
+		"knowledge:view", // 知识库查看权限
 	}
 	assignRolePermissions("dev_engineer", devEngineerPermissions)
 
@@ -274,6 +316,42 @@ func InitDefaultData() error {
 		{Key: "password.require_special", Value: "false", Type: "bool", Group: "password", Description: "密码需要包含特殊字符", IsPublic: false},
 		{Key: "upload.max_size", Value: "10", Type: "int", Group: "upload", Description: "文件上传最大大小(MB)", IsPublic: true},
 		{Key: "upload.allowed_types", Value: "jpg,jpeg,png", Type: "string", Group: "upload", Description: "允许上传的文件类型", IsPublic: true},
+		{Key: "upload.attachment_max_size", Value: "50", Type: "int", Group: "upload", Description: "漏洞附件上传最大大小(MB)", IsPublic: true},
+		{Key: "upload.attachment_allowed_types", Value: "jpg,jpeg,png,gif,pdf,txt,log,json,xml,har,pcap,pcapng,cap,py,sh,go,java,php,js,html,md,doc,docx,xls,xlsx,zip,7z,tar,gz", Type: "string", Group: "upload", Description: "漏洞附件允许上传的文件类型", IsPublic: true},
+		{Key: "upload.signed_url_expire", Value: "30", Type: "int", Group: "upload", Description: "文件签名链接有效期(分钟)，用于Markdown图片展示", IsPublic: false},
+		{Key: "upload.email_link_expire", Value: "72", Type: "int", Group: "upload", Description: "邮件中文件下载链接有效期(小时)", IsPublic: false},
+
+		// Webhook推送配置
+		{Key: "webhook.max_attempts", Value: "6", Type: "int", Group: "webhook", Description: "Webhook最大投递次数(含首次)，失败后按指数退避重试", IsPublic: false},
+		{Key: "webhook.retry_interval", Value: "30", Type: "int", Group: "webhook", Description: "Webhook首次重试间隔(秒)，之后每次翻倍", IsPublic: false},
+		{Key: "webhook.timeout", Value: "10", Type: "int", Group: "webhook", Description: "Webhook请求超时时间(秒)", IsPublic: false},
+
+		// 群机器人配置，项目可单独配置专属群机器人覆盖以下全局配置
+		{Key: "dingtalk.enabled", Value: "false", Type: "bool", Group: "dingtalk", Description: "启用钉钉群机器人", IsPublic: false},
+		{Key: "dingtalk.webhook_url", Value: "", Type: "string", Group: "dingtalk", Description: "钉钉机器人Webhook地址", IsPublic: false},
+		{Key: "dingtalk.secret", Value: "", Type: "string", Group: "dingtalk", Description: "钉钉机器人加签密钥(SEC开头)", IsPublic: false},
+		{Key: "wecom.enabled", Value: "false", Type: "bool", Group: "wecom", Description: "启用企业微信群机器人", IsPublic: false},
+		{Key: "wecom.webhook_url", Value: "", Type: "string", Group: "wecom", Description: "企业微信机器人Webhook地址", IsPublic: false},
+		{Key: "feishu.enabled", Value: "false", Type: "bool", Group: "feishu", Description: "启用飞书群机器人", IsPublic: false},
+		{Key: "feishu.webhook_url", Value: "", Type: "string", Group: "feishu", Description: "飞书机器人Webhook地址", IsPublic: false},
+		{Key: "feishu.secret", Value: "", Type: "string", Group: "feishu", Description: "飞书机器人签名校验密钥", IsPublic: false},
+		{Key: "slack.enabled", Value: "false", Type: "bool", Group: "slack", Description: "启用Slack Incoming Webhook", IsPublic: false},
+		{Key: "slack.webhook_url", Value: "", Type: "string", Group: "slack", Description: "Slack Incoming Webhook地址", IsPublic: false},
+
+		// 超期升级配置
+		{Key: "escalation.enabled", Value: "true", Type: "bool", Group: "escalation", Description: "启用漏洞超期升级通知", IsPublic: false},
+		{Key: "escalation.levels", Value: "0:assignee,2:project_owner,7:department_head", Type: "string", Group: "escalation", Description: "超期升级级别，格式为超期天数:通知对象，多个级别用逗号分隔；通知对象可选assignee指派人、project_owner项目负责人、department_head部门负责人、super_admin超级管理员", IsPublic: false},
+		{Key: "escalation.department_heads", Value: "", Type: "string", Group: "escalation", Description: "部门负责人，格式为部门名称:用户名，多个部门用逗号分隔；指派人所属部门未配置负责人时升级给超级管理员", IsPublic: false},
+
+		// CVE情报离线数据源配置
+		{Key: "cve_feed.enabled", Value: "true", Type: "bool", Group: "cve_feed", Description: "启用CVE情报定时更新", IsPublic: false},
+		{Key: "cve_feed.directory", Value: "./data/cve-feeds", Type: "string", Group: "cve_feed", Description: "CVE情报数据源目录，放入NVD JSON 2.0(nvdcve-*.json[.gz])、CISA KEV(known_exploited_vulnerabilities*.json)和EPSS(epss*.csv[.gz])文件", IsPublic: false},
+		{Key: "cve_feed.mirror_url", Value: "", Type: "string", Group: "cve_feed", Description: "内部镜像地址，配置后更新前先从镜像下载数据源文件到数据源目录，为空时只导入目录中的文件", IsPublic: false},
+		{Key: "cve_feed.mirror_files", Value: "nvdcve-2.0-modified.json.gz,nvdcve-2.0-recent.json.gz,known_exploited_vulnerabilities.json,epss_scores-current.csv.gz", Type: "string", Group: "cve_feed", Description: "从内部镜像下载的文件名，多个用逗号分隔", IsPublic: false},
+		{Key: "cve_feed.auto_enrich", Value: "true", Type: "bool", Group: "cve_feed", Description: "创建或修改漏洞CVE编号时自动补充描述、CWE、CVSS向量、参考链接和KEV/EPSS信息", IsPublic: false},
+
+		// 风险接受配置
+		{Key: "risk_acceptance.max_days", Value: "180", Type: "int", Group: "risk", Description: "风险接受的最长有效期(天)", IsPublic: false},
 	}
 
 	// 遍历配置列表，检查每个配置是否已存在
@@ -290,6 +368,33 @@ func InitDefaultData() error {
 		// 如果配置已存在，跳过创建，保持现有配置不变
 	}
 
+	// 初始化默认SLA策略，仅在没有任何策略时创建，管理员可在系统配置中调整
+	var slaPolicyCount int64
+	db.Model(&SLAPolicy{}).Count(&slaPolicyCount)
+	if slaPolicyCount == 0 {
+		slaPolicies := []SLAPolicy{
+			{Name: "严重漏洞", Severity: "critical", Days: 3, IsActive: true, Description: "严重漏洞3天内修复"},
+			{Name: "高危漏洞", Severity: "high", Days: 7, IsActive: true, Description: "高危漏洞7天内修复"},
+			{Name: "中危漏洞", Severity: "medium", Days: 30, IsActive: true, Description: "中危漏洞30天内修复"},
+			{Name: "低危漏洞", Severity: "low", Days: 90, IsActive: true, Description: "低危漏洞90天内修复"},
+			{Name: "提示信息", Severity: "info", Days: 180, IsActive: true, Description: "提示信息180天内处理"},
+		}
+		for _, policy := range slaPolicies {
+			if err := db.Create(&policy).Error; err != nil {
+				return fmt.Errorf("初始化SLA策略失败: %v", err)
+			}
+		}
+	}
+
+	// 初始化默认漏洞分类，仅在没有任何分类时创建，管理员可在系统配置中调整
+	var vulnCategoryCount int64
+	db.Model(&VulnCategory{}).Count(&vulnCategoryCount)
+	if vulnCategoryCount == 0 {
+		if err := createVulnCategorySeeds(db, defaultVulnCategories, nil); err != nil {
+			return err
+		}
+	}
+
 	// 所有初始化完成，返回成功
 	return nil
 }
@@ -302,6 +407,7 @@ type WeeklyReport struct {
 	FileName         string    `json:"file_name"`         // PDF文件名
 	FilePath         string    `json:"file_path"`         // PDF文件路径
 	FileSize         int64     `json:"file_size"`         // 文件大小（字节）
+	FileStorageID    uint      `json:"file_storage_id"`   // 文件存储记录ID，文件通过存储后端读取
 	TotalSubmitted   int64     `json:"total_submitted"`   // 本周提交漏洞总数
 	TotalFixed       int64     `json:"total_fixed"`       // 本周修复漏洞总数
 	TotalFixing      int64     `json:"total_fixing"`      // 修复中漏洞数
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// RiskAcceptance 风险接受申请表
+// 研发工程师或安全工程师为暂不修复的漏洞提交风险接受申请，经指定审批人批准后漏洞标记为已忽略，
+// 到期后由定时任务自动恢复为未修复。所有申请构成风险例外登记册，供审计导出
+type RiskAcceptance struct {
+	ID                   uint           `gorm:"primary_key" json:"id"`
+	VulnID               uint           `gorm:"not null;index" json:"vuln_id"`                 // 漏洞ID
+	Vuln                 *Vulnerability `gorm:"foreignkey:VulnID" json:"vuln,omitempty"`       // 漏洞
+	RequesterID          uint           `gorm:"not null" json:"requester_id"`                  // 申请人ID
+	Requester            User           `gorm:"foreignkey:RequesterID" json:"requester"`       // 申请人
+	ApproverID           uint           `gorm:"not null;index" json:"approver_id"`             // 指定的审批人ID
+	Approver             User           `gorm:"foreignkey:ApproverID" json:"approver"`         // 审批人
+	Justification        string         `gorm:"type:text" json:"justification"`                // 接受风险的理由
+	CompensatingControls string         `gorm:"type:text" json:"compensating_controls"`        // 补偿性控制措施
+	ExpiresAt            time.Time      `gorm:"index" json:"expires_at"`                       // 到期时间，到期后漏洞恢复为未修复
+	Status               string         `gorm:"size:20;default:'pending';index" json:"status"` // 状态：pending待审批、approved已批准、rejected已拒绝、cancelled已撤回、revoked已撤销、expired已到期
+	PreviousStatus       string         `gorm:"size:20" json:"previous_status"`                // 批准时漏洞的状态
+	ReviewComment        string         `gorm:"type:text" json:"review_comment"`               // 审批意见
+	ReviewedAt           *time.Time     `json:"reviewed_at"`                                   // 审批时间
+	ClosedAt             *time.Time     `json:"closed_at"`                                     // 撤销或到期时间
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// SLAPolicy 漏洞修复SLA策略表
+// 按严重程度定义修复时限，可以进一步限定资产重要性和所属环境。
+// 漏洞审核通过时匹配条件最具体的启用策略，并据此计算修复截止时间
+type SLAPolicy struct {
+	ID               uint      `gorm:"primary_key" json:"id"`
+	Name             string    `gorm:"size:100;not null" json:"name"`          // 策略名称
+	Severity         string    `gorm:"size:20;not null;index" json:"severity"` // 适用的漏洞严重程度
+	AssetImportance  string    `gorm:"size:20" json:"asset_importance"`        // 适用的资产重要性，为空表示不限
+	AssetEnvironment string    `gorm:"size:50" json:"asset_environment"`       // 适用的资产所属环境，为空表示不限
+	Days             int       `gorm:"not null" json:"days"`                   // 修复时限(天)
+	IsActive         bool      `gorm:"default:true" json:"is_active"`          // 是否启用
+	Description      string    `gorm:"size:255" json:"description"`            // 策略说明
+	CreatedBy        uint      `json:"created_by"`                             // 创建人ID
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -51,19 +51,64 @@ type Notification struct {
 	ReadAt    *time.Time `json:"read_at"`                       // 阅读时间，可为空
 }
 
+// NotificationPreference结构体定义用户通知偏好表的数据模型
+// 每个用户每种事件类型一条记录，选择该事件通过哪些渠道通知，未配置的事件使用系统默认渠道
+type NotificationPreference struct {
+	ID        uint      `gorm:"primary_key" json:"id"`                                          // 偏好唯一标识符，主键
+	UserID    uint      `gorm:"not null;unique_index:idx_user_event" json:"user_id"`            // 用户ID，外键
+	EventType string    `gorm:"size:50;not null;unique_index:idx_user_event" json:"event_type"` // 事件类型：vuln_assigned、vuln_status_changed、vuln_comment、vuln_deadline、project_member_added
+	Email     bool      `json:"email"`                                                          // 是否通过邮件通知
+	InApp     bool      `json:"in_app"`                                                         // 是否通过站内通知
+	Webhook   bool      `json:"webhook"`                                                        // 是否推送到个人Webhook
+	CreatedAt time.Time `json:"created_at"`                                                     // 创建时间，GORM自动管理
+	UpdatedAt time.Time `json:"updated_at"`                                                     // 更新时间，GORM自动管理
+}
+
+// NotificationSetting结构体定义用户通知投递设置表的数据模型
+// 汇总模式只作用于邮件和Webhook渠道，站内通知始终即时写入
+type NotificationSetting struct {
+	ID            uint       `gorm:"primary_key" json:"id"`                          // 设置唯一标识符，主键
+	UserID        uint       `gorm:"not null;unique" json:"user_id"`                 // 用户ID，外键，唯一
+	DigestMode    string     `gorm:"size:20;default:'immediate'" json:"digest_mode"` // 投递方式：immediate即时、hourly每小时汇总、daily每日汇总
+	DigestHour    int        `gorm:"default:9" json:"digest_hour"`                   // 每日汇总的发送时刻，0-23点
+	WebhookURL    string     `gorm:"size:500" json:"webhook_url"`                    // 个人Webhook地址，为空时不推送
+	WebhookSecret string     `gorm:"size:255" json:"-"`                              // 个人Webhook签名密钥
+	LastDigestAt  *time.Time `json:"last_digest_at"`                                 // 最近一次发送汇总的时间，可为空
+	CreatedAt     time.Time  `json:"created_at"`                                     // 创建时间，GORM自动管理
+	UpdatedAt     time.Time  `json:"updated_at"`                                     // 更新时间，GORM自动管理
+}
+
+// NotificationDigest结构体定义待汇总通知表的数据模型
+// 用户选择汇总投递时，邮件和Webhook通知先写入该表，由定时任务合并后统一发送
+type NotificationDigest struct {
+	ID        uint       `gorm:"primary_key" json:"id"`     // 记录唯一标识符，主键
+	UserID    uint       `gorm:"index" json:"user_id"`      // 接收者用户ID，外键
+	Channel   string     `gorm:"size:20" json:"channel"`    // 投递渠道：email邮件、webhook个人Webhook
+	EventType string     `gorm:"size:50" json:"event_type"` // 事件类型
+	Title     string     `gorm:"size:255" json:"title"`     // 通知标题
+	Content   string     `gorm:"type:text" json:"content"`  // 通知内容
+	Data      string     `gorm:"type:text" json:"data"`     // JSON格式的附加数据
+	SentAt    *time.Time `gorm:"index" json:"sent_at"`      // 汇总发送时间，为空表示待发送
+	CreatedAt time.Time  `json:"created_at"`                // 创建时间，GORM自动管理
+}
+
 // FileStorage结构体定义文件存储表的数据模型
 // 管理系统中上传的文件，包括头像、附件、导出文件等
 type FileStorage struct {
-	ID        uint      `gorm:"primary_key" json:"id"`         // 文件唯一标识符，主键
-	FileName  string    `gorm:"size:255" json:"file_name"`     // 文件原始名称，最大255字符
-	FilePath  string    `gorm:"size:500" json:"file_path"`     // 文件存储路径，最大500字符
-	FileSize  int64     `json:"file_size"`                     // 文件大小，以字节为单位
-	MimeType  string    `gorm:"size:100" json:"mime_type"`     // 文件MIME类型，如image/jpeg、application/pdf
-	Hash      string    `gorm:"size:64" json:"hash"`           // 文件SHA256哈希值，用于去重和完整性验证
-	UserID    uint      `json:"user_id"`                       // 上传者用户ID，外键
-	User      User      `gorm:"foreignkey:UserID" json:"user"` // 上传者用户对象
-	Category  string    `gorm:"size:50" json:"category"`       // 文件分类：avatar头像、attachment附件、export导出文件
-	CreatedAt time.Time `json:"created_at"`                    // 创建时间，GORM自动管理
+	ID         uint      `gorm:"primary_key" json:"id"`         // 文件唯一标识符，主键
+	FileName   string    `gorm:"size:255" json:"file_name"`     // 文件原始名称，最大255字符
+	FilePath   string    `gorm:"size:500" json:"file_path"`     // 文件存储位置，本地存储为文件路径，远程存储为对象URI
+	Backend    string    `gorm:"size:20" json:"backend"`        // 存储后端：local本地、s3对象存储、webdav
+	StorageKey string    `gorm:"size:500" json:"storage_key"`   // 文件在存储后端中的对象键，为空表示旧版本直接写入本地的文件
+	FileSize   int64     `json:"file_size"`                     // 文件大小，以字节为单位
+	MimeType   string    `gorm:"size:100" json:"mime_type"`     // 文件MIME类型，如image/jpeg、application/pdf
+	Hash       string    `gorm:"size:64" json:"hash"`           // 文件SHA256哈希值，用于去重和完整性验证
+	UserID     uint      `json:"user_id"`                       // 上传者用户ID，外键
+	User       User      `gorm:"foreignkey:UserID" json:"user"` // 上传者用户对象
+	Category   string    `gorm:"size:50" json:"category"`       // 文件分类：avatar头像、attachment附件、vuln_image漏洞图片、weekly_report周报、export导出文件
+	RefType    string    `gorm:"size:50" json:"ref_type"`       // 关联对象类型：vuln漏洞、knowledge知识库文章、finding_template漏洞模板、weekly_report周报，为空表示尚未关联
+	RefID      uint      `gorm:"index" json:"ref_id"`           // 关联对象ID，用于下载时校验访问权限
+	CreatedAt  time.Time `json:"created_at"`                    // 创建时间，GORM自动管理
 }
 
 // Dictionary结构体定义数据字典表的数据模型
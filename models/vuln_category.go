@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// vulnCategorySeed 默认漏洞分类，第一级为OWASP Top 10 2021类别，下级为对应的CWE
+type vulnCategorySeed struct {
+	Name        string
+	Code        string
+	CWEID       string
+	OWASP       string
+	Aliases     string
+	Description string
+	Children    []vulnCategorySeed
+}
+
+// defaultVulnCategories 默认漏洞分类，别名覆盖常见的中英文写法，用于把自由填写的漏洞类型归入分类
+var defaultVulnCategories = []vulnCategorySeed{
+	{Name: "A01:2021-失效的访问控制", Code: "owasp-a01", OWASP: "A01:2021", Aliases: "Broken Access Control,访问控制失效", Description: "用户可以在授权范围之外执行操作或访问数据", Children: []vulnCategorySeed{
+		{Name: "路径遍历", Code: "cwe-22", CWEID: "CWE-22", Aliases: "目录遍历,目录穿越,任意文件读取,任意文件下载,文件包含,Path Traversal,Directory Traversal,LFI", Description: "文件路径未限制在预期目录内"},
+		{Name: "跨站请求伪造", Code: "cwe-352", CWEID: "CWE-352", Aliases: "CSRF,XSRF,Cross-Site Request Forgery", Description: "未验证请求是否由用户主动发起"},
+		{Name: "敏感信息泄露", Code: "cwe-200", CWEID: "CWE-200", Aliases: "信息泄露,信息泄漏,敏感信息泄漏,Information Disclosure,Sensitive Data Exposure", Description: "向未授权的用户暴露敏感信息", Children: []vulnCategorySeed{
+			{Name: "目录列表", Code: "cwe-548", CWEID: "CWE-548", Aliases: "目录浏览,列目录,Directory Listing", Description: "Web服务器开启目录浏览，暴露文件列表"},
+		}},
+		{Name: "访问控制不当", Code: "cwe-284", CWEID: "CWE-284", Aliases: "权限绕过,权限控制不当,Improper Access Control", Description: "未正确限制对资源的访问", Children: []vulnCategorySeed{
+			{Name: "越权访问", Code: "cwe-639", CWEID: "CWE-639", Aliases: "越权,水平越权,垂直越权,平行越权,IDOR,Insecure Direct Object Reference", Description: "通过修改用户可控的标识访问他人的数据"},
+			{Name: "未授权访问", Code: "cwe-862", CWEID: "CWE-862", Aliases: "未授权,缺少授权,Missing Authorization,Unauthorized Access", Description: "敏感功能或接口未进行授权检查"},
+		}},
+		{Name: "URL重定向", Code: "cwe-601", CWEID: "CWE-601", Aliases: "开放重定向,任意URL跳转,URL跳转,Open Redirect", Description: "重定向目标可被用户控制，跳转到不可信站点"},
+	}},
+	{Name: "A02:2021-加密机制失效", Code: "owasp-a02", OWASP: "A02:2021", Aliases: "Cryptographic Failures", Description: "加密缺失或使用不当导致敏感数据泄露", Children: []vulnCategorySeed{
+		{Name: "使用弱加密算法", Code: "cwe-327", CWEID: "CWE-327", Aliases: "弱加密,弱加密算法,弱哈希,Weak Crypto,Broken Crypto", Description: "使用已被攻破或强度不足的加密算法"},
+		{Name: "敏感信息明文传输", Code: "cwe-319", CWEID: "CWE-319", Aliases: "明文传输,未使用HTTPS,Cleartext Transmission", Description: "敏感信息在网络中明文传输"},
+		{Name: "敏感信息明文存储", Code: "cwe-312", CWEID: "CWE-312", Aliases: "明文存储,密码明文存储,Cleartext Storage", Description: "敏感信息未加密存储"},
+	}},
+	{Name: "A03:2021-注入", Code: "owasp-a03", OWASP: "A03:2021", Aliases: "Injection,注入漏洞", Description: "不可信数据作为命令或查询的一部分被解释执行", Children: []vulnCategorySeed{
+		{Name: "SQL注入", Code: "cwe-89", CWEID: "CWE-89", Aliases: "sqli,SQL Injection,SQL注入漏洞,SQL盲注,盲注", Description: "用户输入被拼接到SQL语句中执行"},
+		{Name: "命令注入", Code: "cwe-78", CWEID: "CWE-78", Aliases: "命令执行,远程命令执行,OS命令注入,系统命令执行,Command Injection,OS Command Injection", Description: "用户输入被拼接到操作系统命令中执行"},
+		{Name: "代码注入", Code: "cwe-94", CWEID: "CWE-94", Aliases: "代码执行,远程代码执行,RCE,Code Injection,Remote Code Execution", Description: "用户输入被当作代码解释执行", Children: []vulnCategorySeed{
+			{Name: "模板注入", Code: "cwe-1336", CWEID: "CWE-1336", Aliases: "SSTI,服务端模板注入,Template Injection,Server-Side Template Injection", Description: "用户输入被模板引擎解析执行"},
+			{Name: "表达式注入", Code: "cwe-917", CWEID: "CWE-917", Aliases: "EL表达式注入,OGNL注入,SpEL注入,Expression Language Injection", Description: "用户输入被表达式语言引擎解析执行"},
+		}},
+		{Name: "跨站脚本", Code: "cwe-79", CWEID: "CWE-79", Aliases: "XSS,跨站脚本攻击,存储型XSS,反射型XSS,DOM型XSS,Cross-Site Scripting,Cross Site Scripting", Description: "用户输入未经转义输出到页面，在浏览器中执行脚本"},
+		{Name: "LDAP注入", Code: "cwe-90", CWEID: "CWE-90", Aliases: "LDAP Injection", Description: "用户输入被拼接到LDAP查询中"},
+		{Name: "XPath注入", Code: "cwe-643", CWEID: "CWE-643", Aliases: "XPath Injection", Description: "用户输入被拼接到XPath查询中"},
+		{Name: "CRLF注入", Code: "cwe-93", CWEID: "CWE-93", Aliases: "HTTP响应拆分,HTTP头注入,CRLF Injection,HTTP Response Splitting", Description: "用户输入中的换行符未过滤，可注入HTTP头"},
+	}},
+	{Name: "A04:2021-不安全设计", Code: "owasp-a04", OWASP: "A04:2021", Aliases: "Insecure Design", Description: "设计阶段缺少或无效的安全控制", Children: []vulnCategorySeed{
+		{Name: "任意文件上传", Code: "cwe-434", CWEID: "CWE-434", Aliases: "文件上传,文件上传漏洞,Unrestricted File Upload,File Upload", Description: "未限制上传文件的类型，可上传可执行文件"},
+		{Name: "业务逻辑漏洞", Code: "cwe-840", CWEID: "CWE-840", Aliases: "逻辑漏洞,业务逻辑缺陷,支付漏洞,Business Logic", Description: "业务流程设计缺陷被利用"},
+		{Name: "点击劫持", Code: "cwe-1021", CWEID: "CWE-1021", Aliases: "Clickjacking,UI覆盖", Description: "页面可被嵌入不可信站点的框架中"},
+	}},
+	{Name: "A05:2021-安全配置错误", Code: "owasp-a05", OWASP: "A05:2021", Aliases: "Security Misconfiguration", Description: "默认配置、不完整配置或错误配置导致的安全问题", Children: []vulnCategorySeed{
+		{Name: "配置错误", Code: "cwe-16", CWEID: "CWE-16", Aliases: "配置不当,错误配置,默认配置,Misconfiguration", Description: "系统、中间件或应用的安全配置不当"},
+		{Name: "XML外部实体注入", Code: "cwe-611", CWEID: "CWE-611", Aliases: "XXE,XML外部实体,XML External Entity", Description: "XML解析器处理外部实体，可读取文件或发起请求"},
+		{Name: "跨域配置错误", Code: "cwe-942", CWEID: "CWE-942", Aliases: "CORS,CORS配置错误,跨域资源共享配置错误,CORS Misconfiguration", Description: "跨域资源共享策略允许不可信的来源"},
+	}},
+	{Name: "A06:2021-易受攻击和过时的组件", Code: "owasp-a06", OWASP: "A06:2021", Aliases: "Vulnerable and Outdated Components", Description: "使用存在已知漏洞或不再维护的组件", Children: []vulnCategorySeed{
+		{Name: "第三方组件漏洞", Code: "cwe-1104", CWEID: "CWE-1104", Aliases: "组件漏洞,过时组件,中间件漏洞,框架漏洞,Outdated Component,Vulnerable Component", Description: "使用的第三方组件存在已知漏洞或已停止维护"},
+	}},
+	{Name: "A07:2021-身份识别和身份验证错误", Code: "owasp-a07", OWASP: "A07:2021", Aliases: "Identification and Authentication Failures,Broken Authentication", Description: "身份认证和会话管理实现不当", Children: []vulnCategorySeed{
+		{Name: "身份认证不当", Code: "cwe-287", CWEID: "CWE-287", Aliases: "认证绕过,身份验证绕过,登录绕过,Authentication Bypass,Improper Authentication", Description: "未正确验证用户身份", Children: []vulnCategorySeed{
+			{Name: "弱口令", Code: "cwe-521", CWEID: "CWE-521", Aliases: "弱密码,默认口令,默认密码,Weak Password", Description: "使用容易被猜解的口令或默认口令"},
+			{Name: "暴力破解", Code: "cwe-307", CWEID: "CWE-307", Aliases: "爆破,撞库,登录爆破,无登录失败次数限制,Brute Force", Description: "未限制认证失败次数"},
+			{Name: "会话固定", Code: "cwe-384", CWEID: "CWE-384", Aliases: "Session Fixation", Description: "登录后未更换会话标识"},
+		}},
+		{Name: "硬编码凭证", Code: "cwe-798", CWEID: "CWE-798", Aliases: "硬编码密码,硬编码密钥,密钥泄露,Hard-coded Credentials", Description: "代码或配置中硬编码了密码、密钥等凭证"},
+		{Name: "密码找回缺陷", Code: "cwe-640", CWEID: "CWE-640", Aliases: "任意密码重置,密码重置漏洞,Weak Password Recovery", Description: "密码找回流程可被绕过"},
+	}},
+	{Name: "A08:2021-软件和数据完整性故障", Code: "owasp-a08", OWASP: "A08:2021", Aliases: "Software and Data Integrity Failures", Description: "未验证软件更新、关键数据或CI/CD流程的完整性", Children: []vulnCategorySeed{
+		{Name: "反序列化", Code: "cwe-502", CWEID: "CWE-502", Aliases: "反序列化漏洞,不安全的反序列化,Deserialization,Insecure Deserialization", Description: "反序列化不可信数据"},
+	}},
+	{Name: "A09:2021-安全日志和监控故障", Code: "owasp-a09", OWASP: "A09:2021", Aliases: "Security Logging and Monitoring Failures", Description: "日志记录和监控不足，无法及时发现攻击", Children: []vulnCategorySeed{
+		{Name: "日志记录不足", Code: "cwe-778", CWEID: "CWE-778", Aliases: "日志缺失,审计日志缺失,Insufficient Logging", Description: "安全相关事件未记录日志"},
+		{Name: "日志注入", Code: "cwe-117", CWEID: "CWE-117", Aliases: "Log Injection,日志伪造", Description: "用户输入未经处理写入日志"},
+	}},
+	{Name: "A10:2021-服务端请求伪造", Code: "owasp-a10", OWASP: "A10:2021", Description: "服务端根据用户提供的地址发起请求", Children: []vulnCategorySeed{
+		{Name: "服务端请求伪造", Code: "cwe-918", CWEID: "CWE-918", Aliases: "SSRF,Server-Side Request Forgery", Description: "服务端请求的目标地址可被用户控制"},
+	}},
+	{Name: "其他", Code: "other", Description: "未归入OWASP Top 10的漏洞", Children: []vulnCategorySeed{
+		{Name: "拒绝服务", Code: "cwe-400", CWEID: "CWE-400", Aliases: "DoS,DDoS,资源耗尽,ReDoS,Denial of Service", Description: "资源消耗不受控制导致服务不可用"},
+		{Name: "内存破坏", Code: "cwe-119", CWEID: "CWE-119", Aliases: "缓冲区溢出,栈溢出,堆溢出,内存越界,Buffer Overflow,Memory Corruption", Description: "内存缓冲区操作越界"},
+		{Name: "条件竞争", Code: "cwe-362", CWEID: "CWE-362", Aliases: "竞争条件,并发漏洞,Race Condition", Description: "并发访问共享资源时同步不当"},
+	}},
+}
+
+// createVulnCategorySeeds 按层级创建默认漏洞分类，下级分类沿用上级的OWASP类别
+func createVulnCategorySeeds(db *gorm.DB, seeds []vulnCategorySeed, parent *VulnCategory) error {
+	for i, seed := range seeds {
+		category := VulnCategory{
+			Name:        seed.Name,
+			Code:        seed.Code,
+			Description: seed.Description,
+			CWEID:       seed.CWEID,
+			OWASP:       seed.OWASP,
+			Aliases:     seed.Aliases,
+			Level:       1,
+			Sort:        i + 1,
+			Status:      1,
+		}
+		if parent != nil {
+			category.ParentID = &parent.ID
+			category.Level = parent.Level + 1
+			if category.OWASP == "" {
+				category.OWASP = parent.OWASP
+			}
+		}
+		if err := db.Create(&category).Error; err != nil {
+			return fmt.Errorf("初始化漏洞分类失败: %v", err)
+		}
+		if err := createVulnCategorySeeds(db, seed.Children, &category); err != nil {
+			return err
+		}
+	}
+	return nil
+}
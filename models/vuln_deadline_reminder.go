@@ -14,7 +14,7 @@ type VulnDeadlineReminder struct {
 	SentAt         time.Time `gorm:"not null" json:"sent_at"`               // 发送时间
 	AssigneeID     uint      `gorm:"not null" json:"assignee_id"`           // 被提醒人ID
 	AssigneeEmail  string    `gorm:"size:100" json:"assignee_email"`        // 被提醒人邮箱
-	Status         string    `gorm:"size:20;default:'sent'" json:"status"`  // 发送状态：sent-已发送，failed-发送失败
+	Status         string    `gorm:"size:20;default:'sent'" json:"status"`  // 发送状态：sent-已发送，failed-发送失败，digest-已加入汇总，in_app-仅站内通知
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 
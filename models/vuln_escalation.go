@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// VulnEscalation 漏洞超期升级记录表
+// 漏洞超过修复截止时间后按配置的升级级别依次通知指派人、项目负责人、部门负责人或超级管理员，
+// 每个级别对同一截止时间只通知一次，截止时间顺延后重新计算升级
+type VulnEscalation struct {
+	ID             uint      `gorm:"primary_key" json:"id"`
+	VulnID         uint      `gorm:"not null;index" json:"vuln_id"`        // 漏洞ID
+	Level          int       `gorm:"not null" json:"level"`                // 升级级别，从1开始
+	DaysOverdue    int       `gorm:"not null" json:"days_overdue"`         // 该级别触发时超期的天数
+	Target         string    `gorm:"size:30" json:"target"`                // 通知对象：assignee指派人、project_owner项目负责人、department_head部门负责人、super_admin超级管理员
+	Deadline       time.Time `gorm:"not null" json:"deadline"`             // 触发升级时的修复截止时间
+	RecipientID    uint      `gorm:"not null" json:"recipient_id"`         // 被通知人ID
+	RecipientEmail string    `gorm:"size:100" json:"recipient_email"`      // 被通知人邮箱
+	Status         string    `gorm:"size:20;default:'sent'" json:"status"` // 发送状态：sent-已发送，failed-发送失败，digest-已加入汇总，in_app-仅站内通知
+	SentAt         time.Time `gorm:"not null" json:"sent_at"`              // 发送时间
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// 关联关系
+	Recipient User `gorm:"foreignKey:RecipientID" json:"recipient,omitempty"`
+}
+
+// TableName 指定表名
+func (VulnEscalation) TableName() string {
+	return "vuln_escalations"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// VulnRelation 漏洞关系表
+// 关系是有方向的：源漏洞 <关系类型> 目标漏洞，例如"A duplicate_of B"表示A与B重复，
+// "A child_of B"表示A是根因漏洞B在某个资产上的子漏洞
+type VulnRelation struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	SourceID  uint      `gorm:"not null;index" json:"source_id"`     // 源漏洞ID
+	TargetID  uint      `gorm:"not null;index" json:"target_id"`     // 目标漏洞ID
+	Type      string    `gorm:"size:20;not null" json:"type"`        // 关系类型：duplicate_of重复、caused_by由...引起、blocks阻塞、child_of子漏洞
+	Comment   string    `gorm:"size:255" json:"comment"`             // 关联说明
+	CreatedBy uint      `json:"created_by"`                          // 创建人ID
+	Creator   User      `gorm:"foreignKey:CreatedBy" json:"creator"` // 创建人
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (VulnRelation) TableName() string {
+	return "vuln_relations"
+}
+
+// VulnRelationNode 漏洞关系图中的漏洞节点
+type VulnRelationNode struct {
+	ID        uint   `json:"id"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	Severity  string `json:"severity"`
+	AssetID   uint   `json:"asset_id"`
+	ProjectID uint   `json:"project_id"`
+}
+
+// VulnRelationGraph 漏洞关系图，随漏洞详情返回，不对应数据表
+type VulnRelationGraph struct {
+	Nodes []VulnRelationNode `json:"nodes"`
+	Edges []VulnRelation     `json:"edges"`
+}
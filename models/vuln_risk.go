@@ -0,0 +1,10 @@
+package models
+
+// VulnRiskFactor 漏洞风险优先级评分的单项因子，评分明细以JSON保存在漏洞的RiskFactors字段
+type VulnRiskFactor struct {
+	Code      string  `json:"code"`       // 因子：severity严重程度/CVSS、kev已知被利用、epss利用概率、importance资产重要性、environment资产环境、exposure互联网暴露
+	Label     string  `json:"label"`      // 因子名称
+	Value     string  `json:"value"`      // 因子取值说明
+	Points    float64 `json:"points"`     // 得分
+	MaxPoints float64 `json:"max_points"` // 满分
+}
@@ -14,9 +14,32 @@ type Vulnerability struct {
 	VulnURL       string           `gorm:"size:500" json:"vuln_url"`                // 漏洞地址，最大500字符
 	Description   string           `gorm:"type:text" json:"description"`            // 漏洞详细描述，长文本类型，支持Markdown格式
 	VulnType      string           `gorm:"size:50" json:"vuln_type"`                // 漏洞类型，如SQL注入、XSS、命令执行等
+	CategoryID    *uint            `gorm:"index" json:"category_id"`                // 漏洞分类ID，创建时按漏洞类型或CWE编号自动归类，可手动调整
+	Category      *VulnCategory    `gorm:"foreignkey:CategoryID;save_associations:false" json:"category,omitempty"` // 漏洞分类对象，仅在列表和详情中加载
 	Severity      string           `gorm:"size:20" json:"severity"`                 // 漏洞严重程度：critical严重、high高危、medium中危、low低危、info提示
-	Status        string           `gorm:"size:20;default:'unfixed'" json:"status"` // 漏洞状态：unfixed未修复、fixing修复中、fixed已修复、retesting复测中、completed已完成、ignored已忽略
+	Status        string           `gorm:"size:20;default:'unfixed'" json:"status"` // 漏洞状态：pending待审核、confirmed已确认、rejected已驳回、unfixed未修复、fixing修复中、fixed已修复、retesting复测中、completed已完成、ignored已忽略
+	Source        string           `gorm:"size:50" json:"source"`                   // 漏洞来源，如内部测试、外部报告、扫描器、众测等
 	CVEID         string           `gorm:"size:50" json:"cve_id"`                   // CVE编号，国际通用漏洞编号
+	CNNVDID       string           `gorm:"size:50" json:"cnnvd_id"`                 // CNNVD编号，国家信息安全漏洞库编号
+	CWEID         string           `gorm:"size:100" json:"cwe_id"`                  // CWE编号，多个用逗号分隔，可由CVE情报自动补充
+	InKEV         bool             `gorm:"default:false" json:"in_kev"`             // 是否列入CISA已知被利用漏洞目录，由CVE情报更新
+	EPSSScore     float64          `json:"epss_score"`                              // EPSS利用预测评分，0-1，由CVE情报更新
+	EPSSPercentile float64         `json:"epss_percentile"`                         // EPSS评分百分位，0-1
+	EnrichedAt    *time.Time       `json:"enriched_at"`                             // 最近一次根据CVE情报补充信息的时间
+	RiskScore     float64          `gorm:"index" json:"risk_score"`                 // 风险优先级评分，0-100，综合严重程度、KEV、EPSS、资产重要性、环境和暴露面计算
+	RiskLevel     string           `gorm:"size:10;index" json:"risk_level"`         // 风险优先级：P1立即修复、P2优先修复、P3计划修复、P4择机修复
+	RiskFactors   string           `gorm:"type:text" json:"-"`                      // 风险优先级评分明细，JSON格式
+	RiskBreakdown []VulnRiskFactor `gorm:"-" json:"risk_breakdown,omitempty"`       // 风险优先级评分明细，仅在漏洞详情中返回
+	CVSSScore     float64          `json:"cvss_score"`                              // CVSS评分，取值范围0.0-10.0，有向量时为环境评分
+	CVSSVector    string           `gorm:"size:255" json:"cvss_vector"`             // CVSS向量，支持CVSS:3.0、CVSS:3.1、CVSS:4.0
+	CVSSVersion   string           `gorm:"size:10" json:"cvss_version"`             // CVSS版本，由向量解析得出
+	CVSSBaseScore float64          `json:"cvss_base_score"`                         // CVSS基础评分
+	CVSSTemporalScore      float64 `json:"cvss_temporal_score"`                     // CVSS时间评分（v4.0为威胁评分）
+	CVSSEnvironmentalScore float64 `json:"cvss_environmental_score"`                // CVSS环境评分，环境指标默认取自关联资产
+	SeverityOverridden     bool    `gorm:"default:false" json:"severity_overridden"` // 严重程度是否由管理员手动覆盖
+	POC           string           `gorm:"type:text" json:"poc"`                    // 漏洞验证POC，复现步骤或验证代码
+	Solution      string           `gorm:"type:text" json:"solution"`               // 解决方案，针对漏洞的具体处置方案
+	References    string           `gorm:"type:text" json:"references"`             // 参考链接，多个链接用换行分隔
 	FixSuggestion string           `gorm:"type:text" json:"fix_suggestion"`         // 修复建议，漏洞的解决方法
 	ProjectID     uint             `json:"project_id"`                              // 关联项目ID，外键
 	Project       Project          `gorm:"foreignkey:ProjectID" json:"project"`     // 关联的项目对象
@@ -46,6 +69,19 @@ type Vulnerability struct {
 	ResubmittedBy  *uint            `json:"resubmitted_by"`                           // 重新提交人ID，外键，可为空（安全工程师）
 	Resubmitter    *User            `gorm:"foreignkey:ResubmittedBy" json:"resubmitter"` // 重新提交人用户对象，可为空
 	FixDeadline    *time.Time       `json:"fix_deadline"`                             // 修复截止时间，可为空
+	SLAPolicyID    *uint            `json:"sla_policy_id"`                            // 审核时匹配的SLA策略ID，可为空
+	SLADays        int              `json:"sla_days"`                                 // SLA修复时限(天)
+	SLAStartedAt   *time.Time       `json:"sla_started_at"`                           // SLA开始计时时间（审核通过时间）
+	SLAPausedAt    *time.Time       `json:"sla_paused_at"`                            // SLA暂停时间，复测中、已忽略（含风险接受）等状态暂停计时
+	SLAPausedSeconds int64          `json:"sla_paused_seconds"`                       // SLA累计暂停时长(秒)，暂停时长会顺延修复截止时间
+	SLABreached    bool             `gorm:"default:false" json:"sla_breached"`        // 是否已超出SLA
+	SLABreachedAt  *time.Time       `json:"sla_breached_at"`                          // 超出SLA的时间
+	RemediationSeconds int64        `json:"remediation_seconds"`                      // 修复耗时(秒)，从审核通过到复测通过，不含暂停时长
+	Fingerprint    string           `gorm:"size:64;index" json:"fingerprint"`         // 漏洞指纹，由资产、规范化后的漏洞地址、漏洞类型和CVE编号计算，用于重复检测
+	DuplicateOfID  *uint            `json:"duplicate_of_id"`                          // 合并到的漏洞ID，作为重复漏洞合并后软删除
+	RegressionOfID *uint            `json:"regression_of_id"`                         // 回归的原漏洞ID，已完成的漏洞再次出现时关联原漏洞
+	Relations      *VulnRelationGraph `gorm:"-" json:"relations,omitempty"`           // 漏洞关系图，仅在漏洞详情中返回
+	Knowledge      *KnowledgeArticleRef `gorm:"-" json:"knowledge,omitempty"`         // 匹配的知识库修复指南，仅在漏洞详情中返回
 	RetestResult  string           `gorm:"type:text" json:"retest_result"`          // 复测结果，复测的详细结果说明
 	Tags          string           `gorm:"size:500" json:"tags"`                    // 漏洞标签，用逗号分隔的标签列表
 	Attachments   []VulnAttachment `gorm:"foreignkey:VulnID" json:"attachments"`    // 关联的附件列表
@@ -65,6 +101,8 @@ type VulnAttachment struct {
 	FilePath  string    `gorm:"size:500" json:"file_path"`           // 文件存储路径，最大500字符
 	FileSize  int64     `json:"file_size"`                           // 文件大小，以字节为单位
 	MimeType  string    `gorm:"size:100" json:"mime_type"`           // 文件MIME类型，如image/png
+	Hash      string    `gorm:"size:64;index" json:"hash"`           // 文件SHA256哈希值，用于去重
+	FileStorageID uint  `json:"file_storage_id"`                     // 关联文件存储记录ID，相同内容的附件共享同一存储记录
 	UploadBy  uint      `json:"upload_by"`                           // 上传者ID，外键
 	Uploader  User      `gorm:"foreignkey:UploadBy" json:"uploader"` // 上传者用户对象
 	CreatedAt time.Time `json:"created_at"`                          // 创建时间，GORM自动管理
@@ -95,12 +133,15 @@ type VulnTimeline struct {
 }
 
 // VulnCategory结构体定义漏洞分类表的数据模型
-// 用于对漏洞进行分类管理，支持层级结构
+// 用于对漏洞进行分类管理，支持层级结构，默认按OWASP Top 10 2021归类CWE
 type VulnCategory struct {
 	ID          uint           `gorm:"primary_key" json:"id"`                // 分类唯一标识符，主键
 	Name        string         `gorm:"unique;not null;size:100" json:"name"` // 分类名称，唯一且不能为空，最大100字符
 	Code        string         `gorm:"unique;not null;size:50" json:"code"`  // 分类代码，唯一且不能为空，用于程序逻辑判断
 	Description string         `gorm:"size:255" json:"description"`          // 分类描述，最大255字符
+	CWEID       string         `gorm:"size:20;index" json:"cwe_id"`          // 对应的CWE编号，如CWE-89，OWASP大类为空
+	OWASP       string         `gorm:"size:50" json:"owasp"`                 // 对应的OWASP Top 10类别，如A03:2021
+	Aliases     string         `gorm:"size:500" json:"aliases"`              // 别名，多个用逗号分隔，漏洞类型与名称、代码、CWE编号或别名一致时自动归入该分类
 	ParentID    *uint          `json:"parent_id"`                            // 父分类ID，可为空，用于构建层级结构
 	Parent      *VulnCategory  `gorm:"foreignkey:ParentID" json:"parent"`    // 父分类对象，可为空
 	Children    []VulnCategory `gorm:"foreignkey:ParentID" json:"children"`  // 子分类列表
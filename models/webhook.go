@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// Webhook 出站Webhook订阅表
+// 由管理员维护，漏洞、项目、资产等业务事件发生时向订阅地址推送带HMAC-SHA256签名的JSON数据
+type Webhook struct {
+	ID          uint      `gorm:"primary_key" json:"id"`
+	Name        string    `gorm:"size:100;not null" json:"name"` // 订阅名称
+	URL         string    `gorm:"size:500;not null" json:"url"`  // 推送地址
+	Secret      string    `gorm:"size:255" json:"-"`             // 签名密钥，不在接口中返回
+	Events      string    `gorm:"type:text" json:"events"`       // 订阅的事件，逗号分隔，*表示全部事件
+	IsActive    bool      `gorm:"default:true" json:"is_active"` // 是否启用
+	Description string    `gorm:"size:255" json:"description"`   // 备注
+	CreatedBy   uint      `json:"created_by"`                    // 创建人ID
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery Webhook投递记录表
+// 每次事件推送生成一条记录，失败后按指数退避重试，可在系统设置中查看和重放
+type WebhookDelivery struct {
+	ID           uint       `gorm:"primary_key" json:"id"`
+	WebhookID    uint       `gorm:"not null;index" json:"webhook_id"`              // 订阅ID
+	EventID      string     `gorm:"size:64;index" json:"event_id"`                 // 事件ID，重放时保持不变，便于接收方去重
+	Event        string     `gorm:"size:50" json:"event"`                          // 事件类型
+	Payload      string     `gorm:"type:longtext" json:"payload"`                  // 推送的JSON数据
+	Status       string     `gorm:"size:20;default:'pending';index" json:"status"` // 投递状态：pending-待投递，success-成功，failed-最终失败
+	Attempts     int        `gorm:"default:0" json:"attempts"`                     // 已尝试次数
+	ResponseCode int        `json:"response_code"`                                 // 最近一次响应状态码
+	ResponseBody string     `gorm:"type:text" json:"response_body"`                // 最近一次响应内容（截断）
+	Error        string     `gorm:"size:500" json:"error"`                         // 最近一次错误信息
+	Duration     int64      `json:"duration"`                                      // 最近一次请求耗时（毫秒）
+	NextRetryAt  *time.Time `gorm:"index" json:"next_retry_at"`                    // 下次重试时间，为空表示不再重试
+	DeliveredAt  *time.Time `json:"delivered_at"`                                  // 投递成功时间
+	ReplayOf     *uint      `json:"replay_of"`                                     // 重放来源的投递记录ID
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// 关联关系
+	Webhook Webhook `gorm:"foreignKey:WebhookID" json:"webhook,omitempty"`
+}
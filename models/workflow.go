@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Workflow 漏洞工作流表
+// 管理员按项目类型定义漏洞的状态和状态流转，项目类型没有专属工作流时使用默认工作流，都没有时使用系统内置流程
+type Workflow struct {
+	ID          uint                 `gorm:"primary_key" json:"id"`
+	Name        string               `gorm:"size:100;not null" json:"name"`            // 工作流名称
+	Description string               `gorm:"size:500" json:"description"`              // 描述
+	ProjectType string               `gorm:"size:50;index" json:"project_type"`        // 适用的项目类型，为空表示默认工作流
+	IsActive    bool                 `gorm:"default:true" json:"is_active"`            // 是否启用
+	CreatedBy   uint                 `json:"created_by"`                               // 创建人ID
+	States      []WorkflowState      `gorm:"foreignkey:WorkflowID" json:"states"`      // 状态列表
+	Transitions []WorkflowTransition `gorm:"foreignkey:WorkflowID" json:"transitions"` // 状态流转列表
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+// WorkflowState 工作流状态表
+type WorkflowState struct {
+	ID         uint   `gorm:"primary_key" json:"id"`
+	WorkflowID uint   `gorm:"not null;index" json:"workflow_id"` // 工作流ID
+	Status     string `gorm:"size:20;not null" json:"status"`    // 状态代码，与漏洞的status字段对应
+	Label      string `gorm:"size:50" json:"label"`              // 状态名称
+	IsInitial  bool   `json:"is_initial"`                        // 是否为初始状态，新提交的漏洞进入该状态
+	SortOrder  int    `json:"sort_order"`                        // 排序
+}
+
+// WorkflowTransition 工作流状态流转表
+// 系统角色、项目角色和权限均为空时任何能访问漏洞的用户都可以执行该流转
+type WorkflowTransition struct {
+	ID                uint   `gorm:"primary_key" json:"id"`
+	WorkflowID        uint   `gorm:"not null;index" json:"workflow_id"`   // 工作流ID
+	FromStatus        string `gorm:"size:20;not null" json:"from_status"` // 当前状态
+	ToStatus          string `gorm:"size:20;not null" json:"to_status"`   // 目标状态
+	Name              string `gorm:"size:50" json:"name"`                 // 操作名称
+	Permission        string `gorm:"size:50" json:"permission"`           // 所需权限代码，为空表示不校验权限
+	Roles             string `gorm:"size:255" json:"roles"`               // 允许的系统角色代码，逗号分隔，为空表示不限制
+	ProjectRoles      string `gorm:"size:255" json:"project_roles"`       // 允许的项目角色，逗号分隔：owner项目负责人、member项目成员、reporter漏洞提交人、assignee漏洞指派人，满足其一即可
+	RequiredFields    string `gorm:"size:255" json:"required_fields"`     // 必填项，逗号分隔：comment操作说明、assignee指派人、fix_deadline修复截止时间、fix_suggestion修复建议
+	AutoActions       string `gorm:"size:255" json:"auto_actions"`        // 自动动作，逗号分隔，如set_assigned_at设置指派时间
+	RequiredApprovals int    `json:"required_approvals"`                  // 需要的审批人数，大于1时需要不同的用户分别执行该流转后才会变更状态
+	SortOrder         int    `json:"sort_order"`                          // 排序
+}
+
+// WorkflowApproval 状态流转审批记录表
+// 需要多人审批的流转在审批人数不足时记录在这里，漏洞状态变更后清空
+type WorkflowApproval struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	VulnID     uint      `gorm:"not null;index" json:"vuln_id"` // 漏洞ID
+	FromStatus string    `gorm:"size:20" json:"from_status"`    // 当前状态
+	ToStatus   string    `gorm:"size:20" json:"to_status"`      // 目标状态
+	UserID     uint      `json:"user_id"`                       // 审批人ID
+	User       User      `gorm:"foreignkey:UserID" json:"user"` // 审批人
+	Comment    string    `gorm:"type:text" json:"comment"`      // 审批说明
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -300,7 +300,7 @@ func UploadVulnImage(c *gin.Context) {
 	baseURL := fmt.Sprintf("%s://%s", scheme, host)
 
 	// 调用用户服务上传图片
-	imageURL, err := userService.UploadVulnImage(userID.(uint), file, baseURL)
+	storage, imageURL, err := userService.UploadVulnImage(userID.(uint), file, baseURL)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
@@ -314,6 +314,7 @@ func UploadVulnImage(c *gin.Context) {
 		"msg":  "图片上传成功",
 		"data": gin.H{
 			"image_url": imageURL,
+			"file_id":   storage.ID,
 		},
 	})
 }
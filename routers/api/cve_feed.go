@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var cveFeedService = &services.CVEFeedService{}
+
+// GetCVEFeedStatus 获取CVE情报库状态和最近的导入记录
+func GetCVEFeedStatus(c *gin.Context) {
+	status, err := cveFeedService.GetStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": status,
+	})
+}
+
+// RefreshCVEFeeds 立即更新CVE情报：从内部镜像下载并导入数据源目录中变化的文件
+func RefreshCVEFeeds(c *gin.Context) {
+	result, err := cveFeedService.RefreshFeeds()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  fmt.Sprintf("更新完成，导入%d个文件，跳过%d个未变化的文件，失败%d项", len(result.Imports), len(result.Skipped), len(result.Errors)),
+		"data": result,
+	})
+}
+
+// UploadCVEFeed 上传并导入NVD、KEV或EPSS数据源文件
+func UploadCVEFeed(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请选择要上传的数据源文件",
+		})
+		return
+	}
+
+	// 检查文件大小（限制为500MB，NVD年度数据源解压后较大）
+	if file.Size > 500*1024*1024 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件大小不能超过500MB",
+		})
+		return
+	}
+
+	result, err := cveFeedService.ImportUploadedFeed(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  fmt.Sprintf("导入成功，共%d条记录", result.Imports[0].Records),
+		"data": result,
+	})
+}
+
+// GetCVEIntel 查询CVE编号在本地情报库中的信息
+func GetCVEIntel(c *gin.Context) {
+	intel, err := cveFeedService.GetCVEIntel(c.Param("cve_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": intel,
+	})
+}
+
+// EnrichVuln 根据本地CVE情报补充漏洞信息
+func EnrichVuln(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	vuln, notes, err := cveFeedService.EnrichVuln(uint(vulnID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	msg := "漏洞信息已是最新"
+	if len(notes) > 0 {
+		msg = "已根据CVE情报补充漏洞信息"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  msg,
+		"data": gin.H{
+			"vuln":  vuln,
+			"notes": notes,
+		},
+	})
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+	"vulnmain/services"
+	"vulnmain/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var fileService = &services.FileService{}
+
+// SignedFileURLsRequest 批量获取文件签名链接请求
+type SignedFileURLsRequest struct {
+	FileIDs []uint `json:"file_ids" binding:"required"`
+}
+
+// serveFile 从存储后端读取并输出文件内容
+// 图片和PDF允许内联展示，其他类型一律作为附件下载，避免上传的HTML等文件在浏览器中执行
+func serveFile(c *gin.Context, reader io.ReadCloser, err error, size int64, fileName string, mimeType string, download bool) {
+	if err != nil {
+		if errors.Is(err, utils.ErrObjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": 404,
+				"msg":  "文件不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "读取文件失败: " + err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	inline := strings.HasPrefix(mimeType, "image/") || mimeType == "application/pdf"
+	if mimeType == "image/svg+xml" {
+		inline = false
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	// 文件大小未知时不设置Content-Length
+	if size <= 0 {
+		size = -1
+	}
+
+	disposition := "inline"
+	if download || !inline {
+		disposition = "attachment"
+	}
+
+	c.DataFromReader(http.StatusOK, size, mimeType, reader, map[string]string{
+		"Content-Disposition":    disposition + "; filename*=UTF-8''" + url.PathEscape(fileName),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-store",
+	})
+}
+
+// DownloadFile 下载文件
+// GET /api/files/:id，需要JWT认证，并按文件关联的漏洞校验项目级访问权限
+func DownloadFile(c *gin.Context) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件ID格式错误",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	file, err := fileService.GetFileForUser(uint(fileID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	reader, err := fileService.OpenFile(file)
+	serveFile(c, reader, err, file.FileSize, file.FileName, file.MimeType, c.Query("download") == "1")
+}
+
+// GetSignedFileURLs 批量获取文件的短时效签名链接
+// POST /api/files/signed-urls，用于Markdown渲染和前端直接嵌入图片，无权访问的文件不返回链接
+func GetSignedFileURLs(c *gin.Context) {
+	var req SignedFileURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	urls := fileService.GetSignedFileURLs(req.FileIDs, userID.(uint), roleCode.(string))
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"urls":           urls,
+			"expire_seconds": int(fileService.SignedURLExpire().Seconds()),
+		},
+	})
+}
+
+// DownloadSignedFile 通过签名链接下载文件
+// GET /api/files/:id/signed?expires=...&signature=...，无需JWT认证，签名过期后失效
+func DownloadSignedFile(c *gin.Context) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件ID格式错误",
+		})
+		return
+	}
+
+	file, err := fileService.GetFileBySignature(uint(fileID), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	reader, err := fileService.OpenFile(file)
+	serveFile(c, reader, err, file.FileSize, file.FileName, file.MimeType, c.Query("download") == "1")
+}
+
+// GetWeeklyReportSignedURL 获取历史周报文件的短时效签名链接
+// GET /api/system/weekly-report/file/:id/signed-url，用于前端内嵌预览PDF
+func GetWeeklyReportSignedURL(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "周报ID格式错误",
+		})
+		return
+	}
+
+	db := Init.GetDB()
+	var report models.WeeklyReport
+	if err := db.Where("id = ?", reportID).First(&report).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  "周报记录不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"url":            fileService.SignedWeeklyReportURL(utils.NewResourceSigner(), report.ID, fileService.SignedURLExpire()),
+			"expire_seconds": int(fileService.SignedURLExpire().Seconds()),
+		},
+	})
+}
+
+// DownloadSignedWeeklyReport 通过签名链接下载周报文件
+// GET /api/weekly-reports/:id/signed?expires=...&signature=...，用于邮件和前端预览
+func DownloadSignedWeeklyReport(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "周报ID格式错误",
+		})
+		return
+	}
+
+	report, err := fileService.GetWeeklyReportBySignature(uint(reportID), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	reader, err := fileService.OpenWeeklyReport(report)
+	serveFile(c, reader, err, report.FileSize, report.FileName, "application/pdf", c.Query("download") == "1")
+}
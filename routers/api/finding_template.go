@@ -0,0 +1,274 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var findingTemplateService = &services.FindingTemplateService{}
+
+// GetFindingTemplates 获取当前用户可用的漏洞模板（共享模板和自己的个人模板）
+func GetFindingTemplates(c *gin.Context) {
+	var req services.FindingTemplateListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	result, err := findingTemplateService.GetTemplateList(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": result,
+	})
+}
+
+// GetFindingTemplatePlaceholders 获取漏洞模板支持的占位符
+func GetFindingTemplatePlaceholders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": services.FindingTemplatePlaceholders,
+	})
+}
+
+// GetFindingTemplateStats 获取漏洞模板使用统计
+func GetFindingTemplateStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	stats, err := findingTemplateService.GetTemplateStats(days, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": stats,
+	})
+}
+
+// GetFindingTemplate 获取漏洞模板详情
+func GetFindingTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "模板ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	template, err := findingTemplateService.GetTemplate(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": template,
+	})
+}
+
+// CreateFindingTemplate 创建漏洞模板
+func CreateFindingTemplate(c *gin.Context) {
+	var req services.FindingTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	template, err := findingTemplateService.CreateTemplate(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "创建成功",
+		"data": template,
+	})
+}
+
+// UpdateFindingTemplate 修改漏洞模板
+func UpdateFindingTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "模板ID格式错误",
+		})
+		return
+	}
+
+	var req services.FindingTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	template, err := findingTemplateService.UpdateTemplate(uint(id), &req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "更新成功",
+		"data": template,
+	})
+}
+
+// DeleteFindingTemplate 删除漏洞模板
+func DeleteFindingTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "模板ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	if err := findingTemplateService.DeleteTemplate(uint(id), userID.(uint), roleCode.(string)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// RenderFindingTemplate 按资产和漏洞地址渲染模板，用于预填提交漏洞表单
+func RenderFindingTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "模板ID格式错误",
+		})
+		return
+	}
+
+	var req services.FindingTemplateRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	result, err := findingTemplateService.RenderTemplate(uint(id), &req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": result,
+	})
+}
+
+// CreateTemplateFromVuln 将漏洞转换为模板
+func CreateTemplateFromVuln(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	var req services.FindingTemplateFromVulnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	template, err := findingTemplateService.CreateTemplateFromVuln(uint(vulnID), &req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "已保存为模板",
+		"data": template,
+	})
+}
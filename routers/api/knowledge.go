@@ -0,0 +1,290 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var knowledgeService = &services.KnowledgeService{}
+
+// parseKnowledgeVersion 解析路径中的文章ID和版本号
+func parseKnowledgeVersion(c *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文章ID格式错误",
+		})
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "版本号格式错误",
+		})
+		return 0, 0, false
+	}
+	return uint(id), version, true
+}
+
+// GetKnowledgeList 获取知识库文章列表，支持全文检索
+func GetKnowledgeList(c *gin.Context) {
+	var req services.KnowledgeListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	result, err := knowledgeService.GetKnowledgeList(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": result,
+	})
+}
+
+// GetKnowledge 获取知识库文章详情
+func GetKnowledge(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文章ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	article, err := knowledgeService.GetKnowledge(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": article,
+	})
+}
+
+// MatchKnowledge 按漏洞类型、分类和CWE编号匹配知识库文章，用于提交漏洞时预填修复建议
+func MatchKnowledge(c *gin.Context) {
+	var req services.KnowledgeMatchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	article, err := knowledgeService.MatchKnowledge(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": article,
+	})
+}
+
+// GetKnowledgeVersions 获取知识库文章的历史版本列表
+func GetKnowledgeVersions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文章ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	versions, err := knowledgeService.GetKnowledgeVersions(uint(id), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": versions,
+	})
+}
+
+// GetKnowledgeVersion 获取知识库文章指定版本的内容
+func GetKnowledgeVersion(c *gin.Context) {
+	id, version, ok := parseKnowledgeVersion(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	articleVersion, err := knowledgeService.GetKnowledgeVersion(id, version, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": articleVersion,
+	})
+}
+
+// CreateKnowledge 创建知识库文章
+func CreateKnowledge(c *gin.Context) {
+	var req services.KnowledgeArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	article, err := knowledgeService.CreateKnowledge(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "创建成功",
+		"data": article,
+	})
+}
+
+// UpdateKnowledge 修改知识库文章，保存为新版本
+func UpdateKnowledge(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文章ID格式错误",
+		})
+		return
+	}
+
+	var req services.KnowledgeArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	article, err := knowledgeService.UpdateKnowledge(uint(id), &req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "更新成功",
+		"data": article,
+	})
+}
+
+// DeleteKnowledge 删除知识库文章
+func DeleteKnowledge(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文章ID格式错误",
+		})
+		return
+	}
+
+	if err := knowledgeService.DeleteKnowledge(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// RestoreKnowledgeVersion 将知识库文章恢复为指定版本
+func RestoreKnowledgeVersion(c *gin.Context) {
+	id, version, ok := parseKnowledgeVersion(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	article, err := knowledgeService.RestoreKnowledgeVersion(id, version, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "恢复成功",
+		"data": article,
+	})
+}
@@ -428,3 +428,132 @@ func RefreshProjectStats(c *gin.Context) {
 		},
 	})
 }
+
+// GetProjectChatBots获取项目专属群机器人
+// GET /api/projects/:id/chatbots
+func GetProjectChatBots(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "项目ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	bots, err := chatBotService.GetProjectChatBots(uint(projectID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": bots,
+	})
+}
+
+// SaveProjectChatBot创建或更新项目专属群机器人
+// PUT /api/projects/:id/chatbots/:platform
+func SaveProjectChatBot(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "项目ID格式错误",
+		})
+		return
+	}
+
+	var req services.ProjectChatBotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	bot, err := chatBotService.SaveProjectChatBot(uint(projectID), c.Param("platform"), &req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "保存成功",
+		"data": bot,
+	})
+}
+
+// DeleteProjectChatBot删除项目专属群机器人，删除后恢复使用全局群机器人
+// DELETE /api/projects/:id/chatbots/:platform
+func DeleteProjectChatBot(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "项目ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	if err := chatBotService.DeleteProjectChatBot(uint(projectID), c.Param("platform"), userID.(uint), roleCode.(string)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// TestProjectChatBot向项目专属群机器人发送测试消息
+// POST /api/projects/:id/chatbots/:platform/test
+func TestProjectChatBot(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "项目ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	if err := chatBotService.TestProjectChatBot(uint(projectID), c.Param("platform"), userID.(uint), roleCode.(string)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "测试消息发送失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "测试消息发送成功",
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var realtimeService = &services.RealtimeService{}
+
+// IssueStreamTicket 获取实时推送票据
+// POST /api/stream/ticket，返回一分钟内有效的票据，用于 GET /api/stream?ticket=... 建立连接
+func IssueStreamTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	ticket, expiresAt := realtimeService.IssueStreamTicket(userID.(uint))
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"ticket":     ticket,
+			"expires_at": expiresAt,
+		},
+	})
+}
+
+// StreamEvents 订阅实时推送
+// GET /api/stream?vuln_id=12，以Server-Sent Events方式推送当前用户的站内通知，
+// 指定vuln_id时同时推送该漏洞的时间线和评论。EventSource无法设置请求头，通过查询参数ticket传递推送票据
+func StreamEvents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	var vulnID uint64
+	if vulnIDStr := c.Query("vuln_id"); vulnIDStr != "" {
+		var err error
+		vulnID, err = strconv.ParseUint(vulnIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "漏洞ID格式错误",
+			})
+			return
+		}
+	}
+
+	topics, err := realtimeService.SubscribeTopics(userID.(uint), roleCode.(string), uint(vulnID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	unreadCount, _ := notificationService.GetUnreadCount(userID.(uint))
+
+	events, cancel := services.GetRealtimeBroker().Subscribe(topics...)
+	defer cancel()
+
+	heartbeat := time.NewTicker(realtimeService.HeartbeatInterval())
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁用Nginx缓冲，保证事件即时送达
+
+	// 连接建立后先推送未读通知数，客户端据此初始化通知角标
+	c.SSEvent("ready", gin.H{
+		"topics":       topics,
+		"unread_count": unreadCount,
+	})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			// SSE注释行，客户端会忽略，仅用于保持连接
+			io.WriteString(w, ": ping\n\n")
+			return true
+		}
+	})
+}
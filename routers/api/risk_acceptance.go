@@ -0,0 +1,310 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var riskAcceptanceService = &services.RiskAcceptanceService{}
+
+// GetRiskApprovers 获取可以审批风险接受申请的用户
+func GetRiskApprovers(c *gin.Context) {
+	approvers, err := riskAcceptanceService.GetApprovers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": approvers,
+	})
+}
+
+// CreateRiskAcceptance 为漏洞提交风险接受申请
+func CreateRiskAcceptance(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	var req services.RiskAcceptanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	ra, err := riskAcceptanceService.CreateRiskAcceptance(uint(vulnID), &req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "申请已提交",
+		"data": ra,
+	})
+}
+
+// GetVulnRiskAcceptances 获取漏洞的风险接受申请记录
+func GetVulnRiskAcceptances(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	items, err := riskAcceptanceService.GetVulnRiskAcceptances(uint(vulnID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": items,
+	})
+}
+
+// GetRiskAcceptances 获取风险接受申请列表
+func GetRiskAcceptances(c *gin.Context) {
+	var req services.RiskAcceptanceListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	result, err := riskAcceptanceService.GetRiskAcceptances(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": result,
+	})
+}
+
+// GetRiskAcceptance 获取风险接受申请详情
+func GetRiskAcceptance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "申请ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	ra, err := riskAcceptanceService.GetRiskAcceptanceDetail(uint(id), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": ra,
+	})
+}
+
+// ApproveRiskAcceptance 批准风险接受申请
+func ApproveRiskAcceptance(c *gin.Context) {
+	reviewRiskAcceptance(c, true)
+}
+
+// RejectRiskAcceptance 拒绝风险接受申请
+func RejectRiskAcceptance(c *gin.Context) {
+	reviewRiskAcceptance(c, false)
+}
+
+// reviewRiskAcceptance 审批风险接受申请
+func reviewRiskAcceptance(c *gin.Context, approve bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "申请ID格式错误",
+		})
+		return
+	}
+
+	// 审批意见可选，允许不带请求体
+	var req services.RiskReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+
+	ra, err := riskAcceptanceService.ReviewRiskAcceptance(uint(id), approve, req.Comment, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	msg := "已批准"
+	if !approve {
+		msg = "已拒绝"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  msg,
+		"data": ra,
+	})
+}
+
+// CancelRiskAcceptance 申请人撤回风险接受申请
+func CancelRiskAcceptance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "申请ID格式错误",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := riskAcceptanceService.CancelRiskAcceptance(uint(id), userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "已撤回",
+	})
+}
+
+// RevokeRiskAcceptance 审批人撤销已批准的风险接受
+func RevokeRiskAcceptance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "申请ID格式错误",
+		})
+		return
+	}
+
+	// 审批意见可选，允许不带请求体
+	var req services.RiskReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := riskAcceptanceService.RevokeRiskAcceptance(uint(id), req.Comment, userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "已撤销",
+	})
+}
+
+// ExportRiskRegister 导出风险例外登记册
+func ExportRiskRegister(c *gin.Context) {
+	var req services.RiskAcceptanceListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	excelData, err := riskAcceptanceService.ExportRiskRegister(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("risk_register_%s.xlsx", time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", fmt.Sprintf("%d", len(excelData)))
+
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelData)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportScanResults 导入扫描结果（Nessus、OpenVAS、Burp Suite、Nuclei、OWASP ZAP、SARIF）
+func ImportScanResults(c *gin.Context) {
+	var req services.ScanImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请选择要上传的扫描结果文件",
+		})
+		return
+	}
+
+	// 检查文件大小（限制为50MB）
+	if file.Size > 50*1024*1024 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件大小不能超过50MB",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	result, err := vulnService.ImportScanResults(file, &req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	msg := fmt.Sprintf("导入完成，创建%d条，重复%d条，失败%d条", result.Created, result.Duplicates, result.Failed)
+	if result.DryRun {
+		msg = fmt.Sprintf("预览完成，可导入%d条，重复%d条，失败%d条", result.Created, result.Duplicates, result.Failed)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  msg,
+		"data": result,
+	})
+}
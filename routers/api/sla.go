@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var slaService = &services.SLAService{}
+
+// GetSLAPolicyOptions 获取SLA策略可选项（严重程度、资产重要性、资产所属环境）
+func GetSLAPolicyOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": slaService.GetSLAPolicyOptions(),
+	})
+}
+
+// GetSLAPolicies 获取SLA策略列表
+func GetSLAPolicies(c *gin.Context) {
+	policies, err := slaService.GetSLAPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": policies,
+	})
+}
+
+// CreateSLAPolicy 创建SLA策略
+func CreateSLAPolicy(c *gin.Context) {
+	var req services.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	policy, err := slaService.CreateSLAPolicy(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "创建成功",
+		"data": policy,
+	})
+}
+
+// UpdateSLAPolicy 更新SLA策略
+func UpdateSLAPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "SLA策略ID格式错误",
+		})
+		return
+	}
+
+	var req services.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	policy, err := slaService.UpdateSLAPolicy(uint(id), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "更新成功",
+		"data": policy,
+	})
+}
+
+// DeleteSLAPolicy 删除SLA策略
+func DeleteSLAPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "SLA策略ID格式错误",
+		})
+		return
+	}
+
+	if err := slaService.DeleteSLAPolicy(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// GetSLACompliance 获取SLA达成率统计（按项目、团队、工程师）
+func GetSLACompliance(c *gin.Context) {
+	var req services.SLAComplianceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	report, err := slaService.GetSLACompliance(&req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": report,
+	})
+}
@@ -11,6 +11,22 @@ import (
 )
 
 var systemService = &services.SystemService{}
+var notificationService = &services.NotificationService{}
+var chatBotService = &services.ChatBotService{}
+
+// sensitiveConfigKeys 需要脱敏的系统配置，接口中以星号返回
+var sensitiveConfigKeys = map[string]bool{
+	"email.password":       true,
+	"dingtalk.webhook_url": true,
+	"dingtalk.secret":      true,
+	"wecom.webhook_url":    true,
+	"feishu.webhook_url":   true,
+	"feishu.secret":        true,
+	"slack.webhook_url":    true,
+}
+
+// maskedConfigValue 脱敏后返回的配置值
+const maskedConfigValue = "********"
 
 // GetPublicSystemInfo 获取公开的系统信息（无需认证）
 func GetPublicSystemInfo(c *gin.Context) {
@@ -71,8 +87,8 @@ func GetSystemConfigs(c *gin.Context) {
 
 	// 对敏感信息进行脱敏处理
 	for i := range configs {
-		if configs[i].Key == "email.password" && configs[i].Value != "" {
-			configs[i].Value = "********" // 用星号替换密码
+		if sensitiveConfigKeys[configs[i].Key] && configs[i].Value != "" {
+			configs[i].Value = maskedConfigValue // 用星号替换密码和机器人凭证
 		}
 	}
 
@@ -97,8 +113,8 @@ func GetSystemConfig(c *gin.Context) {
 	}
 
 	// 对敏感信息进行脱敏处理
-	if config.Key == "email.password" && config.Value != "" {
-		config.Value = "********" // 用星号替换密码
+	if sensitiveConfigKeys[config.Key] && config.Value != "" {
+		config.Value = maskedConfigValue // 用星号替换密码和机器人凭证
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -135,6 +151,15 @@ func UpdateSystemConfig(c *gin.Context) {
 		return
 	}
 
+	// 敏感配置提交的是脱敏后的值，说明未修改，保持原值
+	if sensitiveConfigKeys[key] && req.Value == maskedConfigValue {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 200,
+			"msg":  "更新成功",
+		})
+		return
+	}
+
 	err := systemService.UpdateSystemConfig(key, req.Value, req.Description)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -350,6 +375,161 @@ func MarkNotificationRead(c *gin.Context) {
 	})
 }
 
+// GetUnreadNotificationCount 获取未读通知数
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	count, err := notificationService.GetUnreadCount(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"unread_count": count,
+		},
+	})
+}
+
+// MarkAllNotificationsRead 标记全部通知已读
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	count, err := notificationService.MarkAllRead(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "标记成功",
+		"data": gin.H{
+			"marked_count": count,
+		},
+	})
+}
+
+// DeleteNotification 删除通知
+func DeleteNotification(c *gin.Context) {
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "通知ID格式错误",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	if err := notificationService.DeleteNotification(uint(notificationID), userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// GetNotificationPreferences 获取当前用户的通知偏好
+func GetNotificationPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	prefs, err := notificationService.GetPreferences(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": prefs,
+	})
+}
+
+// UpdateNotificationPreferences 更新当前用户的通知偏好
+func UpdateNotificationPreferences(c *gin.Context) {
+	var req services.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	prefs, err := notificationService.UpdatePreferences(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "保存成功",
+		"data": prefs,
+	})
+}
+
 // GetDictionaries 获取数据字典
 func GetDictionaries(c *gin.Context) {
 	dictType := c.Query("type")
@@ -437,3 +617,38 @@ func TestEmailConfig(c *gin.Context) {
 		"msg":  "测试邮件发送成功",
 	})
 }
+
+// TestChatBotConfig 测试群机器人配置
+func TestChatBotConfig(c *gin.Context) {
+	// 检查权限
+	roleCode := c.GetString("role_code")
+	if roleCode != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"msg":  "权限不足",
+		})
+		return
+	}
+
+	var req services.ChatBotTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := chatBotService.TestSend(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  "测试消息发送失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "测试消息发送成功",
+	})
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var vulnAttachmentService = &services.VulnAttachmentService{}
+
+// UploadVulnAttachment 上传漏洞附件
+func UploadVulnAttachment(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请选择要上传的附件: " + err.Error(),
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	attachment, err := vulnAttachmentService.UploadAttachment(uint(vulnID), file, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "上传成功",
+		"data": attachment,
+	})
+}
+
+// GetVulnAttachments 获取漏洞附件列表
+func GetVulnAttachments(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	attachments, err := vulnAttachmentService.GetAttachments(uint(vulnID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": attachments,
+	})
+}
+
+// DownloadVulnAttachment 下载漏洞附件
+func DownloadVulnAttachment(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "附件ID格式错误",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	attachment, err := vulnAttachmentService.GetAttachment(uint(vulnID), uint(attachmentID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	reader, err := fileService.OpenAttachment(attachment)
+	serveFile(c, reader, err, attachment.FileSize, attachment.FileName, attachment.MimeType, true)
+}
+
+// DeleteVulnAttachment 删除漏洞附件
+func DeleteVulnAttachment(c *gin.Context) {
+	vulnID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "附件ID格式错误",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	err = vulnAttachmentService.DeleteAttachment(uint(vulnID), uint(attachmentID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkUpdateVulns 批量操作漏洞（分配、调整严重程度、变更状态、添加标签、设置截止时间、删除）
+func BulkUpdateVulns(c *gin.Context) {
+	var req services.VulnBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+
+	result, err := vulnService.BulkUpdateVulns(&req, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "批量操作完成",
+		"data": result,
+	})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var vulnCategoryService = &services.VulnCategoryService{}
+
+// GetVulnCategories 获取启用的漏洞分类树，用于提交漏洞时选择分类和按分类筛选
+func GetVulnCategories(c *gin.Context) {
+	categories, err := vulnCategoryService.GetVulnCategoryTree(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": categories,
+	})
+}
+
+// GetAllVulnCategories 获取全部漏洞分类树（含已禁用的分类），用于分类管理
+func GetAllVulnCategories(c *gin.Context) {
+	categories, err := vulnCategoryService.GetVulnCategoryTree(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": categories,
+	})
+}
+
+// CreateVulnCategory 创建漏洞分类
+func CreateVulnCategory(c *gin.Context) {
+	var req services.VulnCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := vulnCategoryService.CreateVulnCategory(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "创建成功",
+		"data": category,
+	})
+}
+
+// UpdateVulnCategory 更新漏洞分类
+func UpdateVulnCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞分类ID格式错误",
+		})
+		return
+	}
+
+	var req services.VulnCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	category, err := vulnCategoryService.UpdateVulnCategory(uint(id), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "更新成功",
+		"data": category,
+	})
+}
+
+// DeleteVulnCategory 删除漏洞分类
+func DeleteVulnCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "漏洞分类ID格式错误",
+		})
+		return
+	}
+
+	if err := vulnCategoryService.DeleteVulnCategory(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// ClassifyVulns 为尚未归类的漏洞重新匹配分类
+func ClassifyVulns(c *gin.Context) {
+	count := vulnCategoryService.ClassifyVulns()
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  fmt.Sprintf("归类完成，%d个漏洞已匹配分类", count),
+		"data": gin.H{
+			"classified": count,
+		},
+	})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportVulns 按漏洞ID或漏洞列表的筛选条件导出漏洞（Excel或CSV）
+func ExportVulns(c *gin.Context) {
+	var req services.VulnExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+	req.CurrentUserID = userID.(uint)
+	req.CurrentUserRole = roleCode.(string)
+
+	data, err := vulnService.ExportVulns(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	filename := fmt.Sprintf("vulns_%s.xlsx", time.Now().Format("20060102150405"))
+	if strings.ToLower(req.Format) == "csv" {
+		contentType = "text/csv; charset=utf-8"
+		filename = fmt.Sprintf("vulns_%s.csv", time.Now().Format("20060102150405"))
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", fmt.Sprintf("%d", len(data)))
+
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// DownloadVulnTemplate 下载漏洞导入模板
+func DownloadVulnTemplate(c *gin.Context) {
+	templateData, err := vulnService.GenerateVulnImportTemplate()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	filename := "vuln_import_template.xlsx"
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", fmt.Sprintf("%d", len(templateData)))
+
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", templateData)
+}
+
+// ImportVulns 从Excel或CSV文件批量导入漏洞
+func ImportVulns(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请选择要上传的Excel或CSV文件",
+		})
+		return
+	}
+
+	filename := strings.ToLower(file.Filename)
+	if !strings.HasSuffix(filename, ".xlsx") && !strings.HasSuffix(filename, ".csv") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "请上传Excel或CSV文件（.xlsx或.csv格式）",
+		})
+		return
+	}
+
+	// 检查文件大小（限制为10MB）
+	if file.Size > 10*1024*1024 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "文件大小不能超过10MB",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	roleCode, _ := c.Get("role_code")
+	dryRun := c.PostForm("dry_run") == "true"
+
+	result, err := vulnService.ImportVulns(file, dryRun, userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	msg := fmt.Sprintf("导入完成，成功%d条，失败%d条", result.SuccessCount, result.FailureCount)
+	if dryRun {
+		msg = fmt.Sprintf("校验完成，可导入%d条，失败%d条", result.SuccessCount, result.FailureCount)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  msg,
+		"data": result,
+	})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVulnRiskLevels 获取风险优先级可选项
+func GetVulnRiskLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": vulnService.GetVulnRiskLevels(),
+	})
+}
+
+// RecalculateVulnRisk 重新计算全部漏洞的风险优先级评分
+func RecalculateVulnRisk(c *gin.Context) {
+	count := vulnService.RecalculateVulnRisk()
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  fmt.Sprintf("重新计算完成，%d个漏洞的风险优先级评分有变化", count),
+		"data": gin.H{
+			"updated": count,
+		},
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"vulnmain/services"
@@ -133,10 +134,7 @@ func UpdateVuln(c *gin.Context) {
 
 	vuln, err := vulnService.UpdateVuln(uint(vulnID), &req, userID.(uint), roleCode.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  err.Error(),
-		})
+		respondVulnError(c, err)
 		return
 	}
 
@@ -265,10 +263,7 @@ func AuditVuln(c *gin.Context) {
 
 	err = vulnService.AuditVuln(uint(vulnID), &req, userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  err.Error(),
-		})
+		respondVulnError(c, err)
 		return
 	}
 
@@ -301,10 +296,7 @@ func FixVuln(c *gin.Context) {
 
 	err = vulnService.FixVuln(uint(vulnID), userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code": 400,
-			"msg":  err.Error(),
-		})
+		respondVulnError(c, err)
 		return
 	}
 
@@ -349,9 +341,100 @@ func RetestVuln(c *gin.Context) {
 	}
 
 	err = vulnService.RetestVuln(uint(vulnID), req.Result, userID.(uint))
+	if err != nil {
+		respondVulnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "复测成功",
+	})
+}
+
+// respondVulnError 返回漏洞操作的错误，状态流转失败时附带当前状态、目标状态、允许的流转和审批进度
+func respondVulnError(c *gin.Context, err error) {
+	var te *services.TransitionError
+	if errors.As(err, &te) {
+		status := http.StatusBadRequest
+		switch te.Code {
+		case services.TransitionForbidden:
+			status = http.StatusForbidden
+		case services.TransitionPendingApproval:
+			// 多人审批的流转已记录本次审批，状态尚未变更
+			status = http.StatusAccepted
+		}
+		c.JSON(status, gin.H{
+			"code": status,
+			"msg":  te.Message,
+			"data": te,
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code": 400,
+		"msg":  err.Error(),
+	})
+}
+
+// GetVulnWorkflow 获取漏洞工作流定义
+// 指定project_id时返回该项目使用的工作流，否则返回默认工作流
+func GetVulnWorkflow(c *gin.Context) {
+	var projectID uint64
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		id, err := strconv.ParseUint(projectIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  "项目ID格式错误",
+			})
+			return
+		}
+		projectID = id
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": vulnService.GetVulnWorkflow(uint(projectID)),
+	})
+}
+
+// GetVulnTransitions 获取当前用户对漏洞可执行的状态流转
+func GetVulnTransitions(c *gin.Context) {
+	vulnIDStr := c.Param("id")
+	vulnID, err := strconv.ParseUint(vulnIDStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
+			"msg":  "漏洞ID格式错误",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	roleCode, exists := c.Get("role_code")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户角色信息缺失",
+		})
+		return
+	}
+
+	transitions, err := vulnService.GetAvailableTransitions(uint(vulnID), userID.(uint), roleCode.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
 			"msg":  err.Error(),
 		})
 		return
@@ -359,7 +442,8 @@ func RetestVuln(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
-		"msg":  "复测成功",
+		"msg":  "获取成功",
+		"data": transitions,
 	})
 }
 
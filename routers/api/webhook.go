@@ -0,0 +1,252 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"vulnmain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var webhookService = &services.WebhookService{}
+
+// GetWebhookEvents 获取可订阅的Webhook事件列表
+func GetWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": services.WebhookEvents,
+	})
+}
+
+// GetWebhooks 获取Webhook订阅列表
+func GetWebhooks(c *gin.Context) {
+	webhooks, err := webhookService.GetWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": webhooks,
+	})
+}
+
+// CreateWebhook 创建Webhook订阅
+// 签名密钥仅在创建时返回一次，请妥善保存
+func CreateWebhook(c *gin.Context) {
+	var req services.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"msg":  "用户未认证",
+		})
+		return
+	}
+
+	webhook, secret, err := webhookService.CreateWebhook(&req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "创建成功",
+		"data": gin.H{
+			"webhook": webhook,
+			"secret":  secret,
+		},
+	})
+}
+
+// UpdateWebhook 更新Webhook订阅
+func UpdateWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "Webhook ID格式错误",
+		})
+		return
+	}
+
+	var req services.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	webhook, err := webhookService.UpdateWebhook(uint(id), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "更新成功",
+		"data": webhook,
+	})
+}
+
+// DeleteWebhook 删除Webhook订阅
+func DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "Webhook ID格式错误",
+		})
+		return
+	}
+
+	if err := webhookService.DeleteWebhook(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// TestWebhook 发送测试推送
+func TestWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "Webhook ID格式错误",
+		})
+		return
+	}
+
+	delivery, err := webhookService.TestWebhook(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	msg := "测试推送成功"
+	if delivery.Status != services.DeliverySuccess {
+		msg = "测试推送失败: " + delivery.Error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  msg,
+		"data": delivery,
+	})
+}
+
+// GetWebhookDeliveries 获取Webhook投递记录列表
+func GetWebhookDeliveries(c *gin.Context) {
+	var req services.WebhookDeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := webhookService.GetDeliveries(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": response,
+	})
+}
+
+// GetWebhookDelivery 获取Webhook投递记录详情
+func GetWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "投递记录ID格式错误",
+		})
+		return
+	}
+
+	delivery, err := webhookService.GetDelivery(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": delivery,
+	})
+}
+
+// ReplayWebhookDelivery 重放Webhook投递记录
+func ReplayWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "投递记录ID格式错误",
+		})
+		return
+	}
+
+	delivery, err := webhookService.ReplayDelivery(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "重放成功",
+		"data": delivery,
+	})
+}
@@ -4,7 +4,6 @@ package api
 
 import (
 	"net/http"
-	"os"
 	"strconv"
 	"vulnmain/models"
 	"vulnmain/services"
@@ -249,32 +248,9 @@ func PreviewWeeklyReportFile(c *gin.Context) {
 		return
 	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(report.FilePath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code": 404,
-			"msg":  "PDF文件不存在",
-		})
-		return
-	}
-
-	// 读取文件
-	fileData, err := os.ReadFile(report.FilePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "读取PDF文件失败: " + err.Error(),
-		})
-		return
-	}
-
-	// 设置响应头
-	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", "inline; filename="+report.FileName)
-	c.Header("Content-Length", strconv.Itoa(len(fileData)))
-
-	// 返回PDF数据
-	c.Data(http.StatusOK, "application/pdf", fileData)
+	// 从存储后端读取文件
+	reader, err := fileService.OpenWeeklyReport(&report)
+	serveFile(c, reader, err, report.FileSize, report.FileName, "application/pdf", false)
 }
 
 // DownloadWeeklyReportFile 下载周报PDF文件
@@ -299,30 +275,7 @@ func DownloadWeeklyReportFile(c *gin.Context) {
 		return
 	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(report.FilePath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code": 404,
-			"msg":  "PDF文件不存在",
-		})
-		return
-	}
-
-	// 读取文件
-	fileData, err := os.ReadFile(report.FilePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code": 500,
-			"msg":  "读取PDF文件失败: " + err.Error(),
-		})
-		return
-	}
-
-	// 设置下载响应头
-	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", "attachment; filename="+report.FileName)
-	c.Header("Content-Length", strconv.Itoa(len(fileData)))
-
-	// 返回PDF数据
-	c.Data(http.StatusOK, "application/pdf", fileData)
+	// 从存储后端读取文件
+	reader, err := fileService.OpenWeeklyReport(&report)
+	serveFile(c, reader, err, report.FileSize, report.FileName, "application/pdf", true)
 }
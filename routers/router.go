@@ -50,11 +50,7 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 	// 添加CORS中间件，允许跨域请求
 	r.Use(CORSMiddleware())
 
-	// 静态文件服务 - 提供上传的文件访问
-	r.Static("/uploads", "./uploads")
-
-	// 专门为周报PDF文件提供静态访问
-	r.Static("/weekly-reports", "./uploads/weekly")
+	// 上传文件不再通过静态目录公开访问，统一由文件下载接口校验权限或签名后输出
 
 	// 公开API组 - 不需要JWT认证的接口
 	// 这些接口可以匿名访问，主要用于用户登录和令牌刷新
@@ -67,6 +63,17 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 
 		// 公开系统信息接口
 		publicAPI.GET("/system/info", api.GetPublicSystemInfo) // 获取公开的系统信息（公司名称等）
+
+		// 签名链接下载接口，凭短时效签名访问，用于Markdown图片和邮件链接
+		publicAPI.GET("/files/:id/signed", api.DownloadSignedFile)                 // 通过签名链接下载文件
+		publicAPI.GET("/weekly-reports/:id/signed", api.DownloadSignedWeeklyReport) // 通过签名链接下载周报
+	}
+
+	// 实时推送API组 - 浏览器EventSource无法设置请求头，允许通过查询参数ticket传递短时效推送票据
+	streamAPI := r.Group("/api")
+	streamAPI.Use(middleware.StreamAuthMiddleware())
+	{
+		streamAPI.GET("/stream", api.StreamEvents) // 订阅站内通知和漏洞动态（Server-Sent Events）
 	}
 
 	// 需要认证的API组 - 通过JWT中间件进行身份验证
@@ -80,6 +87,8 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 		authAPI.PUT("/user/password", api.ChangePassword)       // 修改当前用户密码
 		authAPI.PUT("/user/profile", api.UpdateProfile)         // 修改当前用户个人信息
 		authAPI.POST("/upload/vuln-image", api.UploadVulnImage) // 上传漏洞相关图片
+		authAPI.GET("/files/:id", api.DownloadFile)                // 下载文件，按关联漏洞校验访问权限
+		authAPI.POST("/files/signed-urls", api.GetSignedFileURLs)  // 批量获取文件签名链接
 
 		// 仪表板模块 - 需要首页查看权限
 		dashboardAPI := authAPI.Group("/dashboard")
@@ -87,6 +96,7 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 		{
 			dashboardAPI.GET("/stats", api.GetSystemStats)  // 获取系统统计数据
 			dashboardAPI.GET("/data", api.GetDashboardData) // 获取仪表板数据
+			dashboardAPI.GET("/sla", api.GetSLACompliance)  // 获取SLA达成率统计
 		}
 
 		// 用户管理模块 - 采用分层权限控制
@@ -136,8 +146,20 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 		{
 			vulnViewAPI.GET("", api.GetVulnList)            // 获取漏洞列表
 			vulnViewAPI.GET("/stats", api.GetVulnStats)     // 获取漏洞统计信息
+			vulnViewAPI.GET("/workflow", api.GetVulnWorkflow) // 获取漏洞状态机定义
+			vulnViewAPI.GET("/cve-intel/:cve_id", api.GetCVEIntel) // 查询本地CVE情报（NVD、KEV、EPSS）
+			vulnViewAPI.GET("/risk-levels", api.GetVulnRiskLevels)  // 获取风险优先级可选项
+			vulnViewAPI.GET("/categories", api.GetVulnCategories)   // 获取启用的漏洞分类树
 			vulnViewAPI.GET("/:id", api.GetVuln)            // 获取漏洞详情
 			vulnViewAPI.GET("/:id/timeline", api.GetVulnTimeline) // 获取漏洞时间线
+			vulnViewAPI.GET("/:id/transitions", api.GetVulnTransitions) // 获取当前用户可执行的状态流转
+			vulnViewAPI.GET("/:id/duplicates", api.GetVulnDuplicates)   // 获取疑似重复漏洞及相似度
+			vulnViewAPI.GET("/:id/relations", api.GetVulnRelations)     // 获取漏洞关系图
+			vulnViewAPI.GET("/:id/risk-acceptances", api.GetVulnRiskAcceptances) // 获取漏洞的风险接受申请记录
+			vulnViewAPI.POST("/:id/risk-acceptances", api.CreateRiskAcceptance)  // 提交风险接受申请
+			vulnViewAPI.POST("/export", api.ExportVulns)          // 按漏洞ID或筛选条件导出漏洞（Excel或CSV）
+			vulnViewAPI.GET("/:id/attachments", api.GetVulnAttachments)                                // 获取漏洞附件列表
+			vulnViewAPI.GET("/:id/attachments/:attachment_id/download", api.DownloadVulnAttachment) // 下载漏洞附件
 		}
 
 		// 漏洞创建权限组 - 可以创建新漏洞
@@ -145,15 +167,32 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 		vulnCreateAPI.Use(middleware.PermissionMiddleware("vuln:create"))
 		{
 			vulnCreateAPI.POST("", api.CreateVuln) // 创建新漏洞
+			vulnCreateAPI.POST("/duplicates/check", api.CheckVulnDuplicates) // 提交前检查疑似重复漏洞
+			vulnCreateAPI.GET("/import/template", api.DownloadVulnTemplate)   // 下载漏洞导入模板
+			vulnCreateAPI.POST("/import", api.ImportVulns)                     // 从Excel或CSV批量导入漏洞
+			vulnCreateAPI.POST("/import/scan", api.ImportScanResults)         // 导入扫描结果
+			vulnCreateAPI.GET("/templates", api.GetFindingTemplates)                           // 获取可用的漏洞模板（共享和个人）
+			vulnCreateAPI.GET("/templates/placeholders", api.GetFindingTemplatePlaceholders)   // 获取模板支持的占位符
+			vulnCreateAPI.GET("/templates/stats", api.GetFindingTemplateStats)                 // 获取模板使用统计
+			vulnCreateAPI.GET("/templates/:id", api.GetFindingTemplate)                        // 获取漏洞模板详情
+			vulnCreateAPI.POST("/templates", api.CreateFindingTemplate)                        // 创建漏洞模板
+			vulnCreateAPI.PUT("/templates/:id", api.UpdateFindingTemplate)                     // 修改漏洞模板（仅创建人）
+			vulnCreateAPI.DELETE("/templates/:id", api.DeleteFindingTemplate)                  // 删除漏洞模板（仅创建人）
+			vulnCreateAPI.POST("/templates/:id/render", api.RenderFindingTemplate)             // 按资产和漏洞地址渲染模板
+			vulnCreateAPI.POST("/:id/template", api.CreateTemplateFromVuln)                    // 将漏洞转换为模板
 		}
 
 		// 漏洞编辑权限组 - 可以修改漏洞信息
 		vulnEditAPI := vulnAPI.Group("")
 		vulnEditAPI.Use(middleware.PermissionMiddleware("vuln:edit"))
 		{
+			vulnEditAPI.POST("/bulk", api.BulkUpdateVulns)        // 批量操作漏洞，删除操作需要vuln:delete权限
 			vulnEditAPI.PUT("/:id", api.UpdateVuln)               // 更新漏洞信息
 			vulnEditAPI.POST("/:id/comments", api.AddVulnComment) // 添加漏洞评论
 			vulnEditAPI.PUT("/:id/fix", api.FixVuln)              // 标记漏洞为已修复
+			vulnEditAPI.POST("/:id/enrich", api.EnrichVuln)       // 根据本地CVE情报补充漏洞信息
+			vulnEditAPI.POST("/:id/attachments", api.UploadVulnAttachment)                     // 上传漏洞附件
+			vulnEditAPI.DELETE("/:id/attachments/:attachment_id", api.DeleteVulnAttachment) // 删除漏洞附件
 		}
 
 		// 漏洞审核权限组 - 可以审核和复测漏洞
@@ -162,6 +201,10 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 		{
 			vulnAuditAPI.PUT("/:id/audit", api.AuditVuln)   // 审核漏洞
 			vulnAuditAPI.PUT("/:id/retest", api.RetestVuln) // 复测漏洞
+			vulnAuditAPI.POST("/:id/merge", api.MergeVuln)  // 将重复漏洞合并到目标漏洞
+			vulnAuditAPI.POST("/:id/split", api.SplitVuln)  // 将漏洞拆分为多个资产上的子漏洞
+			vulnAuditAPI.POST("/:id/relations", api.CreateVulnRelation)                  // 添加漏洞关系
+			vulnAuditAPI.DELETE("/:id/relations/:relation_id", api.DeleteVulnRelation) // 移除漏洞关系
 		}
 
 		// 漏洞删除权限组 - 可以删除漏洞
@@ -171,6 +214,20 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 			vulnDeleteAPI.DELETE("/:id", api.DeleteVuln) // 删除漏洞
 		}
 
+		// 风险接受模块 - 审批、撤销等操作由服务层按申请人和指定审批人校验
+		riskAPI := authAPI.Group("/risk-acceptances")
+		riskAPI.Use(middleware.PermissionMiddleware("vuln:view"))
+		{
+			riskAPI.GET("", api.GetRiskAcceptances)                 // 获取风险接受申请列表
+			riskAPI.GET("/approvers", api.GetRiskApprovers)         // 获取可选的审批人
+			riskAPI.GET("/export", api.ExportRiskRegister)          // 导出风险例外登记册
+			riskAPI.GET("/:id", api.GetRiskAcceptance)              // 获取申请详情
+			riskAPI.POST("/:id/approve", api.ApproveRiskAcceptance) // 批准申请
+			riskAPI.POST("/:id/reject", api.RejectRiskAcceptance)   // 拒绝申请
+			riskAPI.POST("/:id/cancel", api.CancelRiskAcceptance)   // 撤回申请
+			riskAPI.POST("/:id/revoke", api.RevokeRiskAcceptance)   // 撤销已批准的风险接受
+		}
+
 		// 资产管理模块 - 采用分层权限控制
 		assetAPI := authAPI.Group("/assets")
 
@@ -209,13 +266,29 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 			assetDeleteAPI.DELETE("/:id", api.DeleteAsset) // 删除资产
 		}
 
-		// 知识库管理模块 - TODO: 实现知识库功能
-		// knowledgeAPI := authAPI.Group("/knowledge")
-		// knowledgeAPI.Use(middleware.PermissionMiddleware("knowledge:view"))
-		// {
-		// 	knowledgeAPI.GET("", api.GetKnowledgeList)     // 获取知识库列表
-		// 	knowledgeAPI.GET("/:id", api.GetKnowledge)     // 获取知识库详情
-		// }
+		// 知识库管理模块 - 采用分层权限控制
+		knowledgeAPI := authAPI.Group("/knowledge")
+
+		// 知识库查看权限组 - 可以检索和查看修复指南
+		knowledgeViewAPI := knowledgeAPI.Group("")
+		knowledgeViewAPI.Use(middleware.PermissionMiddleware("knowledge:view"))
+		{
+			knowledgeViewAPI.GET("", api.GetKnowledgeList)                          // 获取知识库列表，支持全文检索
+			knowledgeViewAPI.GET("/match", api.MatchKnowledge)                      // 按漏洞信息匹配文章
+			knowledgeViewAPI.GET("/:id", api.GetKnowledge)                          // 获取知识库详情
+			knowledgeViewAPI.GET("/:id/versions", api.GetKnowledgeVersions)         // 获取历史版本列表
+			knowledgeViewAPI.GET("/:id/versions/:version", api.GetKnowledgeVersion) // 获取指定版本内容
+		}
+
+		// 知识库编辑权限组 - 可以创建、修改、删除文章和恢复历史版本
+		knowledgeEditAPI := knowledgeAPI.Group("")
+		knowledgeEditAPI.Use(middleware.PermissionMiddleware("knowledge:edit"))
+		{
+			knowledgeEditAPI.POST("", api.CreateKnowledge)                                      // 创建文章
+			knowledgeEditAPI.PUT("/:id", api.UpdateKnowledge)                                   // 修改文章
+			knowledgeEditAPI.DELETE("/:id", api.DeleteKnowledge)                                // 删除文章
+			knowledgeEditAPI.POST("/:id/versions/:version/restore", api.RestoreKnowledgeVersion) // 恢复历史版本
+		}
 
 		// 项目管理模块 - 采用分层权限控制
 		projectAPI := authAPI.Group("/projects")
@@ -244,6 +317,11 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 		{
 			projectEditAPI.PUT("/:id", api.UpdateProject)                  // 更新项目信息
 			projectEditAPI.POST("/refresh-stats", api.RefreshProjectStats) // 刷新项目统计数据
+
+			projectEditAPI.GET("/:id/chatbots", api.GetProjectChatBots)                     // 获取项目专属群机器人
+			projectEditAPI.PUT("/:id/chatbots/:platform", api.SaveProjectChatBot)           // 创建或更新项目专属群机器人
+			projectEditAPI.DELETE("/:id/chatbots/:platform", api.DeleteProjectChatBot)      // 删除项目专属群机器人
+			projectEditAPI.POST("/:id/chatbots/:platform/test", api.TestProjectChatBot)     // 测试项目专属群机器人
 		}
 
 		// 项目删除权限组 - 可以删除项目
@@ -266,6 +344,41 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 			systemConfigAPI.POST("/configs", api.CreateSystemConfig)        // 创建系统配置
 			systemConfigAPI.DELETE("/configs/:key", api.DeleteSystemConfig) // 删除系统配置
 			systemConfigAPI.POST("/email/test", api.TestEmailConfig)        // 测试邮件配置
+			systemConfigAPI.POST("/chatbot/test", api.TestChatBotConfig)    // 测试群机器人配置
+
+			systemConfigAPI.GET("/webhooks", api.GetWebhooks)                                     // 获取Webhook订阅列表
+			systemConfigAPI.GET("/webhooks/events", api.GetWebhookEvents)                         // 获取可订阅的事件列表
+			systemConfigAPI.POST("/webhooks", api.CreateWebhook)                                  // 创建Webhook订阅
+			systemConfigAPI.PUT("/webhooks/:id", api.UpdateWebhook)                               // 更新Webhook订阅
+			systemConfigAPI.DELETE("/webhooks/:id", api.DeleteWebhook)                            // 删除Webhook订阅
+			systemConfigAPI.POST("/webhooks/:id/test", api.TestWebhook)                           // 发送测试推送
+			systemConfigAPI.GET("/webhook-deliveries", api.GetWebhookDeliveries)                  // 获取投递记录列表
+			systemConfigAPI.GET("/webhook-deliveries/:id", api.GetWebhookDelivery)                // 获取投递记录详情
+			systemConfigAPI.POST("/webhook-deliveries/:id/replay", api.ReplayWebhookDelivery)     // 重放投递记录
+
+			systemConfigAPI.GET("/workflows", api.GetWorkflows)               // 获取漏洞工作流列表
+			systemConfigAPI.GET("/workflows/options", api.GetWorkflowOptions) // 获取工作流配置可选项
+			systemConfigAPI.GET("/workflows/:id", api.GetWorkflow)            // 获取工作流详情
+			systemConfigAPI.POST("/workflows", api.CreateWorkflow)            // 创建工作流
+			systemConfigAPI.PUT("/workflows/:id", api.UpdateWorkflow)         // 更新工作流
+			systemConfigAPI.DELETE("/workflows/:id", api.DeleteWorkflow)      // 删除工作流
+
+			systemConfigAPI.GET("/sla-policies", api.GetSLAPolicies)              // 获取SLA策略列表
+			systemConfigAPI.GET("/sla-policies/options", api.GetSLAPolicyOptions) // 获取SLA策略可选项
+			systemConfigAPI.POST("/sla-policies", api.CreateSLAPolicy)            // 创建SLA策略
+			systemConfigAPI.PUT("/sla-policies/:id", api.UpdateSLAPolicy)         // 更新SLA策略
+			systemConfigAPI.DELETE("/sla-policies/:id", api.DeleteSLAPolicy)      // 删除SLA策略
+
+			systemConfigAPI.GET("/cve-feeds", api.GetCVEFeedStatus)          // 获取CVE情报库状态和导入记录
+			systemConfigAPI.POST("/cve-feeds/refresh", api.RefreshCVEFeeds)  // 立即更新CVE情报
+			systemConfigAPI.POST("/cve-feeds/upload", api.UploadCVEFeed)     // 上传并导入CVE情报数据源文件
+			systemConfigAPI.POST("/risk-scores/recalculate", api.RecalculateVulnRisk) // 重新计算全部漏洞的风险优先级评分
+
+			systemConfigAPI.GET("/vuln-categories", api.GetAllVulnCategories)       // 获取全部漏洞分类树（含已禁用）
+			systemConfigAPI.POST("/vuln-categories", api.CreateVulnCategory)        // 创建漏洞分类
+			systemConfigAPI.PUT("/vuln-categories/:id", api.UpdateVulnCategory)     // 更新漏洞分类
+			systemConfigAPI.DELETE("/vuln-categories/:id", api.DeleteVulnCategory)  // 删除漏洞分类
+			systemConfigAPI.POST("/vuln-categories/classify", api.ClassifyVulns)    // 为未归类的漏洞重新匹配分类
 		}
 
 		// 系统日志权限组 - 可以查看操作日志
@@ -298,11 +411,18 @@ func InitRouter(r *gin.Engine) *gin.Engine {
 			weeklyReportAPI.GET("/history", api.GetWeeklyReportHistory)     // 获取周报历史记录
 			weeklyReportAPI.GET("/file/:id/preview", api.PreviewWeeklyReportFile) // 预览历史周报文件
 			weeklyReportAPI.GET("/file/:id/download", api.DownloadWeeklyReportFile) // 下载历史周报文件
+			weeklyReportAPI.GET("/file/:id/signed-url", api.GetWeeklyReportSignedURL) // 获取历史周报文件签名链接
 		}
 
 		// 通知相关接口 - 所有已认证用户都可以访问
+		authAPI.POST("/stream/ticket", api.IssueStreamTicket)           // 获取实时推送票据
 		authAPI.GET("/notifications", api.GetNotifications)              // 获取用户通知列表
 		authAPI.PUT("/notifications/:id/read", api.MarkNotificationRead) // 标记通知为已读
+		authAPI.GET("/notifications/unread-count", api.GetUnreadNotificationCount) // 获取未读通知数
+		authAPI.PUT("/notifications/read-all", api.MarkAllNotificationsRead)       // 标记全部通知为已读
+		authAPI.DELETE("/notifications/:id", api.DeleteNotification)              // 删除通知
+		authAPI.GET("/notifications/preferences", api.GetNotificationPreferences)    // 获取通知偏好
+		authAPI.PUT("/notifications/preferences", api.UpdateNotificationPreferences) // 更新通知偏好
 
 		// 数据字典接口 - 所有已认证用户都可以访问
 		authAPI.GET("/dictionaries", api.GetDictionaries) // 获取数据字典
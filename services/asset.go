@@ -188,6 +188,10 @@ func (s *AssetService) CreateAsset(req *AssetCreateRequest, createdBy uint) (*mo
 	// 记录审计日志，追踪资产创建操作
 	s.addAuditLog(asset.ID, "create", "", "", createdBy, "", "")
 
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	webhookService.Emit(WebhookAssetCreated, NewWebhookAssetData(&asset, createdBy))
+
 	// 重新查询资产信息，包含关联的项目、资产组、创建者等数据
 	db.Preload("Project").Preload("AssetGroup").Preload("Creator").Where("id = ?", asset.ID).First(&asset)
 
@@ -295,6 +299,13 @@ func (s *AssetService) UpdateAsset(assetID uint, req *AssetUpdateRequest, userID
 	// 记录审计日志
 	s.addAuditLog(asset.ID, "update", "", "", userID, "", "")
 
+	// 资产重要性、环境和域名影响漏洞的风险优先级评分
+	refreshVulnRisk(db, "asset_id = ?", asset.ID)
+
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	webhookService.Emit(WebhookAssetUpdated, NewWebhookAssetData(&asset, userID))
+
 	// 重新查询资产信息
 	db.Preload("Project").Preload("AssetGroup").Preload("Creator").Where("id = ?", asset.ID).First(&asset)
 
@@ -332,6 +343,10 @@ func (s *AssetService) DeleteAsset(assetID uint, userID uint, userRole string) e
 	// 记录审计日志
 	s.addAuditLog(assetID, "delete", "", "", userID, "", "")
 
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	webhookService.Emit(WebhookAssetDeleted, NewWebhookAssetData(&asset, userID))
+
 	// 更新项目统计信息（如果资产属于某个项目）
 	if projectID != 0 {
 		projectService := &ProjectService{}
@@ -0,0 +1,584 @@
+// 群机器人通知服务包
+// 该包将漏洞、项目通知推送到钉钉、企业微信、飞书和Slack群机器人，项目可配置专属群机器人覆盖全局配置
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+	"vulnmain/utils"
+
+	"github.com/spf13/viper"
+)
+
+// ChatBotService 群机器人通知服务
+type ChatBotService struct{}
+
+// 群机器人平台，同时也是SystemConfig中对应配置的分组名
+const (
+	ChatDingTalk = "dingtalk" // 钉钉
+	ChatWeCom    = "wecom"    // 企业微信
+	ChatFeishu   = "feishu"   // 飞书
+	ChatSlack    = "slack"    // Slack
+)
+
+// ChatPlatforms 支持的群机器人平台
+var ChatPlatforms = []string{ChatDingTalk, ChatWeCom, ChatFeishu, ChatSlack}
+
+// ChatField 消息中的键值信息
+type ChatField struct {
+	Label string
+	Value string
+}
+
+// ChatMessage 群机器人消息，由各平台转换为对应的消息格式
+type ChatMessage struct {
+	Title   string      // 消息标题
+	Content string      // 消息正文
+	Fields  []ChatField // 附加信息，如项目、严重程度，值为空的字段不展示
+	Link    string      // 站内跳转地址，配置了server.external_url时转换为完整链接
+}
+
+// ChatNotifier 群机器人发送接口
+type ChatNotifier interface {
+	Send(msg *ChatMessage) error
+}
+
+// ChatBotConfig 群机器人配置
+type ChatBotConfig struct {
+	Platform   string `json:"platform"`
+	Enabled    bool   `json:"enabled"`
+	WebhookURL string `json:"webhook_url"`
+	Secret     string `json:"secret"`
+}
+
+// ChatBotTestRequest 测试发送请求，未填写地址时使用系统设置中的全局配置
+type ChatBotTestRequest struct {
+	Platform   string `json:"platform" binding:"required"`
+	WebhookURL string `json:"webhook_url"`
+	Secret     string `json:"secret"`
+}
+
+// ProjectChatBotRequest 保存项目群机器人请求
+type ProjectChatBotRequest struct {
+	WebhookURL string `json:"webhook_url"` // 为空时保持不变，首次创建时必填
+	Secret     string `json:"secret"`      // 为空时保持不变
+	IsActive   *bool  `json:"is_active"`
+}
+
+// ProjectChatBotResponse 项目群机器人信息，不返回地址和密钥明文
+type ProjectChatBotResponse struct {
+	models.ProjectChatBot
+	WebhookURL string `json:"webhook_url"` // 脱敏后的Webhook地址
+	HasSecret  bool   `json:"has_secret"`  // 是否配置了加签密钥
+}
+
+// isChatPlatform 判断是否为支持的平台
+func isChatPlatform(platform string) bool {
+	for _, p := range ChatPlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// severityLabel 获取漏洞严重程度的中文名称
+func severityLabel(severity string) string {
+	labels := map[string]string{
+		"critical": "严重",
+		"high":     "高危",
+		"medium":   "中危",
+		"low":      "低危",
+		"info":     "信息",
+	}
+	if label, ok := labels[severity]; ok {
+		return label
+	}
+	return severity
+}
+
+// maskWebhookURL 脱敏Webhook地址，只保留协议和主机
+func maskWebhookURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "********"
+	}
+	return u.Scheme + "://" + u.Host + "/********"
+}
+
+// absoluteLink 将站内跳转地址转换为完整链接，未配置server.external_url时返回空字符串
+func absoluteLink(link string) string {
+	externalURL := strings.TrimRight(viper.GetString("server.external_url"), "/")
+	if link == "" || externalURL == "" {
+		return ""
+	}
+	return externalURL + link
+}
+
+// NewChatNotifier 创建指定平台的群机器人
+func NewChatNotifier(platform, webhookURL, secret string) (ChatNotifier, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("机器人Webhook地址格式错误")
+	}
+
+	switch platform {
+	case ChatDingTalk:
+		return &DingTalkNotifier{WebhookURL: webhookURL, Secret: secret}, nil
+	case ChatWeCom:
+		return &WeComNotifier{WebhookURL: webhookURL}, nil
+	case ChatFeishu:
+		return &FeishuNotifier{WebhookURL: webhookURL, Secret: secret}, nil
+	case ChatSlack:
+		return &SlackNotifier{WebhookURL: webhookURL}, nil
+	}
+	return nil, fmt.Errorf("不支持的群机器人平台: %s", platform)
+}
+
+// chatHTTPClient 群机器人使用的HTTP客户端，项目负责人可以自行填写机器人地址，只允许访问公网地址
+var chatHTTPClient = utils.NewPublicHTTPClient(10 * time.Second)
+
+// postChatJSON 发送JSON请求，返回响应内容
+// 错误信息中不包含响应内容，避免通过测试消息读取内部服务的响应
+func postChatJSON(target string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := chatHTTPClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("机器人返回HTTP %d", resp.StatusCode)
+	}
+	return respBody, nil
+}
+
+// markdownText 生成钉钉、企业微信使用的Markdown正文
+func markdownText(msg *ChatMessage, titleLevel string) string {
+	var b strings.Builder
+	b.WriteString(titleLevel + " " + msg.Title + "\n\n")
+	b.WriteString(msg.Content + "\n\n")
+	for _, field := range msg.Fields {
+		if field.Value != "" {
+			b.WriteString("> " + field.Label + "：" + field.Value + "\n\n")
+		}
+	}
+	if link := absoluteLink(msg.Link); link != "" {
+		b.WriteString("[查看详情](" + link + ")\n")
+	}
+	return b.String()
+}
+
+// DingTalkNotifier 钉钉群机器人
+type DingTalkNotifier struct {
+	WebhookURL string
+	Secret     string // 加签密钥，为空表示未开启加签
+}
+
+// Send 发送钉钉Markdown消息，配置了密钥时按钉钉加签规则在地址上追加timestamp和sign
+func (n *DingTalkNotifier) Send(msg *ChatMessage) error {
+	target := n.WebhookURL
+	if n.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(n.Secret))
+		mac.Write([]byte(timestamp + "\n" + n.Secret))
+		sign := url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + "timestamp=" + timestamp + "&sign=" + sign
+	}
+
+	respBody, err := postChatJSON(target, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  markdownText(msg, "###"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return checkErrCode(respBody)
+}
+
+// WeComNotifier 企业微信群机器人，Webhook地址中的key即为凭证
+type WeComNotifier struct {
+	WebhookURL string
+}
+
+// Send 发送企业微信Markdown消息
+func (n *WeComNotifier) Send(msg *ChatMessage) error {
+	respBody, err := postChatJSON(n.WebhookURL, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": markdownText(msg, "###"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return checkErrCode(respBody)
+}
+
+// checkErrCode 校验钉钉、企业微信的返回结果
+func checkErrCode(respBody []byte) error {
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return errors.New("解析机器人响应失败")
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// FeishuNotifier 飞书群机器人
+type FeishuNotifier struct {
+	WebhookURL string
+	Secret     string // 签名校验密钥，为空表示未开启签名校验
+}
+
+// Send 发送飞书富文本消息，配置了密钥时按飞书签名规则在请求体中附带timestamp和sign
+func (n *FeishuNotifier) Send(msg *ChatMessage) error {
+	lines := [][]map[string]string{
+		{{"tag": "text", "text": msg.Content}},
+	}
+	for _, field := range msg.Fields {
+		if field.Value != "" {
+			lines = append(lines, []map[string]string{{"tag": "text", "text": field.Label + "：" + field.Value}})
+		}
+	}
+	if link := absoluteLink(msg.Link); link != "" {
+		lines = append(lines, []map[string]string{{"tag": "a", "text": "查看详情", "href": link}})
+	}
+
+	payload := map[string]interface{}{
+		"msg_type": "post",
+		"content": map[string]interface{}{
+			"post": map[string]interface{}{
+				"zh_cn": map[string]interface{}{
+					"title":   msg.Title,
+					"content": lines,
+				},
+			},
+		},
+	}
+	if n.Secret != "" {
+		// 飞书以 timestamp + "\n" + secret 作为HMAC密钥，对空消息签名
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+n.Secret))
+		payload["timestamp"] = timestamp
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	respBody, err := postChatJSON(n.WebhookURL, payload)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return errors.New("解析机器人响应失败")
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// SlackNotifier Slack Incoming Webhook，Webhook地址即为凭证
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+// slackEscape 转义Slack mrkdwn中的控制字符
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// Send 发送Slack消息，成功时Slack返回纯文本ok
+func (n *SlackNotifier) Send(msg *ChatMessage) error {
+	var b strings.Builder
+	b.WriteString("*" + slackEscape(msg.Title) + "*\n")
+	b.WriteString(slackEscape(msg.Content))
+	for _, field := range msg.Fields {
+		if field.Value != "" {
+			b.WriteString("\n• " + slackEscape(field.Label) + "：" + slackEscape(field.Value))
+		}
+	}
+	if link := absoluteLink(msg.Link); link != "" {
+		b.WriteString("\n<" + link + "|查看详情>")
+	}
+
+	_, err := postChatJSON(n.WebhookURL, map[string]string{"text": b.String()})
+	return err
+}
+
+// GetChatBotConfig 从数据库获取全局群机器人配置
+func GetChatBotConfig(platform string) (*ChatBotConfig, error) {
+	if !isChatPlatform(platform) {
+		return nil, fmt.Errorf("不支持的群机器人平台: %s", platform)
+	}
+
+	db := Init.GetDB()
+	var configs []models.SystemConfig
+	if err := db.Where("`group` = ?", platform).Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("获取群机器人配置失败: %v", err)
+	}
+
+	config := &ChatBotConfig{Platform: platform}
+	for _, cfg := range configs {
+		switch cfg.Key {
+		case platform + ".enabled":
+			config.Enabled = cfg.Value == "true"
+		case platform + ".webhook_url":
+			config.WebhookURL = cfg.Value
+		case platform + ".secret":
+			config.Secret = cfg.Value
+		}
+	}
+
+	return config, nil
+}
+
+// Notify 推送项目通知：项目配置了专属群机器人的平台发送到项目群，其余平台发送到已启用的全局群机器人
+// 发送在后台进行，失败只记录日志不影响业务流程
+func (s *ChatBotService) Notify(projectID uint, msg *ChatMessage) {
+	db := Init.GetDB()
+
+	overrides := make(map[string]models.ProjectChatBot)
+	if projectID != 0 {
+		var bots []models.ProjectChatBot
+		db.Where("project_id = ?", projectID).Find(&bots)
+		for _, bot := range bots {
+			overrides[bot.Platform] = bot
+		}
+	}
+
+	for _, platform := range ChatPlatforms {
+		var webhookURL, secret string
+		if bot, ok := overrides[platform]; ok {
+			if !bot.IsActive {
+				continue
+			}
+			webhookURL, secret = bot.WebhookURL, bot.Secret
+		} else {
+			config, err := GetChatBotConfig(platform)
+			if err != nil || !config.Enabled || config.WebhookURL == "" {
+				continue
+			}
+			webhookURL, secret = config.WebhookURL, config.Secret
+		}
+
+		notifier, err := NewChatNotifier(platform, webhookURL, secret)
+		if err != nil {
+			fmt.Printf("创建群机器人失败 (平台: %s, 项目ID: %d): %v\n", platform, projectID, err)
+			continue
+		}
+		go func(platform string) {
+			if err := notifier.Send(msg); err != nil {
+				fmt.Printf("发送群机器人消息失败 (平台: %s, 项目ID: %d): %v\n", platform, projectID, err)
+			}
+		}(platform)
+	}
+}
+
+// testMessage 生成测试消息
+func testMessage() *ChatMessage {
+	return &ChatMessage{
+		Title:   "【VulnMain】群机器人配置测试",
+		Content: "如果您在群中看到这条消息，说明群机器人配置成功，漏洞和项目通知将推送到此群。",
+		Fields:  []ChatField{{Label: "发送时间", Value: time.Now().Format("2006-01-02 15:04:05")}},
+	}
+}
+
+// TestSend 发送测试消息，未填写地址时使用全局配置（不要求已启用）
+func (s *ChatBotService) TestSend(req *ChatBotTestRequest) error {
+	webhookURL, secret := req.WebhookURL, req.Secret
+	if webhookURL == "" {
+		config, err := GetChatBotConfig(req.Platform)
+		if err != nil {
+			return err
+		}
+		if config.WebhookURL == "" {
+			return errors.New("请先配置机器人Webhook地址")
+		}
+		webhookURL, secret = config.WebhookURL, config.Secret
+	}
+
+	notifier, err := NewChatNotifier(req.Platform, webhookURL, secret)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(testMessage())
+}
+
+// getManagedProject 获取当前用户可管理的项目，超级管理员可管理全部项目，其他用户仅限负责人或创建人
+func getManagedProject(projectID, userID uint, roleCode string) (*models.Project, error) {
+	db := Init.GetDB()
+
+	var project models.Project
+	query := db.Where("id = ?", projectID)
+	if roleCode != "super_admin" {
+		query = query.Where("owner_id = ? OR created_by = ?", userID, userID)
+	}
+	if err := query.First(&project).Error; err != nil {
+		return nil, errors.New("项目不存在或无权限修改")
+	}
+
+	return &project, nil
+}
+
+// toProjectChatBotResponse 转换为脱敏后的响应
+func toProjectChatBotResponse(bot models.ProjectChatBot) ProjectChatBotResponse {
+	return ProjectChatBotResponse{
+		ProjectChatBot: bot,
+		WebhookURL:     maskWebhookURL(bot.WebhookURL),
+		HasSecret:      bot.Secret != "",
+	}
+}
+
+// GetProjectChatBots 获取项目的专属群机器人
+func (s *ChatBotService) GetProjectChatBots(projectID, userID uint, roleCode string) ([]ProjectChatBotResponse, error) {
+	if _, err := getManagedProject(projectID, userID, roleCode); err != nil {
+		return nil, err
+	}
+
+	db := Init.GetDB()
+	var bots []models.ProjectChatBot
+	if err := db.Where("project_id = ?", projectID).Order("id ASC").Find(&bots).Error; err != nil {
+		return nil, errors.New("获取项目群机器人失败")
+	}
+
+	result := make([]ProjectChatBotResponse, 0, len(bots))
+	for _, bot := range bots {
+		result = append(result, toProjectChatBotResponse(bot))
+	}
+	return result, nil
+}
+
+// SaveProjectChatBot 创建或更新项目在指定平台的专属群机器人
+func (s *ChatBotService) SaveProjectChatBot(projectID uint, platform string, req *ProjectChatBotRequest, userID uint, roleCode string) (*ProjectChatBotResponse, error) {
+	if !isChatPlatform(platform) {
+		return nil, fmt.Errorf("不支持的群机器人平台: %s", platform)
+	}
+	if _, err := getManagedProject(projectID, userID, roleCode); err != nil {
+		return nil, err
+	}
+	if req.WebhookURL != "" {
+		if _, err := NewChatNotifier(platform, req.WebhookURL, req.Secret); err != nil {
+			return nil, err
+		}
+		// 项目负责人可以配置机器人，地址只能指向公网，发送时由chatHTTPClient在建立连接时再次校验
+		if err := utils.ValidatePublicURL(req.WebhookURL); err != nil {
+			return nil, fmt.Errorf("机器人Webhook地址无效: %v", err)
+		}
+	}
+
+	db := Init.GetDB()
+	var bot models.ProjectChatBot
+	if err := db.Where("project_id = ? AND platform = ?", projectID, platform).First(&bot).Error; err != nil {
+		if req.WebhookURL == "" {
+			return nil, errors.New("请填写机器人Webhook地址")
+		}
+		bot = models.ProjectChatBot{
+			ProjectID:  projectID,
+			Platform:   platform,
+			WebhookURL: req.WebhookURL,
+			Secret:     req.Secret,
+			IsActive:   req.IsActive == nil || *req.IsActive,
+			CreatedBy:  userID,
+		}
+		if err := db.Create(&bot).Error; err != nil {
+			return nil, errors.New("保存项目群机器人失败")
+		}
+		// gorm会忽略布尔零值，停用状态需要单独更新
+		if !bot.IsActive {
+			db.Model(&bot).Update("is_active", false)
+		}
+	} else {
+		updates := map[string]interface{}{}
+		if req.WebhookURL != "" {
+			updates["webhook_url"] = req.WebhookURL
+		}
+		if req.Secret != "" {
+			updates["secret"] = req.Secret
+		}
+		if req.IsActive != nil {
+			updates["is_active"] = *req.IsActive
+		}
+		if len(updates) > 0 {
+			if err := db.Model(&bot).Updates(updates).Error; err != nil {
+				return nil, errors.New("保存项目群机器人失败")
+			}
+		}
+		db.Where("id = ?", bot.ID).First(&bot)
+	}
+
+	resp := toProjectChatBotResponse(bot)
+	return &resp, nil
+}
+
+// DeleteProjectChatBot 删除项目专属群机器人，删除后该项目恢复使用全局群机器人
+func (s *ChatBotService) DeleteProjectChatBot(projectID uint, platform string, userID uint, roleCode string) error {
+	if _, err := getManagedProject(projectID, userID, roleCode); err != nil {
+		return err
+	}
+
+	db := Init.GetDB()
+	result := db.Where("project_id = ? AND platform = ?", projectID, platform).Delete(&models.ProjectChatBot{})
+	if result.Error != nil {
+		return errors.New("删除项目群机器人失败")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("项目群机器人不存在")
+	}
+
+	return nil
+}
+
+// TestProjectChatBot 向项目专属群机器人发送测试消息
+func (s *ChatBotService) TestProjectChatBot(projectID uint, platform string, userID uint, roleCode string) error {
+	if _, err := getManagedProject(projectID, userID, roleCode); err != nil {
+		return err
+	}
+
+	db := Init.GetDB()
+	var bot models.ProjectChatBot
+	if err := db.Where("project_id = ? AND platform = ?", projectID, platform).First(&bot).Error; err != nil {
+		return errors.New("项目群机器人不存在")
+	}
+
+	notifier, err := NewChatNotifier(platform, bot.WebhookURL, bot.Secret)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(testMessage())
+}
@@ -0,0 +1,1002 @@
+// CVE情报服务包
+// 该包从本地目录或内部镜像导入NVD JSON 2.0、CISA KEV和FIRST EPSS数据源到本地情报表，全程不访问外网；
+// 漏洞填写CVE编号时根据本地情报补充描述、CWE、CVSS向量和参考链接，并同步KEV和EPSS信息
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CVEFeedService CVE情报服务
+type CVEFeedService struct{}
+
+// CVE情报数据源
+const (
+	CVEFeedNVD  = "nvd"  // NVD JSON 2.0
+	CVEFeedKEV  = "kev"  // CISA已知被利用漏洞目录
+	CVEFeedEPSS = "epss" // FIRST EPSS评分
+)
+
+// 数据源导入状态
+const (
+	CVEFeedImportSuccess = "success"
+	CVEFeedImportFailed  = "failed"
+)
+
+const (
+	defaultCVEFeedDirectory   = "./data/cve-feeds"
+	defaultCVEFeedMirrorFiles = "nvdcve-2.0-modified.json.gz,nvdcve-2.0-recent.json.gz,known_exploited_vulnerabilities.json,epss_scores-current.csv.gz"
+	cveFeedBatchSize          = 500 // 每批写入的记录数
+	maxCVEReferences          = 20  // 每个CVE保存的参考链接数量上限
+)
+
+var cveFeedSourceLabels = map[string]string{
+	CVEFeedNVD:  "NVD",
+	CVEFeedKEV:  "CISA KEV",
+	CVEFeedEPSS: "EPSS",
+}
+
+// cveFeedMutex 避免定时任务和手动更新同时导入
+var cveFeedMutex sync.Mutex
+
+// CVEIntel 本地情报库中某个CVE编号的信息
+type CVEIntel struct {
+	CVEID  string            `json:"cve_id"`
+	Record *models.CVERecord `json:"record"` // NVD情报，未收录时为空
+	KEV    *models.KEVEntry  `json:"kev"`    // KEV条目，未列入时为空
+	EPSS   *models.EPSSScore `json:"epss"`   // EPSS评分，未收录时为空
+}
+
+// empty 判断本地情报库中是否没有该CVE的任何信息
+func (i *CVEIntel) empty() bool {
+	return i.Record == nil && i.KEV == nil && i.EPSS == nil
+}
+
+// CVEFeedRefreshResult CVE情报更新结果
+type CVEFeedRefreshResult struct {
+	Downloaded    []string               `json:"downloaded"`     // 从内部镜像下载的文件
+	Imports       []models.CVEFeedImport `json:"imports"`        // 本次导入的文件
+	Skipped       []string               `json:"skipped"`        // 未变化而跳过的文件
+	Errors        []string               `json:"errors"`         // 下载或导入失败的原因
+	VulnsEnriched int                    `json:"vulns_enriched"` // 根据最新情报更新的漏洞数
+}
+
+// CVEFeedStatus CVE情报库状态
+type CVEFeedStatus struct {
+	Enabled   bool                   `json:"enabled"`
+	Directory string                 `json:"directory"`
+	MirrorURL string                 `json:"mirror_url"`
+	CVECount  int                    `json:"cve_count"`
+	KEVCount  int                    `json:"kev_count"`
+	EPSSCount int                    `json:"epss_count"`
+	Files     []CVEFeedFile          `json:"files"`   // 数据源目录中可识别的文件
+	Imports   []models.CVEFeedImport `json:"imports"` // 最近的导入记录
+}
+
+// CVEFeedFile 数据源目录中的文件
+type CVEFeedFile struct {
+	Name    string    `json:"name"`
+	Source  string    `json:"source"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// detectCVEFeedSource 根据文件名识别数据源，支持gzip压缩文件
+func detectCVEFeedSource(name string) string {
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".gz")
+	switch {
+	case strings.HasPrefix(base, "nvdcve") && strings.HasSuffix(base, ".json"):
+		return CVEFeedNVD
+	case strings.HasPrefix(base, "known_exploited_vulnerabilities") && strings.HasSuffix(base, ".json"):
+		return CVEFeedKEV
+	case strings.HasPrefix(base, "epss") && strings.HasSuffix(base, ".csv"):
+		return CVEFeedEPSS
+	}
+	return ""
+}
+
+// cveFeedDirectory 获取数据源目录
+func cveFeedDirectory() string {
+	dir := strings.TrimSpace(getStringConfig("cve_feed.directory", defaultCVEFeedDirectory))
+	if dir == "" {
+		dir = defaultCVEFeedDirectory
+	}
+	return dir
+}
+
+// cveAutoEnrichEnabled 是否在创建或修改漏洞时自动补充CVE情报
+func cveAutoEnrichEnabled() bool {
+	return getStringConfig("cve_feed.auto_enrich", "true") != "false"
+}
+
+// GetStatus 获取CVE情报库状态和最近的导入记录
+func (s *CVEFeedService) GetStatus() (*CVEFeedStatus, error) {
+	db := Init.GetDB()
+
+	status := &CVEFeedStatus{
+		Enabled:   getStringConfig("cve_feed.enabled", "true") != "false",
+		Directory: cveFeedDirectory(),
+		MirrorURL: getStringConfig("cve_feed.mirror_url", ""),
+		Files:     []CVEFeedFile{},
+		Imports:   []models.CVEFeedImport{},
+	}
+	db.Model(&models.CVERecord{}).Count(&status.CVECount)
+	db.Model(&models.KEVEntry{}).Count(&status.KEVCount)
+	db.Model(&models.EPSSScore{}).Count(&status.EPSSCount)
+
+	if files, err := listCVEFeedFiles(status.Directory); err == nil {
+		status.Files = files
+	}
+	if err := db.Order("id desc").Limit(50).Find(&status.Imports).Error; err != nil {
+		return nil, errors.New("获取导入记录失败")
+	}
+	return status, nil
+}
+
+// listCVEFeedFiles 列出数据源目录中可识别的文件，按文件名排序，NVD年度文件排在modified和recent之前
+func listCVEFeedFiles(dir string) ([]CVEFeedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []CVEFeedFile{}
+	for _, entry := range entries {
+		source := detectCVEFeedSource(entry.Name())
+		if entry.IsDir() || source == "" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, CVEFeedFile{Name: entry.Name(), Source: source, Size: info.Size(), ModTime: info.ModTime().Truncate(time.Second)})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// RefreshScheduledFeeds 定时更新CVE情报，未启用时跳过
+func (s *CVEFeedService) RefreshScheduledFeeds() error {
+	if getStringConfig("cve_feed.enabled", "true") == "false" {
+		return nil
+	}
+	result, err := s.RefreshFeeds()
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return errors.New(strings.Join(result.Errors, "; "))
+	}
+	return nil
+}
+
+// RefreshFeeds 更新CVE情报：配置了内部镜像时先下载数据源文件，再导入目录中新增或变化的文件，
+// 最后根据最新情报更新未关闭漏洞的KEV和EPSS信息
+func (s *CVEFeedService) RefreshFeeds() (*CVEFeedRefreshResult, error) {
+	if !cveFeedMutex.TryLock() {
+		return nil, errors.New("CVE情报正在更新，请稍后再试")
+	}
+	defer cveFeedMutex.Unlock()
+
+	dir := cveFeedDirectory()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建数据源目录失败: %v", err)
+	}
+
+	result := &CVEFeedRefreshResult{
+		Downloaded: []string{},
+		Imports:    []models.CVEFeedImport{},
+		Skipped:    []string{},
+		Errors:     []string{},
+	}
+
+	if mirrorURL := strings.TrimSpace(getStringConfig("cve_feed.mirror_url", "")); mirrorURL != "" {
+		for _, name := range splitWorkflowList(getStringConfig("cve_feed.mirror_files", defaultCVEFeedMirrorFiles)) {
+			downloaded, err := downloadCVEFeedFile(mirrorURL, dir, name)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("下载%s失败: %v", name, err))
+			} else if downloaded {
+				result.Downloaded = append(result.Downloaded, name)
+			}
+		}
+	}
+
+	files, err := listCVEFeedFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据源目录失败: %v", err)
+	}
+
+	db := Init.GetDB()
+	for _, file := range files {
+		var last models.CVEFeedImport
+		if err := db.Where("file_name = ? AND status = ?", file.Name, CVEFeedImportSuccess).Order("id desc").First(&last).Error; err == nil &&
+			last.FileSize == file.Size && last.FileModTime.Unix() == file.ModTime.Unix() {
+			result.Skipped = append(result.Skipped, file.Name)
+			continue
+		}
+
+		record := importCVEFeedFile(db, filepath.Join(dir, file.Name), file)
+		result.Imports = append(result.Imports, *record)
+		if record.Status == CVEFeedImportFailed {
+			result.Errors = append(result.Errors, fmt.Sprintf("导入%s数据源%s失败: %s", cveFeedSourceLabels[file.Source], file.Name, record.Error))
+		}
+	}
+
+	if len(result.Imports) > 0 {
+		result.VulnsEnriched = s.enrichOpenVulns(db)
+	}
+	return result, nil
+}
+
+// ImportUploadedFeed 上传数据源文件到数据源目录并导入，用于无法访问内部镜像的环境
+func (s *CVEFeedService) ImportUploadedFeed(file *multipart.FileHeader) (*CVEFeedRefreshResult, error) {
+	name := filepath.Base(file.Filename)
+	if detectCVEFeedSource(name) == "" {
+		return nil, errors.New("无法识别数据源文件，文件名需以nvdcve、known_exploited_vulnerabilities或epss开头")
+	}
+
+	if !cveFeedMutex.TryLock() {
+		return nil, errors.New("CVE情报正在更新，请稍后再试")
+	}
+	defer cveFeedMutex.Unlock()
+
+	dir := cveFeedDirectory()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建数据源目录失败: %v", err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("无法打开上传的文件")
+	}
+	defer src.Close()
+	if err := writeCVEFeedFile(dir, name, src); err != nil {
+		return nil, fmt.Errorf("保存数据源文件失败: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("保存数据源文件失败: %v", err)
+	}
+
+	db := Init.GetDB()
+	record := importCVEFeedFile(db, filepath.Join(dir, name), CVEFeedFile{
+		Name:    name,
+		Source:  detectCVEFeedSource(name),
+		Size:    info.Size(),
+		ModTime: info.ModTime().Truncate(time.Second),
+	})
+	if record.Status == CVEFeedImportFailed {
+		return nil, fmt.Errorf("导入%s失败: %s", name, record.Error)
+	}
+
+	return &CVEFeedRefreshResult{
+		Downloaded:    []string{},
+		Imports:       []models.CVEFeedImport{*record},
+		Skipped:       []string{},
+		Errors:        []string{},
+		VulnsEnriched: s.enrichOpenVulns(db),
+	}, nil
+}
+
+// downloadCVEFeedFile 从内部镜像下载数据源文件，镜像返回未修改时跳过，返回是否下载了新文件
+func downloadCVEFeedFile(mirrorURL, dir, name string) (bool, error) {
+	name = filepath.Base(name)
+	if detectCVEFeedSource(name) == "" {
+		return false, errors.New("无法识别的数据源文件名")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimRight(mirrorURL, "/")+"/"+url.PathEscape(name), nil)
+	if err != nil {
+		return false, err
+	}
+	target := filepath.Join(dir, name)
+	if info, err := os.Stat(target); err == nil {
+		req.Header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("镜像返回状态码%d", resp.StatusCode)
+	}
+	if err := writeCVEFeedFile(dir, name, resp.Body); err != nil {
+		return false, err
+	}
+
+	// 使用镜像文件的修改时间，下次请求时据此判断文件是否变化
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		os.Chtimes(target, lastModified, lastModified)
+	}
+	return true, nil
+}
+
+// writeCVEFeedFile 先写入临时文件再重命名，避免导入时读到写了一半的文件
+func writeCVEFeedFile(dir, name string, src io.Reader) error {
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// importCVEFeedFile 导入一个数据源文件并记录导入结果
+func importCVEFeedFile(db *gorm.DB, path string, file CVEFeedFile) *models.CVEFeedImport {
+	record := &models.CVEFeedImport{
+		Source:      file.Source,
+		FileName:    file.Name,
+		FileSize:    file.Size,
+		FileModTime: file.ModTime,
+		StartedAt:   time.Now().Truncate(time.Second),
+	}
+
+	count, err := func() (int, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+
+		var r io.Reader = f
+		if strings.HasSuffix(strings.ToLower(file.Name), ".gz") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return 0, errors.New("gzip文件格式错误")
+			}
+			defer gz.Close()
+			r = gz
+		}
+
+		switch file.Source {
+		case CVEFeedNVD:
+			return importNVDFeed(db, r)
+		case CVEFeedKEV:
+			return importKEVFeed(db, r, record.StartedAt)
+		default:
+			return importEPSSFeed(db, r)
+		}
+	}()
+
+	finishedAt := time.Now()
+	record.FinishedAt = &finishedAt
+	record.Records = count
+	record.Status = CVEFeedImportSuccess
+	if err != nil {
+		record.Status = CVEFeedImportFailed
+		record.Error = err.Error()
+	}
+	if err := db.Create(record).Error; err != nil {
+		fmt.Printf("保存CVE情报导入记录失败 (%s): %v\n", file.Name, err)
+	}
+	return record
+}
+
+// cveFeedWriter 批量写入情报记录，按CVE编号唯一索引插入或更新
+type cveFeedWriter struct {
+	db      *gorm.DB
+	table   string
+	columns []string
+	update  string
+	values  []interface{}
+	rows    int
+	total   int
+}
+
+// newCVEFeedWriter 创建批量写入器，guard不为空时只有满足条件的已有记录才会被更新
+func newCVEFeedWriter(db *gorm.DB, table string, columns []string, guard string) *cveFeedWriter {
+	updates := []string{}
+	for _, column := range columns {
+		if column == "cve_id" || column == "created_at" {
+			continue
+		}
+		value := fmt.Sprintf("VALUES(`%s`)", column)
+		if guard != "" {
+			value = fmt.Sprintf("IF(%s, %s, `%s`)", guard, value, column)
+		}
+		updates = append(updates, fmt.Sprintf("`%s` = %s", column, value))
+	}
+	return &cveFeedWriter{db: db, table: table, columns: columns, update: strings.Join(updates, ", ")}
+}
+
+// add 添加一条记录，参数顺序与列一致，达到批量大小时写入数据库
+func (w *cveFeedWriter) add(values ...interface{}) error {
+	w.values = append(w.values, values...)
+	w.rows++
+	if w.rows >= cveFeedBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush 写入缓存的记录
+func (w *cveFeedWriter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(w.columns)), ", ") + ")"
+	sql := fmt.Sprintf("INSERT INTO `%s` (`%s`) VALUES %s ON DUPLICATE KEY UPDATE %s",
+		w.table, strings.Join(w.columns, "`, `"), strings.TrimSuffix(strings.Repeat(placeholder+", ", w.rows), ", "), w.update)
+	if err := w.db.Exec(sql, w.values...).Error; err != nil {
+		return fmt.Errorf("写入情报数据失败: %v", err)
+	}
+	w.total += w.rows
+	w.values = w.values[:0]
+	w.rows = 0
+	return nil
+}
+
+// decodeJSONArrayField 流式解析JSON对象中的数组字段，逐个元素回调，避免一次性加载整个数据源文件
+func decodeJSONArrayField(r io.Reader, field string, fn func(dec *json.Decoder) error) error {
+	dec := json.NewDecoder(r)
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return errors.New("数据源文件格式错误")
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return errors.New("数据源文件格式错误")
+		}
+		if key, _ := token.(string); key != field {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return errors.New("数据源文件格式错误")
+			}
+			continue
+		}
+		if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+			return fmt.Errorf("数据源文件中的%s不是数组", field)
+		}
+		for dec.More() {
+			if err := fn(dec); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return errors.New("数据源文件格式错误")
+		}
+		return nil
+	}
+	return fmt.Errorf("数据源文件中没有%s", field)
+}
+
+// nvdCVSSMetric NVD JSON 2.0中的CVSS评分
+type nvdCVSSMetric struct {
+	Type     string `json:"type"` // Primary为NVD评分，Secondary为CNA评分
+	CVSSData struct {
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+}
+
+// nvdLangValue NVD JSON 2.0中的多语言文本
+type nvdLangValue struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+// nvdVulnerability NVD JSON 2.0中的漏洞条目
+type nvdVulnerability struct {
+	CVE struct {
+		ID           string         `json:"id"`
+		Published    string         `json:"published"`
+		LastModified string         `json:"lastModified"`
+		Descriptions []nvdLangValue `json:"descriptions"`
+		Metrics      struct {
+			CVSSMetricV31 []nvdCVSSMetric `json:"cvssMetricV31"`
+			CVSSMetricV40 []nvdCVSSMetric `json:"cvssMetricV40"`
+			CVSSMetricV30 []nvdCVSSMetric `json:"cvssMetricV30"`
+		} `json:"metrics"`
+		Weaknesses []struct {
+			Description []nvdLangValue `json:"description"`
+		} `json:"weaknesses"`
+		References []struct {
+			URL string `json:"url"`
+		} `json:"references"`
+	} `json:"cve"`
+}
+
+// parseNVDTime 解析NVD时间，格式如2024-01-01T12:00:00.000，按UTC处理
+func parseNVDTime(value string) *time.Time {
+	if len(value) < 19 {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02T15:04:05", value[:19])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// pickNVDMetric 选择CVSS评分：优先CVSS 3.1，其次4.0和3.0，同一版本优先NVD评分
+func pickNVDMetric(item *nvdVulnerability) *nvdCVSSMetric {
+	metrics := item.CVE.Metrics
+	for _, group := range [][]nvdCVSSMetric{metrics.CVSSMetricV31, metrics.CVSSMetricV40, metrics.CVSSMetricV30} {
+		for i := range group {
+			if group[i].Type == "Primary" && group[i].CVSSData.VectorString != "" {
+				return &group[i]
+			}
+		}
+		for i := range group {
+			if group[i].CVSSData.VectorString != "" {
+				return &group[i]
+			}
+		}
+	}
+	return nil
+}
+
+// importNVDFeed 导入NVD JSON 2.0数据源，已有记录只在数据源中的修改时间不早于本地记录时更新
+func importNVDFeed(db *gorm.DB, r io.Reader) (int, error) {
+	writer := newCVEFeedWriter(db, "cve_records",
+		[]string{"cve_id", "description", "cwe_ids", "cvss_vector", "cvss_score", "cvss_severity", "references", "published_at", "created_at", "updated_at", "last_modified_at"},
+		"`last_modified_at` IS NULL OR VALUES(`last_modified_at`) >= `last_modified_at`")
+
+	now := time.Now()
+	err := decodeJSONArrayField(r, "vulnerabilities", func(dec *json.Decoder) error {
+		var item nvdVulnerability
+		if err := dec.Decode(&item); err != nil {
+			return errors.New("NVD数据源格式错误")
+		}
+		cveID := strings.ToUpper(strings.TrimSpace(item.CVE.ID))
+		if !cvePattern.MatchString(cveID) {
+			return nil
+		}
+
+		description := ""
+		for _, d := range item.CVE.Descriptions {
+			if d.Lang == "en" {
+				description = d.Value
+				break
+			}
+		}
+
+		cwes := []string{}
+		for _, weakness := range item.CVE.Weaknesses {
+			for _, d := range weakness.Description {
+				if strings.HasPrefix(d.Value, "CWE-") && !contains(cwes, d.Value) {
+					cwes = append(cwes, d.Value)
+				}
+			}
+		}
+
+		references := []string{}
+		for _, ref := range item.CVE.References {
+			if ref.URL != "" && !contains(references, ref.URL) && len(references) < maxCVEReferences {
+				references = append(references, ref.URL)
+			}
+		}
+
+		vector, score, severity := "", 0.0, ""
+		if metric := pickNVDMetric(&item); metric != nil {
+			vector = metric.CVSSData.VectorString
+			score = metric.CVSSData.BaseScore
+			severity = strings.ToLower(metric.CVSSData.BaseSeverity)
+		}
+
+		return writer.add(cveID, description, truncateRunes(strings.Join(cwes, ","), 255), vector, score, severity,
+			strings.Join(references, "\n"), parseNVDTime(item.CVE.Published), now, now, parseNVDTime(item.CVE.LastModified))
+	})
+	if err != nil {
+		return writer.total, err
+	}
+	if err := writer.flush(); err != nil {
+		return writer.total, err
+	}
+	return writer.total, nil
+}
+
+// kevVulnerability CISA KEV目录中的条目
+type kevVulnerability struct {
+	CVEID                      string `json:"cveID"`
+	VendorProject              string `json:"vendorProject"`
+	Product                    string `json:"product"`
+	VulnerabilityName          string `json:"vulnerabilityName"`
+	DateAdded                  string `json:"dateAdded"`
+	ShortDescription           string `json:"shortDescription"`
+	RequiredAction             string `json:"requiredAction"`
+	DueDate                    string `json:"dueDate"`
+	KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+}
+
+// parseFeedDate 解析YYYY-MM-DD格式的日期
+func parseFeedDate(value string) *time.Time {
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// importKEVFeed 导入CISA KEV目录，目录是完整快照，导入成功后删除已从目录中移除的条目
+func importKEVFeed(db *gorm.DB, r io.Reader, startedAt time.Time) (int, error) {
+	writer := newCVEFeedWriter(db, "kev_entries",
+		[]string{"cve_id", "vendor_project", "product", "vulnerability_name", "short_description", "required_action", "date_added", "due_date", "known_ransomware", "created_at", "updated_at"}, "")
+
+	now := time.Now()
+	err := decodeJSONArrayField(r, "vulnerabilities", func(dec *json.Decoder) error {
+		var item kevVulnerability
+		if err := dec.Decode(&item); err != nil {
+			return errors.New("KEV数据源格式错误")
+		}
+		cveID := strings.ToUpper(strings.TrimSpace(item.CVEID))
+		if !cvePattern.MatchString(cveID) {
+			return nil
+		}
+		return writer.add(cveID, truncateRunes(item.VendorProject, 255), truncateRunes(item.Product, 255), truncateRunes(item.VulnerabilityName, 500),
+			item.ShortDescription, item.RequiredAction, parseFeedDate(item.DateAdded), parseFeedDate(item.DueDate),
+			strings.EqualFold(item.KnownRansomwareCampaignUse, "Known"), now, now)
+	})
+	if err != nil {
+		return writer.total, err
+	}
+	if err := writer.flush(); err != nil {
+		return writer.total, err
+	}
+	if writer.total == 0 {
+		return 0, errors.New("KEV数据源中没有条目")
+	}
+
+	if err := db.Where("updated_at < ?", startedAt).Delete(&models.KEVEntry{}).Error; err != nil {
+		return writer.total, fmt.Errorf("清理已移出目录的KEV条目失败: %v", err)
+	}
+	return writer.total, nil
+}
+
+// importEPSSFeed 导入EPSS评分CSV，首行注释中包含评分日期，表头为cve,epss,percentile
+func importEPSSFeed(db *gorm.DB, r io.Reader) (int, error) {
+	reader := bufio.NewReader(r)
+
+	// 解析注释行中的评分日期，如#model_version:v2023.03.01,score_date:2024-01-01T00:00:00+0000
+	var scoreDate *time.Time
+	for {
+		peek, err := reader.Peek(1)
+		if err != nil || peek[0] != '#' {
+			break
+		}
+		line, _ := reader.ReadString('\n')
+		for _, part := range strings.Split(strings.TrimSpace(strings.TrimPrefix(line, "#")), ",") {
+			if value, ok := strings.CutPrefix(part, "score_date:"); ok && len(value) >= 10 {
+				scoreDate = parseFeedDate(value[:10])
+			}
+		}
+	}
+
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err != nil {
+		return 0, errors.New("EPSS数据源格式错误")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cveIndex, ok1 := columns["cve"]
+	scoreIndex, ok2 := columns["epss"]
+	percentileIndex, ok3 := columns["percentile"]
+	if !ok1 || !ok2 || !ok3 {
+		return 0, errors.New("EPSS数据源缺少cve、epss或percentile列")
+	}
+
+	writer := newCVEFeedWriter(db, "epss_scores", []string{"cve_id", "score", "percentile", "score_date", "created_at", "updated_at"}, "")
+	now := time.Now()
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return writer.total, fmt.Errorf("EPSS数据源格式错误: %v", err)
+		}
+		if len(record) <= cveIndex || len(record) <= scoreIndex || len(record) <= percentileIndex {
+			continue
+		}
+		cveID := strings.ToUpper(strings.TrimSpace(record[cveIndex]))
+		score, err1 := strconv.ParseFloat(strings.TrimSpace(record[scoreIndex]), 64)
+		percentile, err2 := strconv.ParseFloat(strings.TrimSpace(record[percentileIndex]), 64)
+		if !cvePattern.MatchString(cveID) || err1 != nil || err2 != nil {
+			continue
+		}
+		if err := writer.add(cveID, score, percentile, scoreDate, now, now); err != nil {
+			return writer.total, err
+		}
+	}
+	if err := writer.flush(); err != nil {
+		return writer.total, err
+	}
+	return writer.total, nil
+}
+
+// loadCVEIntel 批量查询CVE编号在本地情报库中的信息
+func loadCVEIntel(db *gorm.DB, cveIDs []string) map[string]*CVEIntel {
+	intel := map[string]*CVEIntel{}
+	for _, id := range cveIDs {
+		intel[id] = &CVEIntel{CVEID: id}
+	}
+	for start := 0; start < len(cveIDs); start += cveFeedBatchSize {
+		end := start + cveFeedBatchSize
+		if end > len(cveIDs) {
+			end = len(cveIDs)
+		}
+		chunk := cveIDs[start:end]
+
+		var records []models.CVERecord
+		db.Where("cve_id IN (?)", chunk).Find(&records)
+		for i := range records {
+			if item := intel[records[i].CVEID]; item != nil {
+				item.Record = &records[i]
+			}
+		}
+		var kevs []models.KEVEntry
+		db.Where("cve_id IN (?)", chunk).Find(&kevs)
+		for i := range kevs {
+			if item := intel[kevs[i].CVEID]; item != nil {
+				item.KEV = &kevs[i]
+			}
+		}
+		var scores []models.EPSSScore
+		db.Where("cve_id IN (?)", chunk).Find(&scores)
+		for i := range scores {
+			if item := intel[scores[i].CVEID]; item != nil {
+				item.EPSS = &scores[i]
+			}
+		}
+	}
+	return intel
+}
+
+// vulnCVEID 获取漏洞CVE编号字段中的第一个CVE编号
+func vulnCVEID(vuln *models.Vulnerability) string {
+	if ids := normalizeCVEIDs([]string{vuln.CVEID}); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// GetCVEIntel 查询CVE编号在本地情报库中的信息
+func (s *CVEFeedService) GetCVEIntel(cveID string) (*CVEIntel, error) {
+	ids := normalizeCVEIDs([]string{cveID})
+	if len(ids) == 0 {
+		return nil, errors.New("CVE编号格式错误")
+	}
+	intel := loadCVEIntel(Init.GetDB(), ids[:1])[ids[0]]
+	if intel.empty() {
+		return nil, errors.New("本地情报库中没有该CVE的信息")
+	}
+	return intel, nil
+}
+
+// applyCVEIntel 将CVE情报应用到漏洞：fillContent为true时描述、CVSS向量和参考链接只在为空时填充，
+// CWE为空时填充，KEV和EPSS始终同步为最新情报。返回需要记录到时间线的说明和漏洞是否有变化
+func applyCVEIntel(vuln *models.Vulnerability, intel *CVEIntel, fillContent bool) ([]string, bool) {
+	notes := []string{}
+	changed := false
+
+	if record := intel.Record; record != nil {
+		if fillContent && strings.TrimSpace(vuln.Description) == "" && record.Description != "" {
+			vuln.Description = record.Description
+			notes = append(notes, "描述")
+		}
+		if fillContent && vuln.CVSSVector == "" && vuln.CVSSScore == 0 && record.CVSSVector != "" {
+			vuln.CVSSVector = record.CVSSVector
+			notes = append(notes, "CVSS向量")
+		}
+		if fillContent && strings.TrimSpace(vuln.References) == "" && record.References != "" {
+			vuln.References = record.References
+			notes = append(notes, "参考链接")
+		}
+		if vuln.CWEID == "" && record.CWEIDs != "" {
+			vuln.CWEID = truncateRunes(record.CWEIDs, 100)
+			notes = append(notes, "CWE")
+		}
+	}
+
+	if inKEV := intel.KEV != nil; inKEV != vuln.InKEV {
+		vuln.InKEV = inKEV
+		changed = true
+		if inKEV {
+			notes = append(notes, "已列入CISA KEV")
+		} else {
+			notes = append(notes, "已移出CISA KEV")
+		}
+	}
+
+	score, percentile := 0.0, 0.0
+	if intel.EPSS != nil {
+		score, percentile = intel.EPSS.Score, intel.EPSS.Percentile
+	}
+	if score != vuln.EPSSScore || percentile != vuln.EPSSPercentile {
+		// EPSS每天变化，只在首次获得评分时记录到时间线
+		if vuln.EPSSScore == 0 && score > 0 {
+			notes = append(notes, fmt.Sprintf("EPSS评分%.2f%%", score*100))
+		}
+		vuln.EPSSScore = score
+		vuln.EPSSPercentile = percentile
+		changed = true
+	}
+
+	return notes, changed || len(notes) > 0
+}
+
+// enrichVulnFromCVE 根据本地CVE情报补充待保存的漏洞信息，未启用自动补充或情报库中没有该CVE时返回nil
+func enrichVulnFromCVE(db *gorm.DB, vuln *models.Vulnerability) []string {
+	cveID := vulnCVEID(vuln)
+	if cveID == "" || !cveAutoEnrichEnabled() {
+		return nil
+	}
+	intel := loadCVEIntel(db, []string{cveID})[cveID]
+	if intel.empty() {
+		return nil
+	}
+	notes, _ := applyCVEIntel(vuln, intel, true)
+	now := time.Now()
+	vuln.EnrichedAt = &now
+	return notes
+}
+
+// cveEnrichNote 生成CVE情报补充的时间线说明
+func cveEnrichNote(notes []string) string {
+	return truncateRunes("根据CVE情报补充："+strings.Join(notes, "、"), 255)
+}
+
+// EnrichVuln 手动根据本地CVE情报补充漏洞信息，只填充空白字段
+func (s *CVEFeedService) EnrichVuln(vulnID, userID uint, userRole string) (*models.Vulnerability, []string, error) {
+	db := Init.GetDB()
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, nil, errors.New("漏洞不存在")
+	}
+	vulnService := &VulnService{}
+	if err := vulnService.checkVulnEditPermission(db, &vuln, userID, userRole); err != nil {
+		return nil, nil, err
+	}
+
+	cveID := vulnCVEID(&vuln)
+	if cveID == "" {
+		return nil, nil, errors.New("漏洞没有填写CVE编号")
+	}
+	intel := loadCVEIntel(db, []string{cveID})[cveID]
+	if intel.empty() {
+		return nil, nil, errors.New("本地情报库中没有该CVE的信息")
+	}
+
+	notes, _ := applyCVEIntel(&vuln, intel, true)
+
+	// 补充了CVSS向量时重新计算评分和严重程度，已被手动覆盖的严重程度保持不变
+	var asset models.Asset
+	db.Where("id = ?", vuln.AssetID).First(&asset)
+	if contains(notes, "CVSS向量") {
+		derivedSeverity, err := vulnService.applyCVSS(&vuln, &asset)
+		if err != nil {
+			return nil, nil, err
+		}
+		if derivedSeverity != "" && !vuln.SeverityOverridden {
+			vuln.Severity = derivedSeverity
+		}
+	}
+
+	applyVulnRisk(&vuln, &asset)
+
+	now := time.Now()
+	if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).Updates(map[string]interface{}{
+		"description":              vuln.Description,
+		"cwe_id":                   vuln.CWEID,
+		"cvss_vector":              vuln.CVSSVector,
+		"cvss_version":             vuln.CVSSVersion,
+		"cvss_score":               vuln.CVSSScore,
+		"cvss_base_score":          vuln.CVSSBaseScore,
+		"cvss_temporal_score":      vuln.CVSSTemporalScore,
+		"cvss_environmental_score": vuln.CVSSEnvironmentalScore,
+		"severity":                 vuln.Severity,
+		"references":               vuln.References,
+		"in_kev":                   vuln.InKEV,
+		"epss_score":               vuln.EPSSScore,
+		"epss_percentile":          vuln.EPSSPercentile,
+		"risk_score":               vuln.RiskScore,
+		"risk_level":               vuln.RiskLevel,
+		"risk_factors":             vuln.RiskFactors,
+		"enriched_at":              now,
+	}).Error; err != nil {
+		return nil, nil, errors.New("更新漏洞失败")
+	}
+	vuln.EnrichedAt = &now
+
+	if len(notes) > 0 {
+		vulnService.addTimeline(vuln.ID, userID, "enriched", cveEnrichNote(notes))
+	}
+	return &vuln, notes, nil
+}
+
+// enrichOpenVulns 情报更新后同步未关闭漏洞的CWE、KEV和EPSS信息，返回有变化的漏洞数
+func (s *CVEFeedService) enrichOpenVulns(db *gorm.DB) int {
+	var vulns []models.Vulnerability
+	if err := db.Select("id, cve_id, cwe_id, in_kev, epss_score, epss_percentile").
+		Where("cve_id <> '' AND status NOT IN (?)", vulnClosedStatuses).Find(&vulns).Error; err != nil {
+		fmt.Printf("查询需要更新CVE情报的漏洞失败: %v\n", err)
+		return 0
+	}
+
+	ids := []string{}
+	for i := range vulns {
+		if id := vulnCVEID(&vulns[i]); id != "" && !contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	intel := loadCVEIntel(db, ids)
+
+	vulnService := &VulnService{}
+	updatedIDs := []uint{}
+	now := time.Now()
+	for i := range vulns {
+		vuln := &vulns[i]
+		item := intel[vulnCVEID(vuln)]
+		if item == nil {
+			continue
+		}
+		notes, changed := applyCVEIntel(vuln, item, false)
+		if !changed {
+			continue
+		}
+		// 使用UpdateColumns，情报同步不改变漏洞的更新时间
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).UpdateColumns(map[string]interface{}{
+			"cwe_id":          vuln.CWEID,
+			"in_kev":          vuln.InKEV,
+			"epss_score":      vuln.EPSSScore,
+			"epss_percentile": vuln.EPSSPercentile,
+			"enriched_at":     now,
+		}).Error; err != nil {
+			fmt.Printf("更新漏洞CVE情报失败 (漏洞ID: %d): %v\n", vuln.ID, err)
+			continue
+		}
+		if len(notes) > 0 {
+			vulnService.addTimeline(vuln.ID, 0, "enriched", cveEnrichNote(notes))
+		}
+		updatedIDs = append(updatedIDs, vuln.ID)
+	}
+
+	// KEV和EPSS变化后重新计算风险优先级评分
+	if len(updatedIDs) > 0 {
+		refreshVulnRisk(db, "id IN (?)", updatedIDs)
+	}
+	return len(updatedIDs)
+}
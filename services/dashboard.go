@@ -35,6 +35,15 @@ type DashboardData struct {
 
 	// 当前用户特定数据（仅安全工程师和研发工程师）
 	CurrentUserVulns *UserVulnStats `json:"current_user_vulns,omitempty"`
+
+	// SLA达成率（按项目、团队、工程师统计，研发工程师仅统计分配给自己的漏洞）
+	SLACompliance *SLAComplianceReport `json:"sla_compliance,omitempty"`
+
+	// 优先修复的漏洞（按风险优先级评分排序的前10个待修复漏洞）
+	TopRiskVulns []RiskVulnItem `json:"top_risk_vulns"`
+
+	// 漏洞分类分布（按分类树逐级汇总，范围与优先修复的漏洞一致）
+	CategoryStats []*VulnCategoryStat `json:"category_stats"`
 }
 
 // TrendDataItem 趋势数据项
@@ -65,6 +74,27 @@ type VulnListItem struct {
 	FixDeadline  *time.Time `json:"fix_deadline,omitempty"`
 }
 
+// RiskVulnItem 优先修复的漏洞列表项
+type RiskVulnItem struct {
+	ID           uint                    `json:"id"`
+	Title        string                  `json:"title"`
+	Severity     string                  `json:"severity"`
+	Status       string                  `json:"status"`
+	RiskScore    float64                 `json:"risk_score"`
+	RiskLevel    string                  `json:"risk_level"`
+	CVEID        string                  `json:"cve_id"`
+	InKEV        bool                    `json:"in_kev"`
+	EPSSScore    float64                 `json:"epss_score"`
+	AssetName    string                  `json:"asset_name"`
+	ProjectName  string                  `json:"project_name"`
+	AssigneeName string                  `json:"assignee_name"`
+	FixDeadline  *time.Time              `json:"fix_deadline,omitempty"`
+	Factors      []models.VulnRiskFactor `json:"factors"` // 评分明细
+}
+
+// riskTopExcludedStatuses 不进入优先修复列表的状态：已修复待复测和已结束的漏洞
+var riskTopExcludedStatuses = []string{VulnStatusFixed, VulnStatusRetesting, VulnStatusCompleted, VulnStatusIgnored, VulnStatusRejected, "closed"}
+
 // UserVulnStats 用户漏洞统计
 type UserVulnStats struct {
 	TotalCount      int64            `json:"total_count"`       // 所有历史漏洞总数
@@ -77,16 +107,83 @@ type UserVulnStats struct {
 func (s *DashboardService) GetDashboardData(userID uint, roleCode string) (*DashboardData, error) {
 	db := Init.GetDB()
 
+	var data *DashboardData
+	var err error
 	switch roleCode {
 	case "super_admin":
-		return s.getSuperAdminDashboard(db)
+		data, err = s.getSuperAdminDashboard(db)
 	case "security_engineer":
-		return s.getSecurityEngineerDashboard(db, userID)
+		data, err = s.getSecurityEngineerDashboard(db, userID)
 	case "dev_engineer":
-		return s.getDevEngineerDashboard(db, userID)
+		data, err = s.getDevEngineerDashboard(db, userID)
 	default:
 		return s.getDefaultDashboard(db)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 优先修复的漏洞和漏洞分类分布
+	data.TopRiskVulns = s.getTopRiskVulns(db, userID, roleCode)
+	data.CategoryStats = []*VulnCategoryStat{}
+	if query, ok := s.dashboardVulnQuery(db, userID, roleCode); ok {
+		data.CategoryStats = vulnCategoryStats(db, query)
+	}
+	return data, nil
+}
+
+// dashboardVulnQuery 仪表板漏洞统计范围：管理员和安全工程师与漏洞列表一致，研发工程师只统计分配给自己的漏洞
+func (s *DashboardService) dashboardVulnQuery(db *gorm.DB, userID uint, roleCode string) (*gorm.DB, bool) {
+	vulnService := &VulnService{}
+	query, ok := vulnService.vulnListQuery(db, &VulnListRequest{CurrentUserID: userID, CurrentUserRole: roleCode})
+	if !ok {
+		return nil, false
+	}
+	if roleCode == "dev_engineer" {
+		query = query.Where("assignee_id = ?", userID)
+	}
+	return query, true
+}
+
+// getTopRiskVulns 获取按风险优先级评分排序的前10个待修复漏洞
+func (s *DashboardService) getTopRiskVulns(db *gorm.DB, userID uint, roleCode string) []RiskVulnItem {
+	items := []RiskVulnItem{}
+
+	query, ok := s.dashboardVulnQuery(db, userID, roleCode)
+	if !ok {
+		return items
+	}
+
+	var vulns []models.Vulnerability
+	query.Preload("Asset").Preload("Project").Preload("Assignee").
+		Where("status NOT IN (?)", riskTopExcludedStatuses).
+		Order("risk_score DESC, submitted_at ASC").
+		Limit(10).
+		Find(&vulns)
+
+	for i := range vulns {
+		vuln := &vulns[i]
+		item := RiskVulnItem{
+			ID:          vuln.ID,
+			Title:       vuln.Title,
+			Severity:    vuln.Severity,
+			Status:      vuln.Status,
+			RiskScore:   vuln.RiskScore,
+			RiskLevel:   vuln.RiskLevel,
+			CVEID:       vuln.CVEID,
+			InKEV:       vuln.InKEV,
+			EPSSScore:   vuln.EPSSScore,
+			AssetName:   vuln.Asset.Name,
+			ProjectName: vuln.Project.Name,
+			FixDeadline: vuln.FixDeadline,
+			Factors:     vulnRiskBreakdown(vuln),
+		}
+		if vuln.Assignee != nil {
+			item.AssigneeName = displayName(vuln.Assignee)
+		}
+		items = append(items, item)
+	}
+	return items
 }
 
 // getSuperAdminDashboard 获取超级管理员仪表板数据
@@ -175,6 +272,10 @@ func (s *DashboardService) getSuperAdminDashboard(db *gorm.DB) (*DashboardData,
 		Scan(&latestVulns)
 	data.LatestVulns = latestVulns
 
+	// SLA达成率
+	slaService := &SLAService{}
+	data.SLACompliance, _ = slaService.slaCompliance(db, &slaComplianceFilter{})
+
 	return data, nil
 }
 
@@ -268,6 +369,10 @@ func (s *DashboardService) getSecurityEngineerDashboard(db *gorm.DB, userID uint
 		Scan(&latestVulns)
 	data.LatestVulns = latestVulns
 
+	// SLA达成率
+	slaService := &SLAService{}
+	data.SLACompliance, _ = slaService.slaCompliance(db, &slaComplianceFilter{})
+
 	return data, nil
 }
 
@@ -371,6 +476,10 @@ func (s *DashboardService) getDevEngineerDashboard(db *gorm.DB, userID uint) (*D
 		Scan(&latestVulns)
 	data.LatestVulns = latestVulns
 
+	// 分配给自己的漏洞的SLA达成率
+	slaService := &SLAService{}
+	data.SLACompliance, _ = slaService.slaCompliance(db, &slaComplianceFilter{AssigneeID: userID})
+
 	return data, nil
 }
 
@@ -379,6 +488,8 @@ func (s *DashboardService) getDefaultDashboard(db *gorm.DB) (*DashboardData, err
 	data := &DashboardData{
 		VulnStatusStats: make(map[string]int64),
 		LatestVulns:     []VulnListItem{},
+		TopRiskVulns:    []RiskVulnItem{},
+		CategoryStats:   []*VulnCategoryStat{},
 	}
 	return data, nil
 }
@@ -5,6 +5,7 @@ package services
 import (
 	"crypto/tls"
 	"fmt"
+	"html"
 	"net/smtp"
 	"strconv"
 	"strings"
@@ -597,6 +598,253 @@ func GetVulnDeadlineReminderTemplate(vulnTitle, projectName, assigneeName, sever
 	return EmailTemplate{Subject: subject, Body: body}
 }
 
+// GetVulnCommentTemplate 漏洞新评论通知模板
+func GetVulnCommentTemplate(vulnTitle, projectName, userName, commenterName, comment string) EmailTemplate {
+	subject := fmt.Sprintf("【VulnMain】漏洞新评论：%s", vulnTitle)
+
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>漏洞评论通知</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #6f42c1; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background: #f9f9f9; }
+        .footer { padding: 10px; text-align: center; color: #666; font-size: 12px; }
+        .highlight { color: #6f42c1; font-weight: bold; }
+        .comment { padding: 10px 15px; background: #fff; border-left: 4px solid #6f42c1; white-space: pre-wrap; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>漏洞评论通知</h2>
+        </div>
+        <div class="content">
+            <p>您好，%s！</p>
+            <p>%s 在您参与的漏洞中发表了评论：</p>
+            <p><strong>漏洞标题：</strong><span class="highlight">%s</span></p>
+            <p><strong>所属项目：</strong>%s</p>
+            <div class="comment">%s</div>
+            <p>请登录系统查看详情。</p>
+        </div>
+        <div class="footer">
+            <p>此邮件由VulnMain系统自动发送，请勿回复。</p>
+            <p>发送时间：%s</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, userName, html.EscapeString(commenterName), html.EscapeString(vulnTitle), html.EscapeString(projectName),
+		html.EscapeString(comment), time.Now().Format("2006-01-02 15:04:05"))
+
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetVulnEscalationTemplate 漏洞超期升级通知模板
+func GetVulnEscalationTemplate(vulnTitle, projectName, userName, severity, content string, level int, deadline string) EmailTemplate {
+	subject := fmt.Sprintf("【超期升级·第%d级】漏洞修复已超期 - %s", level, vulnTitle)
+
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>漏洞修复已超期</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #dc3545; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background: #f9f9f9; }
+        .footer { padding: 10px; text-align: center; color: #666; font-size: 12px; }
+        .highlight { color: #dc3545; font-weight: bold; }
+        .detail { padding: 10px 15px; background: #fff; border-left: 4px solid #dc3545; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>漏洞修复已超期（第%d级升级）</h2>
+        </div>
+        <div class="content">
+            <p>您好，%s！</p>
+            <div class="detail">%s</div>
+            <p><strong>漏洞标题：</strong><span class="highlight">%s</span></p>
+            <p><strong>所属项目：</strong>%s</p>
+            <p><strong>严重程度：</strong>%s</p>
+            <p><strong>修复截止时间：</strong>%s</p>
+            <p>请登录系统查看详情并跟进修复进度。</p>
+        </div>
+        <div class="footer">
+            <p>此邮件由VulnMain系统自动发送，请勿回复。</p>
+            <p>发送时间：%s</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, level, userName, html.EscapeString(content), html.EscapeString(vulnTitle), html.EscapeString(projectName),
+		severityLabel(severity), deadline, time.Now().Format("2006-01-02 15:04:05"))
+
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetVulnBulkUpdatedTemplate 漏洞批量操作汇总通知模板
+func GetVulnBulkUpdatedTemplate(userName, summary string, vulnTitles []string) EmailTemplate {
+	subject := fmt.Sprintf("【VulnMain】漏洞批量更新：%s（%d个漏洞）", summary, len(vulnTitles))
+
+	var rows strings.Builder
+	for _, title := range vulnTitles {
+		rows.WriteString(fmt.Sprintf(`
+                <li>%s</li>`, html.EscapeString(title)))
+	}
+
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>漏洞批量更新</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #007bff; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background: #f9f9f9; }
+        .footer { padding: 10px; text-align: center; color: #666; font-size: 12px; }
+        .highlight { color: #007bff; font-weight: bold; }
+        .list { padding: 10px 15px 10px 35px; background: #fff; border-left: 4px solid #007bff; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>漏洞批量更新</h2>
+        </div>
+        <div class="content">
+            <p>您好，%s！</p>
+            <p><span class="highlight">%s</span>，涉及您的 <strong>%d</strong> 个漏洞：</p>
+            <ul class="list">%s
+            </ul>
+            <p>请登录系统查看详情并及时处理。</p>
+        </div>
+        <div class="footer">
+            <p>此邮件由VulnMain系统自动发送，请勿回复。</p>
+            <p>发送时间：%s</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, userName, html.EscapeString(summary), len(vulnTitles), rows.String(), time.Now().Format("2006-01-02 15:04:05"))
+
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetRiskAcceptanceTemplate 风险接受申请、审批和到期通知模板
+func GetRiskAcceptanceTemplate(title, vulnTitle, projectName, userName, content, justification, controls string) EmailTemplate {
+	subject := fmt.Sprintf("【VulnMain】%s：%s", title, vulnTitle)
+
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>%s</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #fd7e14; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background: #f9f9f9; }
+        .footer { padding: 10px; text-align: center; color: #666; font-size: 12px; }
+        .highlight { color: #fd7e14; font-weight: bold; }
+        .detail { padding: 10px 15px; background: #fff; border-left: 4px solid #fd7e14; white-space: pre-wrap; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>%s</h2>
+        </div>
+        <div class="content">
+            <p>您好，%s！</p>
+            <p>%s</p>
+            <p><strong>漏洞标题：</strong><span class="highlight">%s</span></p>
+            <p><strong>所属项目：</strong>%s</p>
+            <p><strong>接受理由：</strong></p>
+            <div class="detail">%s</div>
+            <p><strong>补偿性控制措施：</strong></p>
+            <div class="detail">%s</div>
+            <p>请登录系统查看详情。</p>
+        </div>
+        <div class="footer">
+            <p>此邮件由VulnMain系统自动发送，请勿回复。</p>
+            <p>发送时间：%s</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, html.EscapeString(title), html.EscapeString(title), userName, html.EscapeString(content), html.EscapeString(vulnTitle),
+		html.EscapeString(projectName), html.EscapeString(justification), html.EscapeString(controls), time.Now().Format("2006-01-02 15:04:05"))
+
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetNotificationDigestTemplate 通知汇总邮件模板
+func GetNotificationDigestTemplate(userName string, items []models.NotificationDigest) EmailTemplate {
+	subject := fmt.Sprintf("【VulnMain】通知汇总：您有%d条新通知", len(items))
+
+	var rows strings.Builder
+	for _, item := range items {
+		rows.WriteString(fmt.Sprintf(`
+            <div class="item">
+                <p class="title">%s</p>
+                <p>%s</p>
+                <p class="time">%s</p>
+            </div>`, html.EscapeString(item.Title), html.EscapeString(item.Content), item.CreatedAt.Format("2006-01-02 15:04")))
+	}
+
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>通知汇总</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #007bff; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background: #f9f9f9; }
+        .footer { padding: 10px; text-align: center; color: #666; font-size: 12px; }
+        .item { padding: 10px 15px; margin-bottom: 10px; background: #fff; border-left: 4px solid #007bff; }
+        .item p { margin: 4px 0; }
+        .title { font-weight: bold; color: #007bff; }
+        .time { color: #999; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>通知汇总</h2>
+        </div>
+        <div class="content">
+            <p>您好，%s！</p>
+            <p>自上次汇总以来，您共有 <strong>%d</strong> 条新通知：</p>
+            %s
+            <p>请登录系统查看详情并及时处理。</p>
+        </div>
+        <div class="footer">
+            <p>此邮件由VulnMain系统自动发送，如需调整通知方式请在系统中修改通知偏好。</p>
+            <p>发送时间：%s</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, userName, len(items), rows.String(), time.Now().Format("2006-01-02 15:04:05"))
+
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
 // 邮件发送的便捷方法
 
 // SendProjectCreatedNotification 发送项目创建通知
@@ -0,0 +1,261 @@
+// 漏洞超期升级服务包
+// 该包在漏洞超过修复截止时间后按配置的升级级别逐级通知：默认超期当天通知指派人、
+// 超期2天通知项目负责人、超期7天通知部门负责人（未配置时通知超级管理员）。
+// 每个级别对同一截止时间只升级一次，升级记录写入漏洞时间线
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// EscalationService 超期升级服务
+type EscalationService struct{}
+
+// 升级通知对象
+const (
+	EscalateAssignee       = "assignee"        // 漏洞指派人
+	EscalateProjectOwner   = "project_owner"   // 项目负责人
+	EscalateDepartmentHead = "department_head" // 指派人所属部门的负责人
+	EscalateSuperAdmin     = "super_admin"     // 超级管理员
+)
+
+// 默认升级级别：超期当天通知指派人，超期2天通知项目负责人，超期7天通知部门负责人
+const defaultEscalationLevels = "0:assignee,2:project_owner,7:department_head"
+
+var escalationTargetLabels = map[string]string{
+	EscalateAssignee:       "指派人",
+	EscalateProjectOwner:   "项目负责人",
+	EscalateDepartmentHead: "部门负责人",
+	EscalateSuperAdmin:     "超级管理员",
+}
+
+// escalationExcludedStatuses 不参与升级的状态：待审核、已修复待复测和已结束的漏洞
+var escalationExcludedStatuses = []string{VulnStatusPending, VulnStatusFixed, VulnStatusRetesting, VulnStatusCompleted, VulnStatusIgnored, VulnStatusRejected, "closed"}
+
+// EscalationLevel 超期升级级别
+type EscalationLevel struct {
+	Level  int    `json:"level"`  // 级别，从1开始
+	Days   int    `json:"days"`   // 超期天数达到该值时触发
+	Target string `json:"target"` // 通知对象
+}
+
+// parseEscalationLevels 解析升级级别配置，格式为"超期天数:通知对象"，多个级别用逗号分隔
+func parseEscalationLevels(value string) []EscalationLevel {
+	levels := []EscalationLevel{}
+	for _, item := range splitWorkflowList(value) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		target := strings.TrimSpace(parts[1])
+		if err != nil || days < 0 || escalationTargetLabels[target] == "" {
+			fmt.Printf("忽略无效的超期升级配置: %s\n", item)
+			continue
+		}
+		levels = append(levels, EscalationLevel{Days: days, Target: target})
+	}
+
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Days < levels[j].Days
+	})
+	for i := range levels {
+		levels[i].Level = i + 1
+	}
+	return levels
+}
+
+// getStringConfig 读取字符串类型的系统配置，配置不存在时返回默认值
+func getStringConfig(key, defaultValue string) string {
+	systemService := &SystemService{}
+	config, err := systemService.GetSystemConfig(key)
+	if err != nil {
+		return defaultValue
+	}
+	return config.Value
+}
+
+// GetEscalationLevels 获取当前配置的升级级别，配置为空或无效时使用默认级别
+func (s *EscalationService) GetEscalationLevels() []EscalationLevel {
+	levels := parseEscalationLevels(getStringConfig("escalation.levels", defaultEscalationLevels))
+	if len(levels) == 0 {
+		levels = parseEscalationLevels(defaultEscalationLevels)
+	}
+	return levels
+}
+
+// departmentHead 获取部门负责人，配置格式为"部门名称:用户名"，多个部门用逗号分隔
+func departmentHead(db *gorm.DB, department string) uint {
+	if department == "" {
+		return 0
+	}
+	for _, item := range splitWorkflowList(getStringConfig("escalation.department_heads", "")) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != department {
+			continue
+		}
+		var user models.User
+		if err := db.Where("username = ? AND status = ?", strings.TrimSpace(parts[1]), 1).First(&user).Error; err == nil {
+			return user.ID
+		}
+	}
+	return 0
+}
+
+// superAdminIDs 获取启用的超级管理员
+func superAdminIDs(db *gorm.DB) []uint {
+	var ids []uint
+	db.Model(&models.User{}).
+		Where("status = ? AND role_id IN (SELECT id FROM roles WHERE code = ?)", 1, "super_admin").
+		Pluck("id", &ids)
+	return ids
+}
+
+// escalationRecipients 获取升级级别的通知对象，部门负责人未配置时通知超级管理员
+func (s *EscalationService) escalationRecipients(db *gorm.DB, vuln *models.Vulnerability, target string) []uint {
+	switch target {
+	case EscalateAssignee:
+		if vuln.AssigneeID != nil {
+			return []uint{*vuln.AssigneeID}
+		}
+	case EscalateProjectOwner:
+		if vuln.Project.OwnerID != 0 {
+			return []uint{vuln.Project.OwnerID}
+		}
+	case EscalateDepartmentHead:
+		if vuln.AssigneeID != nil {
+			var assignee models.User
+			if err := db.Where("id = ?", *vuln.AssigneeID).First(&assignee).Error; err == nil {
+				if headID := departmentHead(db, assignee.Department); headID != 0 {
+					return []uint{headID}
+				}
+			}
+		}
+		return superAdminIDs(db)
+	case EscalateSuperAdmin:
+		return superAdminIDs(db)
+	}
+	return nil
+}
+
+// EscalateOverdueVulns 检查超过修复截止时间的漏洞，按升级级别发送通知
+func (s *EscalationService) EscalateOverdueVulns() error {
+	if getStringConfig("escalation.enabled", "true") == "false" {
+		return nil
+	}
+
+	db := Init.GetDB()
+	now := time.Now().Truncate(time.Second)
+	levels := s.GetEscalationLevels()
+
+	var vulns []models.Vulnerability
+	if err := db.Preload("Project").
+		Where("fix_deadline IS NOT NULL AND fix_deadline < ?", now).
+		Where("status NOT IN (?)", escalationExcludedStatuses).
+		Where("sla_paused_at IS NULL"). // 复测中、风险接受等暂停计时的漏洞不升级
+		Find(&vulns).Error; err != nil {
+		return fmt.Errorf("查询超期漏洞失败: %v", err)
+	}
+
+	for i := range vulns {
+		vuln := &vulns[i]
+		daysOverdue := int(now.Sub(*vuln.FixDeadline).Hours() / 24)
+		for _, level := range levels {
+			if daysOverdue < level.Days {
+				break
+			}
+			s.escalate(db, vuln, level, now)
+		}
+	}
+
+	return nil
+}
+
+// escalate 执行一个级别的升级：同一截止时间的同一级别只升级一次
+func (s *EscalationService) escalate(db *gorm.DB, vuln *models.Vulnerability, level EscalationLevel, now time.Time) {
+	deadline := *vuln.FixDeadline
+	var count int64
+	db.Model(&models.VulnEscalation{}).Where("vuln_id = ? AND level = ? AND deadline = ?", vuln.ID, level.Level, deadline).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	// 先写入升级记录，避免邮件发送期间下一次检查重复升级；邮件投递结果在回调中更新
+	recipients := s.escalationRecipients(db, vuln, level.Target)
+	records := map[uint]uint{}
+	names := []string{}
+	for _, recipientID := range recipients {
+		if _, ok := records[recipientID]; ok || recipientID == 0 {
+			continue
+		}
+		record := models.VulnEscalation{
+			VulnID:      vuln.ID,
+			Level:       level.Level,
+			DaysOverdue: level.Days,
+			Target:      level.Target,
+			Deadline:    deadline,
+			RecipientID: recipientID,
+			Status:      "in_app",
+			SentAt:      now,
+		}
+		if err := db.Create(&record).Error; err != nil {
+			fmt.Printf("保存漏洞超期升级记录失败 (漏洞ID: %d): %v\n", vuln.ID, err)
+			continue
+		}
+		records[recipientID] = record.ID
+		names = append(names, userDisplayName(recipientID))
+	}
+
+	vulnService := &VulnService{}
+	target := escalationTargetLabels[level.Target]
+	if len(records) == 0 {
+		// 没有可通知的人员时同样记录，避免每次检查重复处理
+		db.Create(&models.VulnEscalation{
+			VulnID:      vuln.ID,
+			Level:       level.Level,
+			DaysOverdue: level.Days,
+			Target:      level.Target,
+			Deadline:    deadline,
+			Status:      "no_recipient",
+			SentAt:      now,
+		})
+		vulnService.addTimeline(vuln.ID, 0, "escalated", fmt.Sprintf("漏洞已超期，第%d级升级未找到%s，未发送通知", level.Level, target))
+		return
+	}
+
+	description := fmt.Sprintf("漏洞已超期，第%d级升级通知%s：%s", level.Level, target, strings.Join(names, "、"))
+	if level.Days > 0 {
+		description = fmt.Sprintf("漏洞已超期%d天，第%d级升级通知%s：%s", level.Days, level.Level, target, strings.Join(names, "、"))
+	}
+	vulnService.addTimeline(vuln.ID, 0, "escalated", description)
+
+	userIDs := make([]uint, 0, len(records))
+	for recipientID := range records {
+		userIDs = append(userIDs, recipientID)
+	}
+	notificationService := &NotificationService{}
+	notificationService.NotifyVulnEscalation(vuln, userIDs, level.Level, level.Days, deadline.Format("2006-01-02 15:04"), func(recipient *models.User, status string, err error) {
+		updates := map[string]interface{}{"status": status, "recipient_email": recipient.Email}
+		switch status {
+		case EmailFailed:
+			fmt.Printf("发送漏洞超期升级邮件失败 (漏洞ID: %d, 第%d级): %v\n", vuln.ID, level.Level, err)
+		case EmailSkipped:
+			// 关闭邮件提醒或没有邮箱时只记录站内提醒
+			updates = map[string]interface{}{"status": "in_app"}
+		}
+		db.Model(&models.VulnEscalation{}).Where("id = ?", records[recipient.ID]).Updates(updates)
+	})
+
+	webhookService := &WebhookService{}
+	data := NewWebhookVulnData(vuln, 0)
+	data.Comment = description
+	webhookService.Emit(WebhookVulnEscalated, data)
+}
@@ -0,0 +1,679 @@
+// 文件访问服务包
+// 该包负责上传文件的存储、访问控制和签名链接生成，文件下载必须通过FileStorage、VulnAttachment、WeeklyReport记录解析
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+	"vulnmain/utils"
+)
+
+// FileService 文件访问服务
+type FileService struct{}
+
+// fileURLPattern 匹配Markdown内容中的文件引用，可带签名参数
+// 例如 /api/files/12 或 /api/files/12/signed?expires=...&signature=...
+var fileURLPattern = regexp.MustCompile(`/api/files/(\d+)(/signed\?[^\s)"'<>\]]*)?`)
+
+// legacyImagePattern 匹配旧版本通过静态目录访问的漏洞图片地址
+var legacyImagePattern = regexp.MustCompile(`/uploads/vuln-images/(vuln_img_(\d+)_\d+\.[A-Za-z0-9]+)`)
+
+// getIntConfig 读取整数类型的系统配置，读取失败时返回默认值
+func getIntConfig(key string, defaultValue int) int {
+	db := Init.GetDB()
+
+	var config models.SystemConfig
+	if err := db.Where("`key` = ?", key).First(&config).Error; err != nil {
+		return defaultValue
+	}
+
+	value, err := strconv.Atoi(config.Value)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+// SignedURLExpire 获取页面内嵌文件签名链接的有效期
+func (s *FileService) SignedURLExpire() time.Duration {
+	return time.Duration(getIntConfig("upload.signed_url_expire", 30)) * time.Minute
+}
+
+// EmailLinkExpire 获取邮件中文件下载链接的有效期
+func (s *FileService) EmailLinkExpire() time.Duration {
+	return time.Duration(getIntConfig("upload.email_link_expire", 72)) * time.Hour
+}
+
+// SignedFileURL 生成文件的签名访问路径
+func (s *FileService) SignedFileURL(signer *utils.ResourceSigner, fileID uint, ttl time.Duration) string {
+	return fmt.Sprintf("/api/files/%d/signed?%s", fileID, signer.Query(fmt.Sprintf("file:%d", fileID), ttl))
+}
+
+// SignedWeeklyReportURL 生成周报文件的签名访问路径
+func (s *FileService) SignedWeeklyReportURL(signer *utils.ResourceSigner, reportID uint, ttl time.Duration) string {
+	return fmt.Sprintf("/api/weekly-reports/%d/signed?%s", reportID, signer.Query(fmt.Sprintf("weekly_report:%d", reportID), ttl))
+}
+
+// StoreFile 将文件写入默认存储后端并创建FileStorage记录
+// record需填写文件名、大小、类型、上传者和分类，存储相关字段由本方法填充
+func (s *FileService) StoreFile(key string, reader io.Reader, record *models.FileStorage) error {
+	store, err := utils.DefaultStorage()
+	if err != nil {
+		return fmt.Errorf("获取存储后端失败: %v", err)
+	}
+
+	if err := store.Put(key, reader, record.FileSize, record.MimeType); err != nil {
+		return err
+	}
+
+	record.Backend = store.Name()
+	record.StorageKey = key
+	record.FilePath = store.Location(key)
+
+	db := Init.GetDB()
+	if err := db.Create(record).Error; err != nil {
+		store.Delete(key)
+		return errors.New("保存文件记录失败")
+	}
+
+	return nil
+}
+
+// OpenFile 从文件记录所在的存储后端读取文件内容，调用方负责关闭
+func (s *FileService) OpenFile(file *models.FileStorage) (io.ReadCloser, error) {
+	// 旧版本的文件直接写入本地磁盘，没有对象键
+	if file.StorageKey == "" {
+		reader, err := os.Open(file.FilePath)
+		if os.IsNotExist(err) {
+			return nil, utils.ErrObjectNotFound
+		}
+		return reader, err
+	}
+
+	store, err := utils.GetStorage(file.Backend)
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(file.StorageKey)
+}
+
+// RemoveFile 删除文件记录及存储后端中的文件
+func (s *FileService) RemoveFile(file *models.FileStorage) error {
+	db := Init.GetDB()
+	if err := db.Delete(file).Error; err != nil {
+		return errors.New("删除文件记录失败")
+	}
+
+	if file.StorageKey == "" {
+		if err := os.Remove(file.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	store, err := utils.GetStorage(file.Backend)
+	if err != nil {
+		return err
+	}
+	return store.Delete(file.StorageKey)
+}
+
+// OpenAttachment 读取漏洞附件内容
+func (s *FileService) OpenAttachment(attachment *models.VulnAttachment) (io.ReadCloser, error) {
+	db := Init.GetDB()
+
+	var file models.FileStorage
+	if attachment.FileStorageID == 0 || db.Where("id = ?", attachment.FileStorageID).First(&file).Error != nil {
+		file = models.FileStorage{FilePath: attachment.FilePath}
+	}
+	return s.OpenFile(&file)
+}
+
+// OpenWeeklyReport 读取周报PDF内容
+func (s *FileService) OpenWeeklyReport(report *models.WeeklyReport) (io.ReadCloser, error) {
+	db := Init.GetDB()
+
+	var file models.FileStorage
+	if report.FileStorageID == 0 || db.Where("id = ?", report.FileStorageID).First(&file).Error != nil {
+		file = models.FileStorage{FilePath: report.FilePath}
+	}
+	return s.OpenFile(&file)
+}
+
+// GetFileForUser 获取当前用户有权访问的文件记录
+// 漏洞图片跟随所属漏洞、知识库文章或漏洞模板的访问权限，附件跟随引用它的漏洞的访问权限，其他文件仅上传者和超级管理员可访问
+func (s *FileService) GetFileForUser(fileID uint, userID uint, userRole string) (*models.FileStorage, error) {
+	db := Init.GetDB()
+
+	var file models.FileStorage
+	if err := db.Where("id = ?", fileID).First(&file).Error; err != nil {
+		return nil, errors.New("文件不存在")
+	}
+
+	if userRole == "super_admin" || file.UserID == userID {
+		return &file, nil
+	}
+
+	vulnService := &VulnService{}
+	switch file.Category {
+	case "vuln_image":
+		if file.RefID == 0 {
+			break
+		}
+		switch file.RefType {
+		case "vuln":
+			var vuln models.Vulnerability
+			if err := db.Where("id = ?", file.RefID).First(&vuln).Error; err == nil && vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+				return &file, nil
+			}
+		case "knowledge":
+			// 知识库文章的图片对所有可以查看该文章的用户开放
+			var article models.KnowledgeArticle
+			if err := db.Where("id = ?", file.RefID).First(&article).Error; err == nil && canViewKnowledgeArticle(db, &article, userID) {
+				return &file, nil
+			}
+		case "finding_template":
+			// 漏洞模板的图片对所有可以使用该模板的用户开放
+			var count int
+			if visibleFindingTemplates(db, userID).Where("id = ?", file.RefID).Count(&count); count > 0 {
+				return &file, nil
+			}
+		}
+	case "attachment":
+		var attachments []models.VulnAttachment
+		db.Where("file_storage_id = ?", file.ID).Find(&attachments)
+		for _, attachment := range attachments {
+			var vuln models.Vulnerability
+			if err := db.Where("id = ?", attachment.VulnID).First(&vuln).Error; err == nil && vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+				return &file, nil
+			}
+		}
+	}
+
+	// 无权限时同样返回文件不存在，避免泄露文件是否存在
+	return nil, errors.New("文件不存在")
+}
+
+// GetFileBySignature 通过签名链接获取文件记录
+func (s *FileService) GetFileBySignature(fileID uint, expires string, signature string) (*models.FileStorage, error) {
+	if err := utils.NewResourceSigner().Verify(fmt.Sprintf("file:%d", fileID), expires, signature); err != nil {
+		return nil, err
+	}
+
+	db := Init.GetDB()
+	var file models.FileStorage
+	if err := db.Where("id = ?", fileID).First(&file).Error; err != nil {
+		return nil, errors.New("文件不存在")
+	}
+
+	return &file, nil
+}
+
+// GetWeeklyReportBySignature 通过签名链接获取周报记录
+func (s *FileService) GetWeeklyReportBySignature(reportID uint, expires string, signature string) (*models.WeeklyReport, error) {
+	if err := utils.NewResourceSigner().Verify(fmt.Sprintf("weekly_report:%d", reportID), expires, signature); err != nil {
+		return nil, err
+	}
+
+	db := Init.GetDB()
+	var report models.WeeklyReport
+	if err := db.Where("id = ?", reportID).First(&report).Error; err != nil {
+		return nil, errors.New("周报记录不存在")
+	}
+
+	return &report, nil
+}
+
+// GetSignedFileURLs 批量生成当前用户有权访问的文件签名链接，无权访问的文件会被忽略
+func (s *FileService) GetSignedFileURLs(fileIDs []uint, userID uint, userRole string) map[uint]string {
+	signer := utils.NewResourceSigner()
+	ttl := s.SignedURLExpire()
+
+	urls := make(map[uint]string)
+	for _, fileID := range fileIDs {
+		if _, ok := urls[fileID]; ok {
+			continue
+		}
+		if _, err := s.GetFileForUser(fileID, userID, userRole); err != nil {
+			continue
+		}
+		urls[fileID] = s.SignedFileURL(signer, fileID, ttl)
+	}
+
+	return urls
+}
+
+// NormalizeFileURLs 去掉内容中文件引用的签名参数，保存时只保留稳定的 /api/files/:id 地址
+func NormalizeFileURLs(content string) string {
+	return fileURLPattern.ReplaceAllString(content, "/api/files/$1")
+}
+
+// signContent 将内容中的文件引用替换为带签名的短时效链接
+// allowed返回false的文件保留不带签名的原始地址，前端无法直接加载
+func (s *FileService) signContent(content string, allowed func(fileID uint) bool, signURL func(fileID uint) string) string {
+	if !strings.Contains(content, "/api/files/") {
+		return content
+	}
+
+	return fileURLPattern.ReplaceAllStringFunc(content, func(match string) string {
+		sub := fileURLPattern.FindStringSubmatch(match)
+		fileID, err := strconv.ParseUint(sub[1], 10, 32)
+		if err != nil {
+			return match
+		}
+		if !allowed(uint(fileID)) {
+			return fmt.Sprintf("/api/files/%d", fileID)
+		}
+		return signURL(uint(fileID))
+	})
+}
+
+// contentSigner 为一批内容中的图片生成签名链接，按文件ID缓存查询结果
+// 列表中多个漏洞或文章可能引用同一文件
+type contentSigner struct {
+	fileService *FileService
+	signer      *utils.ResourceSigner
+	ttl         time.Duration
+	userID      uint
+	userRole    string
+	files       map[uint]models.FileStorage
+	userAllowed map[uint]bool
+}
+
+// newContentSigner 创建当前用户的内容签名器
+func (s *FileService) newContentSigner(userID uint, userRole string) *contentSigner {
+	return &contentSigner{
+		fileService: s,
+		signer:      utils.NewResourceSigner(),
+		ttl:         s.SignedURLExpire(),
+		userID:      userID,
+		userRole:    userRole,
+		files:       make(map[uint]models.FileStorage),
+		userAllowed: make(map[uint]bool),
+	}
+}
+
+// sign 为属于refType/refID对象或当前用户有权访问的文件签名，调用方需已校验当前用户对该对象的访问权限
+func (c *contentSigner) sign(refType string, refID uint, fields ...*string) {
+	db := Init.GetDB()
+	allowed := func(fileID uint) bool {
+		file, ok := c.files[fileID]
+		if !ok {
+			db.Where("id = ?", fileID).First(&file)
+			c.files[fileID] = file
+		}
+		if file.ID == 0 {
+			return false
+		}
+		if file.RefType == refType && file.RefID == refID {
+			return true
+		}
+
+		if result, ok := c.userAllowed[fileID]; ok {
+			return result
+		}
+		_, err := c.fileService.GetFileForUser(fileID, c.userID, c.userRole)
+		c.userAllowed[fileID] = err == nil
+		return err == nil
+	}
+	signURL := func(fileID uint) string {
+		return c.fileService.SignedFileURL(c.signer, fileID, c.ttl)
+	}
+
+	for _, field := range fields {
+		*field = c.fileService.signContent(*field, allowed, signURL)
+	}
+}
+
+// SignVulnContent 为漏洞Markdown字段中的图片生成签名链接，供前端直接渲染
+// 调用方需已校验当前用户对漏洞的访问权限；只为属于该漏洞或当前用户有权访问的文件签名，
+// 避免在漏洞内容中粘贴其他项目的文件ID来获取下载链接
+func (s *FileService) SignVulnContent(userID uint, userRole string, vulns ...*models.Vulnerability) {
+	signer := s.newContentSigner(userID, userRole)
+	for _, vuln := range vulns {
+		signer.sign("vuln", vuln.ID, &vuln.Description, &vuln.POC, &vuln.Solution, &vuln.FixSuggestion)
+	}
+}
+
+// SignKnowledgeContent 为知识库文章中的图片生成签名链接
+func (s *FileService) SignKnowledgeContent(userID uint, userRole string, articles ...*models.KnowledgeArticle) {
+	signer := s.newContentSigner(userID, userRole)
+	for _, article := range articles {
+		signer.sign("knowledge", article.ID, &article.Description, &article.Impact, &article.Remediation)
+	}
+}
+
+// SignKnowledgeVersionContent 为知识库文章历史版本中的图片生成签名链接
+func (s *FileService) SignKnowledgeVersionContent(userID uint, userRole string, version *models.KnowledgeArticleVersion) {
+	s.newContentSigner(userID, userRole).sign("knowledge", version.ArticleID, &version.Description, &version.Impact, &version.Remediation)
+}
+
+// SignFindingTemplateContent 为漏洞模板中的图片生成签名链接
+func (s *FileService) SignFindingTemplateContent(userID uint, userRole string, templates ...*models.FindingTemplate) {
+	signer := s.newContentSigner(userID, userRole)
+	for _, template := range templates {
+		signer.sign("finding_template", template.ID, &template.Description, &template.POC, &template.Solution, &template.FixSuggestion)
+	}
+}
+
+// contentFileIDs 获取内容中引用的文件ID
+func contentFileIDs(contents ...string) []uint {
+	var fileIDs []uint
+	for _, content := range contents {
+		for _, sub := range fileURLPattern.FindAllStringSubmatch(content, -1) {
+			if fileID, err := strconv.ParseUint(sub[1], 10, 32); err == nil {
+				fileIDs = append(fileIDs, uint(fileID))
+			}
+		}
+	}
+	return fileIDs
+}
+
+// replaceContentFiles 将内容中的文件引用替换为对应的新文件，替换后的地址不带签名参数
+func replaceContentFiles(content string, replacements map[uint]uint) string {
+	if len(replacements) == 0 {
+		return content
+	}
+	return fileURLPattern.ReplaceAllStringFunc(content, func(match string) string {
+		sub := fileURLPattern.FindStringSubmatch(match)
+		fileID, err := strconv.ParseUint(sub[1], 10, 32)
+		if err != nil {
+			return match
+		}
+		if newID, ok := replacements[uint(fileID)]; ok {
+			return fmt.Sprintf("/api/files/%d", newID)
+		}
+		return match
+	})
+}
+
+// BindContentImages 将内容中引用的图片关联到refType/refID对象，图片访问权限跟随该对象
+// 当前用户上传且尚未关联的图片直接关联；已关联到其他对象（如知识库文章）且当前用户有权访问的图片复制一份再关联，
+// 避免原图片被改为只属于新对象。内容中的引用被替换为复制后的文件时返回true，调用方需保存字段
+func (s *FileService) BindContentImages(refType string, refID uint, userID uint, userRole string, fields ...*string) bool {
+	contents := make([]string, len(fields))
+	for i, field := range fields {
+		contents[i] = *field
+	}
+	fileIDs := contentFileIDs(contents...)
+	if len(fileIDs) == 0 {
+		return false
+	}
+
+	db := Init.GetDB()
+	if err := db.Model(&models.FileStorage{}).
+		Where("id IN (?) AND category = ? AND user_id = ? AND ref_id = 0", fileIDs, "vuln_image", userID).
+		Updates(map[string]interface{}{"ref_type": refType, "ref_id": refID}).Error; err != nil {
+		fmt.Printf("关联内容图片失败: %v\n", err)
+	}
+
+	var files []models.FileStorage
+	db.Where("id IN (?) AND category = ? AND ref_id <> 0 AND NOT (ref_type = ? AND ref_id = ?)", fileIDs, "vuln_image", refType, refID).Find(&files)
+
+	replacements := make(map[uint]uint)
+	for i := range files {
+		file := &files[i]
+		if _, err := s.GetFileForUser(file.ID, userID, userRole); err != nil {
+			continue
+		}
+		copied, err := s.copyFile(file, userID, refType, refID)
+		if err != nil {
+			fmt.Printf("复制图片失败 (文件ID: %d): %v\n", file.ID, err)
+			continue
+		}
+		replacements[file.ID] = copied.ID
+	}
+	if len(replacements) == 0 {
+		return false
+	}
+
+	for _, field := range fields {
+		*field = replaceContentFiles(*field, replacements)
+	}
+	return true
+}
+
+// BindVulnImages 关联漏洞内容中引用的图片，图片访问权限跟随漏洞
+// 从知识库文章、漏洞模板等处带入的图片会复制为属于该漏洞的新文件，并保存替换后的内容
+func (s *FileService) BindVulnImages(vuln *models.Vulnerability, userID uint, userRole string) {
+	if !s.BindContentImages("vuln", vuln.ID, userID, userRole, &vuln.Description, &vuln.POC, &vuln.Solution, &vuln.FixSuggestion) {
+		return
+	}
+	db := Init.GetDB()
+	if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).UpdateColumns(map[string]interface{}{
+		"description":    vuln.Description,
+		"poc":            vuln.POC,
+		"solution":       vuln.Solution,
+		"fix_suggestion": vuln.FixSuggestion,
+	}).Error; err != nil {
+		fmt.Printf("保存漏洞图片引用失败 (漏洞ID: %d): %v\n", vuln.ID, err)
+	}
+}
+
+// copyFile 复制文件内容到默认存储后端，新文件由userID上传并关联到refType/refID对象
+func (s *FileService) copyFile(file *models.FileStorage, userID uint, refType string, refID uint) (*models.FileStorage, error) {
+	reader, err := s.OpenFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	ext := filepath.Ext(file.StorageKey)
+	if ext == "" {
+		ext = filepath.Ext(file.FilePath)
+	}
+	copied := models.FileStorage{
+		FileName: file.FileName,
+		FileSize: file.FileSize,
+		MimeType: file.MimeType,
+		Hash:     file.Hash,
+		UserID:   userID,
+		Category: file.Category,
+		RefType:  refType,
+		RefID:    refID,
+	}
+	key := fmt.Sprintf("vuln-images/vuln_img_%d_%d%s", userID, time.Now().UnixNano(), ext)
+	if err := s.StoreFile(key, reader, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// MigrateLegacyVulnImages 迁移旧版本通过静态目录引用的漏洞图片
+// 为图片补建FileStorage记录并关联到漏洞，同时把内容中的地址替换为 /api/files/:id
+func MigrateLegacyVulnImages() error {
+	db := Init.GetDB()
+
+	var vulns []models.Vulnerability
+	if err := db.Where("description LIKE ? OR poc LIKE ? OR solution LIKE ? OR fix_suggestion LIKE ?",
+		"%/uploads/vuln-images/%", "%/uploads/vuln-images/%", "%/uploads/vuln-images/%", "%/uploads/vuln-images/%").
+		Find(&vulns).Error; err != nil {
+		return fmt.Errorf("查询待迁移漏洞失败: %v", err)
+	}
+
+	migrate := func(vuln *models.Vulnerability, content string) string {
+		return legacyImagePattern.ReplaceAllStringFunc(content, func(match string) string {
+			sub := legacyImagePattern.FindStringSubmatch(match)
+			fileName := sub[1]
+			filePath := filepath.Join("uploads", "vuln-images", fileName)
+
+			var file models.FileStorage
+			if err := db.Where("file_path = ?", filePath).First(&file).Error; err != nil {
+				info, statErr := os.Stat(filePath)
+				if statErr != nil {
+					return match
+				}
+				uploaderID, _ := strconv.ParseUint(sub[2], 10, 32)
+				file = models.FileStorage{
+					FileName:   fileName,
+					FilePath:   filePath,
+					Backend:    utils.StorageLocal,
+					StorageKey: "vuln-images/" + fileName,
+					FileSize:   info.Size(),
+					MimeType:   imageMimeType(filepath.Ext(fileName)),
+					UserID:     uint(uploaderID),
+					Category:   "vuln_image",
+					RefType:    "vuln",
+					RefID:      vuln.ID,
+				}
+				if err := db.Create(&file).Error; err != nil {
+					return match
+				}
+			}
+			return fmt.Sprintf("/api/files/%d", file.ID)
+		})
+	}
+
+	for i := range vulns {
+		vuln := &vulns[i]
+		updates := map[string]interface{}{
+			"description":    migrate(vuln, vuln.Description),
+			"poc":            migrate(vuln, vuln.POC),
+			"solution":       migrate(vuln, vuln.Solution),
+			"fix_suggestion": migrate(vuln, vuln.FixSuggestion),
+		}
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).UpdateColumns(updates).Error; err != nil {
+			return fmt.Errorf("迁移漏洞图片失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// imageMimeType 根据扩展名获取图片MIME类型
+func imageMimeType(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// StorageMigrateResult 存储迁移结果
+type StorageMigrateResult struct {
+	Total    int      `json:"total"`    // 需要迁移的文件数
+	Migrated int      `json:"migrated"` // 迁移成功的文件数
+	Failed   int      `json:"failed"`   // 迁移失败的文件数
+	Errors   []string `json:"errors"`   // 失败原因
+}
+
+// legacyStorageKey 根据旧版本的本地文件路径推导对象键
+func legacyStorageKey(filePath string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(filePath)), "uploads/")
+}
+
+// registerLegacyWeeklyReports 为旧版本直接写入本地的周报补建FileStorage记录
+func registerLegacyWeeklyReports() {
+	db := Init.GetDB()
+
+	var reports []models.WeeklyReport
+	db.Where("file_storage_id = 0 AND file_path <> ''").Find(&reports)
+	for _, report := range reports {
+		file := models.FileStorage{
+			FileName:   report.FileName,
+			FilePath:   report.FilePath,
+			Backend:    utils.StorageLocal,
+			StorageKey: legacyStorageKey(report.FilePath),
+			FileSize:   report.FileSize,
+			MimeType:   "application/pdf",
+			UserID:     report.GeneratedBy,
+			Category:   "weekly_report",
+			RefType:    "weekly_report",
+			RefID:      report.ID,
+		}
+		if err := db.Create(&file).Error; err != nil {
+			continue
+		}
+		db.Model(&models.WeeklyReport{}).Where("id = ?", report.ID).Update("file_storage_id", file.ID)
+	}
+}
+
+// MigrateStorage 将文件从当前所在的存储后端迁移到目标后端
+// 迁移后更新FileStorage以及引用它的附件、周报记录，deleteSource为true时删除源文件
+func MigrateStorage(target string, deleteSource bool) (*StorageMigrateResult, error) {
+	db := Init.GetDB()
+
+	targetStore, err := utils.GetStorage(target)
+	if err != nil {
+		return nil, err
+	}
+
+	registerLegacyWeeklyReports()
+
+	var files []models.FileStorage
+	if err := db.Where("backend <> ? OR backend IS NULL OR storage_key = '' OR storage_key IS NULL", targetStore.Name()).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("查询待迁移文件失败: %v", err)
+	}
+
+	result := &StorageMigrateResult{Total: len(files)}
+	fail := func(file *models.FileStorage, err error) {
+		result.Failed++
+		result.Errors = append(result.Errors, fmt.Sprintf("文件%d(%s): %v", file.ID, file.FilePath, err))
+	}
+
+	fileService := &FileService{}
+	for i := range files {
+		file := &files[i]
+		source := *file
+
+		key := file.StorageKey
+		if key == "" {
+			key = legacyStorageKey(file.FilePath)
+		}
+
+		reader, err := fileService.OpenFile(&source)
+		if err != nil {
+			fail(file, err)
+			continue
+		}
+		err = targetStore.Put(key, reader, file.FileSize, file.MimeType)
+		reader.Close()
+		if err != nil {
+			fail(file, err)
+			continue
+		}
+
+		location := targetStore.Location(key)
+		if err := db.Model(&models.FileStorage{}).Where("id = ?", file.ID).Updates(map[string]interface{}{
+			"backend":     targetStore.Name(),
+			"storage_key": key,
+			"file_path":   location,
+		}).Error; err != nil {
+			targetStore.Delete(key)
+			fail(file, err)
+			continue
+		}
+		db.Model(&models.VulnAttachment{}).Where("file_storage_id = ?", file.ID).Update("file_path", location)
+		db.Model(&models.WeeklyReport{}).Where("file_storage_id = ?", file.ID).Update("file_path", location)
+
+		// 源文件与目标位置相同时不能删除
+		if deleteSource && source.FilePath != location {
+			if source.StorageKey == "" {
+				os.Remove(source.FilePath)
+			} else if sourceStore, err := utils.GetStorage(source.Backend); err == nil {
+				sourceStore.Delete(source.StorageKey)
+			}
+		}
+
+		result.Migrated++
+	}
+
+	return result, nil
+}
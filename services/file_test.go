@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSignContentSkipsForeignFiles(t *testing.T) {
+	fileService := &FileService{}
+	// 文件1属于当前漏洞，文件2来自其他项目
+	allowed := func(fileID uint) bool { return fileID == 1 }
+	signURL := func(fileID uint) string {
+		return fmt.Sprintf("/api/files/%d/signed?expires=1&signature=sig", fileID)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "own file is signed",
+			content: "![a](/api/files/1)",
+			want:    "![a](/api/files/1/signed?expires=1&signature=sig)",
+		},
+		{
+			name:    "foreign file is not signed",
+			content: "![b](/api/files/2)",
+			want:    "![b](/api/files/2)",
+		},
+		{
+			name:    "pasted signed link to foreign file is stripped",
+			content: "![b](/api/files/2/signed?expires=9999999999&signature=abc)",
+			want:    "![b](/api/files/2)",
+		},
+		{
+			name:    "mixed content",
+			content: "![a](/api/files/1) ![b](/api/files/2)",
+			want:    "![a](/api/files/1/signed?expires=1&signature=sig) ![b](/api/files/2)",
+		},
+		{
+			name:    "content without files",
+			content: "plain text",
+			want:    "plain text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileService.signContent(tt.content, allowed, signURL); got != tt.want {
+				t.Errorf("signContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceContentFiles(t *testing.T) {
+	replacements := map[uint]uint{1: 10}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "copied file is replaced",
+			content: "![a](/api/files/1)",
+			want:    "![a](/api/files/10)",
+		},
+		{
+			name:    "signature is dropped when replaced",
+			content: "![a](/api/files/1/signed?expires=1&signature=sig)",
+			want:    "![a](/api/files/10)",
+		},
+		{
+			name:    "other files are kept",
+			content: "![a](/api/files/1) ![b](/api/files/2)",
+			want:    "![a](/api/files/10) ![b](/api/files/2)",
+		},
+		{
+			name:    "similar ids are not replaced",
+			content: "![c](/api/files/11)",
+			want:    "![c](/api/files/11)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceContentFiles(tt.content, replacements); got != tt.want {
+				t.Errorf("replaceContentFiles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if ids := contentFileIDs("![a](/api/files/1)", "![b](/api/files/2/signed?expires=1&signature=sig) text"); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("contentFileIDs() = %v, want [1 2]", ids)
+	}
+}
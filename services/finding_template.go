@@ -0,0 +1,641 @@
+// 漏洞模板服务包
+// 该包管理常见漏洞的个人和共享模板：提交漏洞时选择模板预填标题、描述、修复建议、严重程度和标签，
+// 模板文本中的{{asset.name}}、{{vuln_url}}等占位符按所选资产和漏洞地址替换；
+// 每次使用模板提交漏洞都会记录使用情况，已提交的漏洞也可以转换为模板
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+	"vulnmain/utils"
+
+	"github.com/jinzhu/gorm"
+)
+
+// FindingTemplateService 漏洞模板服务
+type FindingTemplateService struct{}
+
+// FindingTemplateRequest 创建或修改漏洞模板请求，文本字段可以使用占位符
+type FindingTemplateRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	Scope         string  `json:"scope"` // personal个人、shared共享，默认个人
+	Title         string  `json:"title" binding:"required"`
+	VulnType      string  `json:"vuln_type" binding:"required"`
+	CategoryID    *uint   `json:"category_id"`
+	Severity      string  `json:"severity"`
+	CVSSVector    string  `json:"cvss_vector"`
+	CVSSScore     float64 `json:"cvss_score"`
+	Description   string  `json:"description"`
+	POC           string  `json:"poc"`
+	Solution      string  `json:"solution"`
+	FixSuggestion string  `json:"fix_suggestion"`
+	References    string  `json:"references"`
+	Tags          string  `json:"tags"`
+}
+
+// FindingTemplateListRequest 漏洞模板列表请求
+type FindingTemplateListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Keyword  string `form:"keyword"`   // 按模板名称、标题和漏洞类型搜索
+	Scope    string `form:"scope"`     // personal个人、shared共享、mine自己创建的，为空时返回全部可用模板
+	VulnType string `form:"vuln_type"` // 漏洞类型
+}
+
+// FindingTemplateListResponse 漏洞模板列表
+type FindingTemplateListResponse struct {
+	Templates []models.FindingTemplate `json:"templates"`
+	Total     int64                    `json:"total"`
+	Page      int                      `json:"page"`
+	PageSize  int                      `json:"page_size"`
+}
+
+// FindingTemplateRenderRequest 按资产和漏洞地址渲染模板的请求
+type FindingTemplateRenderRequest struct {
+	AssetID   uint   `json:"asset_id" binding:"required"`
+	VulnURL   string `json:"vuln_url"`
+	ProjectID uint   `json:"project_id"` // 为空时使用资产所属项目
+}
+
+// FindingTemplateFromVulnRequest 将漏洞转换为模板的请求
+type FindingTemplateFromVulnRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Scope string `json:"scope"`
+}
+
+// FindingTemplateStat 漏洞模板使用统计
+type FindingTemplateStat struct {
+	TemplateID  uint       `json:"template_id"`
+	Name        string     `json:"name"`
+	Scope       string     `json:"scope"`
+	OwnerName   string     `json:"owner_name"`
+	UsageCount  int        `json:"usage_count"`  // 累计使用次数
+	RecentCount int        `json:"recent_count"` // 统计周期内的使用次数
+	RecentUsers int        `json:"recent_users"` // 统计周期内的使用人数
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+// FindingTemplateStatsResponse 漏洞模板使用统计
+type FindingTemplateStatsResponse struct {
+	Days        int                    `json:"days"`
+	Templates   []*FindingTemplateStat `json:"templates"`
+	TotalUsage  int                    `json:"total_usage"`  // 全部可见模板的累计使用次数
+	RecentUsage int                    `json:"recent_usage"` // 统计周期内的使用次数
+}
+
+// FindingTemplatePlaceholders 模板支持的占位符
+var FindingTemplatePlaceholders = []VulnWorkflowOption{
+	{Code: "{{asset.name}}", Label: "资产名称"},
+	{Code: "{{asset.ip}}", Label: "资产IP"},
+	{Code: "{{asset.domain}}", Label: "资产域名"},
+	{Code: "{{asset.port}}", Label: "资产端口"},
+	{Code: "{{asset.department}}", Label: "资产所属部门"},
+	{Code: "{{vuln_url}}", Label: "漏洞地址"},
+	{Code: "{{project.name}}", Label: "项目名称"},
+	{Code: "{{reporter.name}}", Label: "提交人"},
+	{Code: "{{date}}", Label: "提交日期"},
+}
+
+var findingTemplatePlaceholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_.]+)\s*\}\}`)
+
+// findingTemplateValues 生成占位符的替换值
+func findingTemplateValues(asset *models.Asset, project *models.Project, reporter *models.User, vulnURL string) map[string]string {
+	values := map[string]string{
+		"vuln_url": vulnURL,
+		"date":     time.Now().Format("2006-01-02"),
+	}
+	if asset != nil {
+		values["asset.name"] = asset.Name
+		values["asset.ip"] = asset.IP
+		values["asset.domain"] = asset.Domain
+		values["asset.port"] = asset.Port
+		values["asset.department"] = asset.Department
+	}
+	if project != nil {
+		values["project.name"] = project.Name
+	}
+	if reporter != nil {
+		values["reporter.name"] = displayName(reporter)
+	}
+	return values
+}
+
+// renderFindingTemplate 替换文本中的占位符，不认识的占位符原样保留
+func renderFindingTemplate(text string, values map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return findingTemplatePlaceholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		key := findingTemplatePlaceholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := values[strings.ToLower(key)]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// canManageFindingTemplate 模板只能由创建人或超级管理员修改和删除
+func canManageFindingTemplate(template *models.FindingTemplate, userID uint, userRole string) bool {
+	return userRole == "super_admin" || template.OwnerID == userID
+}
+
+// visibleFindingTemplates 用户可用的模板：共享模板和自己的个人模板
+func visibleFindingTemplates(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.FindingTemplate{}).Where("scope = ? OR owner_id = ?", models.FindingTemplateScopeShared, userID)
+}
+
+// getVisibleFindingTemplate 获取用户可用的模板
+func getVisibleFindingTemplate(db *gorm.DB, id, userID uint) (*models.FindingTemplate, error) {
+	var template models.FindingTemplate
+	if err := visibleFindingTemplates(db, userID).Preload("Owner").Preload("Category").
+		Where("id = ?", id).First(&template).Error; err != nil {
+		return nil, errors.New("漏洞模板不存在")
+	}
+	return &template, nil
+}
+
+// signFindingTemplates 为模板内容中的图片生成当前用户可用的签名链接
+// 图片保存为不带签名的 /api/files/:id 地址，img标签不会携带JWT，返回前需要签名
+func signFindingTemplates(db *gorm.DB, userID uint, templates ...*models.FindingTemplate) {
+	userRole := ""
+	if user, err := loadVulnActor(db, userID); err == nil {
+		userRole = user.Role.Code
+	}
+	fileService := &FileService{}
+	fileService.SignFindingTemplateContent(userID, userRole, templates...)
+}
+
+// getSignedFindingTemplate 获取用户可用的模板并为内容中的图片签名
+func getSignedFindingTemplate(db *gorm.DB, id, userID uint) (*models.FindingTemplate, error) {
+	template, err := getVisibleFindingTemplate(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	signFindingTemplates(db, userID, template)
+	return template, nil
+}
+
+// bindFindingTemplateImages 将模板内容中引用的图片关联到模板，图片访问权限跟随模板
+// 关联到模板后，使用模板提交漏洞时图片会被复制给漏洞，而不是把原图片改为只属于第一个漏洞；
+// 旧版本保存的模板图片尚未关联或已被关联到漏洞，使用模板时以创建人身份补充关联
+func bindFindingTemplateImages(db *gorm.DB, template *models.FindingTemplate, userID uint) {
+	if len(contentFileIDs(template.Description, template.POC, template.Solution, template.FixSuggestion)) == 0 {
+		return
+	}
+	user, err := loadVulnActor(db, userID)
+	if err != nil {
+		return
+	}
+	fileService := &FileService{}
+	if !fileService.BindContentImages("finding_template", template.ID, userID, user.Role.Code,
+		&template.Description, &template.POC, &template.Solution, &template.FixSuggestion) {
+		return
+	}
+	if err := db.Model(&models.FindingTemplate{}).Where("id = ?", template.ID).UpdateColumns(map[string]interface{}{
+		"description":    template.Description,
+		"poc":            template.POC,
+		"solution":       template.Solution,
+		"fix_suggestion": template.FixSuggestion,
+	}).Error; err != nil {
+		fmt.Printf("保存漏洞模板图片引用失败 (模板ID: %d): %v\n", template.ID, err)
+	}
+}
+
+// validateFindingTemplateRequest 校验并规范化模板请求
+func validateFindingTemplateRequest(db *gorm.DB, req *FindingTemplateRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Title = strings.TrimSpace(req.Title)
+	req.VulnType = strings.TrimSpace(req.VulnType)
+	if req.Name == "" || req.Title == "" || req.VulnType == "" {
+		return errors.New("模板名称、漏洞标题和漏洞类型不能为空")
+	}
+	if len([]rune(req.Name)) > 100 {
+		return errors.New("模板名称不能超过100个字符")
+	}
+	if len([]rune(req.VulnType)) > 50 {
+		return errors.New("漏洞类型不能超过50个字符")
+	}
+	switch req.Scope {
+	case "":
+		req.Scope = models.FindingTemplateScopePersonal
+	case models.FindingTemplateScopePersonal, models.FindingTemplateScopeShared:
+	default:
+		return errors.New("模板范围只能为personal或shared")
+	}
+	if req.Severity != "" && !isWorkflowOption(slaSeverities, req.Severity) {
+		return fmt.Errorf("无效的严重程度 '%s'", req.Severity)
+	}
+	if req.CVSSScore < 0 || req.CVSSScore > 10 {
+		return errors.New("CVSS评分必须在0.0-10.0之间")
+	}
+	req.CVSSVector = strings.TrimSpace(req.CVSSVector)
+	if req.CVSSVector != "" {
+		if _, err := utils.CalculateCVSS(req.CVSSVector, nil); err != nil {
+			return err
+		}
+	}
+	if req.CategoryID != nil && *req.CategoryID == 0 {
+		req.CategoryID = nil
+	}
+	if req.CategoryID != nil {
+		if err := checkVulnCategory(db, *req.CategoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTemplateList 获取用户可用的漏洞模板，按使用次数排序
+func (s *FindingTemplateService) GetTemplateList(req *FindingTemplateListRequest, userID uint) (*FindingTemplateListResponse, error) {
+	db := Init.GetDB()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := visibleFindingTemplates(db, userID)
+	switch req.Scope {
+	case models.FindingTemplateScopePersonal:
+		query = query.Where("scope = ? AND owner_id = ?", models.FindingTemplateScopePersonal, userID)
+	case models.FindingTemplateScopeShared:
+		query = query.Where("scope = ?", models.FindingTemplateScopeShared)
+	case "mine":
+		query = query.Where("owner_id = ?", userID)
+	}
+	if req.VulnType != "" {
+		query = query.Where("vuln_type = ?", req.VulnType)
+	}
+	if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("name LIKE ? OR title LIKE ? OR vuln_type LIKE ? OR tags LIKE ?", like, like, like, like)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var templates []models.FindingTemplate
+	if err := query.Preload("Owner").Preload("Category").Order("usage_count DESC, updated_at DESC").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&templates).Error; err != nil {
+		return nil, errors.New("获取漏洞模板失败")
+	}
+	templatePtrs := make([]*models.FindingTemplate, len(templates))
+	for i := range templates {
+		templatePtrs[i] = &templates[i]
+	}
+	signFindingTemplates(db, userID, templatePtrs...)
+
+	return &FindingTemplateListResponse{
+		Templates: templates,
+		Total:     total,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+	}, nil
+}
+
+// GetTemplate 获取漏洞模板详情
+func (s *FindingTemplateService) GetTemplate(id, userID uint) (*models.FindingTemplate, error) {
+	return getSignedFindingTemplate(Init.GetDB(), id, userID)
+}
+
+// CreateTemplate 创建漏洞模板
+func (s *FindingTemplateService) CreateTemplate(req *FindingTemplateRequest, userID uint) (*models.FindingTemplate, error) {
+	db := Init.GetDB()
+
+	if err := validateFindingTemplateRequest(db, req); err != nil {
+		return nil, err
+	}
+
+	template := models.FindingTemplate{
+		Name:          req.Name,
+		Scope:         req.Scope,
+		OwnerID:       userID,
+		Title:         req.Title,
+		VulnType:      req.VulnType,
+		CategoryID:    req.CategoryID,
+		Severity:      req.Severity,
+		CVSSVector:    req.CVSSVector,
+		CVSSScore:     req.CVSSScore,
+		Description:   NormalizeFileURLs(req.Description),
+		POC:           NormalizeFileURLs(req.POC),
+		Solution:      NormalizeFileURLs(req.Solution),
+		FixSuggestion: NormalizeFileURLs(req.FixSuggestion),
+		References:    req.References,
+		Tags:          req.Tags,
+	}
+	if err := db.Create(&template).Error; err != nil {
+		return nil, errors.New("创建漏洞模板失败")
+	}
+	bindFindingTemplateImages(db, &template, userID)
+
+	return getSignedFindingTemplate(db, template.ID, userID)
+}
+
+// UpdateTemplate 修改漏洞模板
+func (s *FindingTemplateService) UpdateTemplate(id uint, req *FindingTemplateRequest, userID uint, userRole string) (*models.FindingTemplate, error) {
+	db := Init.GetDB()
+
+	template, err := getVisibleFindingTemplate(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageFindingTemplate(template, userID, userRole) {
+		return nil, errors.New("只能修改自己创建的模板")
+	}
+	if err := validateFindingTemplateRequest(db, req); err != nil {
+		return nil, err
+	}
+
+	req.Description = NormalizeFileURLs(req.Description)
+	req.POC = NormalizeFileURLs(req.POC)
+	req.Solution = NormalizeFileURLs(req.Solution)
+	req.FixSuggestion = NormalizeFileURLs(req.FixSuggestion)
+	if err := db.Model(&models.FindingTemplate{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":           req.Name,
+		"scope":          req.Scope,
+		"title":          req.Title,
+		"vuln_type":      req.VulnType,
+		"category_id":    req.CategoryID,
+		"severity":       req.Severity,
+		"cvss_vector":    req.CVSSVector,
+		"cvss_score":     req.CVSSScore,
+		"description":    req.Description,
+		"poc":            req.POC,
+		"solution":       req.Solution,
+		"fix_suggestion": req.FixSuggestion,
+		"references":     req.References,
+		"tags":           req.Tags,
+	}).Error; err != nil {
+		return nil, errors.New("更新漏洞模板失败")
+	}
+	template.Description = req.Description
+	template.POC = req.POC
+	template.Solution = req.Solution
+	template.FixSuggestion = req.FixSuggestion
+	bindFindingTemplateImages(db, template, userID)
+
+	return getSignedFindingTemplate(db, id, userID)
+}
+
+// DeleteTemplate 删除漏洞模板，使用记录保留
+func (s *FindingTemplateService) DeleteTemplate(id, userID uint, userRole string) error {
+	db := Init.GetDB()
+
+	template, err := getVisibleFindingTemplate(db, id, userID)
+	if err != nil {
+		return err
+	}
+	if !canManageFindingTemplate(template, userID, userRole) {
+		return errors.New("只能删除自己创建的模板")
+	}
+	if err := db.Delete(&models.FindingTemplate{ID: id}).Error; err != nil {
+		return errors.New("删除漏洞模板失败")
+	}
+	return nil
+}
+
+// applyFindingTemplate 用模板补充请求中未填写的字段，并替换全部文本字段中的占位符
+func applyFindingTemplate(db *gorm.DB, req *VulnCreateRequest, template *models.FindingTemplate, asset *models.Asset, reporterID uint) {
+	if req.Title == "" {
+		req.Title = template.Title
+	}
+	if req.VulnType == "" {
+		req.VulnType = template.VulnType
+	}
+	if req.CategoryID == nil || *req.CategoryID == 0 {
+		req.CategoryID = template.CategoryID
+	}
+	if req.Severity == "" {
+		req.Severity = template.Severity
+	}
+	if req.CVSSVector == "" && req.CVSSScore == 0 {
+		req.CVSSVector = template.CVSSVector
+		req.CVSSScore = template.CVSSScore
+	}
+	if req.Description == "" {
+		req.Description = template.Description
+	}
+	if req.POC == "" {
+		req.POC = template.POC
+	}
+	if req.Solution == "" {
+		req.Solution = template.Solution
+	}
+	if req.FixSuggestion == "" {
+		req.FixSuggestion = template.FixSuggestion
+	}
+	if req.References == "" {
+		req.References = template.References
+	}
+	if req.Tags == "" {
+		req.Tags = template.Tags
+	}
+
+	var project *models.Project
+	projectID := req.ProjectID
+	if projectID == 0 {
+		projectID = asset.ProjectID
+	}
+	if projectID != 0 {
+		var p models.Project
+		if db.Where("id = ?", projectID).First(&p).Error == nil {
+			project = &p
+		}
+	}
+	var reporter models.User
+	db.Where("id = ?", reporterID).First(&reporter)
+
+	values := findingTemplateValues(asset, project, &reporter, req.VulnURL)
+	req.Title = truncateRunes(renderFindingTemplate(req.Title, values), 255)
+	req.Description = renderFindingTemplate(req.Description, values)
+	req.POC = renderFindingTemplate(req.POC, values)
+	req.Solution = renderFindingTemplate(req.Solution, values)
+	req.FixSuggestion = renderFindingTemplate(req.FixSuggestion, values)
+	req.References = renderFindingTemplate(req.References, values)
+	req.Tags = renderFindingTemplate(req.Tags, values)
+}
+
+// RenderTemplate 按资产和漏洞地址渲染模板，返回可直接用于提交漏洞的请求
+func (s *FindingTemplateService) RenderTemplate(id uint, req *FindingTemplateRenderRequest, userID uint) (*VulnCreateRequest, error) {
+	db := Init.GetDB()
+
+	template, err := getVisibleFindingTemplate(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	var asset models.Asset
+	if err := db.Where("id = ?", req.AssetID).First(&asset).Error; err != nil {
+		return nil, errors.New("资产不存在")
+	}
+
+	templateID := template.ID
+	createReq := &VulnCreateRequest{
+		VulnURL:    req.VulnURL,
+		AssetID:    asset.ID,
+		ProjectID:  req.ProjectID,
+		TemplateID: &templateID,
+	}
+	if createReq.ProjectID == 0 {
+		createReq.ProjectID = asset.ProjectID
+	}
+	bindFindingTemplateImages(db, template, template.OwnerID)
+	applyFindingTemplate(db, createReq, template, &asset, userID)
+
+	// 渲染结果用于前端预览和填写表单，模板中的图片需要签名后才能加载
+	userRole := ""
+	if user, err := loadVulnActor(db, userID); err == nil {
+		userRole = user.Role.Code
+	}
+	fileService := &FileService{}
+	fileService.newContentSigner(userID, userRole).sign("finding_template", template.ID,
+		&createReq.Description, &createReq.POC, &createReq.Solution, &createReq.FixSuggestion)
+	return createReq, nil
+}
+
+// recordFindingTemplateUsage 记录模板的使用情况
+func recordFindingTemplateUsage(db *gorm.DB, templateID, vulnID, userID uint) {
+	now := time.Now()
+	if err := db.Create(&models.FindingTemplateUsage{TemplateID: templateID, VulnID: vulnID, UserID: userID}).Error; err != nil {
+		fmt.Printf("记录漏洞模板使用失败 (模板ID: %d): %v\n", templateID, err)
+		return
+	}
+	db.Model(&models.FindingTemplate{}).Where("id = ?", templateID).UpdateColumns(map[string]interface{}{
+		"usage_count":  gorm.Expr("usage_count + ?", 1),
+		"last_used_at": now,
+	})
+}
+
+// templatizeText 将漏洞内容中的资产信息和漏洞地址替换为占位符，便于模板用于其他资产
+func templatizeText(text string, replacements [][2]string) string {
+	for _, replacement := range replacements {
+		text = strings.ReplaceAll(text, replacement[0], replacement[1])
+	}
+	return text
+}
+
+// CreateTemplateFromVuln 将已提交的漏洞转换为模板，漏洞地址和资产信息替换为占位符
+func (s *FindingTemplateService) CreateTemplateFromVuln(vulnID uint, req *FindingTemplateFromVulnRequest, userID uint, userRole string) (*models.FindingTemplate, error) {
+	db := Init.GetDB()
+
+	var vuln models.Vulnerability
+	if err := db.Preload("Asset").Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	vulnService := &VulnService{}
+	if !vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	// 先替换较长的内容，避免漏洞地址中的域名或IP被单独替换
+	var replacements [][2]string
+	if vuln.VulnURL != "" {
+		replacements = append(replacements, [2]string{vuln.VulnURL, "{{vuln_url}}"})
+	}
+	for _, item := range [][2]string{
+		{vuln.Asset.Domain, "{{asset.domain}}"},
+		{vuln.Asset.IP, "{{asset.ip}}"},
+		{vuln.Asset.Name, "{{asset.name}}"},
+	} {
+		// 过短的资产名称容易误替换普通文本
+		if len([]rune(item[0])) >= 3 {
+			replacements = append(replacements, item)
+		}
+	}
+
+	templateReq := &FindingTemplateRequest{
+		Name:          req.Name,
+		Scope:         req.Scope,
+		Title:         templatizeText(vuln.Title, replacements),
+		VulnType:      vuln.VulnType,
+		CategoryID:    vuln.CategoryID,
+		Severity:      vuln.Severity,
+		CVSSVector:    vuln.CVSSVector,
+		CVSSScore:     vuln.CVSSScore,
+		Description:   templatizeText(vuln.Description, replacements),
+		POC:           templatizeText(vuln.POC, replacements),
+		Solution:      templatizeText(vuln.Solution, replacements),
+		FixSuggestion: templatizeText(vuln.FixSuggestion, replacements),
+		References:    vuln.References,
+		Tags:          vuln.Tags,
+	}
+	if templateReq.CategoryID != nil && checkVulnCategory(db, *templateReq.CategoryID) != nil {
+		templateReq.CategoryID = nil
+	}
+	if templateReq.CVSSVector != "" {
+		templateReq.CVSSScore = 0
+	}
+
+	template, err := s.CreateTemplate(templateReq, userID)
+	if err != nil {
+		return nil, err
+	}
+	db.Model(&models.FindingTemplate{}).Where("id = ?", template.ID).UpdateColumn("source_vuln_id", vuln.ID)
+	template.SourceVulnID = &vuln.ID
+	return template, nil
+}
+
+// GetTemplateStats 统计用户可用模板的使用情况，超级管理员统计全部模板
+func (s *FindingTemplateService) GetTemplateStats(days int, userID uint, userRole string) (*FindingTemplateStatsResponse, error) {
+	db := Init.GetDB()
+
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+
+	query := db.Model(&models.FindingTemplate{})
+	if userRole != "super_admin" {
+		query = visibleFindingTemplates(db, userID)
+	}
+	var templates []models.FindingTemplate
+	if err := query.Preload("Owner").Order("usage_count DESC, id ASC").Find(&templates).Error; err != nil {
+		return nil, errors.New("获取漏洞模板统计失败")
+	}
+
+	result := &FindingTemplateStatsResponse{Days: days, Templates: []*FindingTemplateStat{}}
+	if len(templates) == 0 {
+		return result, nil
+	}
+	ids := make([]uint, 0, len(templates))
+	for _, template := range templates {
+		ids = append(ids, template.ID)
+	}
+
+	var rows []struct {
+		TemplateID uint
+		Count      int
+		Users      int
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	db.Model(&models.FindingTemplateUsage{}).Select("template_id, COUNT(*) as count, COUNT(DISTINCT user_id) as users").
+		Where("template_id IN (?) AND created_at >= ?", ids, since).Group("template_id").Scan(&rows)
+	recent := map[uint]int{}
+	users := map[uint]int{}
+	for _, row := range rows {
+		recent[row.TemplateID] = row.Count
+		users[row.TemplateID] = row.Users
+	}
+
+	for i := range templates {
+		template := &templates[i]
+		result.Templates = append(result.Templates, &FindingTemplateStat{
+			TemplateID:  template.ID,
+			Name:        template.Name,
+			Scope:       template.Scope,
+			OwnerName:   displayName(&template.Owner),
+			UsageCount:  template.UsageCount,
+			RecentCount: recent[template.ID],
+			RecentUsers: users[template.ID],
+			LastUsedAt:  template.LastUsedAt,
+		})
+		result.TotalUsage += template.UsageCount
+		result.RecentUsage += recent[template.ID]
+	}
+	return result, nil
+}
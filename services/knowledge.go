@@ -0,0 +1,571 @@
+// 知识库服务包
+// 该包管理按漏洞分类或CWE编写的修复指南：文章每次修改保存完整的历史版本并支持恢复，
+// 使用MySQL全文索引检索；创建漏洞未填写修复建议时按漏洞类型、分类和CWE编号匹配文章预填，
+// 漏洞详情返回匹配到的文章
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// KnowledgeService 知识库服务
+type KnowledgeService struct{}
+
+// KnowledgeArticleRequest 创建或修改知识库文章请求
+type KnowledgeArticleRequest struct {
+	Title       string `json:"title" binding:"required"`
+	CategoryID  *uint  `json:"category_id"` // 适用的漏洞分类
+	CWEID       string `json:"cwe_id"`      // 适用的CWE编号
+	VulnTypes   string `json:"vuln_types"`  // 适用的漏洞类型，多个用逗号分隔
+	Description string `json:"description"`
+	Impact      string `json:"impact"`
+	Remediation string `json:"remediation" binding:"required"`
+	FixExamples string `json:"fix_examples"`
+	References  string `json:"references"`
+	Tags        string `json:"tags"`
+	Status      string `json:"status"`      // draft草稿、published已发布，默认已发布
+	ChangeNote  string `json:"change_note"` // 修改说明，记录在历史版本中
+}
+
+// KnowledgeListRequest 知识库文章列表请求
+type KnowledgeListRequest struct {
+	Page       int    `form:"page"`
+	PageSize   int    `form:"page_size"`
+	Keyword    string `form:"keyword"`     // 全文检索标题、描述、危害、修复建议、修复示例和标签
+	CategoryID *uint  `form:"category_id"` // 漏洞分类，包含下级分类的文章
+	CWEID      string `form:"cwe_id"`
+	Status     string `form:"status"` // 只有知识库编辑者可以查看草稿
+}
+
+// KnowledgeListResponse 知识库文章列表
+type KnowledgeListResponse struct {
+	Articles []models.KnowledgeArticle `json:"articles"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+}
+
+// KnowledgeMatchRequest 按漏洞信息匹配知识库文章的请求，用于提交漏洞时预填修复建议
+type KnowledgeMatchRequest struct {
+	VulnType   string `form:"vuln_type"`
+	CategoryID *uint  `form:"category_id"`
+	CWEID      string `form:"cwe_id"`
+}
+
+// canEditKnowledge 判断用户是否可以编辑知识库、查看草稿
+func canEditKnowledge(user *models.User) bool {
+	return user.Role.Code == "super_admin" || user.HasPermission("knowledge:edit")
+}
+
+// validateKnowledgeRequest 校验并规范化知识库文章请求
+func validateKnowledgeRequest(db *gorm.DB, req *KnowledgeArticleRequest) error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || strings.TrimSpace(req.Remediation) == "" {
+		return errors.New("标题和修复建议不能为空")
+	}
+	if len([]rune(req.Title)) > 255 {
+		return errors.New("标题不能超过255个字符")
+	}
+	switch req.Status {
+	case "":
+		req.Status = models.KnowledgeStatusPublished
+	case models.KnowledgeStatusDraft, models.KnowledgeStatusPublished:
+	default:
+		return errors.New("文章状态只能为draft或published")
+	}
+	cweID, err := normalizeCWEID(req.CWEID)
+	if err != nil {
+		return err
+	}
+	req.CWEID = cweID
+	if req.CategoryID != nil && *req.CategoryID == 0 {
+		req.CategoryID = nil
+	}
+	if req.CategoryID != nil {
+		var count int
+		db.Model(&models.VulnCategory{}).Where("id = ?", *req.CategoryID).Count(&count)
+		if count == 0 {
+			return errors.New("漏洞分类不存在")
+		}
+	}
+	if req.CategoryID == nil && req.CWEID == "" && strings.TrimSpace(req.VulnTypes) == "" {
+		return errors.New("请至少填写适用的漏洞分类、CWE编号或漏洞类型")
+	}
+	return nil
+}
+
+// newKnowledgeVersion 根据文章当前内容生成历史版本
+func newKnowledgeVersion(article *models.KnowledgeArticle, editorID uint, changeNote string) *models.KnowledgeArticleVersion {
+	return &models.KnowledgeArticleVersion{
+		ArticleID:   article.ID,
+		Version:     article.Version,
+		Title:       article.Title,
+		CategoryID:  article.CategoryID,
+		CWEID:       article.CWEID,
+		VulnTypes:   article.VulnTypes,
+		Description: article.Description,
+		Impact:      article.Impact,
+		Remediation: article.Remediation,
+		FixExamples: article.FixExamples,
+		References:  article.References,
+		Tags:        article.Tags,
+		Status:      article.Status,
+		ChangeNote:  truncateRunes(changeNote, 255),
+		EditorID:    editorID,
+	}
+}
+
+// GetKnowledgeList 获取知识库文章列表，填写关键词时按全文索引的相关度排序
+func (s *KnowledgeService) GetKnowledgeList(req *KnowledgeListRequest, userID uint) (*KnowledgeListResponse, error) {
+	db := Init.GetDB()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	user, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&models.KnowledgeArticle{})
+	if !canEditKnowledge(user) {
+		query = query.Where("status = ?", models.KnowledgeStatusPublished)
+	} else if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.CategoryID != nil {
+		query = query.Where("category_id IN (?)", vulnCategoryDescendantIDs(db, *req.CategoryID))
+	}
+	if req.CWEID != "" {
+		query = query.Where("cwe_id = ?", strings.ToUpper(strings.TrimSpace(req.CWEID)))
+	}
+
+	var total int64
+	var order interface{} = "updated_at DESC"
+	keyword := strings.TrimSpace(req.Keyword)
+	if keyword != "" {
+		// 全文索引使用ngram分词，单字关键词或数据库不支持全文索引时使用模糊匹配
+		match := "MATCH(" + models.KnowledgeFullTextColumns + ") AGAINST (? IN NATURAL LANGUAGE MODE)"
+		fullText := query.Where(match, keyword)
+		if len([]rune(keyword)) >= 2 && fullText.Count(&total).Error == nil {
+			query = fullText
+			order = gorm.Expr(match+" DESC", keyword)
+		} else {
+			like := "%" + keyword + "%"
+			query = query.Where("title LIKE ? OR description LIKE ? OR remediation LIKE ? OR fix_examples LIKE ? OR tags LIKE ? OR vuln_types LIKE ?",
+				like, like, like, like, like, like)
+			query.Count(&total)
+		}
+	} else {
+		query.Count(&total)
+	}
+
+	var articles []models.KnowledgeArticle
+	if err := query.Preload("Category").Preload("Editor").Order(order).
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&articles).Error; err != nil {
+		return nil, errors.New("获取知识库文章失败")
+	}
+	articlePtrs := make([]*models.KnowledgeArticle, len(articles))
+	for i := range articles {
+		articlePtrs[i] = &articles[i]
+	}
+	fileService := &FileService{}
+	fileService.SignKnowledgeContent(userID, user.Role.Code, articlePtrs...)
+
+	return &KnowledgeListResponse{
+		Articles: articles,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// getKnowledgeArticle 获取文章，草稿只有知识库编辑者可以查看
+func getKnowledgeArticle(db *gorm.DB, id, userID uint) (*models.KnowledgeArticle, error) {
+	var article models.KnowledgeArticle
+	if err := db.Preload("Category").Preload("Author").Preload("Editor").Where("id = ?", id).First(&article).Error; err != nil {
+		return nil, errors.New("知识库文章不存在")
+	}
+	if article.Status != models.KnowledgeStatusPublished {
+		user, err := loadVulnActor(db, userID)
+		if err != nil || !canEditKnowledge(user) {
+			return nil, errors.New("知识库文章不存在")
+		}
+	}
+	return &article, nil
+}
+
+// canViewKnowledgeArticle 判断用户是否可以查看文章，用于校验文章图片的访问权限
+// 已发布的文章需要knowledge:view权限，草稿只有知识库编辑者可以查看
+func canViewKnowledgeArticle(db *gorm.DB, article *models.KnowledgeArticle, userID uint) bool {
+	user, err := loadVulnActor(db, userID)
+	if err != nil {
+		return false
+	}
+	if canEditKnowledge(user) {
+		return true
+	}
+	return article.Status == models.KnowledgeStatusPublished && user.HasPermission("knowledge:view")
+}
+
+// knowledgeUserRole 获取用户的角色代码，用于文章图片的签名和关联
+func knowledgeUserRole(db *gorm.DB, userID uint) string {
+	var user models.User
+	if err := db.Preload("Role").Where("id = ?", userID).First(&user).Error; err != nil {
+		return ""
+	}
+	return user.Role.Code
+}
+
+// getSignedKnowledgeArticle 获取文章并为内容中的图片生成当前用户可用的签名链接
+// 图片保存为不带签名的 /api/files/:id 地址，img标签不会携带JWT，返回前需要签名
+func getSignedKnowledgeArticle(db *gorm.DB, id, userID uint) (*models.KnowledgeArticle, error) {
+	article, err := getKnowledgeArticle(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	fileService := &FileService{}
+	fileService.SignKnowledgeContent(userID, knowledgeUserRole(db, userID), article)
+	return article, nil
+}
+
+// bindKnowledgeImages 将文章内容中引用的图片关联到文章，内容被替换为复制后的图片时返回true
+func bindKnowledgeImages(db *gorm.DB, article *models.KnowledgeArticle, userID uint) bool {
+	fileService := &FileService{}
+	return fileService.BindContentImages("knowledge", article.ID, userID, knowledgeUserRole(db, userID),
+		&article.Description, &article.Impact, &article.Remediation)
+}
+
+// GetKnowledge 获取知识库文章详情
+func (s *KnowledgeService) GetKnowledge(id, userID uint) (*models.KnowledgeArticle, error) {
+	return getSignedKnowledgeArticle(Init.GetDB(), id, userID)
+}
+
+// CreateKnowledge 创建知识库文章，同时保存第1个版本
+func (s *KnowledgeService) CreateKnowledge(req *KnowledgeArticleRequest, userID uint) (*models.KnowledgeArticle, error) {
+	db := Init.GetDB()
+
+	if err := validateKnowledgeRequest(db, req); err != nil {
+		return nil, err
+	}
+
+	article := models.KnowledgeArticle{
+		Title:       req.Title,
+		CategoryID:  req.CategoryID,
+		CWEID:       req.CWEID,
+		VulnTypes:   strings.TrimSpace(req.VulnTypes),
+		Description: NormalizeFileURLs(req.Description),
+		Impact:      NormalizeFileURLs(req.Impact),
+		Remediation: NormalizeFileURLs(req.Remediation),
+		FixExamples: req.FixExamples,
+		References:  req.References,
+		Tags:        req.Tags,
+		Status:      req.Status,
+		Version:     1,
+		AuthorID:    userID,
+		UpdatedBy:   userID,
+	}
+
+	changeNote := req.ChangeNote
+	if changeNote == "" {
+		changeNote = "创建文章"
+	}
+	tx := db.Begin()
+	if err := tx.Create(&article).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("创建知识库文章失败")
+	}
+	// 关联文章中的图片，图片访问权限跟随文章
+	if bindKnowledgeImages(db, &article, userID) {
+		if err := tx.Model(&models.KnowledgeArticle{}).Where("id = ?", article.ID).UpdateColumns(map[string]interface{}{
+			"description": article.Description,
+			"impact":      article.Impact,
+			"remediation": article.Remediation,
+		}).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.New("创建知识库文章失败")
+		}
+	}
+	if err := tx.Create(newKnowledgeVersion(&article, userID, changeNote)).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("保存文章版本失败")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("创建知识库文章失败")
+	}
+
+	return getSignedKnowledgeArticle(db, article.ID, userID)
+}
+
+// saveKnowledgeVersion 用请求内容更新文章并递增版本号，同时保存新版本
+func saveKnowledgeVersion(db *gorm.DB, article *models.KnowledgeArticle, req *KnowledgeArticleRequest, userID uint, changeNote string) error {
+	article.Title = req.Title
+	article.CategoryID = req.CategoryID
+	article.CWEID = req.CWEID
+	article.VulnTypes = strings.TrimSpace(req.VulnTypes)
+	article.Description = NormalizeFileURLs(req.Description)
+	article.Impact = NormalizeFileURLs(req.Impact)
+	article.Remediation = NormalizeFileURLs(req.Remediation)
+	article.FixExamples = req.FixExamples
+	article.References = req.References
+	article.Tags = req.Tags
+	article.Status = req.Status
+	article.Version++
+	article.UpdatedBy = userID
+	bindKnowledgeImages(db, article, userID)
+
+	tx := db.Begin()
+	if err := tx.Model(&models.KnowledgeArticle{}).Where("id = ?", article.ID).Updates(map[string]interface{}{
+		"title":        article.Title,
+		"category_id":  article.CategoryID,
+		"cwe_id":       article.CWEID,
+		"vuln_types":   article.VulnTypes,
+		"description":  article.Description,
+		"impact":       article.Impact,
+		"remediation":  article.Remediation,
+		"fix_examples": article.FixExamples,
+		"references":   article.References,
+		"tags":         article.Tags,
+		"status":       article.Status,
+		"version":      article.Version,
+		"updated_by":   article.UpdatedBy,
+	}).Error; err != nil {
+		tx.Rollback()
+		return errors.New("更新知识库文章失败")
+	}
+	if err := tx.Create(newKnowledgeVersion(article, userID, changeNote)).Error; err != nil {
+		tx.Rollback()
+		return errors.New("保存文章版本失败")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("更新知识库文章失败")
+	}
+	return nil
+}
+
+// UpdateKnowledge 修改知识库文章，每次修改生成新版本
+func (s *KnowledgeService) UpdateKnowledge(id uint, req *KnowledgeArticleRequest, userID uint) (*models.KnowledgeArticle, error) {
+	db := Init.GetDB()
+
+	var article models.KnowledgeArticle
+	if err := db.Where("id = ?", id).First(&article).Error; err != nil {
+		return nil, errors.New("知识库文章不存在")
+	}
+	if err := validateKnowledgeRequest(db, req); err != nil {
+		return nil, err
+	}
+
+	changeNote := req.ChangeNote
+	if changeNote == "" {
+		changeNote = "修改文章"
+	}
+	if err := saveKnowledgeVersion(db, &article, req, userID, changeNote); err != nil {
+		return nil, err
+	}
+	return getSignedKnowledgeArticle(db, id, userID)
+}
+
+// DeleteKnowledge 删除知识库文章，历史版本保留
+func (s *KnowledgeService) DeleteKnowledge(id uint) error {
+	db := Init.GetDB()
+
+	var article models.KnowledgeArticle
+	if err := db.Where("id = ?", id).First(&article).Error; err != nil {
+		return errors.New("知识库文章不存在")
+	}
+	if err := db.Delete(&article).Error; err != nil {
+		return errors.New("删除知识库文章失败")
+	}
+	return nil
+}
+
+// GetKnowledgeVersions 获取文章的历史版本列表，按版本号倒序，不含正文
+func (s *KnowledgeService) GetKnowledgeVersions(id, userID uint) ([]models.KnowledgeArticleVersion, error) {
+	db := Init.GetDB()
+
+	if _, err := getKnowledgeArticle(db, id, userID); err != nil {
+		return nil, err
+	}
+	var versions []models.KnowledgeArticleVersion
+	if err := db.Select("id, article_id, version, title, status, change_note, editor_id, created_at").Preload("Editor").
+		Where("article_id = ?", id).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, errors.New("获取文章版本失败")
+	}
+	return versions, nil
+}
+
+// GetKnowledgeVersion 获取文章指定版本的完整内容
+func (s *KnowledgeService) GetKnowledgeVersion(id uint, version int, userID uint) (*models.KnowledgeArticleVersion, error) {
+	db := Init.GetDB()
+
+	if _, err := getKnowledgeArticle(db, id, userID); err != nil {
+		return nil, err
+	}
+	var articleVersion models.KnowledgeArticleVersion
+	if err := db.Preload("Editor").Where("article_id = ? AND version = ?", id, version).First(&articleVersion).Error; err != nil {
+		return nil, errors.New("文章版本不存在")
+	}
+	fileService := &FileService{}
+	fileService.SignKnowledgeVersionContent(userID, knowledgeUserRole(db, userID), &articleVersion)
+	return &articleVersion, nil
+}
+
+// RestoreKnowledgeVersion 将文章恢复为指定版本的内容，恢复结果作为新版本保存
+func (s *KnowledgeService) RestoreKnowledgeVersion(id uint, version int, userID uint) (*models.KnowledgeArticle, error) {
+	db := Init.GetDB()
+
+	var article models.KnowledgeArticle
+	if err := db.Where("id = ?", id).First(&article).Error; err != nil {
+		return nil, errors.New("知识库文章不存在")
+	}
+	var articleVersion models.KnowledgeArticleVersion
+	if err := db.Where("article_id = ? AND version = ?", id, version).First(&articleVersion).Error; err != nil {
+		return nil, errors.New("文章版本不存在")
+	}
+	if version == article.Version {
+		return nil, errors.New("该版本已是当前版本")
+	}
+
+	// 版本中的分类可能已被删除，恢复时不再关联
+	categoryID := articleVersion.CategoryID
+	if categoryID != nil {
+		var count int
+		db.Model(&models.VulnCategory{}).Where("id = ?", *categoryID).Count(&count)
+		if count == 0 {
+			categoryID = nil
+		}
+	}
+	req := &KnowledgeArticleRequest{
+		Title:       articleVersion.Title,
+		CategoryID:  categoryID,
+		CWEID:       articleVersion.CWEID,
+		VulnTypes:   articleVersion.VulnTypes,
+		Description: articleVersion.Description,
+		Impact:      articleVersion.Impact,
+		Remediation: articleVersion.Remediation,
+		FixExamples: articleVersion.FixExamples,
+		References:  articleVersion.References,
+		Tags:        articleVersion.Tags,
+		Status:      articleVersion.Status,
+	}
+	if err := saveKnowledgeVersion(db, &article, req, userID, fmt.Sprintf("恢复到版本%d", version)); err != nil {
+		return nil, err
+	}
+	return getSignedKnowledgeArticle(db, id, userID)
+}
+
+// vulnCategoryChain 获取分类及其各级上级分类，从自身开始
+func vulnCategoryChain(db *gorm.DB, categoryID uint) []models.VulnCategory {
+	chain := []models.VulnCategory{}
+	id := categoryID
+	for depth := 0; depth < vulnCategoryMaxDepth; depth++ {
+		var category models.VulnCategory
+		if err := db.Where("id = ?", id).First(&category).Error; err != nil {
+			break
+		}
+		chain = append(chain, category)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return chain
+}
+
+// matchKnowledgeArticle 按漏洞信息匹配已发布的知识库文章，未指定分类时按漏洞类型和CWE编号自动归类。
+// 匹配顺序：文章适用的漏洞类型与漏洞类型一致、文章关联漏洞所属分类或该分类的CWE、
+// 文章的CWE编号与漏洞的CWE编号一致、文章关联漏洞所属分类的上级分类；同等条件下取最近修改的文章
+func matchKnowledgeArticle(db *gorm.DB, vulnType string, categoryID *uint, cweIDs string) *models.KnowledgeArticle {
+	var articles []models.KnowledgeArticle
+	db.Select("id, title, category_id, cwe_id, vuln_types, remediation, version").
+		Where("status = ?", models.KnowledgeStatusPublished).Order("updated_at DESC").Find(&articles)
+	if len(articles) == 0 {
+		return nil
+	}
+
+	if key := normalizeCategoryKey(vulnType); key != "" {
+		for i := range articles {
+			for _, item := range splitWorkflowList(strings.ReplaceAll(articles[i].VulnTypes, "，", ",")) {
+				if normalizeCategoryKey(item) == key {
+					return &articles[i]
+				}
+			}
+		}
+	}
+
+	if categoryID == nil {
+		categoryID = matchVulnCategory(db, vulnType, cweIDs)
+	}
+	var chain []models.VulnCategory
+	if categoryID != nil {
+		chain = vulnCategoryChain(db, *categoryID)
+	}
+	matchCategory := func(category *models.VulnCategory) *models.KnowledgeArticle {
+		for i := range articles {
+			if articles[i].CategoryID != nil && *articles[i].CategoryID == category.ID {
+				return &articles[i]
+			}
+		}
+		if category.CWEID != "" {
+			for i := range articles {
+				if articles[i].CWEID == category.CWEID {
+					return &articles[i]
+				}
+			}
+		}
+		return nil
+	}
+
+	if len(chain) > 0 {
+		if article := matchCategory(&chain[0]); article != nil {
+			return article
+		}
+	}
+	for _, cweID := range splitWorkflowList(strings.ToUpper(cweIDs)) {
+		for i := range articles {
+			if articles[i].CWEID == cweID {
+				return &articles[i]
+			}
+		}
+	}
+	for i := 1; i < len(chain); i++ {
+		if article := matchCategory(&chain[i]); article != nil {
+			return article
+		}
+	}
+	return nil
+}
+
+// MatchKnowledge 按漏洞类型、分类和CWE编号匹配知识库文章，用于提交漏洞时预填修复建议
+func (s *KnowledgeService) MatchKnowledge(req *KnowledgeMatchRequest, userID uint) (*models.KnowledgeArticle, error) {
+	db := Init.GetDB()
+
+	categoryID := req.CategoryID
+	if categoryID != nil && *categoryID == 0 {
+		categoryID = nil
+	}
+	article := matchKnowledgeArticle(db, req.VulnType, categoryID, req.CWEID)
+	if article == nil {
+		return nil, errors.New("没有匹配的知识库文章")
+	}
+	return getSignedKnowledgeArticle(db, article.ID, userID)
+}
+
+// vulnKnowledgeRef 获取漏洞匹配的知识库文章，用于在漏洞详情中链接到文章
+func vulnKnowledgeRef(db *gorm.DB, vuln *models.Vulnerability) *models.KnowledgeArticleRef {
+	article := matchKnowledgeArticle(db, vuln.VulnType, vuln.CategoryID, vuln.CWEID)
+	if article == nil {
+		return nil
+	}
+	return &models.KnowledgeArticleRef{ID: article.ID, Title: article.Title, Version: article.Version}
+}
@@ -0,0 +1,584 @@
+// 站内通知服务包
+// 该包负责将业务事件分发为站内通知，所有发送邮件的业务节点同时写入Notification记录
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+)
+
+// NotificationService 站内通知服务
+type NotificationService struct{}
+
+// 通知事件类型
+const (
+	EventVulnAssigned       = "vuln_assigned"        // 漏洞分派
+	EventVulnStatusChanged  = "vuln_status_changed"  // 漏洞状态变更
+	EventVulnComment        = "vuln_comment"         // 漏洞新增评论
+	EventVulnDeadline       = "vuln_deadline"        // 漏洞即将到期
+	EventVulnEscalation     = "vuln_escalation"      // 漏洞超期升级
+	EventVulnBulkUpdated    = "vuln_bulk_updated"    // 漏洞批量操作
+	EventRiskAcceptance     = "risk_acceptance"      // 风险接受申请、审批和到期
+	EventProjectCreated     = "project_created"      // 项目创建
+	EventProjectMemberAdded = "project_member_added" // 项目新增成员
+	EventUserRegistered     = "user_registered"      // 账号创建
+	EventPasswordReset      = "password_reset"       // 密码重置
+)
+
+// NotificationData 通知附加数据，以JSON格式保存在Notification.Data中
+// 前端根据Link或VulnID、ProjectID跳转到对应的漏洞或项目
+type NotificationData struct {
+	Event       string `json:"event"`                  // 事件类型
+	VulnID      uint   `json:"vuln_id,omitempty"`      // 关联漏洞ID
+	VulnTitle   string `json:"vuln_title,omitempty"`   // 漏洞标题
+	ProjectID   uint   `json:"project_id,omitempty"`   // 关联项目ID
+	ProjectName string `json:"project_name,omitempty"` // 项目名称
+	Severity    string `json:"severity,omitempty"`     // 漏洞严重程度
+	OldStatus   string `json:"old_status,omitempty"`   // 变更前状态
+	NewStatus   string `json:"new_status,omitempty"`   // 变更后状态
+	DaysLeft    int    `json:"days_left,omitempty"`    // 距离截止时间天数
+	Deadline    string `json:"deadline,omitempty"`     // 修复截止时间
+	Link        string `json:"link,omitempty"`         // 前端跳转地址
+}
+
+// 邮件投递结果
+const (
+	EmailSent    = "sent"    // 已即时发送
+	EmailFailed  = "failed"  // 即时发送失败
+	EmailDigest  = "digest"  // 已加入汇总，由定时任务合并发送
+	EmailSkipped = "skipped" // 用户关闭了邮件渠道或没有邮箱
+)
+
+// NotificationEvent 待分发的通知事件
+type NotificationEvent struct {
+	Type    string           // 通知类型：vuln、project、system，对应Notification.Type
+	Title   string           // 通知标题
+	Content string           // 通知内容
+	UserIDs []uint           // 接收者用户ID
+	Data    NotificationData // 附加数据
+
+	// Email 生成发给指定接收者的即时邮件，为nil时该事件不发送邮件
+	Email func(recipient *models.User) EmailTemplate
+	// OnEmail 邮件投递结果回调，可为nil。即时发送的结果在发送协程中回调
+	OnEmail func(recipient *models.User, status string, err error)
+	// Chat 生成发往项目群机器人的消息，每个事件只发送一次，为nil时该事件不推送到群
+	Chat func() *ChatMessage
+}
+
+// vulnLink 生成漏洞详情的前端跳转地址
+func vulnLink(projectID, vulnID uint) string {
+	if projectID != 0 {
+		return fmt.Sprintf("/projects/detail?id=%d&vuln_id=%d", projectID, vulnID)
+	}
+	return fmt.Sprintf("/projects?vuln_id=%d", vulnID)
+}
+
+// projectLink 生成项目详情的前端跳转地址
+func projectLink(projectID uint) string {
+	return fmt.Sprintf("/projects/detail?id=%d", projectID)
+}
+
+// Dispatch 分发通知事件，按接收者的通知偏好选择站内通知、邮件和个人Webhook渠道
+// 接收者会去重，用户ID为0的接收者会被忽略，投递失败只记录日志不影响业务流程
+func (s *NotificationService) Dispatch(event *NotificationEvent) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		fmt.Printf("序列化通知数据失败: %v\n", err)
+		return
+	}
+
+	if event.Chat != nil && event.Data.ProjectID != 0 {
+		chatBotService := &ChatBotService{}
+		chatBotService.Notify(event.Data.ProjectID, event.Chat())
+	}
+
+	db := Init.GetDB()
+	systemService := &SystemService{}
+	seen := make(map[uint]bool)
+	for _, userID := range event.UserIDs {
+		if userID == 0 || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		channels := s.GetChannels(userID, event.Data.Event)
+		if channels.InApp {
+			if err := systemService.CreateNotification(userID, event.Type, event.Title, event.Content, string(data)); err != nil {
+				fmt.Printf("创建站内通知失败 (用户ID: %d): %v\n", userID, err)
+			}
+		}
+
+		if event.Email == nil && !channels.Webhook {
+			continue
+		}
+
+		var user models.User
+		if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+			continue
+		}
+		setting := s.getSetting(userID)
+
+		if event.Email != nil {
+			s.deliverEmail(event, &user, channels.Email, setting, string(data))
+		}
+		if channels.Webhook && setting.WebhookURL != "" {
+			s.deliverWebhook(event, &user, setting, string(data))
+		}
+	}
+}
+
+// deliverEmail 按用户的投递方式即时发送邮件或加入汇总
+func (s *NotificationService) deliverEmail(event *NotificationEvent, user *models.User, enabled bool, setting *models.NotificationSetting, data string) {
+	if !enabled || user.Email == "" {
+		s.emailResult(event, user, EmailSkipped, nil)
+		return
+	}
+
+	if setting.DigestMode != DigestImmediate {
+		if err := s.enqueueDigest(user.ID, DigestChannelEmail, event, data); err != nil {
+			fmt.Printf("加入通知汇总失败 (用户ID: %d): %v\n", user.ID, err)
+			s.emailResult(event, user, EmailFailed, err)
+			return
+		}
+		s.emailResult(event, user, EmailDigest, nil)
+		return
+	}
+
+	template := event.Email(user)
+	go func() {
+		if err := SendEmail([]string{user.Email}, template.Subject, template.Body); err != nil {
+			// 记录邮件发送失败的日志，但不影响业务流程
+			fmt.Printf("发送通知邮件失败 (用户ID: %d, 事件: %s): %v\n", user.ID, event.Data.Event, err)
+			s.emailResult(event, user, EmailFailed, err)
+			return
+		}
+		s.emailResult(event, user, EmailSent, nil)
+	}()
+}
+
+// emailResult 回调邮件投递结果
+func (s *NotificationService) emailResult(event *NotificationEvent, user *models.User, status string, err error) {
+	if event.OnEmail != nil {
+		event.OnEmail(user, status, err)
+	}
+}
+
+// deliverWebhook 按用户的投递方式即时推送个人Webhook或加入汇总
+func (s *NotificationService) deliverWebhook(event *NotificationEvent, user *models.User, setting *models.NotificationSetting, data string) {
+	if setting.DigestMode != DigestImmediate {
+		if err := s.enqueueDigest(user.ID, DigestChannelWebhook, event, data); err != nil {
+			fmt.Printf("加入通知汇总失败 (用户ID: %d): %v\n", user.ID, err)
+		}
+		return
+	}
+
+	payload := UserWebhookPayload{
+		Event:     event.Data.Event,
+		Title:     event.Title,
+		Content:   event.Content,
+		Data:      json.RawMessage(data),
+		CreatedAt: time.Now(),
+	}
+	go func() {
+		if err := postUserWebhook(setting, payload); err != nil {
+			fmt.Printf("推送个人Webhook失败 (用户ID: %d, 事件: %s): %v\n", user.ID, event.Data.Event, err)
+		}
+	}()
+}
+
+// displayName 获取用户显示名称，优先使用真实姓名
+func displayName(user *models.User) string {
+	if user.RealName != "" {
+		return user.RealName
+	}
+	return user.Username
+}
+
+// userDisplayName 根据用户ID获取显示名称，用户不存在时返回空字符串
+func userDisplayName(userID uint) string {
+	db := Init.GetDB()
+
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return ""
+	}
+	return displayName(&user)
+}
+
+// vulnChatFields 生成群机器人消息中的漏洞信息
+func vulnChatFields(vuln *models.Vulnerability, projectName string) []ChatField {
+	return []ChatField{
+		{Label: "所属项目", Value: projectName},
+		{Label: "严重程度", Value: severityLabel(vuln.Severity)},
+		{Label: "当前状态", Value: vulnStatusLabel(vuln.Status)},
+	}
+}
+
+// NotifyVulnAssigned 通知研发工程师有新漏洞分派
+func (s *NotificationService) NotifyVulnAssigned(vuln *models.Vulnerability, assigneeID uint, projectName string) {
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("新漏洞分派：%s", vuln.Title),
+		Content: fmt.Sprintf("项目「%s」中的漏洞「%s」已分派给您处理，请及时修复。", projectName, vuln.Title),
+		UserIDs: []uint{assigneeID},
+		Data: NotificationData{
+			Event:       EventVulnAssigned,
+			VulnID:      vuln.ID,
+			VulnTitle:   vuln.Title,
+			ProjectID:   vuln.ProjectID,
+			ProjectName: projectName,
+			Severity:    vuln.Severity,
+			Link:        vulnLink(vuln.ProjectID, vuln.ID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetVulnAssignedTemplate(vuln.Title, projectName, displayName(recipient), vuln.Severity)
+		},
+		Chat: func() *ChatMessage {
+			return &ChatMessage{
+				Title:   fmt.Sprintf("漏洞分派：%s", vuln.Title),
+				Content: fmt.Sprintf("项目「%s」中的漏洞「%s」已分派给 %s 处理。", projectName, vuln.Title, userDisplayName(assigneeID)),
+				Fields:  vulnChatFields(vuln, projectName),
+				Link:    vulnLink(vuln.ProjectID, vuln.ID),
+			}
+		},
+	})
+}
+
+// NotifyVulnStatusChanged 通知下一处理人漏洞状态已变更
+func (s *NotificationService) NotifyVulnStatusChanged(vuln *models.Vulnerability, oldStatus, newStatus string, nextUserID uint, projectName string) {
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("漏洞状态更新：%s", vuln.Title),
+		Content: fmt.Sprintf("漏洞「%s」状态由「%s」变更为「%s」，请及时处理。", vuln.Title, vulnStatusLabel(oldStatus), vulnStatusLabel(newStatus)),
+		UserIDs: []uint{nextUserID},
+		Data: NotificationData{
+			Event:       EventVulnStatusChanged,
+			VulnID:      vuln.ID,
+			VulnTitle:   vuln.Title,
+			ProjectID:   vuln.ProjectID,
+			ProjectName: projectName,
+			Severity:    vuln.Severity,
+			OldStatus:   oldStatus,
+			NewStatus:   newStatus,
+			Link:        vulnLink(vuln.ProjectID, vuln.ID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetVulnStatusChangedTemplate(vuln.Title, projectName, oldStatus, newStatus, displayName(recipient))
+		},
+		Chat: func() *ChatMessage {
+			return &ChatMessage{
+				Title:   fmt.Sprintf("漏洞状态更新：%s", vuln.Title),
+				Content: fmt.Sprintf("漏洞「%s」状态由「%s」变更为「%s」，下一处理人：%s。", vuln.Title, vulnStatusLabel(oldStatus), vulnStatusLabel(newStatus), userDisplayName(nextUserID)),
+				Fields:  vulnChatFields(vuln, projectName),
+				Link:    vulnLink(vuln.ProjectID, vuln.ID),
+			}
+		},
+	})
+}
+
+// NotifyVulnComment 通知漏洞参与人有新评论，评论人自己不会收到通知
+// 参与人包括漏洞提交人、指派人以及此前发表过评论的用户
+func (s *NotificationService) NotifyVulnComment(vuln *models.Vulnerability, comment *models.VulnComment, projectName string) {
+	db := Init.GetDB()
+
+	userIDs := []uint{vuln.ReporterID}
+	if vuln.AssigneeID != nil {
+		userIDs = append(userIDs, *vuln.AssigneeID)
+	}
+	var commenterIDs []uint
+	db.Model(&models.VulnComment{}).Where("vuln_id = ? AND user_id <> ?", vuln.ID, comment.UserID).Pluck("DISTINCT user_id", &commenterIDs)
+	userIDs = append(userIDs, commenterIDs...)
+
+	recipients := make([]uint, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID != comment.UserID {
+			recipients = append(recipients, userID)
+		}
+	}
+
+	commenterName := displayName(&comment.User)
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("漏洞新评论：%s", vuln.Title),
+		Content: fmt.Sprintf("%s 评论了漏洞「%s」。", commenterName, vuln.Title),
+		UserIDs: recipients,
+		Data: NotificationData{
+			Event:       EventVulnComment,
+			VulnID:      vuln.ID,
+			VulnTitle:   vuln.Title,
+			ProjectID:   vuln.ProjectID,
+			ProjectName: projectName,
+			Severity:    vuln.Severity,
+			Link:        vulnLink(vuln.ProjectID, vuln.ID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetVulnCommentTemplate(vuln.Title, projectName, displayName(recipient), commenterName, comment.Content)
+		},
+		Chat: func() *ChatMessage {
+			return &ChatMessage{
+				Title:   fmt.Sprintf("漏洞新评论：%s", vuln.Title),
+				Content: fmt.Sprintf("%s 评论了漏洞「%s」。", commenterName, vuln.Title),
+				Fields:  vulnChatFields(vuln, projectName),
+				Link:    vulnLink(vuln.ProjectID, vuln.ID),
+			}
+		},
+	})
+}
+
+// NotifyVulnDeadline 提醒研发工程师漏洞即将到期，onEmail用于记录邮件提醒的投递结果
+func (s *NotificationService) NotifyVulnDeadline(vuln *models.Vulnerability, assigneeID uint, projectName string, daysLeft int, deadline string,
+	onEmail func(recipient *models.User, status string, err error)) {
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("漏洞修复即将到期：%s", vuln.Title),
+		Content: fmt.Sprintf("漏洞「%s」将在%d天后（%s）到期，请尽快完成修复。", vuln.Title, daysLeft, deadline),
+		UserIDs: []uint{assigneeID},
+		Data: NotificationData{
+			Event:       EventVulnDeadline,
+			VulnID:      vuln.ID,
+			VulnTitle:   vuln.Title,
+			ProjectID:   vuln.ProjectID,
+			ProjectName: projectName,
+			Severity:    vuln.Severity,
+			NewStatus:   vuln.Status,
+			DaysLeft:    daysLeft,
+			Deadline:    deadline,
+			Link:        vulnLink(vuln.ProjectID, vuln.ID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetVulnDeadlineReminderTemplate(vuln.Title, projectName, displayName(recipient), vuln.Severity, vuln.Status, daysLeft, deadline)
+		},
+		OnEmail: onEmail,
+		Chat: func() *ChatMessage {
+			return &ChatMessage{
+				Title:   fmt.Sprintf("漏洞修复即将到期：%s", vuln.Title),
+				Content: fmt.Sprintf("漏洞「%s」将在%d天后（%s）到期，处理人：%s。", vuln.Title, daysLeft, deadline, userDisplayName(assigneeID)),
+				Fields:  vulnChatFields(vuln, projectName),
+				Link:    vulnLink(vuln.ProjectID, vuln.ID),
+			}
+		},
+	})
+}
+
+// NotifyVulnEscalation 漏洞超期后按升级级别通知指派人、项目负责人、部门负责人或超级管理员
+func (s *NotificationService) NotifyVulnEscalation(vuln *models.Vulnerability, userIDs []uint, level, daysOverdue int, deadline string,
+	onEmail func(recipient *models.User, status string, err error)) {
+	assignee := "未分配"
+	if vuln.AssigneeID != nil {
+		if name := userDisplayName(*vuln.AssigneeID); name != "" {
+			assignee = name
+		}
+	}
+	content := fmt.Sprintf("漏洞「%s」已超过修复截止时间（%s）%d天，当前处理人：%s，已升级至第%d级，请督促尽快修复。",
+		vuln.Title, deadline, daysOverdue, assignee, level)
+	if daysOverdue == 0 {
+		content = fmt.Sprintf("漏洞「%s」已超过修复截止时间（%s），当前处理人：%s，请尽快完成修复。", vuln.Title, deadline, assignee)
+	}
+
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("漏洞修复已超期：%s", vuln.Title),
+		Content: content,
+		UserIDs: userIDs,
+		Data: NotificationData{
+			Event:       EventVulnEscalation,
+			VulnID:      vuln.ID,
+			VulnTitle:   vuln.Title,
+			ProjectID:   vuln.ProjectID,
+			ProjectName: vuln.Project.Name,
+			Severity:    vuln.Severity,
+			NewStatus:   vuln.Status,
+			DaysLeft:    -daysOverdue,
+			Deadline:    deadline,
+			Link:        vulnLink(vuln.ProjectID, vuln.ID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetVulnEscalationTemplate(vuln.Title, vuln.Project.Name, displayName(recipient), vuln.Severity, content, level, deadline)
+		},
+		OnEmail: onEmail,
+		Chat: func() *ChatMessage {
+			return &ChatMessage{
+				Title:   fmt.Sprintf("漏洞修复已超期（第%d级升级）：%s", level, vuln.Title),
+				Content: content,
+				Fields:  vulnChatFields(vuln, vuln.Project.Name),
+				Link:    vulnLink(vuln.ProjectID, vuln.ID),
+			}
+		},
+	})
+}
+
+// NotifyVulnsBulkUpdated 批量操作后每个接收者只收到一条汇总通知，summary描述执行的操作
+func (s *NotificationService) NotifyVulnsBulkUpdated(userID uint, summary string, vulns []models.Vulnerability) {
+	titles := make([]string, 0, len(vulns))
+	for _, vuln := range vulns {
+		titles = append(titles, fmt.Sprintf("#%d %s", vuln.ID, vuln.Title))
+	}
+	listed := titles
+	if len(listed) > 10 {
+		listed = listed[:10]
+	}
+	content := fmt.Sprintf("%s，涉及您的%d个漏洞：%s", summary, len(vulns), strings.Join(listed, "；"))
+	if len(titles) > len(listed) {
+		content += fmt.Sprintf("等%d个", len(titles))
+	}
+
+	data := NotificationData{Event: EventVulnBulkUpdated}
+	if len(vulns) == 1 {
+		data.VulnID = vulns[0].ID
+		data.VulnTitle = vulns[0].Title
+		data.ProjectID = vulns[0].ProjectID
+		data.Link = vulnLink(vulns[0].ProjectID, vulns[0].ID)
+	}
+
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("漏洞批量更新：%s", summary),
+		Content: content,
+		UserIDs: []uint{userID},
+		Data:    data,
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetVulnBulkUpdatedTemplate(displayName(recipient), summary, titles)
+		},
+	})
+}
+
+// NotifyRiskAcceptance 通知风险接受申请的审批人、申请人、漏洞指派人或项目负责人
+func (s *NotificationService) NotifyRiskAcceptance(vuln *models.Vulnerability, ra *models.RiskAcceptance, userIDs []uint, title, content string) {
+	s.Dispatch(&NotificationEvent{
+		Type:    "vuln",
+		Title:   fmt.Sprintf("%s：%s", title, vuln.Title),
+		Content: content,
+		UserIDs: userIDs,
+		Data: NotificationData{
+			Event:       EventRiskAcceptance,
+			VulnID:      vuln.ID,
+			VulnTitle:   vuln.Title,
+			ProjectID:   vuln.ProjectID,
+			ProjectName: vuln.Project.Name,
+			Severity:    vuln.Severity,
+			NewStatus:   vuln.Status,
+			Deadline:    ra.ExpiresAt.Format("2006-01-02"),
+			Link:        vulnLink(vuln.ProjectID, vuln.ID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			return GetRiskAcceptanceTemplate(title, vuln.Title, vuln.Project.Name, displayName(recipient), content, ra.Justification, ra.CompensatingControls)
+		},
+		Chat: func() *ChatMessage {
+			return &ChatMessage{
+				Title:   fmt.Sprintf("%s：%s", title, vuln.Title),
+				Content: content,
+				Fields:  vulnChatFields(vuln, vuln.Project.Name),
+				Link:    vulnLink(vuln.ProjectID, vuln.ID),
+			}
+		},
+	})
+}
+
+// NotifyProjectMembers 通知成员已加入项目，event为EventProjectCreated或EventProjectMemberAdded
+func (s *NotificationService) NotifyProjectMembers(event string, projectID uint, projectName, ownerName string, memberIDs []uint) {
+	title := fmt.Sprintf("您已被添加到项目：%s", projectName)
+	content := fmt.Sprintf("%s 已将您添加为项目「%s」的成员。", ownerName, projectName)
+	if event == EventProjectCreated {
+		title = fmt.Sprintf("新项目创建：%s", projectName)
+		content = fmt.Sprintf("%s 创建了项目「%s」，您是该项目的成员。", ownerName, projectName)
+	}
+
+	// 邮件中列出本次加入的成员
+	db := Init.GetDB()
+	var members []models.User
+	db.Where("id IN (?)", memberIDs).Find(&members)
+	memberNames := make([]string, 0, len(members))
+	for i := range members {
+		memberNames = append(memberNames, displayName(&members[i]))
+	}
+
+	s.Dispatch(&NotificationEvent{
+		Type:    "project",
+		Title:   title,
+		Content: content,
+		UserIDs: memberIDs,
+		Data: NotificationData{
+			Event:       event,
+			ProjectID:   projectID,
+			ProjectName: projectName,
+			Link:        projectLink(projectID),
+		},
+		Email: func(recipient *models.User) EmailTemplate {
+			if event == EventProjectCreated {
+				return GetProjectCreatedTemplate(projectName, ownerName, memberNames)
+			}
+			return GetProjectMemberAddedTemplate(projectName, ownerName, memberNames)
+		},
+		Chat: func() *ChatMessage {
+			if event == EventProjectCreated {
+				return &ChatMessage{
+					Title:   fmt.Sprintf("新项目创建：%s", projectName),
+					Content: fmt.Sprintf("%s 创建了项目「%s」。", ownerName, projectName),
+					Fields:  []ChatField{{Label: "项目成员", Value: strings.Join(memberNames, "、")}},
+					Link:    projectLink(projectID),
+				}
+			}
+			return &ChatMessage{
+				Title:   fmt.Sprintf("项目新增成员：%s", projectName),
+				Content: fmt.Sprintf("%s 将 %s 添加为项目「%s」的成员。", ownerName, strings.Join(memberNames, "、"), projectName),
+				Link:    projectLink(projectID),
+			}
+		},
+	})
+}
+
+// NotifyAccount 发送账号相关的系统通知，内容中不得包含密码等敏感信息
+func (s *NotificationService) NotifyAccount(event string, userID uint, title, content string) {
+	s.Dispatch(&NotificationEvent{
+		Type:    "system",
+		Title:   title,
+		Content: content,
+		UserIDs: []uint{userID},
+		Data:    NotificationData{Event: event},
+	})
+}
+
+// GetUnreadCount 获取用户未读通知数
+func (s *NotificationService) GetUnreadCount(userID uint) (int64, error) {
+	db := Init.GetDB()
+
+	var count int64
+	if err := db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error; err != nil {
+		return 0, errors.New("查询未读通知数失败")
+	}
+
+	return count, nil
+}
+
+// MarkAllRead 将用户的全部未读通知标记为已读，返回标记的数量
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	db := Init.GetDB()
+
+	result := db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		return 0, errors.New("标记通知失败")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteNotification 删除用户自己的通知
+func (s *NotificationService) DeleteNotification(notificationID uint, userID uint) error {
+	db := Init.GetDB()
+
+	result := db.Where("id = ? AND user_id = ?", notificationID, userID).Delete(&models.Notification{})
+	if result.Error != nil {
+		return errors.New("删除通知失败")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("通知不存在")
+	}
+
+	return nil
+}
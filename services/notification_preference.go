@@ -0,0 +1,414 @@
+// 通知偏好服务包
+// 该包负责用户按事件选择通知渠道、汇总投递方式以及汇总通知的定时发送
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+	"vulnmain/utils"
+)
+
+// 投递方式
+const (
+	DigestImmediate = "immediate" // 即时发送
+	DigestHourly    = "hourly"    // 每小时汇总
+	DigestDaily     = "daily"     // 每日汇总
+)
+
+// 汇总渠道
+const (
+	DigestChannelEmail   = "email"   // 邮件
+	DigestChannelWebhook = "webhook" // 个人Webhook
+)
+
+// NotificationChannels 事件的通知渠道
+type NotificationChannels struct {
+	Email   bool `json:"email"`   // 邮件
+	InApp   bool `json:"in_app"`  // 站内通知
+	Webhook bool `json:"webhook"` // 个人Webhook
+}
+
+// PreferenceEvent 可配置通知偏好的事件
+type PreferenceEvent struct {
+	EventType string               `json:"event_type"` // 事件类型
+	Label     string               `json:"label"`      // 事件名称
+	Default   NotificationChannels `json:"-"`          // 默认渠道
+}
+
+// PreferenceEvents 可配置通知偏好的事件列表
+// 账号创建、密码重置等安全相关通知不可关闭，不在此列表中
+var PreferenceEvents = []PreferenceEvent{
+	{EventType: EventVulnAssigned, Label: "漏洞分派", Default: NotificationChannels{Email: true, InApp: true}},
+	{EventType: EventVulnStatusChanged, Label: "漏洞状态变更", Default: NotificationChannels{Email: true, InApp: true}},
+	{EventType: EventVulnComment, Label: "漏洞评论", Default: NotificationChannels{InApp: true}},
+	{EventType: EventVulnDeadline, Label: "截止时间提醒", Default: NotificationChannels{Email: true, InApp: true}},
+	{EventType: EventVulnEscalation, Label: "超期升级", Default: NotificationChannels{Email: true, InApp: true}},
+	{EventType: EventVulnBulkUpdated, Label: "批量操作", Default: NotificationChannels{Email: true, InApp: true}},
+	{EventType: EventRiskAcceptance, Label: "风险接受", Default: NotificationChannels{Email: true, InApp: true}},
+	{EventType: EventProjectMemberAdded, Label: "加入项目", Default: NotificationChannels{Email: true, InApp: true}},
+}
+
+// preferenceEventType 获取事件对应的偏好事件类型，项目创建与新增成员共用"加入项目"偏好
+func preferenceEventType(event string) string {
+	if event == EventProjectCreated {
+		return EventProjectMemberAdded
+	}
+	return event
+}
+
+// findPreferenceEvent 查找可配置的偏好事件
+func findPreferenceEvent(eventType string) (PreferenceEvent, bool) {
+	for _, event := range PreferenceEvents {
+		if event.EventType == eventType {
+			return event, true
+		}
+	}
+	return PreferenceEvent{}, false
+}
+
+// NotificationPreferencesResponse 用户通知偏好
+type NotificationPreferencesResponse struct {
+	DigestMode    string                    `json:"digest_mode"`    // 投递方式
+	DigestHour    int                       `json:"digest_hour"`    // 每日汇总的发送时刻
+	WebhookURL    string                    `json:"webhook_url"`    // 个人Webhook地址
+	WebhookSecret string                    `json:"webhook_secret"` // 个人Webhook签名密钥，用于校验推送来源
+	Events        []EventPreferenceResponse `json:"events"`         // 各事件的通知渠道
+}
+
+// EventPreferenceResponse 单个事件的通知渠道
+type EventPreferenceResponse struct {
+	EventType string `json:"event_type"` // 事件类型
+	Label     string `json:"label"`      // 事件名称
+	NotificationChannels
+}
+
+// UpdateNotificationPreferencesRequest 更新通知偏好请求
+type UpdateNotificationPreferencesRequest struct {
+	DigestMode              string                         `json:"digest_mode" binding:"required"`
+	DigestHour              *int                           `json:"digest_hour"`
+	WebhookURL              string                         `json:"webhook_url"`
+	RegenerateWebhookSecret bool                           `json:"regenerate_webhook_secret"` // 重新生成个人Webhook签名密钥
+	Events                  []UpdateEventPreferenceRequest `json:"events"`
+}
+
+// UpdateEventPreferenceRequest 更新单个事件的通知渠道
+type UpdateEventPreferenceRequest struct {
+	EventType string `json:"event_type" binding:"required"`
+	NotificationChannels
+}
+
+// UserWebhookPayload 推送到个人Webhook的数据
+type UserWebhookPayload struct {
+	Event     string               `json:"event"`           // 事件类型，汇总推送时为digest
+	Title     string               `json:"title"`           // 通知标题
+	Content   string               `json:"content"`         // 通知内容
+	Data      json.RawMessage      `json:"data,omitempty"`  // 附加数据
+	Items     []UserWebhookPayload `json:"items,omitempty"` // 汇总推送包含的通知
+	CreatedAt time.Time            `json:"created_at"`      // 通知时间
+}
+
+// GetChannels 获取用户某个事件的通知渠道，未配置时使用默认渠道
+func (s *NotificationService) GetChannels(userID uint, event string) NotificationChannels {
+	prefEvent, ok := findPreferenceEvent(preferenceEventType(event))
+	if !ok {
+		// 不可配置的事件始终发送
+		return NotificationChannels{Email: true, InApp: true}
+	}
+
+	db := Init.GetDB()
+	var pref models.NotificationPreference
+	if err := db.Where("user_id = ? AND event_type = ?", userID, prefEvent.EventType).First(&pref).Error; err != nil {
+		return prefEvent.Default
+	}
+
+	return NotificationChannels{Email: pref.Email, InApp: pref.InApp, Webhook: pref.Webhook}
+}
+
+// getSetting 获取用户通知投递设置，未配置时返回即时发送的默认设置
+func (s *NotificationService) getSetting(userID uint) *models.NotificationSetting {
+	db := Init.GetDB()
+
+	setting := models.NotificationSetting{UserID: userID, DigestMode: DigestImmediate, DigestHour: 9}
+	db.Where("user_id = ?", userID).First(&setting)
+	if setting.DigestMode == "" {
+		setting.DigestMode = DigestImmediate
+	}
+
+	return &setting
+}
+
+// enqueueDigest 将通知加入汇总队列
+func (s *NotificationService) enqueueDigest(userID uint, channel string, event *NotificationEvent, data string) error {
+	db := Init.GetDB()
+
+	digest := models.NotificationDigest{
+		UserID:    userID,
+		Channel:   channel,
+		EventType: event.Data.Event,
+		Title:     event.Title,
+		Content:   event.Content,
+		Data:      data,
+	}
+
+	return db.Create(&digest).Error
+}
+
+// postUserWebhook 推送数据到个人Webhook
+// 签名方式与系统Webhook相同，只允许访问公网地址，防止用户借助个人Webhook访问内网服务
+func postUserWebhook(setting *models.NotificationSetting, payload UserWebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if setting.WebhookSecret == "" {
+		setting.WebhookSecret = randomHex(32)
+		if setting.ID != 0 {
+			Init.GetDB().Model(setting).Update("webhook_secret", setting.WebhookSecret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, setting.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VulnMain-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, payload.Event)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(setting.WebhookSecret, timestamp, body))
+
+	resp, err := utils.NewPublicHTTPClient(10 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetPreferences 获取用户的通知偏好
+func (s *NotificationService) GetPreferences(userID uint) (*NotificationPreferencesResponse, error) {
+	db := Init.GetDB()
+
+	var prefs []models.NotificationPreference
+	if err := db.Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, errors.New("获取通知偏好失败")
+	}
+	prefMap := make(map[string]models.NotificationPreference)
+	for _, pref := range prefs {
+		prefMap[pref.EventType] = pref
+	}
+
+	setting := s.getSetting(userID)
+	resp := &NotificationPreferencesResponse{
+		DigestMode:    setting.DigestMode,
+		DigestHour:    setting.DigestHour,
+		WebhookURL:    setting.WebhookURL,
+		WebhookSecret: setting.WebhookSecret,
+		Events:        make([]EventPreferenceResponse, 0, len(PreferenceEvents)),
+	}
+
+	for _, event := range PreferenceEvents {
+		channels := event.Default
+		if pref, ok := prefMap[event.EventType]; ok {
+			channels = NotificationChannels{Email: pref.Email, InApp: pref.InApp, Webhook: pref.Webhook}
+		}
+		resp.Events = append(resp.Events, EventPreferenceResponse{
+			EventType:            event.EventType,
+			Label:                event.Label,
+			NotificationChannels: channels,
+		})
+	}
+
+	return resp, nil
+}
+
+// UpdatePreferences 更新用户的通知偏好
+func (s *NotificationService) UpdatePreferences(userID uint, req *UpdateNotificationPreferencesRequest) (*NotificationPreferencesResponse, error) {
+	if req.DigestMode != DigestImmediate && req.DigestMode != DigestHourly && req.DigestMode != DigestDaily {
+		return nil, errors.New("投递方式只能是immediate、hourly或daily")
+	}
+	if req.DigestHour != nil && (*req.DigestHour < 0 || *req.DigestHour > 23) {
+		return nil, errors.New("每日汇总时间必须在0-23点之间")
+	}
+	if req.WebhookURL != "" {
+		if err := utils.ValidatePublicURL(req.WebhookURL); err != nil {
+			return nil, fmt.Errorf("Webhook地址无效: %v", err)
+		}
+	}
+	for _, event := range req.Events {
+		if _, ok := findPreferenceEvent(event.EventType); !ok {
+			return nil, fmt.Errorf("不支持的事件类型: %s", event.EventType)
+		}
+	}
+
+	db := Init.GetDB()
+	tx := db.Begin()
+
+	var setting models.NotificationSetting
+	if err := tx.Where("user_id = ?", userID).First(&setting).Error; err != nil {
+		setting = models.NotificationSetting{UserID: userID, DigestHour: 9}
+	}
+	setting.DigestMode = req.DigestMode
+	if req.DigestHour != nil {
+		setting.DigestHour = *req.DigestHour
+	}
+	// 首次设置、更换地址或主动要求时重新生成签名密钥
+	if req.WebhookURL != "" && (setting.WebhookSecret == "" || setting.WebhookURL != req.WebhookURL || req.RegenerateWebhookSecret) {
+		setting.WebhookSecret = randomHex(32)
+	}
+	setting.WebhookURL = req.WebhookURL
+	if err := tx.Save(&setting).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("保存通知设置失败")
+	}
+
+	for _, event := range req.Events {
+		var pref models.NotificationPreference
+		if err := tx.Where("user_id = ? AND event_type = ?", userID, event.EventType).First(&pref).Error; err != nil {
+			pref = models.NotificationPreference{UserID: userID, EventType: event.EventType}
+		}
+		pref.Email = event.Email
+		pref.InApp = event.InApp
+		pref.Webhook = event.Webhook
+		if err := tx.Save(&pref).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.New("保存通知偏好失败")
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("保存通知偏好失败")
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// digestDue 判断用户当前是否应发送汇总
+// 每小时汇总每次执行都发送；每日汇总在用户设置的时刻发送；切换回即时发送后，遗留的汇总在下次执行时发出
+func digestDue(setting *models.NotificationSetting, now time.Time) bool {
+	if setting.LastDigestAt != nil && now.Sub(*setting.LastDigestAt) < 50*time.Minute {
+		return false
+	}
+
+	switch setting.DigestMode {
+	case DigestDaily:
+		return now.Hour() == setting.DigestHour
+	default:
+		return true
+	}
+}
+
+// SendDigests 合并发送待汇总的通知，由定时任务每小时调用
+func (s *NotificationService) SendDigests(now time.Time) error {
+	db := Init.GetDB()
+
+	var userIDs []uint
+	if err := db.Model(&models.NotificationDigest{}).Where("sent_at IS NULL").Pluck("DISTINCT user_id", &userIDs).Error; err != nil {
+		return fmt.Errorf("查询待汇总通知失败: %v", err)
+	}
+
+	for _, userID := range userIDs {
+		setting := s.getSetting(userID)
+		if !digestDue(setting, now) {
+			continue
+		}
+
+		var user models.User
+		if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+			continue
+		}
+
+		var items []models.NotificationDigest
+		if err := db.Where("user_id = ? AND sent_at IS NULL", userID).Order("created_at ASC").Find(&items).Error; err != nil || len(items) == 0 {
+			continue
+		}
+
+		var emailItems, webhookItems []models.NotificationDigest
+		for _, item := range items {
+			if item.Channel == DigestChannelWebhook {
+				webhookItems = append(webhookItems, item)
+			} else {
+				emailItems = append(emailItems, item)
+			}
+		}
+
+		sentIDs := make([]uint, 0, len(items))
+		if len(emailItems) > 0 {
+			if err := s.sendEmailDigest(&user, emailItems); err != nil {
+				fmt.Printf("发送通知汇总邮件失败 (用户ID: %d): %v\n", userID, err)
+			} else {
+				for _, item := range emailItems {
+					sentIDs = append(sentIDs, item.ID)
+				}
+			}
+		}
+		if len(webhookItems) > 0 {
+			if err := s.sendWebhookDigest(setting, webhookItems); err != nil {
+				fmt.Printf("推送个人Webhook汇总失败 (用户ID: %d): %v\n", userID, err)
+			} else {
+				for _, item := range webhookItems {
+					sentIDs = append(sentIDs, item.ID)
+				}
+			}
+		}
+
+		if len(sentIDs) > 0 {
+			db.Model(&models.NotificationDigest{}).Where("id IN (?)", sentIDs).Update("sent_at", now)
+		}
+		if setting.ID != 0 {
+			db.Model(setting).Update("last_digest_at", now)
+		}
+	}
+
+	return nil
+}
+
+// sendEmailDigest 发送汇总邮件，用户没有邮箱时直接视为已发送
+func (s *NotificationService) sendEmailDigest(user *models.User, items []models.NotificationDigest) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	template := GetNotificationDigestTemplate(displayName(user), items)
+	return SendEmail([]string{user.Email}, template.Subject, template.Body)
+}
+
+// sendWebhookDigest 推送汇总到个人Webhook，用户已清空Webhook地址时直接视为已发送
+func (s *NotificationService) sendWebhookDigest(setting *models.NotificationSetting, items []models.NotificationDigest) error {
+	if setting.WebhookURL == "" {
+		return nil
+	}
+
+	payload := UserWebhookPayload{
+		Event:     "digest",
+		Title:     fmt.Sprintf("通知汇总：共%d条新通知", len(items)),
+		CreatedAt: time.Now(),
+	}
+	for _, item := range items {
+		entry := UserWebhookPayload{
+			Event:     item.EventType,
+			Title:     item.Title,
+			Content:   item.Content,
+			CreatedAt: item.CreatedAt,
+		}
+		if item.Data != "" {
+			entry.Data = json.RawMessage(item.Data)
+		}
+		payload.Items = append(payload.Items, entry)
+	}
+
+	return postUserWebhook(setting, payload)
+}
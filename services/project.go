@@ -182,37 +182,17 @@ func (s *ProjectService) CreateProject(req *CreateProjectRequest, creatorID uint
 		return nil, fmt.Errorf("提交事务失败: %v", err)
 	}
 
-	// 发送邮件通知给项目成员
-	go func() {
-		// 获取项目成员邮箱列表
-		var memberEmails []string
-		var memberNames []string
-
-		for _, memberID := range req.MemberIDs {
-			var member models.User
-			if err := db.First(&member, memberID).Error; err == nil && member.Email != "" {
-				memberEmails = append(memberEmails, member.Email)
-				if member.RealName != "" {
-					memberNames = append(memberNames, member.RealName)
-				} else {
-					memberNames = append(memberNames, member.Username)
-				}
-			}
-		}
+	// 按通知偏好通知项目成员（站内通知、邮件、个人Webhook）
+	ownerName := owner.RealName
+	if ownerName == "" {
+		ownerName = owner.Username
+	}
+	notificationService := &NotificationService{}
+	notificationService.NotifyProjectMembers(EventProjectCreated, project.ID, req.Name, ownerName, req.MemberIDs)
 
-		// 发送项目创建通知
-		if len(memberEmails) > 0 {
-			ownerName := owner.RealName
-			if ownerName == "" {
-				ownerName = owner.Username
-			}
-
-			if err := SendProjectCreatedNotification(req.Name, ownerName, memberEmails); err != nil {
-				// 记录邮件发送失败的日志，但不影响项目创建
-				fmt.Printf("发送项目创建通知邮件失败: %v\n", err)
-			}
-		}
-	}()
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	webhookService.Emit(WebhookProjectCreated, NewWebhookProjectData(project, creatorID))
 
 	// 返回项目信息
 	return s.GetProject(project.ID, creatorID, "super_admin")
@@ -539,48 +519,32 @@ func (s *ProjectService) UpdateProject(projectID uint, req *UpdateProjectRequest
 		return nil, fmt.Errorf("提交事务失败: %v", err)
 	}
 
-	// 发送邮件通知给新增的项目成员
+	// 按通知偏好通知新增的项目成员（站内通知、邮件、个人Webhook）
 	if len(newMemberIDs) > 0 {
 		go func() {
 			// 获取项目信息
 			var updatedProject models.Project
 			if err := db.Preload("Owner").First(&updatedProject, projectID).Error; err != nil {
-				fmt.Printf("获取项目信息失败，无法发送邮件通知: %v\n", err)
+				fmt.Printf("获取项目信息失败，无法发送通知: %v\n", err)
 				return
 			}
 
-			// 获取新增成员的邮箱列表
-			var newMemberEmails []string
-			var newMemberNames []string
-
-			for _, memberID := range newMemberIDs {
-				var member models.User
-				if err := db.First(&member, memberID).Error; err == nil && member.Email != "" {
-					newMemberEmails = append(newMemberEmails, member.Email)
-					if member.RealName != "" {
-						newMemberNames = append(newMemberNames, member.RealName)
-					} else {
-						newMemberNames = append(newMemberNames, member.Username)
-					}
-				}
-			}
-
-			// 发送项目成员新增通知
-			if len(newMemberEmails) > 0 {
-				ownerName := updatedProject.Owner.RealName
-				if ownerName == "" {
-					ownerName = updatedProject.Owner.Username
-				}
-
-				// 使用项目新增成员通知模板
-				if err := SendProjectMemberAddedNotification(updatedProject.Name, ownerName, newMemberEmails); err != nil {
-					// 记录邮件发送失败的日志，但不影响项目更新
-					fmt.Printf("发送项目成员新增通知邮件失败: %v\n", err)
-				}
+			ownerName := updatedProject.Owner.RealName
+			if ownerName == "" {
+				ownerName = updatedProject.Owner.Username
 			}
+			notificationService := &NotificationService{}
+			notificationService.NotifyProjectMembers(EventProjectMemberAdded, projectID, updatedProject.Name, ownerName, newMemberIDs)
 		}()
 	}
 
+	// 推送出站Webhook
+	var updatedProject models.Project
+	if err := db.First(&updatedProject, projectID).Error; err == nil {
+		webhookService := &WebhookService{}
+		webhookService.Emit(WebhookProjectUpdated, NewWebhookProjectData(&updatedProject, userID))
+	}
+
 	// 返回更新后的项目信息
 	return s.GetProject(projectID, userID, roleCode)
 }
@@ -637,6 +601,10 @@ func (s *ProjectService) DeleteProject(projectID, userID uint, roleCode string)
 		return fmt.Errorf("提交事务失败: %v", err)
 	}
 
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	webhookService.Emit(WebhookProjectDeleted, NewWebhookProjectData(&project, userID))
+
 	return nil
 }
 
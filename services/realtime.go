@@ -0,0 +1,195 @@
+// 实时推送服务包
+// 该包通过消息代理将站内通知、漏洞时间线和评论实时推送给已连接的客户端
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+	"vulnmain/utils"
+
+	"github.com/spf13/viper"
+)
+
+// 实时事件类型，对应SSE的event字段
+const (
+	RealtimeNotification = "notification" // 新的站内通知
+	RealtimeTimeline     = "timeline"     // 漏洞时间线新增记录（状态变更、审核、复测等）
+	RealtimeComment      = "comment"      // 漏洞新增评论
+)
+
+// 消息代理类型
+const (
+	RealtimeBrokerMemory = "memory" // 进程内代理，仅适用于单实例部署
+)
+
+// RealtimeEvent 实时推送事件，需可JSON序列化，便于跨实例的代理（如Redis）传输
+type RealtimeEvent struct {
+	Type      string      `json:"type"`              // 事件类型
+	VulnID    uint        `json:"vuln_id,omitempty"` // 关联漏洞ID
+	Data      interface{} `json:"data"`              // 事件数据：通知、时间线或评论记录
+	CreatedAt time.Time   `json:"created_at"`        // 事件产生时间
+}
+
+// RealtimeBroker 实时消息代理接口
+// 事件按主题发布，订阅者可同时订阅多个主题。多实例部署时实现基于Redis pub/sub的代理，
+// 并通过SetRealtimeBroker替换默认的进程内代理即可，业务代码无需改动
+type RealtimeBroker interface {
+	// Publish 向主题发布事件，没有订阅者时直接丢弃
+	Publish(topic string, event *RealtimeEvent)
+	// Subscribe 订阅主题，返回事件通道和取消订阅函数，取消后通道会被关闭
+	Subscribe(topics ...string) (<-chan *RealtimeEvent, func())
+}
+
+// UserTopic 用户主题，推送该用户的站内通知
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// VulnTopic 漏洞主题，推送该漏洞的时间线和评论
+func VulnTopic(vulnID uint) string {
+	return fmt.Sprintf("vuln:%d", vulnID)
+}
+
+// MemoryBroker 进程内消息代理
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan *RealtimeEvent]struct{}
+	bufferSize  int
+}
+
+// NewMemoryBroker 创建进程内消息代理
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[string]map[chan *RealtimeEvent]struct{}),
+		bufferSize:  32,
+	}
+}
+
+// Publish 向主题发布事件
+// 订阅者缓冲区已满时丢弃该事件，避免单个慢连接阻塞业务流程
+func (b *MemoryBroker) Publish(topic string, event *RealtimeEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+			fmt.Printf("实时推送缓冲区已满，丢弃事件 (主题: %s, 类型: %s)\n", topic, event.Type)
+		}
+	}
+}
+
+// Subscribe 订阅一个或多个主题
+func (b *MemoryBroker) Subscribe(topics ...string) (<-chan *RealtimeEvent, func()) {
+	ch := make(chan *RealtimeEvent, b.bufferSize)
+
+	b.mu.Lock()
+	for _, topic := range topics {
+		if b.subscribers[topic] == nil {
+			b.subscribers[topic] = make(map[chan *RealtimeEvent]struct{})
+		}
+		b.subscribers[topic][ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for _, topic := range topics {
+				delete(b.subscribers[topic], ch)
+				if len(b.subscribers[topic]) == 0 {
+					delete(b.subscribers, topic)
+				}
+			}
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+var (
+	realtimeBroker   RealtimeBroker
+	realtimeBrokerMu sync.Mutex
+)
+
+// GetRealtimeBroker 获取当前使用的消息代理，首次调用时根据config.yml中的realtime.broker创建
+func GetRealtimeBroker() RealtimeBroker {
+	realtimeBrokerMu.Lock()
+	defer realtimeBrokerMu.Unlock()
+
+	if realtimeBroker == nil {
+		name := viper.GetString("realtime.broker")
+		if name != "" && name != RealtimeBrokerMemory {
+			fmt.Printf("不支持的实时消息代理: %s，使用进程内代理\n", name)
+		}
+		realtimeBroker = NewMemoryBroker()
+	}
+	return realtimeBroker
+}
+
+// SetRealtimeBroker 替换消息代理，需在服务启动、路由注册之前调用
+func SetRealtimeBroker(broker RealtimeBroker) {
+	realtimeBrokerMu.Lock()
+	defer realtimeBrokerMu.Unlock()
+	realtimeBroker = broker
+}
+
+// publishRealtime 发布实时事件
+func publishRealtime(topic string, eventType string, vulnID uint, data interface{}) {
+	GetRealtimeBroker().Publish(topic, &RealtimeEvent{
+		Type:      eventType,
+		VulnID:    vulnID,
+		Data:      data,
+		CreatedAt: time.Now(),
+	})
+}
+
+// RealtimeService 实时推送服务
+type RealtimeService struct{}
+
+// HeartbeatInterval 获取SSE心跳间隔，防止代理服务器因连接空闲而断开
+func (s *RealtimeService) HeartbeatInterval() time.Duration {
+	seconds := viper.GetInt("realtime.heartbeat_seconds")
+	if seconds <= 0 {
+		seconds = 25
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// streamTicketTTL 实时推送票据有效期，只需覆盖获取票据到建立连接的时间
+const streamTicketTTL = time.Minute
+
+// IssueStreamTicket 为用户签发实时推送票据，EventSource断线重连前需重新获取
+func (s *RealtimeService) IssueStreamTicket(userID uint) (string, time.Time) {
+	return utils.NewResourceSigner().StreamTicket(userID, streamTicketTTL)
+}
+
+// SubscribeTopics 获取用户可订阅的主题
+// 用户始终订阅自己的通知主题；指定vulnID时校验漏洞访问权限后追加漏洞主题
+func (s *RealtimeService) SubscribeTopics(userID uint, userRole string, vulnID uint) ([]string, error) {
+	topics := []string{UserTopic(userID)}
+	if vulnID == 0 {
+		return topics, nil
+	}
+
+	db := Init.GetDB()
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	vulnService := &VulnService{}
+	if !vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	return append(topics, VulnTopic(vulnID)), nil
+}
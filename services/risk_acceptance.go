@@ -0,0 +1,701 @@
+// 风险接受服务包
+// 该包实现漏洞风险接受（风险例外）流程：申请、指定审批人审批、撤回、撤销、到期自动恢复以及登记册导出
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+	"github.com/xuri/excelize/v2"
+)
+
+// RiskAcceptanceService 风险接受服务
+type RiskAcceptanceService struct{}
+
+// 风险接受申请状态
+const (
+	RiskPending   = "pending"   // 待审批
+	RiskApproved  = "approved"  // 已批准，漏洞处于已忽略状态
+	RiskRejected  = "rejected"  // 已拒绝
+	RiskCancelled = "cancelled" // 申请人已撤回
+	RiskRevoked   = "revoked"   // 审批人已撤销，漏洞恢复为未修复
+	RiskExpired   = "expired"   // 已到期，漏洞恢复为未修复
+)
+
+// riskStatusLabels 风险接受申请状态名称
+var riskStatusLabels = map[string]string{
+	RiskPending:   "待审批",
+	RiskApproved:  "已批准",
+	RiskRejected:  "已拒绝",
+	RiskCancelled: "已撤回",
+	RiskRevoked:   "已撤销",
+	RiskExpired:   "已到期",
+}
+
+// RiskAcceptanceRequest 风险接受申请请求
+type RiskAcceptanceRequest struct {
+	Justification        string `json:"justification" binding:"required"`
+	CompensatingControls string `json:"compensating_controls" binding:"required"`
+	ExpiresAt            string `json:"expires_at" binding:"required"` // 到期日期，格式YYYY-MM-DD
+	ApproverID           uint   `json:"approver_id" binding:"required"`
+}
+
+// RiskReviewRequest 审批、撤销请求
+type RiskReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// RiskAcceptanceListRequest 风险接受申请列表请求，也用于登记册导出
+type RiskAcceptanceListRequest struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"page_size"`
+	Status    string `form:"status"`
+	ProjectID uint   `form:"project_id"`
+	VulnID    uint   `form:"vuln_id"`
+	Scope     string `form:"scope"`      // mine我提交的、approve待我审批的，为空表示有权查看的全部
+	StartDate string `form:"start_date"` // 申请时间起，格式YYYY-MM-DD
+	EndDate   string `form:"end_date"`   // 申请时间止，格式YYYY-MM-DD
+}
+
+// RiskAcceptanceListResponse 风险接受申请列表
+type RiskAcceptanceListResponse struct {
+	Items    []models.RiskAcceptance `json:"items"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+}
+
+// RiskApprover 可选的审批人
+type RiskApprover struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	RealName string `json:"real_name"`
+}
+
+// canApproveRisk 判断用户是否可以作为风险接受审批人
+func canApproveRisk(user *models.User) bool {
+	return user.Role.Code == "super_admin" || user.HasPermission("risk:approve")
+}
+
+// canViewRiskRegister 判断用户是否可以查看完整的风险例外登记册
+func canViewRiskRegister(user *models.User) bool {
+	return user.Role.Code == "super_admin" || user.HasPermission("risk:view")
+}
+
+// isRiskAcceptanceBlockedStatus 判断漏洞状态是否不能申请或批准风险接受（已忽略、已完成、已驳回）
+func isRiskAcceptanceBlockedStatus(status string) bool {
+	switch normalizeVulnStatus(status) {
+	case VulnStatusIgnored, VulnStatusCompleted, VulnStatusRejected:
+		return true
+	}
+	return false
+}
+
+// GetApprovers 获取可以审批风险接受申请的用户
+func (s *RiskAcceptanceService) GetApprovers() ([]RiskApprover, error) {
+	db := Init.GetDB()
+
+	var users []models.User
+	if err := db.Preload("Role.Permissions").Where("status = ?", 1).Find(&users).Error; err != nil {
+		return nil, errors.New("获取审批人失败")
+	}
+
+	approvers := []RiskApprover{}
+	for i := range users {
+		if canApproveRisk(&users[i]) {
+			approvers = append(approvers, RiskApprover{ID: users[i].ID, Username: users[i].Username, RealName: users[i].RealName})
+		}
+	}
+	return approvers, nil
+}
+
+// CreateRiskAcceptance 提交风险接受申请，研发工程师和安全工程师可以为能访问的漏洞提交
+func (s *RiskAcceptanceService) CreateRiskAcceptance(vulnID uint, req *RiskAcceptanceRequest, userID uint, userRole string) (*models.RiskAcceptance, error) {
+	db := Init.GetDB()
+	vulnService := &VulnService{}
+
+	if userRole != "super_admin" && userRole != "security_engineer" && userRole != "dev_engineer" {
+		return nil, errors.New("无权限提交风险接受申请")
+	}
+
+	var vuln models.Vulnerability
+	if err := db.Preload("Project").Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if !vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	if isRiskAcceptanceBlockedStatus(vuln.Status) {
+		return nil, fmt.Errorf("「%s」状态的漏洞不能申请风险接受", vulnStatusLabel(vuln.Status))
+	}
+
+	var count int64
+	db.Model(&models.RiskAcceptance{}).Where("vuln_id = ? AND status IN (?)", vulnID, []string{RiskPending, RiskApproved}).Count(&count)
+	if count > 0 {
+		return nil, errors.New("该漏洞已有待审批或生效中的风险接受申请")
+	}
+
+	req.Justification = strings.TrimSpace(req.Justification)
+	req.CompensatingControls = strings.TrimSpace(req.CompensatingControls)
+	if req.Justification == "" || req.CompensatingControls == "" {
+		return nil, errors.New("请填写接受风险的理由和补偿性控制措施")
+	}
+
+	// 到期时间为所选日期当天结束
+	expiresDate, err := time.ParseInLocation("2006-01-02", req.ExpiresAt, time.Local)
+	if err != nil {
+		return nil, errors.New("到期时间格式错误，请使用YYYY-MM-DD格式")
+	}
+	expiresAt := expiresDate.Add(24*time.Hour - time.Second)
+	if !expiresAt.After(time.Now()) {
+		return nil, errors.New("到期时间不能是过去的日期")
+	}
+	maxDays := getIntConfig("risk_acceptance.max_days", 180)
+	if expiresDate.After(time.Now().AddDate(0, 0, maxDays)) {
+		return nil, fmt.Errorf("风险接受的有效期不能超过%d天", maxDays)
+	}
+
+	if req.ApproverID == userID {
+		return nil, errors.New("不能指定自己为审批人")
+	}
+	approver, err := loadVulnActor(db, req.ApproverID)
+	if err != nil || approver.Status != 1 {
+		return nil, errors.New("指定的审批人不存在")
+	}
+	if !canApproveRisk(approver) {
+		return nil, errors.New("指定的用户没有审批风险接受的权限")
+	}
+
+	ra := models.RiskAcceptance{
+		VulnID:               vulnID,
+		RequesterID:          userID,
+		ApproverID:           req.ApproverID,
+		Justification:        req.Justification,
+		CompensatingControls: req.CompensatingControls,
+		ExpiresAt:            expiresAt,
+		Status:               RiskPending,
+	}
+	if err := db.Create(&ra).Error; err != nil {
+		return nil, errors.New("提交风险接受申请失败")
+	}
+
+	vulnService.addTimeline(vulnID, userID, "risk_requested", fmt.Sprintf("提交风险接受申请，有效期至 %s，审批人：%s", req.ExpiresAt, displayName(approver)))
+
+	notificationService := &NotificationService{}
+	notificationService.NotifyRiskAcceptance(&vuln, &ra, []uint{ra.ApproverID},
+		"风险接受待审批",
+		fmt.Sprintf("%s 为漏洞「%s」提交了风险接受申请，有效期至 %s，请审批。", userDisplayName(userID), vuln.Title, req.ExpiresAt))
+
+	return s.GetRiskAcceptance(ra.ID)
+}
+
+// GetRiskAcceptance 获取风险接受申请详情
+func (s *RiskAcceptanceService) GetRiskAcceptance(id uint) (*models.RiskAcceptance, error) {
+	db := Init.GetDB()
+
+	var ra models.RiskAcceptance
+	if err := db.Preload("Vuln").Preload("Vuln.Project").Preload("Requester").Preload("Approver").Where("id = ?", id).First(&ra).Error; err != nil {
+		return nil, errors.New("风险接受申请不存在")
+	}
+	return &ra, nil
+}
+
+// GetRiskAcceptanceDetail 获取风险接受申请详情，申请人、审批人、登记册查看人和能访问漏洞的用户可以查看
+func (s *RiskAcceptanceService) GetRiskAcceptanceDetail(id, userID uint, userRole string) (*models.RiskAcceptance, error) {
+	db := Init.GetDB()
+
+	ra, err := s.GetRiskAcceptance(id)
+	if err != nil {
+		return nil, err
+	}
+	if ra.RequesterID == userID || ra.ApproverID == userID {
+		return ra, nil
+	}
+
+	user, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	vulnService := &VulnService{}
+	if canViewRiskRegister(user) || (ra.Vuln != nil && vulnService.canAccessVuln(db, ra.Vuln, userID, userRole)) {
+		return ra, nil
+	}
+	return nil, errors.New("风险接受申请不存在")
+}
+
+// ReviewRiskAcceptance 审批风险接受申请，只有指定的审批人或超级管理员可以审批，拒绝时必须填写意见
+// 批准后漏洞变更为已忽略；漏洞在申请后已被忽略、完成或驳回时，批准操作自动撤回申请
+func (s *RiskAcceptanceService) ReviewRiskAcceptance(id uint, approve bool, comment string, userID uint) (*models.RiskAcceptance, error) {
+	db := Init.GetDB()
+	vulnService := &VulnService{}
+
+	ra, err := s.GetRiskAcceptance(id)
+	if err != nil {
+		return nil, err
+	}
+	if ra.Status != RiskPending {
+		return nil, fmt.Errorf("申请当前为「%s」状态，不能审批", riskStatusLabels[ra.Status])
+	}
+
+	reviewer, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ra.ApproverID != userID && reviewer.Role.Code != "super_admin" {
+		return nil, errors.New("只有指定的审批人可以审批该申请")
+	}
+
+	comment = strings.TrimSpace(comment)
+	if !approve && comment == "" {
+		return nil, errors.New("拒绝时必须填写审批意见")
+	}
+
+	var vuln models.Vulnerability
+	if err := db.Preload("Project").Where("id = ?", ra.VulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	now := time.Now().Truncate(time.Second)
+	notificationService := &NotificationService{}
+
+	if !approve {
+		if err := db.Model(&models.RiskAcceptance{}).Where("id = ?", ra.ID).Updates(map[string]interface{}{
+			"status":         RiskRejected,
+			"review_comment": comment,
+			"reviewed_at":    now,
+		}).Error; err != nil {
+			return nil, errors.New("审批失败")
+		}
+		vulnService.addTimeline(vuln.ID, userID, "risk_rejected", fmt.Sprintf("风险接受申请被拒绝：%s", comment))
+		notificationService.NotifyRiskAcceptance(&vuln, ra, []uint{ra.RequesterID},
+			"风险接受申请被拒绝",
+			fmt.Sprintf("漏洞「%s」的风险接受申请被 %s 拒绝，审批意见：%s", vuln.Title, displayName(reviewer), comment))
+		return s.GetRiskAcceptance(id)
+	}
+
+	if !ra.ExpiresAt.After(now) {
+		return nil, errors.New("申请的有效期已过，请重新提交申请")
+	}
+
+	// 提交申请后漏洞可能已被关闭，此时申请自动作废
+	if isRiskAcceptanceBlockedStatus(vuln.Status) {
+		note := fmt.Sprintf("漏洞已处于「%s」状态，风险接受申请自动撤回", vulnStatusLabel(vuln.Status))
+		if err := db.Model(&models.RiskAcceptance{}).Where("id = ?", ra.ID).Updates(map[string]interface{}{
+			"status":         RiskCancelled,
+			"review_comment": note,
+			"closed_at":      now,
+		}).Error; err != nil {
+			return nil, errors.New("审批失败")
+		}
+		vulnService.addTimeline(vuln.ID, userID, "risk_cancelled", note)
+		return nil, errors.New(note)
+	}
+
+	oldStatus := vuln.Status
+	tx := db.Begin()
+	change, err := vulnService.applySystemStatus(tx, &vuln, VulnStatusIgnored, reviewer, ra.Justification)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	vuln.IgnoreReason = fmt.Sprintf("风险接受（有效期至 %s）：%s\n补偿性控制措施：%s", ra.ExpiresAt.Format("2006-01-02"), ra.Justification, ra.CompensatingControls)
+
+	if err := tx.Save(&vuln).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("更新漏洞状态失败")
+	}
+	if err := tx.Model(&models.RiskAcceptance{}).Where("id = ?", ra.ID).Updates(map[string]interface{}{
+		"status":          RiskApproved,
+		"previous_status": oldStatus,
+		"review_comment":  comment,
+		"reviewed_at":     now,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("审批失败")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("审批失败")
+	}
+
+	vulnService.recordStatusEffects(db, change)
+	vulnService.recordStatusChange(vuln.ID, userID, oldStatus, VulnStatusIgnored, fmt.Sprintf("风险接受已批准，有效期至 %s", ra.ExpiresAt.Format("2006-01-02")))
+	s.updateProjectStats(vuln.ProjectID)
+
+	recipients := []uint{ra.RequesterID}
+	if vuln.AssigneeID != nil {
+		recipients = append(recipients, *vuln.AssigneeID)
+	}
+	notificationService.NotifyRiskAcceptance(&vuln, ra, recipients,
+		"风险接受申请已批准",
+		fmt.Sprintf("漏洞「%s」的风险接受申请已由 %s 批准，有效期至 %s，到期后漏洞将自动恢复为未修复。", vuln.Title, displayName(reviewer), ra.ExpiresAt.Format("2006-01-02")))
+
+	webhookService := &WebhookService{}
+	data := NewWebhookVulnData(&vuln, userID)
+	data.OldStatus = oldStatus
+	data.Comment = ra.Justification
+	webhookService.Emit(WebhookVulnRiskAccepted, data)
+
+	return s.GetRiskAcceptance(id)
+}
+
+// CancelRiskAcceptance 申请人撤回待审批的申请
+func (s *RiskAcceptanceService) CancelRiskAcceptance(id uint, userID uint) error {
+	db := Init.GetDB()
+
+	ra, err := s.GetRiskAcceptance(id)
+	if err != nil {
+		return err
+	}
+	if ra.RequesterID != userID {
+		return errors.New("只有申请人可以撤回申请")
+	}
+	if ra.Status != RiskPending {
+		return errors.New("只能撤回待审批的申请")
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := db.Model(&models.RiskAcceptance{}).Where("id = ?", ra.ID).Updates(map[string]interface{}{"status": RiskCancelled, "closed_at": now}).Error; err != nil {
+		return errors.New("撤回申请失败")
+	}
+
+	vulnService := &VulnService{}
+	vulnService.addTimeline(ra.VulnID, userID, "risk_cancelled", "撤回风险接受申请")
+	return nil
+}
+
+// RevokeRiskAcceptance 审批人或超级管理员提前撤销已批准的风险接受，漏洞恢复为未修复
+func (s *RiskAcceptanceService) RevokeRiskAcceptance(id uint, comment string, userID uint) error {
+	db := Init.GetDB()
+
+	ra, err := s.GetRiskAcceptance(id)
+	if err != nil {
+		return err
+	}
+	if ra.Status != RiskApproved {
+		return errors.New("只能撤销已批准的风险接受")
+	}
+
+	operator, err := loadVulnActor(db, userID)
+	if err != nil {
+		return err
+	}
+	if ra.ApproverID != userID && operator.Role.Code != "super_admin" {
+		return errors.New("只有审批人可以撤销该风险接受")
+	}
+
+	note := "风险接受已撤销"
+	if comment = strings.TrimSpace(comment); comment != "" {
+		note += "：" + comment
+	}
+	return s.closeRiskAcceptance(db, ra, RiskRevoked, operator, note)
+}
+
+// ExpireRiskAcceptances 将到期的风险接受标记为已到期，漏洞恢复为未修复并通知指派人和项目负责人
+func (s *RiskAcceptanceService) ExpireRiskAcceptances() error {
+	db := Init.GetDB()
+
+	var expired []models.RiskAcceptance
+	if err := db.Preload("Vuln").Preload("Requester").Preload("Approver").
+		Where("status = ? AND expires_at <= ?", RiskApproved, time.Now()).Find(&expired).Error; err != nil {
+		return fmt.Errorf("查询到期的风险接受失败: %v", err)
+	}
+
+	for i := range expired {
+		// 到期恢复由系统执行，以审批人身份记录
+		approver, err := loadVulnActor(db, expired[i].ApproverID)
+		if err != nil {
+			approver = &models.User{}
+		}
+		if err := s.closeRiskAcceptance(db, &expired[i], RiskExpired, approver, "风险接受已到期"); err != nil {
+			fmt.Printf("处理到期的风险接受失败 (ID: %d): %v\n", expired[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// closeRiskAcceptance 撤销或到期：关闭申请并将仍处于已忽略状态的漏洞恢复为未修复
+func (s *RiskAcceptanceService) closeRiskAcceptance(db *gorm.DB, ra *models.RiskAcceptance, status string, operator *models.User, note string) error {
+	vulnService := &VulnService{}
+
+	var vuln models.Vulnerability
+	if err := db.Preload("Project").Where("id = ?", ra.VulnID).First(&vuln).Error; err != nil {
+		return errors.New("漏洞不存在")
+	}
+
+	now := time.Now().Truncate(time.Second)
+	oldStatus := vuln.Status
+	var change *StatusChange
+
+	tx := db.Begin()
+	if normalizeVulnStatus(vuln.Status) == VulnStatusIgnored {
+		target := VulnStatusUnfixed
+		if resolveVulnWorkflow(db, vuln.ProjectID).state(target) == nil && ra.PreviousStatus != "" {
+			target = ra.PreviousStatus
+		}
+		var err error
+		if change, err = vulnService.applySystemStatus(tx, &vuln, target, operator, note); err != nil {
+			tx.Rollback()
+			return err
+		}
+		vuln.IgnoredAt = nil
+		vuln.IgnoreReason = ""
+		if err := tx.Save(&vuln).Error; err != nil {
+			tx.Rollback()
+			return errors.New("恢复漏洞状态失败")
+		}
+	}
+	if err := tx.Model(&models.RiskAcceptance{}).Where("id = ?", ra.ID).Updates(map[string]interface{}{"status": status, "closed_at": now}).Error; err != nil {
+		tx.Rollback()
+		return errors.New("更新风险接受状态失败")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("更新风险接受状态失败")
+	}
+
+	if change == nil {
+		vulnService.addTimeline(vuln.ID, operator.ID, "risk_closed", note)
+		return nil
+	}
+
+	vulnService.recordStatusEffects(db, change)
+	vulnService.recordStatusChange(vuln.ID, operator.ID, oldStatus, vuln.Status, note)
+	s.updateProjectStats(vuln.ProjectID)
+
+	recipients := []uint{vuln.Project.OwnerID}
+	if vuln.AssigneeID != nil {
+		recipients = append(recipients, *vuln.AssigneeID)
+	}
+	notificationService := &NotificationService{}
+	notificationService.NotifyRiskAcceptance(&vuln, ra, recipients,
+		note,
+		fmt.Sprintf("漏洞「%s」%s，已恢复为「%s」状态，请安排修复。", vuln.Title, note, vulnStatusLabel(vuln.Status)))
+
+	webhookService := &WebhookService{}
+	data := NewWebhookVulnData(&vuln, operator.ID)
+	data.OldStatus = oldStatus
+	data.Comment = note
+	webhookService.Emit(WebhookVulnRiskExpired, data)
+
+	return nil
+}
+
+// updateProjectStats 更新漏洞所属项目的统计信息，失败不影响业务流程
+func (s *RiskAcceptanceService) updateProjectStats(projectID uint) {
+	if projectID == 0 {
+		return
+	}
+	projectService := &ProjectService{}
+	if err := projectService.UpdateProjectStats(projectID); err != nil {
+		fmt.Printf("更新项目统计失败 (项目ID: %d): %v\n", projectID, err)
+	}
+}
+
+// riskAcceptanceQuery 构建风险接受申请查询，按用户权限限制可见范围
+// 超级管理员和拥有risk:view权限的用户可以查看全部申请，其他用户只能查看自己提交或需要自己审批的申请
+func (s *RiskAcceptanceService) riskAcceptanceQuery(db *gorm.DB, req *RiskAcceptanceListRequest, userID uint) (*gorm.DB, error) {
+	user, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&models.RiskAcceptance{})
+	switch req.Scope {
+	case "mine":
+		query = query.Where("requester_id = ?", userID)
+	case "approve":
+		query = query.Where("approver_id = ?", userID)
+	default:
+		if !canViewRiskRegister(user) {
+			query = query.Where("requester_id = ? OR approver_id = ?", userID, userID)
+		}
+	}
+
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.VulnID != 0 {
+		query = query.Where("vuln_id = ?", req.VulnID)
+	}
+	if req.ProjectID != 0 {
+		query = query.Where("vuln_id IN (SELECT id FROM vulnerabilities WHERE project_id = ?)", req.ProjectID)
+	}
+	if req.StartDate != "" {
+		if start, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local); err == nil {
+			query = query.Where("created_at >= ?", start)
+		}
+	}
+	if req.EndDate != "" {
+		if end, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local); err == nil {
+			query = query.Where("created_at < ?", end.AddDate(0, 0, 1))
+		}
+	}
+
+	return query, nil
+}
+
+// GetRiskAcceptances 获取风险接受申请列表
+func (s *RiskAcceptanceService) GetRiskAcceptances(req *RiskAcceptanceListRequest, userID uint) (*RiskAcceptanceListResponse, error) {
+	db := Init.GetDB()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query, err := s.riskAcceptanceQuery(db, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var items []models.RiskAcceptance
+	if err := query.Preload("Vuln").Preload("Vuln.Project").Preload("Requester").Preload("Approver").
+		Order("created_at DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&items).Error; err != nil {
+		return nil, errors.New("获取风险接受申请失败")
+	}
+
+	return &RiskAcceptanceListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// GetVulnRiskAcceptances 获取漏洞的全部风险接受申请，能访问漏洞的用户都可以查看
+func (s *RiskAcceptanceService) GetVulnRiskAcceptances(vulnID, userID uint, userRole string) ([]models.RiskAcceptance, error) {
+	db := Init.GetDB()
+	vulnService := &VulnService{}
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if !vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	var items []models.RiskAcceptance
+	if err := db.Preload("Requester").Preload("Approver").Where("vuln_id = ?", vulnID).Order("created_at DESC").Find(&items).Error; err != nil {
+		return nil, errors.New("获取风险接受申请失败")
+	}
+	return items, nil
+}
+
+// ExportRiskRegister 导出风险例外登记册，供审计使用
+func (s *RiskAcceptanceService) ExportRiskRegister(req *RiskAcceptanceListRequest, userID uint) ([]byte, error) {
+	db := Init.GetDB()
+
+	user, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewRiskRegister(user) {
+		return nil, errors.New("无权限导出风险例外登记册")
+	}
+
+	query, err := s.riskAcceptanceQuery(db, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.RiskAcceptance
+	if err := query.Preload("Vuln").Preload("Vuln.Project").Preload("Requester").Preload("Approver").
+		Order("created_at DESC").Find(&items).Error; err != nil {
+		return nil, errors.New("查询风险接受申请失败")
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "风险例外登记册"
+	f.SetSheetName("Sheet1", sheetName)
+
+	headers := []string{
+		"编号", "漏洞ID", "漏洞标题", "严重程度", "当前漏洞状态", "所属项目", "申请人", "审批人",
+		"接受理由", "补偿性控制措施", "申请时间", "审批时间", "到期时间", "申请状态", "审批意见", "关闭时间",
+	}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+	}
+
+	style, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold: true,
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#E6E6FA"},
+			Pattern: 1,
+		},
+	})
+	if err == nil {
+		f.SetRowStyle(sheetName, 1, 1, style)
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	for i, ra := range items {
+		row := i + 2
+
+		vulnTitle, severity, vulnStatus, projectName := "", "", "", ""
+		if ra.Vuln != nil {
+			vulnTitle = ra.Vuln.Title
+			severity = severityLabel(ra.Vuln.Severity)
+			vulnStatus = vulnStatusLabel(ra.Vuln.Status)
+			projectName = ra.Vuln.Project.Name
+		}
+
+		data := []interface{}{
+			ra.ID,
+			ra.VulnID,
+			vulnTitle,
+			severity,
+			vulnStatus,
+			projectName,
+			displayName(&ra.Requester),
+			displayName(&ra.Approver),
+			ra.Justification,
+			ra.CompensatingControls,
+			ra.CreatedAt.Format("2006-01-02 15:04:05"),
+			formatTime(ra.ReviewedAt),
+			ra.ExpiresAt.Format("2006-01-02"),
+			riskStatusLabels[ra.Status],
+			ra.ReviewComment,
+			formatTime(ra.ClosedAt),
+		}
+		for j, value := range data {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+
+	for i := range headers {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheetName, col, col, 18)
+	}
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, errors.New("生成Excel文件失败")
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,461 @@
+// 扫描结果导入服务包
+// 该包将扫描器输出的发现导入为待审核漏洞：按IP或域名匹配项目内的资产（可选自动创建），
+// 映射严重程度和CVE编号，跳过与未关闭漏洞重复的发现，并支持预览和逐条结果报告
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// maxScanImportFindings 单次导入的最大扫描发现数量
+const maxScanImportFindings = 5000
+
+// 扫描结果导入的逐条处理结果
+const (
+	ScanRowCreated   = "created"   // 已创建漏洞
+	ScanRowReady     = "ready"     // 预览：可以导入
+	ScanRowDuplicate = "duplicate" // 与未关闭漏洞或本次导入中的其他发现重复，已跳过
+	ScanRowFailed    = "failed"    // 解析或导入失败
+)
+
+// ScanImportRequest 扫描结果导入请求
+type ScanImportRequest struct {
+	ProjectID        uint   `form:"project_id" binding:"required"`
+	Format           string `form:"format"`             // 扫描结果格式，为空时根据文件内容识别
+	DryRun           bool   `form:"dry_run"`            // 仅预览，不创建资产和漏洞
+	AutoCreateAssets bool   `form:"auto_create_assets"` // 未匹配到资产的主机自动创建资产
+	IncludeInfo      bool   `form:"include_info"`       // 导入提示级别的发现，默认跳过
+	AssetID          uint   `form:"asset_id"`           // 默认资产，用于没有主机信息的发现（如SARIF代码扫描结果）
+	AssigneeID       uint   `form:"assignee_id"`        // 漏洞指派人，可选
+	Tags             string `form:"tags"`               // 附加到导入漏洞的标签
+}
+
+// ScanImportRow 单条扫描发现的导入结果
+type ScanImportRow struct {
+	Row          int    `json:"row"` // 在扫描结果中的序号
+	Title        string `json:"title"`
+	Host         string `json:"host"`
+	VulnURL      string `json:"vuln_url"`
+	Severity     string `json:"severity"`
+	CVEID        string `json:"cve_id"`
+	AssetID      uint   `json:"asset_id"`
+	AssetName    string `json:"asset_name"`
+	AssetCreated bool   `json:"asset_created"` // 资产由本次导入自动创建，预览时表示将会创建
+	Status       string `json:"status"`        // created、ready、duplicate、failed
+	VulnID       uint   `json:"vuln_id,omitempty"`
+	DuplicateOf  uint   `json:"duplicate_of,omitempty"` // 重复的未关闭漏洞ID，与本次导入的其他发现重复时为0
+	Message      string `json:"message,omitempty"`
+}
+
+// ScanImportResult 扫描结果导入结果
+type ScanImportResult struct {
+	Format        string          `json:"format"`
+	Source        string          `json:"source"`
+	DryRun        bool            `json:"dry_run"`
+	Total         int             `json:"total"`          // 扫描发现总数
+	Created       int             `json:"created"`        // 创建的漏洞数，预览时为可导入数
+	Duplicates    int             `json:"duplicates"`     // 重复跳过数
+	SkippedInfo   int             `json:"skipped_info"`   // 跳过的提示级别发现数
+	Failed        int             `json:"failed"`         // 失败数
+	AssetsCreated int             `json:"assets_created"` // 自动创建的资产数
+	Rows          []ScanImportRow `json:"rows"`
+}
+
+// scanAssetMatcher 按IP或域名匹配项目内的资产，并缓存自动创建的资产
+type scanAssetMatcher struct {
+	assets  []*models.Asset
+	created map[string]*models.Asset
+}
+
+// newScanAssetMatcher 加载项目内的资产
+func newScanAssetMatcher(db *gorm.DB, projectID uint) *scanAssetMatcher {
+	var assets []models.Asset
+	db.Where("project_id = ?", projectID).Find(&assets)
+	matcher := &scanAssetMatcher{created: map[string]*models.Asset{}}
+	for i := range assets {
+		matcher.assets = append(matcher.assets, &assets[i])
+	}
+	return matcher
+}
+
+// assetHostname 获取资产域名中的主机名，资产域名带有http或https前缀
+func assetHostname(domain string) string {
+	domain = strings.TrimSpace(domain)
+	if domain == "" {
+		return ""
+	}
+	if !strings.Contains(domain, "://") {
+		domain = "http://" + domain
+	}
+	u, err := url.Parse(domain)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// match 依次按IP、域名和资产名称匹配资产
+func (m *scanAssetMatcher) match(finding *ScanFinding) *models.Asset {
+	if finding.IP != "" {
+		for _, asset := range m.assets {
+			for _, ip := range strings.Split(asset.IP, ",") {
+				if strings.TrimSpace(ip) == finding.IP {
+					return asset
+				}
+			}
+		}
+	}
+	if finding.Hostname != "" {
+		for _, asset := range m.assets {
+			if assetHostname(asset.Domain) == finding.Hostname {
+				return asset
+			}
+		}
+	}
+	for _, asset := range m.assets {
+		if host := finding.Host(); host != "" && strings.EqualFold(asset.Name, host) {
+			return asset
+		}
+	}
+	return nil
+}
+
+// isScanDuplicateTarget 判断漏洞是否仍未关闭，已驳回的漏洞视为已关闭
+func isScanDuplicateTarget(status string) bool {
+	return !isVulnClosedStatus(status) && normalizeVulnStatus(status) != VulnStatusRejected
+}
+
+// scanDedupeKey 导入去重键：同一资产、地址、类型和CVE的指纹加上漏洞名称，避免同一端口上不同插件的发现被误判为重复
+func scanDedupeKey(fingerprint, title string) string {
+	return fingerprint + "|" + strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// ImportScanResults 导入扫描结果
+func (s *VulnService) ImportScanResults(file *multipart.FileHeader, req *ScanImportRequest, userID uint, userRole string) (*ScanImportResult, error) {
+	db := Init.GetDB()
+
+	// 验证项目是否存在、未过期且当前用户可访问
+	var project models.Project
+	if err := db.Where("id = ?", req.ProjectID).First(&project).Error; err != nil {
+		return nil, errors.New("项目不存在")
+	}
+	if project.EndDate != nil && time.Now().After(*project.EndDate) {
+		return nil, errors.New("项目已过期，无法导入漏洞")
+	}
+	if !canSubmitToProject(db, &project, userID, userRole) {
+		return nil, errors.New("无权限向该项目导入漏洞")
+	}
+
+	var defaultAsset *models.Asset
+	if req.AssetID != 0 {
+		var asset models.Asset
+		if err := db.Where("id = ? AND project_id = ?", req.AssetID, project.ID).First(&asset).Error; err != nil {
+			return nil, errors.New("默认资产不存在或不属于该项目")
+		}
+		defaultAsset = &asset
+	}
+	if req.AssigneeID != 0 {
+		var assignee models.User
+		if err := db.Where("id = ?", req.AssigneeID).First(&assignee).Error; err != nil {
+			return nil, errors.New("指定的分配人不存在")
+		}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("无法打开上传的文件")
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, errors.New("读取上传的文件失败")
+	}
+
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = DetectScanFormat(data)
+		if format == "" {
+			return nil, errors.New("无法识别扫描结果格式，请指定格式")
+		}
+	}
+	if scanFormatLabels[format] == "" {
+		return nil, errors.New("不支持的扫描结果格式")
+	}
+
+	findings, err := ParseScanResults(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(findings) == 0 {
+		return nil, errors.New("扫描结果中没有发现")
+	}
+	if len(findings) > maxScanImportFindings {
+		return nil, fmt.Errorf("单次最多导入%d条扫描发现", maxScanImportFindings)
+	}
+
+	result := &ScanImportResult{
+		Format: format,
+		Source: scanFormatLabels[format],
+		DryRun: req.DryRun,
+		Total:  len(findings),
+		Rows:   []ScanImportRow{},
+	}
+
+	// 扫描结果导入的漏洞统一进入待审核，工作流没有待审核状态时使用初始状态
+	status := VulnStatusPending
+	if workflow := resolveVulnWorkflow(db, project.ID); workflow.state(status) == nil {
+		status = workflow.Initial
+	}
+
+	matcher := newScanAssetMatcher(db, project.ID)
+	seen := map[string]bool{}
+	created := []models.Vulnerability{}
+	fileService := &FileService{}
+	for i := range findings {
+		finding := &findings[i]
+		if finding.Error == "" && finding.Severity == "info" && !req.IncludeInfo {
+			result.SkippedInfo++
+			continue
+		}
+
+		row := ScanImportRow{
+			Row:      finding.Row,
+			Title:    finding.Title,
+			Host:     finding.Host(),
+			VulnURL:  finding.URL,
+			Severity: finding.Severity,
+		}
+		if len(finding.CVEIDs) > 0 {
+			row.CVEID = finding.CVEIDs[0]
+		}
+
+		vuln, err := s.importScanFinding(db, finding, req, &project, defaultAsset, matcher, status, userID, result, &row, seen)
+		if err != nil {
+			row.Status = ScanRowFailed
+			row.Message = err.Error()
+			result.Failed++
+		} else if vuln != nil {
+			// 知识库文章预填的修复建议中的图片复制为漏洞自己的文件
+			fileService.BindVulnImages(vuln, userID, userRole)
+			created = append(created, *vuln)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if req.DryRun || len(created) == 0 {
+		return result, nil
+	}
+
+	// 更新项目统计信息
+	projectService := &ProjectService{}
+	if err := projectService.UpdateProjectStats(project.ID); err != nil {
+		fmt.Printf("更新项目统计失败 (项目ID: %d): %v\n", project.ID, err)
+	}
+
+	// 指派人汇总通知一次，避免逐条通知
+	if req.AssigneeID != 0 {
+		notificationService := &NotificationService{}
+		notificationService.NotifyVulnsBulkUpdated(req.AssigneeID, fmt.Sprintf("从%s扫描结果导入了%d个漏洞并分配给你", result.Source, len(created)), created)
+	}
+
+	webhookService := &WebhookService{}
+	for i := range created {
+		webhookService.Emit(WebhookVulnCreated, NewWebhookVulnData(&created[i], userID))
+	}
+
+	return result, nil
+}
+
+// importScanFinding 导入一条扫描发现，返回创建的漏洞；预览、重复或失败时返回nil
+func (s *VulnService) importScanFinding(db *gorm.DB, finding *ScanFinding, req *ScanImportRequest, project *models.Project, defaultAsset *models.Asset,
+	matcher *scanAssetMatcher, status string, userID uint, result *ScanImportResult, row *ScanImportRow, seen map[string]bool) (*models.Vulnerability, error) {
+	if finding.Error != "" {
+		return nil, errors.New(finding.Error)
+	}
+	if finding.Severity == "" {
+		return nil, errors.New("无法识别严重程度")
+	}
+
+	// 匹配资产：按主机匹配，其次使用本次导入已创建的资产，最后使用默认资产
+	host := finding.Host()
+	asset := matcher.match(finding)
+	if asset == nil && host != "" {
+		asset = matcher.created[host]
+		row.AssetCreated = asset != nil
+	}
+	if asset == nil && host == "" {
+		asset = defaultAsset
+	}
+	if asset == nil {
+		switch {
+		case host == "":
+			return nil, errors.New("扫描结果没有主机信息，请指定默认资产")
+		case !req.AutoCreateAssets:
+			return nil, fmt.Errorf("未找到主机 %s 对应的资产", host)
+		}
+		var err error
+		if asset, err = s.createScanAsset(db, finding, project, userID, result, req.DryRun); err != nil {
+			return nil, err
+		}
+		matcher.created[host] = asset
+		row.AssetCreated = true
+		result.AssetsCreated++
+	}
+	row.AssetID = asset.ID
+	row.AssetName = asset.Name
+
+	vuln := models.Vulnerability{
+		Title:         truncateRunes(finding.Title, 255),
+		VulnURL:       truncateRunes(finding.URL, 500),
+		Description:   finding.Description,
+		VulnType:      truncateRunes(finding.VulnType, 50),
+		Status:        status,
+		Source:        result.Source,
+		CVEID:         row.CVEID,
+		CVSSScore:     finding.CVSSScore,
+		CVSSVector:    finding.CVSSVector,
+		POC:           finding.Evidence,
+		References:    strings.Join(finding.References, "\n"),
+		FixSuggestion: finding.Solution,
+		ProjectID:     project.ID,
+		AssetID:       asset.ID,
+		ReporterID:    userID,
+		SubmittedAt:   time.Now().Truncate(time.Second),
+		Tags:          req.Tags,
+	}
+	if req.AssigneeID != 0 {
+		assigneeID := req.AssigneeID
+		vuln.AssigneeID = &assigneeID
+	}
+
+	// 根据本地CVE情报补充扫描器未提供的信息，以及KEV和EPSS信息
+	enrichNotes := enrichVulnFromCVE(db, &vuln)
+
+	// 按扫描器给出的漏洞类型和CWE编号归类
+	vuln.CategoryID = matchVulnCategory(db, vuln.VulnType, vuln.CWEID)
+
+	// 扫描器未给出修复建议时使用匹配的知识库文章
+	if strings.TrimSpace(vuln.FixSuggestion) == "" {
+		if article := matchKnowledgeArticle(db, vuln.VulnType, vuln.CategoryID, vuln.CWEID); article != nil {
+			vuln.FixSuggestion = article.Remediation
+		}
+	}
+
+	// 扫描器给出CVSS向量时按向量推导严重程度，向量无法解析时沿用扫描器的评级
+	if derived, err := s.applyCVSS(&vuln, asset); err != nil {
+		vuln.CVSSVector = ""
+		s.applyCVSS(&vuln, asset)
+		vuln.Severity = finding.Severity
+	} else if vuln.CVSSVector != "" && derived != "" {
+		vuln.Severity = derived
+	} else {
+		vuln.Severity = finding.Severity
+	}
+	row.Severity = vuln.Severity
+	applyVulnRisk(&vuln, asset)
+
+	// 去重：与本次导入的其他发现或未关闭的漏洞重复时跳过
+	vuln.Fingerprint = VulnFingerprint(vuln.AssetID, vuln.VulnURL, vuln.VulnType, vuln.CVEID)
+	key := scanDedupeKey(vuln.Fingerprint, vuln.Title)
+	if seen[key] {
+		row.Status = ScanRowDuplicate
+		row.Message = "与本次导入的其他发现重复"
+		result.Duplicates++
+		return nil, nil
+	}
+	seen[key] = true
+	if asset.ID != 0 {
+		var existing []models.Vulnerability
+		db.Select("id, title, status").Where("fingerprint = ?", vuln.Fingerprint).Order("id desc").Find(&existing)
+		for _, other := range existing {
+			if isScanDuplicateTarget(other.Status) && scanDedupeKey(vuln.Fingerprint, other.Title) == key {
+				row.Status = ScanRowDuplicate
+				row.DuplicateOf = other.ID
+				row.Message = fmt.Sprintf("与未关闭的漏洞#%d重复", other.ID)
+				result.Duplicates++
+				return nil, nil
+			}
+		}
+	}
+
+	if req.DryRun {
+		row.Status = ScanRowReady
+		result.Created++
+		return nil, nil
+	}
+
+	slaNote := trackVulnSLA(db, &vuln, vuln.Status, vuln.SubmittedAt)
+	if err := db.Create(&vuln).Error; err != nil {
+		return nil, errors.New("创建漏洞失败")
+	}
+
+	s.addTimeline(vuln.ID, userID, "created", fmt.Sprintf("漏洞由%s扫描结果导入", result.Source))
+	if len(enrichNotes) > 0 {
+		s.addTimeline(vuln.ID, userID, "enriched", cveEnrichNote(enrichNotes))
+	}
+	if vuln.AssigneeID != nil {
+		s.addTimeline(vuln.ID, userID, "assigned", "漏洞已分配")
+	}
+	if slaNote != "" {
+		s.addTimeline(vuln.ID, userID, "sla", slaNote)
+	}
+	// 与已完成漏洞指纹一致时标记为回归
+	s.detectDuplicates(db, &vuln, userID)
+
+	row.Status = ScanRowCreated
+	row.VulnID = vuln.ID
+	result.Created++
+	return &vuln, nil
+}
+
+// createScanAsset 为未匹配到资产的主机自动创建资产，预览时只返回待创建的资产
+func (s *VulnService) createScanAsset(db *gorm.DB, finding *ScanFinding, project *models.Project, userID uint, result *ScanImportResult, dryRun bool) (*models.Asset, error) {
+	asset := models.Asset{
+		Name:        finding.Host(),
+		Type:        "server",
+		IP:          finding.IP,
+		Port:        finding.Port,
+		Importance:  "medium", // 默认中等重要性
+		ProjectID:   project.ID,
+		CreatedBy:   userID,
+		Tags:        "scanner",
+		Description: fmt.Sprintf("导入%s扫描结果时自动创建", result.Source),
+		Status:      "active",
+	}
+	if finding.Hostname != "" {
+		scheme := "http://"
+		if strings.HasPrefix(strings.ToLower(finding.URL), "https://") || finding.Port == "443" {
+			scheme = "https://"
+		}
+		asset.Domain = scheme + finding.Hostname
+	}
+	if dryRun {
+		return &asset, nil
+	}
+
+	if err := db.Create(&asset).Error; err != nil {
+		return nil, fmt.Errorf("自动创建资产 %s 失败", asset.Name)
+	}
+	assetService := &AssetService{}
+	assetService.addAuditLog(asset.ID, "import", "", "", userID, "", "")
+	return &asset, nil
+}
@@ -0,0 +1,920 @@
+// 扫描结果解析服务包
+// 该包将Nessus、OpenVAS、Burp Suite、Nuclei、OWASP ZAP和SARIF格式的扫描结果
+// 解析为统一的扫描发现，供扫描结果导入使用
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"vulnmain/utils"
+)
+
+// 支持的扫描结果格式
+const (
+	ScanFormatNessus  = "nessus"
+	ScanFormatOpenVAS = "openvas"
+	ScanFormatBurp    = "burp"
+	ScanFormatNuclei  = "nuclei"
+	ScanFormatZAP     = "zap"
+	ScanFormatSARIF   = "sarif"
+)
+
+// scanFormatLabels 扫描结果格式对应的扫描器名称，作为漏洞来源
+var scanFormatLabels = map[string]string{
+	ScanFormatNessus:  "Nessus",
+	ScanFormatOpenVAS: "OpenVAS",
+	ScanFormatBurp:    "Burp Suite",
+	ScanFormatNuclei:  "Nuclei",
+	ScanFormatZAP:     "OWASP ZAP",
+	ScanFormatSARIF:   "SARIF",
+}
+
+var (
+	cvePattern     = regexp.MustCompile(`(?i)CVE-\d{4}-\d{4,}`)
+	htmlTagPattern = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+	hrefPattern    = regexp.MustCompile(`href="([^"]+)"`)
+)
+
+// ScanFinding 解析后的扫描发现
+type ScanFinding struct {
+	Row         int      // 在扫描结果中的序号，从1开始
+	Title       string   // 漏洞名称
+	IP          string   // 主机IP
+	Hostname    string   // 主机域名
+	Port        string   // 端口
+	URL         string   // 漏洞地址
+	Severity    string   // 映射后的严重程度
+	CVEIDs      []string // CVE编号
+	CVSSScore   float64  // 扫描器给出的CVSS评分
+	CVSSVector  string   // 扫描器给出的CVSS向量
+	VulnType    string   // 漏洞类型，取扫描器的插件族、模板ID或规则名称
+	Description string   // 漏洞描述
+	Solution    string   // 修复建议
+	References  []string // 参考链接
+	Evidence    string   // 扫描器输出的验证信息
+	Error       string   // 该条结果解析失败的原因
+}
+
+// Host 主机标识，优先使用域名
+func (f *ScanFinding) Host() string {
+	if f.Hostname != "" {
+		return f.Hostname
+	}
+	return f.IP
+}
+
+// flexString 兼容JSON中字符串和数字两种写法的字段
+type flexString string
+
+func (v *flexString) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = flexString(n.String())
+	return nil
+}
+
+// flexStrings 兼容JSON中单个字符串和字符串数组两种写法的字段
+type flexStrings []string
+
+func (v *flexStrings) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*v = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s != "" {
+		*v = strings.Split(s, ",")
+	}
+	return nil
+}
+
+// DetectScanFormat 根据文件内容识别扫描结果格式
+func DetectScanFormat(data []byte) string {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return ""
+	}
+
+	switch data[0] {
+	case '<':
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return ""
+			}
+			if start, ok := token.(xml.StartElement); ok {
+				switch start.Name.Local {
+				case "NessusClientData_v2":
+					return ScanFormatNessus
+				case "issues":
+					return ScanFormatBurp
+				case "report", "get_reports_response":
+					return ScanFormatOpenVAS
+				}
+				return ""
+			}
+		}
+	case '[':
+		return ScanFormatNuclei
+	case '{':
+		// JSONL只解析第一行
+		var first map[string]json.RawMessage
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(&first); err != nil {
+			return ""
+		}
+		switch {
+		case first["runs"] != nil:
+			return ScanFormatSARIF
+		case first["site"] != nil:
+			return ScanFormatZAP
+		case first["template-id"] != nil || first["template"] != nil:
+			return ScanFormatNuclei
+		}
+	}
+	return ""
+}
+
+// ParseScanResults 按格式解析扫描结果
+func ParseScanResults(format string, data []byte) ([]ScanFinding, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var findings []ScanFinding
+	var err error
+	switch format {
+	case ScanFormatNessus:
+		findings, err = parseNessus(data)
+	case ScanFormatOpenVAS:
+		findings, err = parseOpenVAS(data)
+	case ScanFormatBurp:
+		findings, err = parseBurp(data)
+	case ScanFormatNuclei:
+		findings, err = parseNuclei(data)
+	case ScanFormatZAP:
+		findings, err = parseZAP(data)
+	case ScanFormatSARIF:
+		findings, err = parseSARIF(data)
+	default:
+		return nil, errors.New("不支持的扫描结果格式")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range findings {
+		finding := &findings[i]
+		if finding.Row == 0 {
+			finding.Row = i + 1
+		}
+		finding.Title = strings.TrimSpace(finding.Title)
+		finding.CVEIDs = normalizeCVEIDs(finding.CVEIDs)
+		if finding.Error == "" && finding.Title == "" {
+			finding.Error = "缺少漏洞名称"
+		}
+	}
+	return findings, nil
+}
+
+// normalizeCVEIDs 规范化并去重CVE编号
+func normalizeCVEIDs(ids []string) []string {
+	result := []string{}
+	for _, id := range ids {
+		for _, match := range cvePattern.FindAllString(id, -1) {
+			match = strings.ToUpper(match)
+			if !contains(result, match) {
+				result = append(result, match)
+			}
+		}
+	}
+	return result
+}
+
+// stripHTML 去除扫描器输出中的HTML标签，保留段落换行
+func stripHTML(s string) string {
+	s = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n", "</li>", "\n").Replace(s)
+	s = html.UnescapeString(htmlTagPattern.ReplaceAllString(s, ""))
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// joinSections 拼接非空的内容段落
+func joinSections(sections ...string) string {
+	result := []string{}
+	for _, section := range sections {
+		if section = strings.TrimSpace(section); section != "" {
+			result = append(result, section)
+		}
+	}
+	return strings.Join(result, "\n\n")
+}
+
+// parseScore 解析评分，无效时返回0
+func parseScore(s string) float64 {
+	score, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || score < 0 || score > 10 {
+		return 0
+	}
+	return score
+}
+
+// severityFromLabel 将扫描器的风险等级映射为系统严重程度
+func severityFromLabel(label string) string {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "critical", "严重":
+		return "critical"
+	case "high", "高", "高危":
+		return "high"
+	case "medium", "moderate", "中", "中危":
+		return "medium"
+	case "low", "低", "低危":
+		return "low"
+	case "info", "information", "informational", "none", "log", "debug", "提示":
+		return "info"
+	}
+	return ""
+}
+
+// splitHost 从URL或主机地址中拆分主机和端口
+func splitHost(raw string) (host, port string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ""
+	}
+	return u.Hostname(), u.Port()
+}
+
+// assignHost 根据主机地址设置扫描发现的IP或域名
+func (f *ScanFinding) assignHost(host string) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return
+	}
+	if net.ParseIP(host) != nil {
+		if f.IP == "" {
+			f.IP = host
+		}
+		return
+	}
+	if f.Hostname == "" {
+		f.Hostname = strings.ToLower(host)
+	}
+}
+
+// decodeXMLElements 流式解析XML，对所有指定名称的元素调用fn
+func decodeXMLElements(data []byte, name string, fn func(decoder *xml.Decoder, start *xml.StartElement) error) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			if err := fn(decoder, &start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseNessus 解析Nessus的.nessus（v2）文件
+func parseNessus(data []byte) ([]ScanFinding, error) {
+	type nessusItem struct {
+		Port         string   `xml:"port,attr"`
+		Protocol     string   `xml:"protocol,attr"`
+		Severity     string   `xml:"severity,attr"`
+		PluginID     string   `xml:"pluginID,attr"`
+		PluginName   string   `xml:"pluginName,attr"`
+		PluginFamily string   `xml:"pluginFamily,attr"`
+		Synopsis     string   `xml:"synopsis"`
+		Description  string   `xml:"description"`
+		Solution     string   `xml:"solution"`
+		RiskFactor   string   `xml:"risk_factor"`
+		CVEs         []string `xml:"cve"`
+		CVSS3Vector  string   `xml:"cvss3_vector"`
+		CVSS3Score   string   `xml:"cvss3_base_score"`
+		CVSSScore    string   `xml:"cvss_base_score"`
+		SeeAlso      string   `xml:"see_also"`
+		PluginOutput string   `xml:"plugin_output"`
+	}
+	type nessusHost struct {
+		Name       string `xml:"name,attr"`
+		Properties []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"HostProperties>tag"`
+		Items []nessusItem `xml:"ReportItem"`
+	}
+
+	findings := []ScanFinding{}
+	err := decodeXMLElements(data, "ReportHost", func(decoder *xml.Decoder, start *xml.StartElement) error {
+		var host nessusHost
+		if err := decoder.DecodeElement(&host, start); err != nil {
+			return err
+		}
+		properties := map[string]string{}
+		for _, property := range host.Properties {
+			properties[property.Name] = strings.TrimSpace(property.Value)
+		}
+
+		for _, item := range host.Items {
+			finding := ScanFinding{
+				Title:       item.PluginName,
+				VulnType:    item.PluginFamily,
+				CVEIDs:      item.CVEs,
+				Description: joinSections(item.Synopsis, item.Description),
+				Solution:    strings.TrimSpace(item.Solution),
+				References:  strings.Fields(item.SeeAlso),
+				Evidence:    strings.TrimSpace(item.PluginOutput),
+			}
+			finding.assignHost(properties["host-ip"])
+			finding.assignHost(properties["host-fqdn"])
+			finding.assignHost(host.Name)
+			if item.Port != "" && item.Port != "0" {
+				finding.Port = item.Port
+				finding.URL = net.JoinHostPort(finding.Host(), item.Port)
+			} else {
+				finding.URL = finding.Host()
+			}
+
+			if strings.HasPrefix(item.CVSS3Vector, "CVSS:3") {
+				finding.CVSSVector = strings.TrimSpace(item.CVSS3Vector)
+			}
+			finding.CVSSScore = parseScore(item.CVSS3Score)
+			if finding.CVSSScore == 0 {
+				finding.CVSSScore = parseScore(item.CVSSScore)
+			}
+
+			// severity属性：0信息、1低危、2中危、3高危、4严重
+			switch item.Severity {
+			case "0":
+				finding.Severity = "info"
+			case "1":
+				finding.Severity = "low"
+			case "2":
+				finding.Severity = "medium"
+			case "3":
+				finding.Severity = "high"
+			case "4":
+				finding.Severity = "critical"
+			default:
+				finding.Severity = severityFromLabel(item.RiskFactor)
+			}
+			findings = append(findings, finding)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解析Nessus文件失败: %v", err)
+	}
+	return findings, nil
+}
+
+// parseOpenVAS 解析OpenVAS/GVM导出的XML报告
+func parseOpenVAS(data []byte) ([]ScanFinding, error) {
+	type openvasResult struct {
+		Name string `xml:"name"`
+		Host struct {
+			IP       string `xml:",chardata"`
+			Hostname string `xml:"hostname"`
+		} `xml:"host"`
+		Port        string `xml:"port"`
+		Threat      string `xml:"threat"`
+		Severity    string `xml:"severity"`
+		Description string `xml:"description"`
+		NVT         struct {
+			OID      string `xml:"oid,attr"`
+			Name     string `xml:"name"`
+			Family   string `xml:"family"`
+			CVSSBase string `xml:"cvss_base"`
+			CVE      string `xml:"cve"`
+			Tags     string `xml:"tags"`
+			Solution string `xml:"solution"`
+			Refs     []struct {
+				Type string `xml:"type,attr"`
+				ID   string `xml:"id,attr"`
+			} `xml:"refs>ref"`
+			Severities []struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:"value"`
+			} `xml:"severities>severity"`
+		} `xml:"nvt"`
+	}
+
+	findings := []ScanFinding{}
+	err := decodeXMLElements(data, "result", func(decoder *xml.Decoder, start *xml.StartElement) error {
+		var result openvasResult
+		if err := decoder.DecodeElement(&result, start); err != nil {
+			return err
+		}
+
+		// tags格式为"key=value|key=value"
+		tags := map[string]string{}
+		for _, tag := range strings.Split(result.NVT.Tags, "|") {
+			if parts := strings.SplitN(tag, "=", 2); len(parts) == 2 {
+				tags[parts[0]] = strings.TrimSpace(parts[1])
+			}
+		}
+
+		finding := ScanFinding{
+			Title:       result.Name,
+			VulnType:    result.NVT.Family,
+			Description: joinSections(tags["summary"], tags["insight"], tags["impact"]),
+			Solution:    strings.TrimSpace(result.NVT.Solution),
+			Evidence:    strings.TrimSpace(result.Description),
+			CVEIDs:      []string{result.NVT.CVE},
+		}
+		if finding.Title == "" {
+			finding.Title = result.NVT.Name
+		}
+		if finding.Solution == "" {
+			finding.Solution = tags["solution"]
+		}
+		for _, ref := range result.NVT.Refs {
+			switch strings.ToLower(ref.Type) {
+			case "cve":
+				finding.CVEIDs = append(finding.CVEIDs, ref.ID)
+			case "url":
+				finding.References = append(finding.References, ref.ID)
+			}
+		}
+		for _, severity := range result.NVT.Severities {
+			if value := strings.TrimSpace(severity.Value); strings.HasPrefix(value, "CVSS:3") || strings.HasPrefix(value, "CVSS:4") {
+				finding.CVSSVector = value
+			}
+		}
+
+		finding.assignHost(result.Host.IP)
+		finding.assignHost(result.Host.Hostname)
+		// 端口格式为"443/tcp"，非数字端口（如general/tcp）忽略
+		if port := strings.SplitN(result.Port, "/", 2)[0]; port != "" {
+			if _, err := strconv.Atoi(port); err == nil {
+				finding.Port = port
+			}
+		}
+		finding.URL = finding.Host()
+		if finding.Port != "" {
+			finding.URL = net.JoinHostPort(finding.Host(), finding.Port)
+		}
+
+		finding.CVSSScore = parseScore(result.Severity)
+		if finding.CVSSScore == 0 {
+			finding.CVSSScore = parseScore(result.NVT.CVSSBase)
+		}
+		finding.Severity = severityFromLabel(result.Threat)
+		if finding.CVSSScore > 0 {
+			// OpenVAS的High涵盖7.0-10.0，按评分区分严重和高危
+			finding.Severity = utils.CVSSSeverity(finding.CVSSScore)
+		}
+		findings = append(findings, finding)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解析OpenVAS报告失败: %v", err)
+	}
+	return findings, nil
+}
+
+// parseBurp 解析Burp Suite导出的XML问题报告
+func parseBurp(data []byte) ([]ScanFinding, error) {
+	type burpIssue struct {
+		Name string `xml:"name"`
+		Host struct {
+			IP  string `xml:"ip,attr"`
+			URL string `xml:",chardata"`
+		} `xml:"host"`
+		Path                         string `xml:"path"`
+		Location                     string `xml:"location"`
+		Severity                     string `xml:"severity"`
+		Confidence                   string `xml:"confidence"`
+		IssueBackground              string `xml:"issueBackground"`
+		RemediationBackground        string `xml:"remediationBackground"`
+		IssueDetail                  string `xml:"issueDetail"`
+		RemediationDetail            string `xml:"remediationDetail"`
+		VulnerabilityClassifications string `xml:"vulnerabilityClassifications"`
+		References                   string `xml:"references"`
+	}
+
+	findings := []ScanFinding{}
+	err := decodeXMLElements(data, "issue", func(decoder *xml.Decoder, start *xml.StartElement) error {
+		var issue burpIssue
+		if err := decoder.DecodeElement(&issue, start); err != nil {
+			return err
+		}
+
+		finding := ScanFinding{
+			Title:       issue.Name,
+			VulnType:    issue.Name,
+			Description: stripHTML(issue.IssueBackground),
+			Solution:    stripHTML(joinSections(issue.RemediationDetail, issue.RemediationBackground)),
+			Evidence:    stripHTML(issue.IssueDetail),
+			Severity:    severityFromLabel(issue.Severity),
+		}
+		if strings.EqualFold(issue.Severity, "false positive") || strings.EqualFold(issue.Confidence, "false positive") {
+			finding.Error = "Burp已标记为误报"
+		}
+
+		base := strings.TrimRight(strings.TrimSpace(issue.Host.URL), "/")
+		host, port := splitHost(base)
+		finding.assignHost(strings.TrimSpace(issue.Host.IP))
+		finding.assignHost(host)
+		finding.Port = port
+		finding.URL = base + strings.TrimSpace(issue.Path)
+		finding.CVEIDs = cvePattern.FindAllString(issue.IssueDetail+issue.VulnerabilityClassifications+issue.References, -1)
+		for _, match := range hrefPattern.FindAllStringSubmatch(issue.References, -1) {
+			finding.References = append(finding.References, html.UnescapeString(match[1]))
+		}
+		findings = append(findings, finding)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解析Burp报告失败: %v", err)
+	}
+	return findings, nil
+}
+
+// nucleiResult Nuclei的JSON输出
+type nucleiResult struct {
+	TemplateID string `json:"template-id"`
+	Info       struct {
+		Name           string      `json:"name"`
+		Severity       string      `json:"severity"`
+		Description    string      `json:"description"`
+		Remediation    string      `json:"remediation"`
+		Reference      flexStrings `json:"reference"`
+		Classification struct {
+			CVEID       flexStrings `json:"cve-id"`
+			CVSSMetrics string      `json:"cvss-metrics"`
+			CVSSScore   flexString  `json:"cvss-score"`
+		} `json:"classification"`
+	} `json:"info"`
+	Host             string     `json:"host"`
+	MatchedAt        string     `json:"matched-at"`
+	IP               string     `json:"ip"`
+	Port             flexString `json:"port"`
+	MatcherName      string     `json:"matcher-name"`
+	ExtractedResults []string   `json:"extracted-results"`
+	CurlCommand      string     `json:"curl-command"`
+}
+
+// parseNuclei 解析Nuclei的JSONL输出，同时兼容-json-export导出的JSON数组
+func parseNuclei(data []byte) ([]ScanFinding, error) {
+	data = bytes.TrimSpace(data)
+	findings := []ScanFinding{}
+
+	if len(data) > 0 && data[0] == '[' {
+		var results []nucleiResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("解析Nuclei结果失败: %v", err)
+		}
+		for i := range results {
+			finding := nucleiFinding(&results[i])
+			finding.Row = i + 1
+			findings = append(findings, finding)
+		}
+		return findings, nil
+	}
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var result nucleiResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			findings = append(findings, ScanFinding{Row: i + 1, Error: fmt.Sprintf("第%d行JSON格式错误: %v", i+1, err)})
+			continue
+		}
+		finding := nucleiFinding(&result)
+		finding.Row = i + 1
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// nucleiFinding 将一条Nuclei结果转换为扫描发现
+func nucleiFinding(result *nucleiResult) ScanFinding {
+	finding := ScanFinding{
+		Title:       result.Info.Name,
+		VulnType:    result.TemplateID,
+		Severity:    severityFromLabel(result.Info.Severity),
+		CVEIDs:      result.Info.Classification.CVEID,
+		CVSSScore:   parseScore(string(result.Info.Classification.CVSSScore)),
+		Description: strings.TrimSpace(result.Info.Description),
+		Solution:    strings.TrimSpace(result.Info.Remediation),
+		References:  result.Info.Reference,
+		URL:         result.MatchedAt,
+	}
+	if finding.Title == "" {
+		finding.Title = result.TemplateID
+	}
+	if result.MatcherName != "" {
+		finding.Title += " (" + result.MatcherName + ")"
+	}
+	if strings.HasPrefix(result.Info.Classification.CVSSMetrics, "CVSS:3") {
+		finding.CVSSVector = result.Info.Classification.CVSSMetrics
+	}
+	if finding.URL == "" {
+		finding.URL = result.Host
+	}
+
+	host, port := splitHost(result.Host)
+	finding.assignHost(result.IP)
+	finding.assignHost(host)
+	finding.Port = string(result.Port)
+	if finding.Port == "" {
+		finding.Port = port
+	}
+
+	evidence := []string{}
+	if len(result.ExtractedResults) > 0 {
+		evidence = append(evidence, "提取结果：\n"+strings.Join(result.ExtractedResults, "\n"))
+	}
+	if result.CurlCommand != "" {
+		evidence = append(evidence, "复现命令：\n"+result.CurlCommand)
+	}
+	finding.Evidence = joinSections(evidence...)
+	return finding
+}
+
+// parseZAP 解析OWASP ZAP导出的JSON报告，同一告警的多个实例合并为一条
+func parseZAP(data []byte) ([]ScanFinding, error) {
+	type zapAlert struct {
+		Name       string `json:"name"`
+		Alert      string `json:"alert"`
+		RiskCode   string `json:"riskcode"`
+		Confidence string `json:"confidence"`
+		Desc       string `json:"desc"`
+		Solution   string `json:"solution"`
+		OtherInfo  string `json:"otherinfo"`
+		Reference  string `json:"reference"`
+		CWEID      string `json:"cweid"`
+		Instances  []struct {
+			URI      string `json:"uri"`
+			Method   string `json:"method"`
+			Param    string `json:"param"`
+			Evidence string `json:"evidence"`
+		} `json:"instances"`
+	}
+	type zapSite struct {
+		Name   string     `json:"@name"`
+		Host   string     `json:"@host"`
+		Port   string     `json:"@port"`
+		Alerts []zapAlert `json:"alerts"`
+	}
+	var report struct {
+		Site json.RawMessage `json:"site"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("解析ZAP报告失败: %v", err)
+	}
+
+	// 旧版本只有一个站点时site为对象
+	var sites []zapSite
+	if site := bytes.TrimSpace(report.Site); len(site) > 0 && site[0] == '{' {
+		sites = make([]zapSite, 1)
+		if err := json.Unmarshal(site, &sites[0]); err != nil {
+			return nil, fmt.Errorf("解析ZAP报告失败: %v", err)
+		}
+	} else if len(site) > 0 {
+		if err := json.Unmarshal(site, &sites); err != nil {
+			return nil, fmt.Errorf("解析ZAP报告失败: %v", err)
+		}
+	}
+
+	findings := []ScanFinding{}
+	for _, site := range sites {
+		for _, alert := range site.Alerts {
+			finding := ScanFinding{
+				Title:       alert.Name,
+				VulnType:    alert.Name,
+				Description: stripHTML(alert.Desc),
+				Solution:    stripHTML(alert.Solution),
+				URL:         site.Name,
+				Port:        site.Port,
+			}
+			if finding.Title == "" {
+				finding.Title = alert.Alert
+				finding.VulnType = alert.Alert
+			}
+			if alert.CWEID != "" && alert.CWEID != "-1" && alert.CWEID != "0" {
+				finding.VulnType = "CWE-" + alert.CWEID
+			}
+			finding.assignHost(site.Host)
+
+			// riskcode：0信息、1低危、2中危、3高危；confidence为0表示误报
+			switch alert.RiskCode {
+			case "0":
+				finding.Severity = "info"
+			case "1":
+				finding.Severity = "low"
+			case "2":
+				finding.Severity = "medium"
+			case "3":
+				finding.Severity = "high"
+			}
+			if alert.Confidence == "0" {
+				finding.Error = "ZAP已标记为误报"
+			}
+
+			instances := []string{}
+			for i, instance := range alert.Instances {
+				if i == 0 && instance.URI != "" {
+					finding.URL = instance.URI
+				}
+				if i >= 20 {
+					instances = append(instances, fmt.Sprintf("……共%d处", len(alert.Instances)))
+					break
+				}
+				line := strings.TrimSpace(instance.Method + " " + instance.URI)
+				if instance.Param != "" {
+					line += " 参数: " + instance.Param
+				}
+				if instance.Evidence != "" {
+					line += " 证据: " + instance.Evidence
+				}
+				instances = append(instances, line)
+			}
+			if len(instances) > 0 {
+				finding.Evidence = "影响位置：\n" + strings.Join(instances, "\n")
+			}
+			finding.Evidence = joinSections(finding.Evidence, stripHTML(alert.OtherInfo))
+			finding.References = strings.Fields(stripHTML(alert.Reference))
+			finding.CVEIDs = cvePattern.FindAllString(alert.Reference+alert.OtherInfo, -1)
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// parseSARIF 解析SARIF 2.1.0格式的结果，代码扫描结果没有主机信息，需要导入时指定资产
+func parseSARIF(data []byte) ([]ScanFinding, error) {
+	type sarifMessage struct {
+		Text     string `json:"text"`
+		Markdown string `json:"markdown"`
+	}
+	type sarifProperties struct {
+		SecuritySeverity flexString `json:"security-severity"`
+		Tags             []string   `json:"tags"`
+	}
+	type sarifRule struct {
+		ID                   string          `json:"id"`
+		Name                 string          `json:"name"`
+		ShortDescription     sarifMessage    `json:"shortDescription"`
+		FullDescription      sarifMessage    `json:"fullDescription"`
+		Help                 sarifMessage    `json:"help"`
+		HelpURI              string          `json:"helpUri"`
+		Properties           sarifProperties `json:"properties"`
+		DefaultConfiguration struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	var report struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Name  string      `json:"name"`
+					Rules []sarifRule `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID     string          `json:"ruleId"`
+				RuleIndex  *int            `json:"ruleIndex"`
+				Level      string          `json:"level"`
+				Message    sarifMessage    `json:"message"`
+				Properties sarifProperties `json:"properties"`
+				Locations  []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("解析SARIF文件失败: %v", err)
+	}
+
+	findings := []ScanFinding{}
+	for _, run := range report.Runs {
+		rules := map[string]*sarifRule{}
+		for i := range run.Tool.Driver.Rules {
+			rules[run.Tool.Driver.Rules[i].ID] = &run.Tool.Driver.Rules[i]
+		}
+
+		for _, result := range run.Results {
+			rule := rules[result.RuleID]
+			if rule == nil && result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(run.Tool.Driver.Rules) {
+				rule = &run.Tool.Driver.Rules[*result.RuleIndex]
+			}
+			if rule == nil {
+				rule = &sarifRule{ID: result.RuleID}
+			}
+
+			finding := ScanFinding{
+				Title:    rule.ShortDescription.Text,
+				VulnType: rule.Name,
+			}
+			if finding.Title == "" {
+				finding.Title = rule.Name
+			}
+			if finding.Title == "" {
+				finding.Title = rule.ID
+			}
+			if finding.VulnType == "" {
+				finding.VulnType = rule.ID
+			}
+			if run.Tool.Driver.Name != "" {
+				finding.Title = fmt.Sprintf("[%s] %s", run.Tool.Driver.Name, finding.Title)
+			}
+			finding.Description = joinSections(result.Message.Text, rule.FullDescription.Text)
+			finding.Solution = rule.Help.Markdown
+			if finding.Solution == "" {
+				finding.Solution = rule.Help.Text
+			}
+			if rule.HelpURI != "" {
+				finding.References = []string{rule.HelpURI}
+			}
+			finding.CVEIDs = cvePattern.FindAllString(strings.Join(append(rule.Properties.Tags, rule.ID, result.Message.Text), " "), -1)
+
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				finding.URL = location.ArtifactLocation.URI
+				if location.Region.StartLine > 0 {
+					finding.URL = fmt.Sprintf("%s:%d", finding.URL, location.Region.StartLine)
+				}
+			}
+
+			// 优先使用security-severity评分，其次按告警级别映射
+			score := parseScore(string(result.Properties.SecuritySeverity))
+			if score == 0 {
+				score = parseScore(string(rule.Properties.SecuritySeverity))
+			}
+			level := result.Level
+			if level == "" {
+				level = rule.DefaultConfiguration.Level
+			}
+			switch {
+			case score > 0:
+				finding.CVSSScore = score
+				finding.Severity = utils.CVSSSeverity(score)
+			case level == "error":
+				finding.Severity = "high"
+			case level == "warning", level == "":
+				finding.Severity = "medium"
+			case level == "note":
+				finding.Severity = "low"
+			default:
+				finding.Severity = "info"
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// wantFinding 扫描发现中需要校验的字段
+type wantFinding struct {
+	Title      string
+	IP         string
+	Hostname   string
+	Port       string
+	URL        string
+	Severity   string
+	CVEIDs     []string
+	CVSSScore  float64
+	CVSSVector string
+	VulnType   string
+	References []string
+	Error      string
+}
+
+func TestParseScanResults(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		want   []wantFinding
+	}{
+		{
+			file:   "sample.nessus",
+			format: ScanFormatNessus,
+			want: []wantFinding{
+				{
+					Title: "Apache Log4j RCE (Log4Shell)", IP: "10.0.0.5", Hostname: "web01.example.com", Port: "443",
+					URL: "web01.example.com:443", Severity: "critical", CVEIDs: []string{"CVE-2021-44228"},
+					CVSSScore: 10, CVSSVector: "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", VulnType: "Misc.",
+					References: []string{"https://logging.apache.org/log4j/2.x/security.html", "https://www.cve.org/CVERecord?id=CVE-2021-44228"},
+				},
+				{
+					Title: "Nessus Scan Information", IP: "10.0.0.5", Hostname: "web01.example.com",
+					URL: "web01.example.com", Severity: "info", CVEIDs: []string{}, VulnType: "Settings",
+				},
+			},
+		},
+		{
+			file:   "sample_openvas.xml",
+			format: ScanFormatOpenVAS,
+			want: []wantFinding{
+				{
+					Title: "OpenSSH Multiple Vulnerabilities", IP: "192.168.1.20", Hostname: "db01.example.com", Port: "22",
+					URL: "db01.example.com:22", Severity: "critical", CVEIDs: []string{"CVE-2024-6387"},
+					CVSSScore: 9.8, CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", VulnType: "General",
+					References: []string{"https://www.openssh.com/txt/release-9.8"},
+				},
+				{
+					Title: "OS Detection Consolidation and Reporting", IP: "192.168.1.20",
+					URL: "192.168.1.20", Severity: "info", CVEIDs: []string{}, VulnType: "Product detection",
+				},
+			},
+		},
+		{
+			file:   "sample_burp.xml",
+			format: ScanFormatBurp,
+			want: []wantFinding{
+				{
+					Title: "SQL injection", IP: "203.0.113.10", Hostname: "shop.example.com", Port: "8443",
+					URL: "https://shop.example.com:8443/api/items", Severity: "high", CVEIDs: []string{}, VulnType: "SQL injection",
+					References: []string{"https://portswigger.net/web-security/sql-injection"},
+				},
+				{
+					Title: "Cross-site scripting (reflected)", IP: "203.0.113.10", Hostname: "shop.example.com", Port: "8443",
+					URL: "https://shop.example.com:8443/search", CVEIDs: []string{}, VulnType: "Cross-site scripting (reflected)",
+					Error: "Burp已标记为误报",
+				},
+			},
+		},
+		{
+			file:   "sample_nuclei.jsonl",
+			format: ScanFormatNuclei,
+			want: []wantFinding{
+				{
+					Title: "Apache 2.4.49 - Path Traversal", IP: "198.51.100.7", Hostname: "app.example.com", Port: "443",
+					URL: "https://app.example.com/cgi-bin/.%2e/etc/passwd", Severity: "critical", CVEIDs: []string{"CVE-2021-41773"},
+					CVSSScore: 9.8, CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", VulnType: "CVE-2021-41773",
+					References: []string{"https://httpd.apache.org/security/vulnerabilities_24.html"},
+				},
+				{
+					Title: "Wappalyzer Technology Detection (nginx)", Hostname: "app.example.com", Port: "8080",
+					URL: "app.example.com:8080", Severity: "info", CVEIDs: []string{}, VulnType: "tech-detect",
+					References: []string{"https://github.com/projectdiscovery/nuclei-templates"},
+				},
+				{
+					CVEIDs: []string{},
+					Error:  "第3行JSON格式错误: invalid character 'n' looking for beginning of object key string",
+				},
+			},
+		},
+		{
+			file:   "sample_zap.json",
+			format: ScanFormatZAP,
+			want: []wantFinding{
+				{
+					Title: "Content Security Policy (CSP) Header Not Set", Hostname: "portal.example.com", Port: "443",
+					URL: "https://portal.example.com/login", Severity: "medium", CVEIDs: []string{}, VulnType: "CWE-693",
+					References: []string{"https://developer.mozilla.org/en-US/docs/Web/HTTP/CSP"},
+				},
+				{
+					Title: "Timestamp Disclosure - Unix", Hostname: "portal.example.com", Port: "443",
+					URL: "https://portal.example.com", Severity: "info", CVEIDs: []string{}, VulnType: "CWE-200",
+					Error: "ZAP已标记为误报",
+				},
+			},
+		},
+		{
+			file:   "sample.sarif",
+			format: ScanFormatSARIF,
+			want: []wantFinding{
+				{
+					Title: "[CodeQL] Database query built from user-controlled sources", URL: "services/user.go:42",
+					Severity: "high", CVEIDs: []string{}, CVSSScore: 8.8, VulnType: "SqlInjection",
+					References: []string{"https://codeql.github.com/codeql-query-help/go/go-sql-injection/"},
+				},
+				{
+					Title: "[CodeQL] Log entries created from user input", URL: "routers/api/user.go",
+					Severity: "high", CVEIDs: []string{}, VulnType: "LogInjection",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "scan", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format := DetectScanFormat(data); format != tt.format {
+				t.Errorf("DetectScanFormat() = %q, want %q", format, tt.format)
+			}
+
+			findings, err := ParseScanResults(tt.format, data)
+			if err != nil {
+				t.Fatalf("ParseScanResults() error = %v", err)
+			}
+			if len(findings) != len(tt.want) {
+				t.Fatalf("ParseScanResults() returned %d findings, want %d", len(findings), len(tt.want))
+			}
+			for i, f := range findings {
+				if f.Row != i+1 {
+					t.Errorf("finding %d: Row = %d, want %d", i, f.Row, i+1)
+				}
+				got := wantFinding{
+					Title: f.Title, IP: f.IP, Hostname: f.Hostname, Port: f.Port, URL: f.URL,
+					Severity: f.Severity, CVEIDs: f.CVEIDs, CVSSScore: f.CVSSScore, CVSSVector: f.CVSSVector,
+					VulnType: f.VulnType, References: f.References, Error: f.Error,
+				}
+				if len(got.References) == 0 {
+					got.References = nil
+				}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("finding %d:\n got  %+v\n want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectScanFormatUnknown(t *testing.T) {
+	for _, data := range []string{"", "plain text", `<html><body></body></html>`, `{"foo": 1}`} {
+		if format := DetectScanFormat([]byte(data)); format != "" {
+			t.Errorf("DetectScanFormat(%q) = %q, want empty", data, format)
+		}
+	}
+	if _, err := ParseScanResults("csv", []byte("a,b")); err == nil {
+		t.Error("ParseScanResults() with unsupported format should fail")
+	}
+}
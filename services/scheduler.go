@@ -5,6 +5,7 @@ package services
 import (
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -12,9 +13,23 @@ import (
 
 // SchedulerService 定时任务服务
 type SchedulerService struct {
-	cron           *cron.Cron
-	weeklyReportService *WeeklyReportService
-	vulnService    *VulnService
+	cron                  *cron.Cron
+	tasks                 map[cron.EntryID]scheduledTask // 已注册的任务，按EntryID查找任务名称
+	weeklyReportID        cron.EntryID
+	weeklyReportService   *WeeklyReportService
+	vulnService           *VulnService
+	notificationService   *NotificationService
+	webhookService        *WebhookService
+	riskAcceptanceService *RiskAcceptanceService
+	slaService            *SLAService
+	escalationService     *EscalationService
+	cveFeedService        *CVEFeedService
+}
+
+// scheduledTask 定时任务的展示信息
+type scheduledTask struct {
+	Name     string
+	Schedule string
 }
 
 // NewSchedulerService 创建定时任务服务实例
@@ -22,11 +37,18 @@ func NewSchedulerService() *SchedulerService {
 	// 创建带时区的cron实例
 	location, _ := time.LoadLocation("Asia/Shanghai")
 	c := cron.New(cron.WithLocation(location))
-	
+
 	return &SchedulerService{
-		cron:           c,
-		weeklyReportService: &WeeklyReportService{},
-		vulnService:    &VulnService{},
+		cron:                  c,
+		tasks:                 make(map[cron.EntryID]scheduledTask),
+		weeklyReportService:   &WeeklyReportService{},
+		vulnService:           &VulnService{},
+		notificationService:   &NotificationService{},
+		webhookService:        &WebhookService{},
+		riskAcceptanceService: &RiskAcceptanceService{},
+		slaService:            &SLAService{},
+		escalationService:     &EscalationService{},
+		cveFeedService:        &CVEFeedService{},
 	}
 }
 
@@ -35,26 +57,74 @@ func (s *SchedulerService) Start() error {
 	// 添加周报任务：每周五下午18点执行
 	// cron表达式：分 时 日 月 周
 	// 0 18 * * 5 表示每周五18:00执行
-	_, err := s.cron.AddFunc("0 18 * * 5", s.sendWeeklyReport)
+	var err error
+	s.weeklyReportID, err = s.addTask("0 18 * * 5", "周报发送", "每周五 18:00", s.sendWeeklyReport)
 	if err != nil {
 		return fmt.Errorf("添加周报定时任务失败: %v", err)
 	}
 
 	// 添加漏洞截止时间提醒任务：每天上午8点执行
 	// 0 8 * * * 表示每天08:00执行
-	_, err = s.cron.AddFunc("0 8 * * *", s.sendVulnDeadlineReminders)
+	_, err = s.addTask("0 8 * * *", "漏洞截止时间提醒", "每天 08:00", s.sendVulnDeadlineReminders)
 	if err != nil {
 		return fmt.Errorf("添加漏洞截止时间提醒任务失败: %v", err)
 	}
 
+	// 添加通知汇总任务：每小时整点执行，按用户设置发送每小时或每日汇总
+	// 0 * * * * 表示每小时的第0分钟执行
+	_, err = s.addTask("0 * * * *", "通知汇总", "每小时整点", s.sendNotificationDigests)
+	if err != nil {
+		return fmt.Errorf("添加通知汇总任务失败: %v", err)
+	}
+
+	// 添加Webhook重试任务：每分钟执行，重试到期的失败投递
+	_, err = s.addTask("* * * * *", "Webhook重试", "每分钟", s.retryWebhookDeliveries)
+	if err != nil {
+		return fmt.Errorf("添加Webhook重试任务失败: %v", err)
+	}
+
+	// 添加风险接受到期任务：每小时第5分钟执行，到期的风险接受恢复为未修复
+	_, err = s.addTask("5 * * * *", "风险接受到期处理", "每小时第5分钟", s.expireRiskAcceptances)
+	if err != nil {
+		return fmt.Errorf("添加风险接受到期任务失败: %v", err)
+	}
+
+	// 添加SLA超期检查任务：每小时第10分钟执行，标记超过修复截止时间的漏洞
+	_, err = s.addTask("10 * * * *", "SLA超期检查", "每小时第10分钟", s.checkSLABreaches)
+	if err != nil {
+		return fmt.Errorf("添加SLA超期检查任务失败: %v", err)
+	}
+
+	// 添加超期升级任务：每小时第15分钟执行，按升级级别通知指派人、项目负责人和部门负责人
+	_, err = s.addTask("15 * * * *", "超期升级通知", "每小时第15分钟", s.escalateOverdueVulns)
+	if err != nil {
+		return fmt.Errorf("添加超期升级任务失败: %v", err)
+	}
+
+	// 添加CVE情报更新任务：每天凌晨3:30执行，从内部镜像或数据源目录导入NVD、KEV和EPSS数据
+	_, err = s.addTask("30 3 * * *", "CVE情报更新", "每天 03:30", s.refreshCVEFeeds)
+	if err != nil {
+		return fmt.Errorf("添加CVE情报更新任务失败: %v", err)
+	}
 
 	// 启动定时任务
 	s.cron.Start()
 	log.Println("定时任务服务已启动")
-	
+
 	return nil
 }
 
+// addTask 注册定时任务并记录任务名称
+// cron.Entries()按下次执行时间排序，不能按位置判断任务，需要保存AddFunc返回的EntryID
+func (s *SchedulerService) addTask(spec, name, schedule string, cmd func()) (cron.EntryID, error) {
+	id, err := s.cron.AddFunc(spec, cmd)
+	if err != nil {
+		return 0, err
+	}
+	s.tasks[id] = scheduledTask{Name: name, Schedule: schedule}
+	return id, nil
+}
+
 // Stop 停止定时任务
 func (s *SchedulerService) Stop() {
 	if s.cron != nil {
@@ -66,7 +136,7 @@ func (s *SchedulerService) Stop() {
 // sendWeeklyReport 发送周报的定时任务
 func (s *SchedulerService) sendWeeklyReport() {
 	log.Println("开始执行周报发送任务...")
-	
+
 	err := s.weeklyReportService.SendWeeklyReport()
 	if err != nil {
 		log.Printf("周报发送失败: %v", err)
@@ -78,7 +148,7 @@ func (s *SchedulerService) sendWeeklyReport() {
 // sendWeeklyReportTest 测试用的周报发送任务
 func (s *SchedulerService) sendWeeklyReportTest() {
 	log.Println("开始执行测试周报发送任务...")
-	
+
 	err := s.weeklyReportService.SendWeeklyReport()
 	if err != nil {
 		log.Printf("测试周报发送失败: %v", err)
@@ -99,10 +169,57 @@ func (s *SchedulerService) sendVulnDeadlineReminders() {
 	}
 }
 
+// sendNotificationDigests 发送通知汇总的定时任务
+func (s *SchedulerService) sendNotificationDigests() {
+	log.Println("开始执行通知汇总任务...")
+
+	err := s.notificationService.SendDigests(time.Now())
+	if err != nil {
+		log.Printf("通知汇总发送失败: %v", err)
+	} else {
+		log.Println("通知汇总发送完成")
+	}
+}
+
+// retryWebhookDeliveries 重试失败的Webhook投递的定时任务
+func (s *SchedulerService) retryWebhookDeliveries() {
+	if err := s.webhookService.RetryDueDeliveries(); err != nil {
+		log.Printf("Webhook投递重试失败: %v", err)
+	}
+}
+
+// expireRiskAcceptances 处理到期的风险接受的定时任务
+func (s *SchedulerService) expireRiskAcceptances() {
+	if err := s.riskAcceptanceService.ExpireRiskAcceptances(); err != nil {
+		log.Printf("风险接受到期处理失败: %v", err)
+	}
+}
+
+// checkSLABreaches 检查超出SLA的漏洞的定时任务
+func (s *SchedulerService) checkSLABreaches() {
+	if err := s.slaService.CheckSLABreaches(); err != nil {
+		log.Printf("SLA超期检查失败: %v", err)
+	}
+}
+
+// escalateOverdueVulns 超期漏洞逐级升级通知的定时任务
+func (s *SchedulerService) escalateOverdueVulns() {
+	if err := s.escalationService.EscalateOverdueVulns(); err != nil {
+		log.Printf("超期升级通知失败: %v", err)
+	}
+}
+
+// refreshCVEFeeds 更新CVE情报的定时任务
+func (s *SchedulerService) refreshCVEFeeds() {
+	if err := s.cveFeedService.RefreshScheduledFeeds(); err != nil {
+		log.Printf("CVE情报更新失败: %v", err)
+	}
+}
+
 // ManualSendWeeklyReport 手动发送周报（用于测试或紧急情况）
 func (s *SchedulerService) ManualSendWeeklyReport() error {
 	log.Println("手动发送周报...")
-	
+
 	err := s.weeklyReportService.SendWeeklyReport()
 	if err != nil {
 		log.Printf("手动周报发送失败: %v", err)
@@ -127,42 +244,38 @@ func (s *SchedulerService) ManualSendVulnDeadlineReminders() error {
 
 // GetNextWeeklyReportTime 获取下次周报发送时间
 func (s *SchedulerService) GetNextWeeklyReportTime() time.Time {
-	entries := s.cron.Entries()
-	if len(entries) > 0 {
-		return entries[0].Next
+	if s.weeklyReportID == 0 {
+		return time.Time{}
 	}
-	return time.Time{}
+	return s.cron.Entry(s.weeklyReportID).Next
 }
 
-// GetSchedulerStatus 获取定时任务状态
+// GetSchedulerStatus 获取定时任务状态，任务按注册顺序返回
 func (s *SchedulerService) GetSchedulerStatus() map[string]interface{} {
 	entries := s.cron.Entries()
-	
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
 	status := map[string]interface{}{
 		"running":    len(entries) > 0,
 		"task_count": len(entries),
 		"tasks":      []map[string]interface{}{},
 	}
-	
-	for i, entry := range entries {
+
+	for _, entry := range entries {
 		taskInfo := map[string]interface{}{
-			"id":        i + 1,
-			"next_run":  entry.Next.Format("2006-01-02 15:04:05"),
-			"prev_run":  entry.Prev.Format("2006-01-02 15:04:05"),
+			"id":       int(entry.ID),
+			"next_run": entry.Next.Format("2006-01-02 15:04:05"),
+			"prev_run": entry.Prev.Format("2006-01-02 15:04:05"),
 		}
-		
-		// 根据任务索引确定任务名称
-		if i == 0 {
-			taskInfo["name"] = "周报发送"
-			taskInfo["schedule"] = "每周五 18:00"
-		} else if i == 1 {
-			taskInfo["name"] = "漏洞截止时间提醒"
-			taskInfo["schedule"] = "每天 08:00"
+
+		if task, ok := s.tasks[entry.ID]; ok {
+			taskInfo["name"] = task.Name
+			taskInfo["schedule"] = task.Schedule
 		}
-		
+
 		status["tasks"] = append(status["tasks"].([]map[string]interface{}), taskInfo)
 	}
-	
+
 	return status
 }
 
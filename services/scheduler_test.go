@@ -0,0 +1,34 @@
+package services
+
+import "testing"
+
+func TestSchedulerStatusNamesByEntryID(t *testing.T) {
+	s := NewSchedulerService()
+
+	// 注册顺序与下次执行时间顺序不同，状态中的名称必须按EntryID对应
+	tasks := []struct {
+		spec, name string
+	}{
+		{"0 18 * * 5", "周报发送"},
+		{"30 3 * * *", "CVE情报更新"},
+		{"* * * * *", "Webhook重试"},
+	}
+	for _, task := range tasks {
+		if _, err := s.addTask(task.spec, task.name, task.spec, func() {}); err != nil {
+			t.Fatalf("addTask(%s): %v", task.spec, err)
+		}
+	}
+	s.cron.Start()
+	defer s.cron.Stop()
+
+	status := s.GetSchedulerStatus()
+	got := status["tasks"].([]map[string]interface{})
+	if len(got) != len(tasks) {
+		t.Fatalf("got %d tasks, want %d", len(got), len(tasks))
+	}
+	for i, task := range tasks {
+		if got[i]["name"] != task.name || got[i]["schedule"] != task.spec {
+			t.Errorf("task %d = %v/%v, want %s/%s", i, got[i]["name"], got[i]["schedule"], task.name, task.spec)
+		}
+	}
+}
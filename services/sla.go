@@ -0,0 +1,563 @@
+// SLA服务包
+// 该包提供漏洞修复SLA策略管理和SLA计时：漏洞审核通过时按严重程度和资产属性匹配策略并计算修复截止时间，
+// 复测中、已忽略（含风险接受批准）期间暂停计时并顺延截止时间，复测通过时记录修复耗时，
+// 定时任务标记超出时限的漏洞，仪表板和周报按项目、团队、工程师统计SLA达成率
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SLAService SLA服务
+type SLAService struct{}
+
+// SLAPolicyRequest 创建或更新SLA策略请求
+type SLAPolicyRequest struct {
+	Name             string `json:"name" binding:"required"`
+	Severity         string `json:"severity" binding:"required"`
+	AssetImportance  string `json:"asset_importance"`
+	AssetEnvironment string `json:"asset_environment"`
+	Days             int    `json:"days" binding:"required"`
+	IsActive         *bool  `json:"is_active"`
+	Description      string `json:"description"`
+}
+
+// SLAPolicyOptions SLA策略可选项
+type SLAPolicyOptions struct {
+	Severities   []VulnWorkflowOption `json:"severities"`
+	Importances  []VulnWorkflowOption `json:"importances"`
+	Environments []VulnWorkflowOption `json:"environments"`
+}
+
+// SLAComplianceRequest SLA达成率统计请求
+type SLAComplianceRequest struct {
+	ProjectID uint   `form:"project_id"`
+	StartDate string `form:"start_date"` // 按SLA开始计时时间筛选，YYYY-MM-DD
+	EndDate   string `form:"end_date"`
+}
+
+// SLAComplianceItem SLA达成率统计项
+type SLAComplianceItem struct {
+	ID                  uint    `json:"id"`
+	Name                string  `json:"name"`
+	Total               int64   `json:"total"`                 // 纳入SLA的漏洞数
+	Met                 int64   `json:"met"`                   // 按时修复完成数
+	Breached            int64   `json:"breached"`              // 超出SLA数
+	OnTrack             int64   `json:"on_track"`              // 未超期的处理中漏洞数
+	Paused              int64   `json:"paused"`                // 暂停计时中的漏洞数
+	ComplianceRate      float64 `json:"compliance_rate"`       // SLA达成率(%)，未超期漏洞占比
+	AvgRemediationHours float64 `json:"avg_remediation_hours"` // 平均修复耗时(小时)，不含暂停时长
+
+	remediationSeconds int64
+	remediated         int64
+}
+
+// SLAComplianceReport SLA达成率统计
+type SLAComplianceReport struct {
+	Overall    SLAComplianceItem   `json:"overall"`
+	ByProject  []SLAComplianceItem `json:"by_project"`
+	ByTeam     []SLAComplianceItem `json:"by_team"`     // 按修复人所属部门统计
+	ByEngineer []SLAComplianceItem `json:"by_engineer"` // 按修复人统计
+}
+
+// slaComplianceFilter SLA统计范围
+type slaComplianceFilter struct {
+	ProjectIDs []uint
+	AssigneeID uint
+	StartedAt  *time.Time // SLA开始计时时间起
+	StartedTo  *time.Time // SLA开始计时时间止（不含）
+}
+
+// 漏洞严重程度
+var slaSeverities = []VulnWorkflowOption{
+	{Code: "critical", Label: "严重"},
+	{Code: "high", Label: "高危"},
+	{Code: "medium", Label: "中危"},
+	{Code: "low", Label: "低危"},
+	{Code: "info", Label: "信息"},
+}
+
+// 资产重要性
+var slaAssetImportances = []VulnWorkflowOption{
+	{Code: "extremely_high", Label: "极高"},
+	{Code: "high", Label: "高"},
+	{Code: "medium", Label: "中"},
+	{Code: "low", Label: "低"},
+}
+
+// 资产所属环境
+var slaAssetEnvironments = []VulnWorkflowOption{
+	{Code: "production", Label: "生产环境"},
+	{Code: "pre_production", Label: "准生产环境"},
+	{Code: "staging", Label: "预发环境"},
+	{Code: "testing", Label: "测试环境"},
+	{Code: "development", Label: "开发环境"},
+	{Code: "disaster_recovery", Label: "容灾环境"},
+}
+
+// slaPausedStatuses 暂停SLA计时的状态：复测中等待验证、已忽略属于风险例外，
+// 已完成和已驳回的漏洞不再需要修复，重新打开或重新提交后继续计时
+var slaPausedStatuses = []string{VulnStatusRetesting, VulnStatusIgnored, VulnStatusCompleted, VulnStatusRejected, "closed"}
+
+// isSLAPausedStatus 判断状态是否暂停SLA计时
+func isSLAPausedStatus(status string) bool {
+	return contains(slaPausedStatuses, status)
+}
+
+// formatSLADuration 格式化时长
+func formatSLADuration(seconds int64) string {
+	if seconds < 3600 {
+		return fmt.Sprintf("%d分钟", seconds/60)
+	}
+	days := seconds / 86400
+	hours := seconds % 86400 / 3600
+	if days == 0 {
+		return fmt.Sprintf("%d小时", hours)
+	}
+	return fmt.Sprintf("%d天%d小时", days, hours)
+}
+
+// matchSLAPolicy 匹配漏洞适用的SLA策略：限定条件越多越优先，条件相同时取时限最短的策略
+func matchSLAPolicy(db *gorm.DB, vuln *models.Vulnerability) *models.SLAPolicy {
+	var policies []models.SLAPolicy
+	if err := db.Where("severity = ? AND is_active = ?", vuln.Severity, true).Find(&policies).Error; err != nil || len(policies) == 0 {
+		return nil
+	}
+
+	asset := vuln.Asset
+	if vuln.AssetID != 0 && asset.ID != vuln.AssetID {
+		asset = models.Asset{}
+		db.Select("id, importance, environment").Where("id = ?", vuln.AssetID).First(&asset)
+	}
+
+	var matched *models.SLAPolicy
+	bestScore := -1
+	for i := range policies {
+		p := &policies[i]
+		if p.AssetImportance != "" && p.AssetImportance != asset.Importance {
+			continue
+		}
+		if p.AssetEnvironment != "" && p.AssetEnvironment != asset.Environment {
+			continue
+		}
+		score := 0
+		if p.AssetImportance != "" {
+			score++
+		}
+		if p.AssetEnvironment != "" {
+			score++
+		}
+		if score > bestScore || (score == bestScore && p.Days < matched.Days) {
+			matched = p
+			bestScore = score
+		}
+	}
+	return matched
+}
+
+// trackVulnSLA 漏洞状态变更时更新SLA计时，只修改传入的漏洞对象，返回需要记录到时间线的说明。
+// 漏洞首次进入待审核以外的处理状态时开始计时，进入暂停状态时停止计时，离开暂停状态时顺延截止时间
+func trackVulnSLA(db *gorm.DB, vuln *models.Vulnerability, to string, now time.Time) string {
+	to = normalizeVulnStatus(to)
+	paused := isSLAPausedStatus(to)
+
+	// 开始计时，按匹配的SLA策略计算修复截止时间
+	if vuln.SLAStartedAt == nil {
+		if to == VulnStatusPending || paused {
+			return ""
+		}
+		startedAt := now
+		vuln.SLAStartedAt = &startedAt
+		policy := matchSLAPolicy(db, vuln)
+		if policy == nil {
+			if vuln.FixDeadline != nil {
+				return fmt.Sprintf("SLA开始计时，未匹配到SLA策略，沿用修复截止时间 %s", vuln.FixDeadline.Format("2006-01-02 15:04"))
+			}
+			return "SLA开始计时，未匹配到SLA策略，未设置修复截止时间"
+		}
+		deadline := now.AddDate(0, 0, policy.Days)
+		policyID := policy.ID
+		vuln.SLAPolicyID = &policyID
+		vuln.SLADays = policy.Days
+		vuln.FixDeadline = &deadline
+		return fmt.Sprintf("按SLA策略「%s」（%d天）计算修复截止时间：%s", policy.Name, policy.Days, deadline.Format("2006-01-02 15:04"))
+	}
+
+	// 暂停计时，暂停前已超过截止时间的记为超期
+	if vuln.SLAPausedAt == nil && paused {
+		markSLABreached(vuln, now)
+		pausedAt := now
+		vuln.SLAPausedAt = &pausedAt
+		if to != VulnStatusCompleted && to != "closed" {
+			return fmt.Sprintf("SLA暂停计时（%s）", vulnStatusLabel(to))
+		}
+		return completeVulnSLA(vuln)
+	}
+
+	// 经复测中进入已完成时计时已经暂停，修复耗时计算到暂停时为止
+	if vuln.SLAPausedAt != nil && (to == VulnStatusCompleted || to == "closed") && vuln.RemediationSeconds == 0 {
+		return completeVulnSLA(vuln)
+	}
+
+	// 恢复计时，暂停时长顺延修复截止时间
+	if vuln.SLAPausedAt != nil && !paused {
+		pausedFor := now.Sub(*vuln.SLAPausedAt)
+		vuln.SLAPausedSeconds += int64(pausedFor.Seconds())
+		vuln.SLAPausedAt = nil
+		vuln.RemediationSeconds = 0
+		if vuln.FixDeadline == nil {
+			return "SLA恢复计时"
+		}
+		deadline := vuln.FixDeadline.Add(pausedFor)
+		vuln.FixDeadline = &deadline
+		return fmt.Sprintf("SLA恢复计时，暂停%s，修复截止时间顺延至 %s", formatSLADuration(int64(pausedFor.Seconds())), deadline.Format("2006-01-02 15:04"))
+	}
+
+	return ""
+}
+
+// completeVulnSLA 漏洞完成时计算修复耗时，不含暂停时长，计算到SLA暂停计时的时间为止
+func completeVulnSLA(vuln *models.Vulnerability) string {
+	vuln.RemediationSeconds = completedRemediationSeconds(*vuln.SLAStartedAt, *vuln.SLAPausedAt, vuln.SLAPausedSeconds)
+	result := "在SLA时限内完成"
+	if vuln.SLABreached {
+		result = "超出SLA时限"
+	}
+	return fmt.Sprintf("修复耗时%s，%s", formatSLADuration(vuln.RemediationSeconds), result)
+}
+
+// completedRemediationSeconds 计算从开始计时到暂停计时的修复耗时，扣除中途暂停的时长
+func completedRemediationSeconds(startedAt, pausedAt time.Time, pausedSeconds int64) int64 {
+	seconds := int64(pausedAt.Sub(startedAt).Seconds()) - pausedSeconds
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// markSLABreached 计时中的漏洞超过修复截止时间时记为超期
+func markSLABreached(vuln *models.Vulnerability, now time.Time) bool {
+	if vuln.SLABreached || vuln.SLAStartedAt == nil || vuln.SLAPausedAt != nil || vuln.FixDeadline == nil || !now.After(*vuln.FixDeadline) {
+		return false
+	}
+	breachedAt := *vuln.FixDeadline
+	vuln.SLABreached = true
+	vuln.SLABreachedAt = &breachedAt
+	return true
+}
+
+// CheckSLABreaches 定时检查计时中的漏洞，超过修复截止时间的标记为超出SLA
+func (s *SLAService) CheckSLABreaches() error {
+	db := Init.GetDB()
+	now := time.Now().Truncate(time.Second)
+
+	var vulns []models.Vulnerability
+	if err := db.Where("sla_started_at IS NOT NULL AND sla_paused_at IS NULL AND sla_breached = ? AND fix_deadline IS NOT NULL AND fix_deadline < ?", false, now).
+		Find(&vulns).Error; err != nil {
+		return fmt.Errorf("查询超出SLA的漏洞失败: %v", err)
+	}
+
+	vulnService := &VulnService{}
+	for i := range vulns {
+		vuln := &vulns[i]
+		if !markSLABreached(vuln, now) {
+			continue
+		}
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).Updates(map[string]interface{}{
+			"sla_breached":    true,
+			"sla_breached_at": vuln.SLABreachedAt,
+		}).Error; err != nil {
+			fmt.Printf("标记漏洞超出SLA失败 (ID: %d): %v\n", vuln.ID, err)
+			continue
+		}
+		vulnService.addTimeline(vuln.ID, 0, "sla_breached", fmt.Sprintf("漏洞已超出SLA修复时限（截止时间 %s）", vuln.FixDeadline.Format("2006-01-02 15:04")))
+	}
+
+	return nil
+}
+
+// GetSLAPolicyOptions 获取SLA策略可选项
+func (s *SLAService) GetSLAPolicyOptions() *SLAPolicyOptions {
+	return &SLAPolicyOptions{
+		Severities:   slaSeverities,
+		Importances:  slaAssetImportances,
+		Environments: slaAssetEnvironments,
+	}
+}
+
+// GetSLAPolicies 获取SLA策略列表
+func (s *SLAService) GetSLAPolicies() ([]models.SLAPolicy, error) {
+	db := Init.GetDB()
+
+	var policies []models.SLAPolicy
+	if err := db.Order("severity ASC, days ASC, id ASC").Find(&policies).Error; err != nil {
+		return nil, errors.New("获取SLA策略列表失败")
+	}
+	return policies, nil
+}
+
+// validateSLAPolicyRequest 校验SLA策略请求
+func validateSLAPolicyRequest(req *SLAPolicyRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("策略名称不能为空")
+	}
+	if !isWorkflowOption(slaSeverities, req.Severity) {
+		return errors.New("无效的漏洞严重程度")
+	}
+	if req.AssetImportance != "" && !isWorkflowOption(slaAssetImportances, req.AssetImportance) {
+		return errors.New("无效的资产重要性")
+	}
+	if req.AssetEnvironment != "" && !isWorkflowOption(slaAssetEnvironments, req.AssetEnvironment) {
+		return errors.New("无效的资产所属环境")
+	}
+	if req.Days <= 0 {
+		return errors.New("修复时限必须大于0天")
+	}
+	return nil
+}
+
+// CreateSLAPolicy 创建SLA策略
+func (s *SLAService) CreateSLAPolicy(req *SLAPolicyRequest, userID uint) (*models.SLAPolicy, error) {
+	db := Init.GetDB()
+
+	if err := validateSLAPolicyRequest(req); err != nil {
+		return nil, err
+	}
+
+	policy := models.SLAPolicy{
+		Name:             req.Name,
+		Severity:         req.Severity,
+		AssetImportance:  req.AssetImportance,
+		AssetEnvironment: req.AssetEnvironment,
+		Days:             req.Days,
+		IsActive:         true,
+		Description:      req.Description,
+		CreatedBy:        userID,
+	}
+	if err := db.Create(&policy).Error; err != nil {
+		return nil, errors.New("创建SLA策略失败")
+	}
+
+	// GORM不会写入bool零值，停用状态需要单独更新
+	if req.IsActive != nil && !*req.IsActive {
+		db.Model(&policy).Update("is_active", false)
+	}
+
+	return &policy, nil
+}
+
+// UpdateSLAPolicy 更新SLA策略，已计算的漏洞修复截止时间不受影响
+func (s *SLAService) UpdateSLAPolicy(id uint, req *SLAPolicyRequest) (*models.SLAPolicy, error) {
+	db := Init.GetDB()
+
+	var policy models.SLAPolicy
+	if err := db.Where("id = ?", id).First(&policy).Error; err != nil {
+		return nil, errors.New("SLA策略不存在")
+	}
+	if err := validateSLAPolicyRequest(req); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":              req.Name,
+		"severity":          req.Severity,
+		"asset_importance":  req.AssetImportance,
+		"asset_environment": req.AssetEnvironment,
+		"days":              req.Days,
+		"description":       req.Description,
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if err := db.Model(&policy).Updates(updates).Error; err != nil {
+		return nil, errors.New("更新SLA策略失败")
+	}
+
+	db.Where("id = ?", id).First(&policy)
+	return &policy, nil
+}
+
+// DeleteSLAPolicy 删除SLA策略，已匹配该策略的漏洞保留计算好的修复截止时间
+func (s *SLAService) DeleteSLAPolicy(id uint) error {
+	db := Init.GetDB()
+
+	var policy models.SLAPolicy
+	if err := db.Where("id = ?", id).First(&policy).Error; err != nil {
+		return errors.New("SLA策略不存在")
+	}
+	if err := db.Delete(&policy).Error; err != nil {
+		return errors.New("删除SLA策略失败")
+	}
+	return nil
+}
+
+// GetSLACompliance 按用户角色获取SLA达成率统计：管理员和安全工程师查看全部，研发工程师查看分配给自己的漏洞
+func (s *SLAService) GetSLACompliance(req *SLAComplianceRequest, userID uint, roleCode string) (*SLAComplianceReport, error) {
+	filter := &slaComplianceFilter{}
+	if req.StartDate != "" {
+		if start, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local); err == nil {
+			filter.StartedAt = &start
+		}
+	}
+	if req.EndDate != "" {
+		if end, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local); err == nil {
+			end = end.AddDate(0, 0, 1)
+			filter.StartedTo = &end
+		}
+	}
+	if req.ProjectID != 0 {
+		filter.ProjectIDs = []uint{req.ProjectID}
+	}
+	if roleCode != "super_admin" && roleCode != "security_engineer" {
+		filter.AssigneeID = userID
+	}
+	return s.slaCompliance(Init.GetDB(), filter)
+}
+
+// slaCompliance 统计范围内纳入SLA的漏洞的达成情况
+func (s *SLAService) slaCompliance(db *gorm.DB, filter *slaComplianceFilter) (*SLAComplianceReport, error) {
+	var rows []struct {
+		ProjectID          uint
+		ProjectName        string
+		AssigneeID         uint
+		AssigneeName       string
+		Username           string
+		Department         string
+		Status             string
+		SLABreached        bool       `gorm:"column:sla_breached"`
+		SLAStartedAt       *time.Time `gorm:"column:sla_started_at"`
+		SLAPausedAt        *time.Time `gorm:"column:sla_paused_at"`
+		SLAPausedSeconds   int64      `gorm:"column:sla_paused_seconds"`
+		RemediationSeconds int64
+	}
+
+	query := db.Table("vulnerabilities").
+		Select("vulnerabilities.project_id, projects.name as project_name, vulnerabilities.assignee_id, users.real_name as assignee_name, users.username, users.department, " +
+			"vulnerabilities.status, vulnerabilities.sla_breached, vulnerabilities.sla_started_at, vulnerabilities.sla_paused_at, vulnerabilities.sla_paused_seconds, vulnerabilities.remediation_seconds").
+		Joins("LEFT JOIN projects ON vulnerabilities.project_id = projects.id").
+		Joins("LEFT JOIN users ON vulnerabilities.assignee_id = users.id").
+		Where("vulnerabilities.deleted_at IS NULL AND vulnerabilities.sla_started_at IS NOT NULL")
+	if len(filter.ProjectIDs) > 0 {
+		query = query.Where("vulnerabilities.project_id IN (?)", filter.ProjectIDs)
+	}
+	if filter.AssigneeID != 0 {
+		query = query.Where("vulnerabilities.assignee_id = ?", filter.AssigneeID)
+	}
+	if filter.StartedAt != nil {
+		query = query.Where("vulnerabilities.sla_started_at >= ?", *filter.StartedAt)
+	}
+	if filter.StartedTo != nil {
+		query = query.Where("vulnerabilities.sla_started_at < ?", *filter.StartedTo)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, errors.New("获取SLA统计失败")
+	}
+
+	report := &SLAComplianceReport{
+		Overall:    SLAComplianceItem{Name: "全部"},
+		ByProject:  []SLAComplianceItem{},
+		ByTeam:     []SLAComplianceItem{},
+		ByEngineer: []SLAComplianceItem{},
+	}
+	projects := map[uint]*SLAComplianceItem{}
+	teams := map[string]*SLAComplianceItem{}
+	engineers := map[uint]*SLAComplianceItem{}
+
+	for _, row := range rows {
+		team := row.Department
+		if team == "" {
+			team = "未设置部门"
+		}
+		engineer := row.AssigneeName
+		if engineer == "" {
+			engineer = row.Username
+		}
+		if row.AssigneeID == 0 {
+			engineer = "未分配"
+		}
+		if projects[row.ProjectID] == nil {
+			projects[row.ProjectID] = &SLAComplianceItem{ID: row.ProjectID, Name: row.ProjectName}
+		}
+		if teams[team] == nil {
+			teams[team] = &SLAComplianceItem{Name: team}
+		}
+		if engineers[row.AssigneeID] == nil {
+			engineers[row.AssigneeID] = &SLAComplianceItem{ID: row.AssigneeID, Name: engineer}
+		}
+
+		status := normalizeVulnStatus(row.Status)
+		// 旧版本经复测中完成的漏洞没有记录修复耗时，按暂停计时的时间补算
+		if status == VulnStatusCompleted && row.RemediationSeconds == 0 && row.SLAStartedAt != nil && row.SLAPausedAt != nil {
+			row.RemediationSeconds = completedRemediationSeconds(*row.SLAStartedAt, *row.SLAPausedAt, row.SLAPausedSeconds)
+		}
+		for _, item := range []*SLAComplianceItem{&report.Overall, projects[row.ProjectID], teams[team], engineers[row.AssigneeID]} {
+			item.Total++
+			switch {
+			case row.SLABreached:
+				item.Breached++
+			case status == VulnStatusCompleted:
+				item.Met++
+			case row.SLAPausedAt != nil:
+				item.Paused++
+			default:
+				item.OnTrack++
+			}
+			if status == VulnStatusCompleted {
+				item.remediated++
+				item.remediationSeconds += row.RemediationSeconds
+			}
+		}
+	}
+
+	report.Overall.finish()
+	for _, item := range projects {
+		report.ByProject = append(report.ByProject, *item)
+	}
+	for _, item := range teams {
+		report.ByTeam = append(report.ByTeam, *item)
+	}
+	for _, item := range engineers {
+		report.ByEngineer = append(report.ByEngineer, *item)
+	}
+	sortSLAComplianceItems(report.ByProject)
+	sortSLAComplianceItems(report.ByTeam)
+	sortSLAComplianceItems(report.ByEngineer)
+
+	return report, nil
+}
+
+// finish 计算达成率和平均修复耗时，保留一位小数
+func (item *SLAComplianceItem) finish() {
+	if item.Total > 0 {
+		item.ComplianceRate = math.Round(float64(item.Total-item.Breached)/float64(item.Total)*1000) / 10
+	}
+	if item.remediated > 0 {
+		item.AvgRemediationHours = math.Round(float64(item.remediationSeconds)/float64(item.remediated)/360) / 10
+	}
+}
+
+// sortSLAComplianceItems 计算各统计项并按达成率从低到高排序，便于优先关注超期较多的对象
+func sortSLAComplianceItems(items []SLAComplianceItem) {
+	for i := range items {
+		items[i].finish()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ComplianceRate != items[j].ComplianceRate {
+			return items[i].ComplianceRate < items[j].ComplianceRate
+		}
+		if items[i].Breached != items[j].Breached {
+			return items[i].Breached > items[j].Breached
+		}
+		return items[i].Name < items[j].Name
+	})
+}
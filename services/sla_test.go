@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"vulnmain/models"
+)
+
+// slaStep 漏洞在开始计时后第hours小时变更为to状态
+type slaStep struct {
+	to    string
+	hours int
+}
+
+func TestTrackVulnSLARemediationSeconds(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return start.Add(time.Duration(hours) * time.Hour) }
+
+	tests := []struct {
+		name  string
+		steps []slaStep
+		want  int64
+	}{
+		{
+			name:  "completed directly from fixed",
+			steps: []slaStep{{VulnStatusFixed, 10}, {VulnStatusCompleted, 20}},
+			want:  20 * 3600,
+		},
+		{
+			name:  "completed after retesting",
+			steps: []slaStep{{VulnStatusFixed, 10}, {VulnStatusRetesting, 12}, {VulnStatusCompleted, 30}},
+			want:  12 * 3600,
+		},
+		{
+			name:  "failed retest resumes the clock",
+			steps: []slaStep{{VulnStatusRetesting, 5}, {VulnStatusUnfixed, 8}, {VulnStatusFixed, 10}, {VulnStatusRetesting, 11}, {VulnStatusCompleted, 40}},
+			want:  (11 - 3) * 3600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startedAt := start
+			vuln := &models.Vulnerability{SLAStartedAt: &startedAt}
+			note := ""
+			for _, step := range tt.steps {
+				note = trackVulnSLA(nil, vuln, step.to, at(step.hours))
+			}
+			if vuln.RemediationSeconds != tt.want {
+				t.Errorf("RemediationSeconds = %d, want %d", vuln.RemediationSeconds, tt.want)
+			}
+			if note == "" {
+				t.Error("completing the vulnerability should record the remediation time")
+			}
+		})
+	}
+}
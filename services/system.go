@@ -231,7 +231,9 @@ func (s *SystemService) MarkNotificationRead(notificationID uint, userID uint) e
 	}
 
 	if !notification.IsRead {
+		now := time.Now()
 		notification.IsRead = true
+		notification.ReadAt = &now
 		if err := db.Save(&notification).Error; err != nil {
 			return errors.New("标记通知失败")
 		}
@@ -257,6 +259,9 @@ func (s *SystemService) CreateNotification(userID uint, notificationType, title,
 		return errors.New("创建通知失败")
 	}
 
+	// 推送给在线的接收者
+	publishRealtime(UserTopic(userID), RealtimeNotification, 0, notification)
+
 	return nil
 }
 
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"mime/multipart"
-	"os"
 	"path/filepath"
 	"time"
 	Init "vulnmain/Init"
@@ -97,6 +96,10 @@ func (s *UserService) CreateUser(req *UserCreateRequest) (*models.User, error) {
 	// 重新查询用户信息(包含关联的角色)
 	db.Preload("Role").Where("id = ?", user.Model.ID).First(&user)
 
+	// 站内欢迎通知，不包含初始密码
+	notificationService := &NotificationService{}
+	notificationService.NotifyAccount(EventUserRegistered, user.Model.ID, "欢迎使用漏洞管理系统", "您的账号已创建，首次登录后请及时修改初始密码。")
+
 	// 发送用户注册成功邮件通知
 	go func() {
 		userName := user.RealName
@@ -282,6 +285,10 @@ func (s *UserService) ResetPassword(userID uint, newPassword string) error {
 		return errors.New("重置密码失败")
 	}
 
+	// 站内通知密码已重置，新密码只通过邮件发送
+	notificationService := &NotificationService{}
+	notificationService.NotifyAccount(EventPasswordReset, user.Model.ID, "您的密码已被重置", "管理员已重置您的登录密码，新密码已发送至您的邮箱，登录后请及时修改。")
+
 	// 发送密码重置邮件通知
 	go func() {
 		userName := user.RealName
@@ -431,13 +438,8 @@ func (s *UserService) GetUserStats() (map[string]interface{}, error) {
 }
 
 // UploadVulnImage 上传漏洞相关图片
-func (s *UserService) UploadVulnImage(userID uint, file *multipart.FileHeader, baseURL string) (string, error) {
-	// 创建上传目录
-	uploadDir := "uploads/vuln-images"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return "", errors.New("创建上传目录失败")
-	}
-
+// 图片写入配置的存储后端并记录到FileStorage中，返回带短时效签名的访问地址，保存漏洞时签名参数会被去除并关联到漏洞
+func (s *UserService) UploadVulnImage(userID uint, file *multipart.FileHeader, baseURL string) (*models.FileStorage, string, error) {
 	// 生成唯一文件名
 	fileExt := filepath.Ext(file.Filename)
 	if fileExt == "" {
@@ -457,29 +459,30 @@ func (s *UserService) UploadVulnImage(userID uint, file *multipart.FileHeader, b
 		}
 	}
 
-	fileName := fmt.Sprintf("vuln_img_%d_%d%s", userID, time.Now().Unix(), fileExt)
-	filePath := filepath.Join(uploadDir, fileName)
+	fileName := fmt.Sprintf("vuln_img_%d_%d%s", userID, time.Now().UnixNano(), fileExt)
 
 	// 保存文件
 	src, err := file.Open()
 	if err != nil {
-		return "", errors.New("打开文件失败")
+		return nil, "", errors.New("打开文件失败")
 	}
 	defer src.Close()
 
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return "", errors.New("创建文件失败")
+	// 记录文件信息，下载时通过该记录校验权限
+	storage := models.FileStorage{
+		FileName: file.Filename,
+		FileSize: file.Size,
+		MimeType: imageMimeType(fileExt),
+		UserID:   userID,
+		Category: "vuln_image",
 	}
-	defer dst.Close()
-
-	// 复制文件内容
-	if _, err := dst.ReadFrom(src); err != nil {
-		return "", errors.New("保存文件失败")
+	fileService := &FileService{}
+	if err := fileService.StoreFile("vuln-images/"+fileName, src, &storage); err != nil {
+		return nil, "", fmt.Errorf("保存文件失败: %v", err)
 	}
 
-	// 生成访问URL
-	imageURL := fmt.Sprintf("%s/uploads/vuln-images/%s", baseURL, fileName)
+	// 生成带签名的访问URL
+	imageURL := baseURL + fileService.SignedFileURL(utils.NewResourceSigner(), storage.ID, fileService.SignedURLExpire())
 
-	return imageURL, nil
+	return &storage, imageURL, nil
 }
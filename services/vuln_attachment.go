@@ -0,0 +1,265 @@
+// 漏洞附件服务包
+// 该包提供漏洞附件的上传、查询、下载和删除功能，附件内容按SHA256去重存储
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+)
+
+// VulnAttachmentService 漏洞附件服务
+type VulnAttachmentService struct{}
+
+// UploadConfig 文件上传配置
+type UploadConfig struct {
+	MaxSize                int64    `json:"max_size"`                 // 图片上传最大大小（字节）
+	AllowedTypes           []string `json:"allowed_types"`            // 图片允许的扩展名
+	AttachmentMaxSize      int64    `json:"attachment_max_size"`      // 附件上传最大大小（字节）
+	AttachmentAllowedTypes []string `json:"attachment_allowed_types"` // 附件允许的扩展名
+}
+
+// GetUploadConfig 从系统配置中读取文件上传配置
+func GetUploadConfig() *UploadConfig {
+	db := Init.GetDB()
+
+	config := &UploadConfig{
+		MaxSize:                10 * 1024 * 1024,
+		AllowedTypes:           []string{"jpg", "jpeg", "png"},
+		AttachmentMaxSize:      50 * 1024 * 1024,
+		AttachmentAllowedTypes: []string{"jpg", "jpeg", "png", "pdf", "txt", "har", "pcap", "pcapng", "zip"},
+	}
+
+	var configs []models.SystemConfig
+	if err := db.Where("`group` = ?", "upload").Find(&configs).Error; err != nil {
+		return config
+	}
+
+	splitTypes := func(value string) []string {
+		var types []string
+		for _, t := range strings.Split(value, ",") {
+			t = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "."))
+			if t != "" {
+				types = append(types, t)
+			}
+		}
+		return types
+	}
+
+	for _, cfg := range configs {
+		switch cfg.Key {
+		case "upload.max_size":
+			if size, err := strconv.ParseInt(cfg.Value, 10, 64); err == nil && size > 0 {
+				config.MaxSize = size * 1024 * 1024
+			}
+		case "upload.allowed_types":
+			if types := splitTypes(cfg.Value); len(types) > 0 {
+				config.AllowedTypes = types
+			}
+		case "upload.attachment_max_size":
+			if size, err := strconv.ParseInt(cfg.Value, 10, 64); err == nil && size > 0 {
+				config.AttachmentMaxSize = size * 1024 * 1024
+			}
+		case "upload.attachment_allowed_types":
+			if types := splitTypes(cfg.Value); len(types) > 0 {
+				config.AttachmentAllowedTypes = types
+			}
+		}
+	}
+
+	return config
+}
+
+// getAccessibleVuln 获取当前用户有权访问的漏洞
+func (s *VulnAttachmentService) getAccessibleVuln(vulnID uint, userID uint, userRole string) (*models.Vulnerability, error) {
+	db := Init.GetDB()
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	vulnService := &VulnService{}
+	if !vulnService.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	return &vuln, nil
+}
+
+// UploadAttachment 上传漏洞附件
+// 相同内容的文件只保存一份，通过FileStorage.Hash去重
+func (s *VulnAttachmentService) UploadAttachment(vulnID uint, file *multipart.FileHeader, userID uint, userRole string) (*models.VulnAttachment, error) {
+	db := Init.GetDB()
+
+	if _, err := s.getAccessibleVuln(vulnID, userID, userRole); err != nil {
+		return nil, err
+	}
+
+	// 校验文件类型和大小
+	config := GetUploadConfig()
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Filename), "."))
+	if ext == "" || !contains(config.AttachmentAllowedTypes, ext) {
+		return nil, fmt.Errorf("不支持的附件类型，允许的类型：%s", strings.Join(config.AttachmentAllowedTypes, ","))
+	}
+	if file.Size > config.AttachmentMaxSize {
+		return nil, fmt.Errorf("附件大小不能超过%dMB", config.AttachmentMaxSize/1024/1024)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("打开文件失败")
+	}
+	defer src.Close()
+
+	// 计算文件SHA256哈希值
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, src); err != nil {
+		return nil, errors.New("读取文件失败")
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	// 同一漏洞下不重复保存相同内容的附件
+	var existingCount int64
+	db.Model(&models.VulnAttachment{}).Where("vuln_id = ? AND hash = ?", vulnID, hash).Count(&existingCount)
+	if existingCount > 0 {
+		return nil, errors.New("该文件已作为附件上传")
+	}
+
+	mimeType := file.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		if guessed := mime.TypeByExtension("." + ext); guessed != "" {
+			mimeType = guessed
+		} else {
+			mimeType = "application/octet-stream"
+		}
+	}
+
+	// 查找是否已存在相同内容的文件
+	var storage models.FileStorage
+	if err := db.Where("hash = ?", hash).First(&storage).Error; err != nil {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return nil, errors.New("读取文件失败")
+		}
+
+		storage = models.FileStorage{
+			FileName: file.Filename,
+			FileSize: file.Size,
+			MimeType: mimeType,
+			Hash:     hash,
+			UserID:   userID,
+			Category: "attachment",
+		}
+		fileService := &FileService{}
+		key := fmt.Sprintf("attachments/%s/%s.%s", hash[:2], hash, ext)
+		if err := fileService.StoreFile(key, src, &storage); err != nil {
+			return nil, fmt.Errorf("保存附件失败: %v", err)
+		}
+	}
+
+	attachment := models.VulnAttachment{
+		VulnID:        vulnID,
+		FileName:      file.Filename,
+		FilePath:      storage.FilePath,
+		FileSize:      storage.FileSize,
+		MimeType:      mimeType,
+		Hash:          hash,
+		FileStorageID: storage.ID,
+		UploadBy:      userID,
+	}
+	if err := db.Create(&attachment).Error; err != nil {
+		return nil, errors.New("保存附件失败")
+	}
+
+	vulnService := &VulnService{}
+	vulnService.addTimeline(vulnID, userID, "attachment_added", fmt.Sprintf("上传附件：%s", file.Filename))
+
+	db.Preload("Uploader").Where("id = ?", attachment.ID).First(&attachment)
+
+	return &attachment, nil
+}
+
+// GetAttachments 获取漏洞附件列表
+func (s *VulnAttachmentService) GetAttachments(vulnID uint, userID uint, userRole string) ([]models.VulnAttachment, error) {
+	db := Init.GetDB()
+
+	if _, err := s.getAccessibleVuln(vulnID, userID, userRole); err != nil {
+		return nil, err
+	}
+
+	var attachments []models.VulnAttachment
+	if err := db.Preload("Uploader").Where("vuln_id = ?", vulnID).Order("created_at DESC").Find(&attachments).Error; err != nil {
+		return nil, errors.New("获取附件列表失败")
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment 获取单个漏洞附件，用于下载
+func (s *VulnAttachmentService) GetAttachment(vulnID uint, attachmentID uint, userID uint, userRole string) (*models.VulnAttachment, error) {
+	db := Init.GetDB()
+
+	if _, err := s.getAccessibleVuln(vulnID, userID, userRole); err != nil {
+		return nil, err
+	}
+
+	var attachment models.VulnAttachment
+	if err := db.Where("id = ? AND vuln_id = ?", attachmentID, vulnID).First(&attachment).Error; err != nil {
+		return nil, errors.New("附件不存在")
+	}
+
+	return &attachment, nil
+}
+
+// DeleteAttachment 删除漏洞附件
+// 只有上传者、漏洞提交人和超级管理员可以删除附件，文件不再被引用时一并删除
+func (s *VulnAttachmentService) DeleteAttachment(vulnID uint, attachmentID uint, userID uint, userRole string) error {
+	db := Init.GetDB()
+
+	vuln, err := s.getAccessibleVuln(vulnID, userID, userRole)
+	if err != nil {
+		return err
+	}
+
+	var attachment models.VulnAttachment
+	if err := db.Where("id = ? AND vuln_id = ?", attachmentID, vulnID).First(&attachment).Error; err != nil {
+		return errors.New("附件不存在")
+	}
+
+	if userRole != "super_admin" && attachment.UploadBy != userID && vuln.ReporterID != userID {
+		return errors.New("无权限删除此附件")
+	}
+
+	if err := db.Delete(&attachment).Error; err != nil {
+		return errors.New("删除附件失败")
+	}
+
+	// 文件不再被任何附件引用时删除存储记录和文件
+	if attachment.FileStorageID != 0 {
+		var refCount int64
+		db.Model(&models.VulnAttachment{}).Where("file_storage_id = ?", attachment.FileStorageID).Count(&refCount)
+		if refCount == 0 {
+			var storage models.FileStorage
+			if err := db.Where("id = ?", attachment.FileStorageID).First(&storage).Error; err == nil {
+				fileService := &FileService{}
+				if err := fileService.RemoveFile(&storage); err != nil {
+					fmt.Printf("删除附件文件失败: %v\n", err)
+				}
+			}
+		}
+	}
+
+	vulnService := &VulnService{}
+	vulnService.addTimeline(vulnID, userID, "attachment_deleted", fmt.Sprintf("删除附件：%s", attachment.FileName))
+
+	return nil
+}
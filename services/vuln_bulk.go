@@ -0,0 +1,403 @@
+// 漏洞批量操作服务包
+// 该包对一组漏洞ID或满足列表筛选条件的漏洞批量执行分配、调整严重程度、变更状态、添加标签、
+// 设置修复截止时间和删除操作。每个漏洞按与UpdateVuln相同的规则单独校验权限并返回执行结果，
+// 操作完成后每个相关人员只收到一条汇总通知
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 批量操作类型
+const (
+	VulnBulkAssign   = "assign"   // 分配
+	VulnBulkSeverity = "severity" // 调整严重程度
+	VulnBulkStatus   = "status"   // 变更状态
+	VulnBulkAddTag   = "add_tag"  // 添加标签
+	VulnBulkDeadline = "deadline" // 设置修复截止时间
+	VulnBulkDelete   = "delete"   // 删除
+)
+
+// maxVulnBulkSize 单次批量操作的最大漏洞数量
+const maxVulnBulkSize = 500
+
+// VulnBulkRequest 批量操作请求，ids和filter二选一
+type VulnBulkRequest struct {
+	Action          string           `json:"action" binding:"required"`
+	IDs             []uint           `json:"ids"`
+	Filter          *VulnListRequest `json:"filter" binding:"-"` // 按漏洞列表的筛选条件选择漏洞，忽略分页参数
+	AssigneeID      *uint            `json:"assignee_id"`        // assign：新的指派人
+	Severity        string           `json:"severity"`           // severity：新的严重程度
+	Status          string           `json:"status"`             // status：目标状态
+	Reason          string           `json:"reason"`             // status：驳回、忽略等操作的原因
+	CascadeChildren bool             `json:"cascade_children"`   // status：关闭时是否同步到子漏洞
+	Tag             string           `json:"tag"`                // add_tag：要添加的标签
+	FixDeadline     string           `json:"fix_deadline"`       // deadline：修复截止时间，格式YYYY-MM-DD
+}
+
+// VulnBulkItemResult 单个漏洞的执行结果
+type VulnBulkItemResult struct {
+	VulnID  uint   `json:"vuln_id"`
+	Title   string `json:"title"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// VulnBulkResult 批量操作结果
+type VulnBulkResult struct {
+	Action    string               `json:"action"`
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Items     []VulnBulkItemResult `json:"items"`
+}
+
+// vulnBulkContext 批量操作上下文，校验后的参数在执行前准备好，避免每个漏洞重复查询
+type vulnBulkContext struct {
+	db       *gorm.DB
+	req      *VulnBulkRequest
+	actor    *models.User
+	userID   uint
+	userRole string
+	now      time.Time
+	assignee *models.User
+	deadline *time.Time
+}
+
+// resolveBulkVulns 获取批量操作的漏洞：按ID时不存在的漏洞返回失败结果，按筛选条件时只包含当前用户可见的漏洞
+func (s *VulnService) resolveBulkVulns(db *gorm.DB, req *VulnBulkRequest, userID uint, userRole string) ([]models.Vulnerability, []VulnBulkItemResult, error) {
+	var vulns []models.Vulnerability
+	missing := []VulnBulkItemResult{}
+
+	if len(req.IDs) > 0 {
+		ids := []uint{}
+		seen := map[uint]bool{}
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > maxVulnBulkSize {
+			return nil, nil, fmt.Errorf("单次最多操作%d个漏洞", maxVulnBulkSize)
+		}
+		if err := db.Where("id IN (?)", ids).Order("id").Find(&vulns).Error; err != nil {
+			return nil, nil, errors.New("查询漏洞失败")
+		}
+
+		found := map[uint]bool{}
+		for _, vuln := range vulns {
+			found[vuln.ID] = true
+		}
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, VulnBulkItemResult{VulnID: id, Error: "漏洞不存在"})
+			}
+		}
+		return vulns, missing, nil
+	}
+
+	if req.Filter == nil {
+		return nil, nil, errors.New("请选择要操作的漏洞")
+	}
+	req.Filter.CurrentUserID = userID
+	req.Filter.CurrentUserRole = userRole
+	query, ok := s.vulnListQuery(db, req.Filter)
+	if !ok {
+		return []models.Vulnerability{}, missing, nil
+	}
+
+	var total int64
+	query.Count(&total)
+	if total > maxVulnBulkSize {
+		return nil, nil, fmt.Errorf("筛选出%d个漏洞，单次最多操作%d个，请缩小筛选范围", total, maxVulnBulkSize)
+	}
+	if err := query.Order("id").Find(&vulns).Error; err != nil {
+		return nil, nil, errors.New("查询漏洞失败")
+	}
+	return vulns, missing, nil
+}
+
+// prepareBulk 校验批量操作参数
+func (s *VulnService) prepareBulk(ctx *vulnBulkContext) error {
+	req := ctx.req
+	if ctx.userRole == "dev_engineer" && req.Action != VulnBulkStatus {
+		return errors.New("研发工程师只能批量变更漏洞状态")
+	}
+
+	switch req.Action {
+	case VulnBulkAssign:
+		if req.AssigneeID == nil || *req.AssigneeID == 0 {
+			return errors.New("请选择指派人")
+		}
+		var assignee models.User
+		if err := ctx.db.Where("id = ?", *req.AssigneeID).First(&assignee).Error; err != nil {
+			return errors.New("指定的分配人不存在")
+		}
+		ctx.assignee = &assignee
+	case VulnBulkSeverity:
+		if !isWorkflowOption(slaSeverities, req.Severity) {
+			return errors.New("无效的严重程度")
+		}
+	case VulnBulkStatus:
+		if req.Status == "" {
+			return errors.New("请选择目标状态")
+		}
+	case VulnBulkAddTag:
+		req.Tag = strings.TrimSpace(req.Tag)
+		if req.Tag == "" || strings.Contains(req.Tag, ",") {
+			return errors.New("请填写一个有效的标签")
+		}
+	case VulnBulkDeadline:
+		deadline, err := time.Parse("2006-01-02", req.FixDeadline)
+		if err != nil {
+			return errors.New("修复截止时间格式错误，请使用YYYY-MM-DD格式")
+		}
+		if deadline.Before(ctx.now.Truncate(24 * time.Hour)) {
+			return errors.New("修复截止时间不能是过去的日期")
+		}
+		ctx.deadline = &deadline
+	case VulnBulkDelete:
+		if ctx.userRole != "super_admin" && !ctx.actor.HasPermission("vuln:delete") {
+			return errors.New("无权限删除漏洞")
+		}
+	default:
+		return errors.New("无效的批量操作类型")
+	}
+	return nil
+}
+
+// BulkUpdateVulns 批量操作漏洞，单个漏洞失败不影响其他漏洞
+func (s *VulnService) BulkUpdateVulns(req *VulnBulkRequest, userID uint, userRole string) (*VulnBulkResult, error) {
+	db := Init.GetDB()
+
+	actor, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &vulnBulkContext{
+		db:       db,
+		req:      req,
+		actor:    actor,
+		userID:   userID,
+		userRole: userRole,
+		now:      time.Now().Truncate(time.Second),
+	}
+	if err := s.prepareBulk(ctx); err != nil {
+		return nil, err
+	}
+
+	vulns, items, err := s.resolveBulkVulns(db, req, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VulnBulkResult{Action: req.Action, Items: items}
+	notify := map[uint][]models.Vulnerability{}
+	projectIDs := map[uint]bool{}
+	for i := range vulns {
+		vuln := &vulns[i]
+		item := VulnBulkItemResult{VulnID: vuln.ID, Title: vuln.Title}
+		recipient, err := s.bulkUpdateVuln(ctx, vuln)
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Success = true
+			projectIDs[vuln.ProjectID] = true
+			if recipient != 0 && recipient != userID {
+				notify[recipient] = append(notify[recipient], *vuln)
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		return result.Items[i].VulnID < result.Items[j].VulnID
+	})
+	for _, item := range result.Items {
+		if item.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	result.Total = len(result.Items)
+
+	// 更新项目统计信息
+	projectService := &ProjectService{}
+	for projectID := range projectIDs {
+		if projectID == 0 {
+			continue
+		}
+		if err := projectService.UpdateProjectStats(projectID); err != nil {
+			fmt.Printf("更新项目统计失败 (项目ID: %d): %v\n", projectID, err)
+		}
+	}
+
+	// 每个接收者只发送一条汇总通知
+	if len(notify) > 0 {
+		summary := s.bulkSummary(ctx)
+		notificationService := &NotificationService{}
+		for recipientID, changed := range notify {
+			notificationService.NotifyVulnsBulkUpdated(recipientID, summary, changed)
+		}
+	}
+
+	return result, nil
+}
+
+// bulkSeverity 按与编辑漏洞相同的规则调整严重程度并返回时间线说明：
+// 有CVSS评分的漏洞只有超级管理员可以覆盖推导出的严重程度，其他用户的覆盖请求返回错误
+func (s *VulnService) bulkSeverity(vuln *models.Vulnerability, derived, requested, userRole string) (string, error) {
+	oldSeverity := vuln.Severity
+	note, err := s.resolveSeverity(vuln, derived, requested, userRole)
+	if err != nil {
+		return "", err
+	}
+	if note == "" {
+		note = fmt.Sprintf("严重程度由 %s 调整为 %s", oldSeverity, vuln.Severity)
+	}
+	return note, nil
+}
+
+// bulkSummary 批量操作的描述，用于汇总通知
+func (s *VulnService) bulkSummary(ctx *vulnBulkContext) string {
+	operator := displayName(ctx.actor)
+	switch ctx.req.Action {
+	case VulnBulkAssign:
+		return fmt.Sprintf("%s 将漏洞批量分配给您", operator)
+	case VulnBulkSeverity:
+		return fmt.Sprintf("%s 将漏洞严重程度批量调整为%s", operator, severityLabel(ctx.req.Severity))
+	case VulnBulkStatus:
+		return fmt.Sprintf("%s 将漏洞状态批量变更为「%s」", operator, vulnStatusLabel(ctx.req.Status))
+	case VulnBulkAddTag:
+		return fmt.Sprintf("%s 为漏洞批量添加了标签「%s」", operator, ctx.req.Tag)
+	case VulnBulkDeadline:
+		return fmt.Sprintf("%s 将漏洞修复截止时间批量调整为%s", operator, ctx.deadline.Format("2006-01-02"))
+	case VulnBulkDelete:
+		return fmt.Sprintf("%s 批量删除了漏洞", operator)
+	}
+	return fmt.Sprintf("%s 批量更新了漏洞", operator)
+}
+
+// bulkUpdateVuln 对单个漏洞执行批量操作，返回需要通知的用户ID
+func (s *VulnService) bulkUpdateVuln(ctx *vulnBulkContext, vuln *models.Vulnerability) (uint, error) {
+	db, req, userID := ctx.db, ctx.req, ctx.userID
+	if err := s.checkVulnEditPermission(db, vuln, userID, ctx.userRole); err != nil {
+		return 0, err
+	}
+
+	recipient := uint(0)
+	if vuln.AssigneeID != nil {
+		recipient = *vuln.AssigneeID
+	}
+
+	switch req.Action {
+	case VulnBulkAssign:
+		if vuln.AssigneeID != nil && *vuln.AssigneeID == ctx.assignee.ID {
+			return 0, errors.New("漏洞已分配给该用户")
+		}
+		vuln.AssigneeID = &ctx.assignee.ID
+		vuln.AssignedAt = &ctx.now
+		if err := db.Save(vuln).Error; err != nil {
+			return 0, errors.New("更新漏洞失败")
+		}
+		s.addTimeline(vuln.ID, userID, "assigned", fmt.Sprintf("批量操作：漏洞重新分配给 %s", displayName(ctx.assignee)))
+
+		webhookService := &WebhookService{}
+		webhookService.Emit(WebhookVulnAssigned, NewWebhookVulnData(vuln, userID))
+		return ctx.assignee.ID, nil
+
+	case VulnBulkSeverity:
+		if vuln.Severity == req.Severity {
+			return 0, errors.New("严重程度未变化")
+		}
+		var asset models.Asset
+		db.Where("id = ?", vuln.AssetID).First(&asset)
+		derived, err := s.applyCVSS(vuln, &asset)
+		if err != nil {
+			return 0, err
+		}
+		note, err := s.bulkSeverity(vuln, derived, req.Severity, ctx.userRole)
+		if err != nil {
+			return 0, err
+		}
+		applyVulnRisk(vuln, &asset)
+		if err := db.Save(vuln).Error; err != nil {
+			return 0, errors.New("更新漏洞失败")
+		}
+		s.addTimeline(vuln.ID, userID, "severity", "批量操作："+note)
+
+	case VulnBulkStatus:
+		oldStatus := vuln.Status
+		if normalizeVulnStatus(oldStatus) == req.Status {
+			return 0, errors.New("漏洞已处于该状态")
+		}
+		change, err := s.ChangeStatus(db, vuln, req.Status, ctx.actor, req.Reason)
+		if err != nil {
+			return 0, err
+		}
+		if err := db.Save(vuln).Error; err != nil {
+			return 0, errors.New("更新漏洞失败")
+		}
+		s.recordStatusEffects(db, change)
+		s.recordStatusChange(vuln.ID, userID, oldStatus, req.Status, "批量操作")
+		if req.CascadeChildren && isVulnClosedStatus(vuln.Status) {
+			s.cascadeToChildren(db, vuln, ctx.actor, 0)
+		}
+
+		webhookService := &WebhookService{}
+		data := NewWebhookVulnData(vuln, userID)
+		data.OldStatus = oldStatus
+		switch req.Status {
+		case VulnStatusFixed:
+			webhookService.Emit(WebhookVulnFixed, data)
+		case VulnStatusRejected:
+			data.Comment = vuln.RejectReason
+			webhookService.Emit(WebhookVulnRejected, data)
+		}
+
+		// 状态变更通知下一处理人，复测通过时通知项目负责人
+		if vuln.ProjectID != 0 {
+			db.Where("id = ?", vuln.ProjectID).First(&vuln.Project)
+		}
+		if next := s.getNextUserForStatus(vuln, req.Status, nil); next != nil {
+			recipient = *next
+		}
+
+	case VulnBulkAddTag:
+		tags := splitWorkflowList(vuln.Tags)
+		if contains(tags, req.Tag) {
+			return 0, errors.New("漏洞已有该标签")
+		}
+		vuln.Tags = strings.Join(append(tags, req.Tag), ",")
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).Update("tags", vuln.Tags).Error; err != nil {
+			return 0, errors.New("更新漏洞失败")
+		}
+		s.addTimeline(vuln.ID, userID, "tagged", fmt.Sprintf("批量操作：添加标签「%s」", req.Tag))
+
+	case VulnBulkDeadline:
+		vuln.FixDeadline = ctx.deadline
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).Update("fix_deadline", *ctx.deadline).Error; err != nil {
+			return 0, errors.New("更新漏洞失败")
+		}
+		s.addTimeline(vuln.ID, userID, "deadline", fmt.Sprintf("批量操作：修复截止时间调整为%s", ctx.deadline.Format("2006-01-02")))
+
+	case VulnBulkDelete:
+		if err := db.Delete(vuln).Error; err != nil {
+			return 0, errors.New("删除漏洞失败")
+		}
+		s.addTimeline(vuln.ID, userID, "deleted", "漏洞已删除（批量操作）")
+	}
+
+	return recipient, nil
+}
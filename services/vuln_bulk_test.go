@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"vulnmain/models"
+)
+
+func TestBulkSeverity(t *testing.T) {
+	tests := []struct {
+		name           string
+		current        string
+		derived        string
+		requested      string
+		role           string
+		wantSeverity   string
+		wantOverridden bool
+		wantNote       string
+		wantErr        bool
+	}{
+		{name: "no cvss", current: "medium", requested: "high", role: "security_engineer", wantSeverity: "high", wantNote: "严重程度由 medium 调整为 high"},
+		{name: "requested matches derived", current: "low", derived: "critical", requested: "critical", role: "security_engineer", wantSeverity: "critical", wantNote: "严重程度由 low 调整为 critical"},
+		{name: "non-admin override rejected", current: "critical", derived: "critical", requested: "low", role: "security_engineer", wantErr: true},
+		{name: "admin override recorded", current: "critical", derived: "critical", requested: "low", role: "super_admin", wantSeverity: "low", wantOverridden: true, wantNote: "管理员将严重程度由 critical（CVSS 9.8）调整为 low"},
+	}
+
+	s := &VulnService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vuln := &models.Vulnerability{Severity: tt.current}
+			if tt.derived != "" {
+				vuln.CVSSScore = 9.8
+			}
+			note, err := s.bulkSeverity(vuln, tt.derived, tt.requested, tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bulkSeverity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if vuln.Severity != tt.wantSeverity || vuln.SeverityOverridden != tt.wantOverridden {
+				t.Errorf("severity = %s (overridden %v), want %s (overridden %v)", vuln.Severity, vuln.SeverityOverridden, tt.wantSeverity, tt.wantOverridden)
+			}
+			if note != tt.wantNote {
+				t.Errorf("note = %q, want %q", note, tt.wantNote)
+			}
+		})
+	}
+}
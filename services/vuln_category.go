@@ -0,0 +1,563 @@
+// 漏洞分类服务包
+// 该包管理基于CWE和OWASP Top 10的层级漏洞分类：创建漏洞、导入扫描结果时按漏洞类型、
+// CWE编号和分类别名把自由填写的漏洞类型归入分类，仪表板和周报按分类树逐级汇总漏洞数量
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// VulnCategoryService 漏洞分类服务
+type VulnCategoryService struct{}
+
+// VulnCategoryRequest 创建或更新漏洞分类请求
+type VulnCategoryRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	Description string `json:"description"`
+	CWEID       string `json:"cwe_id"`    // CWE编号，如CWE-89，也可以只填数字
+	OWASP       string `json:"owasp"`     // OWASP Top 10类别，如A03:2021
+	Aliases     string `json:"aliases"`   // 别名，多个用逗号分隔
+	ParentID    *uint  `json:"parent_id"` // 上级分类，为空时为第一级分类
+	Sort        int    `json:"sort"`
+	Status      *int   `json:"status"` // 1启用，0禁用，默认启用
+}
+
+// VulnCategoryStat 漏洞分类统计，合计数量包含全部下级分类的漏洞
+type VulnCategoryStat struct {
+	CategoryID uint                `json:"category_id"` // 分类ID，0表示未分类
+	Name       string              `json:"name"`
+	Code       string              `json:"code"`
+	CWEID      string              `json:"cwe_id"`
+	OWASP      string              `json:"owasp"`
+	Level      int                 `json:"level"`
+	Count      int64               `json:"count"` // 直接归入该分类的漏洞数
+	Total      int64               `json:"total"` // 含下级分类的漏洞数
+	Open       int64               `json:"open"`  // 含下级分类的未关闭漏洞数
+	Children   []*VulnCategoryStat `json:"children,omitempty"`
+}
+
+// vulnCategoryMaxDepth 分类树的最大层级，同时用于防止父子关系成环时无限循环
+const vulnCategoryMaxDepth = 5
+
+var cweIDPattern = regexp.MustCompile(`^CWE-\d+$`)
+
+// normalizeCWEID 规范化CWE编号，只填数字时补全CWE-前缀
+func normalizeCWEID(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(value, "CWE-") {
+		value = "CWE-" + value
+	}
+	if !cweIDPattern.MatchString(value) {
+		return "", errors.New("CWE编号格式错误，应为CWE-数字")
+	}
+	return value, nil
+}
+
+// buildVulnCategoryTree 将分类列表组装为树，上级分类不存在的分类作为第一级分类
+func buildVulnCategoryTree(categories []models.VulnCategory) []models.VulnCategory {
+	exists := map[uint]bool{}
+	for _, category := range categories {
+		exists[category.ID] = true
+	}
+	byParent := map[uint][]models.VulnCategory{}
+	for _, category := range categories {
+		parentID := uint(0)
+		if category.ParentID != nil && exists[*category.ParentID] && *category.ParentID != category.ID {
+			parentID = *category.ParentID
+		}
+		byParent[parentID] = append(byParent[parentID], category)
+	}
+
+	var build func(parentID uint, depth int) []models.VulnCategory
+	build = func(parentID uint, depth int) []models.VulnCategory {
+		children := byParent[parentID]
+		if depth > vulnCategoryMaxDepth {
+			return nil
+		}
+		for i := range children {
+			children[i].Children = build(children[i].ID, depth+1)
+		}
+		return children
+	}
+	tree := build(0, 1)
+	if tree == nil {
+		tree = []models.VulnCategory{}
+	}
+	return tree
+}
+
+// GetVulnCategoryTree 获取漏洞分类树，includeDisabled为false时只返回启用的分类
+func (s *VulnCategoryService) GetVulnCategoryTree(includeDisabled bool) ([]models.VulnCategory, error) {
+	db := Init.GetDB()
+
+	query := db.Order("level ASC, sort ASC, id ASC")
+	if !includeDisabled {
+		query = query.Where("status = ?", 1)
+	}
+	var categories []models.VulnCategory
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, errors.New("获取漏洞分类失败")
+	}
+	return buildVulnCategoryTree(categories), nil
+}
+
+// validateVulnCategoryRequest 校验分类请求，返回上级分类
+func validateVulnCategoryRequest(db *gorm.DB, req *VulnCategoryRequest, id uint) (*models.VulnCategory, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Name == "" || req.Code == "" {
+		return nil, errors.New("分类名称和代码不能为空")
+	}
+	cweID, err := normalizeCWEID(req.CWEID)
+	if err != nil {
+		return nil, err
+	}
+	req.CWEID = cweID
+	if req.Status != nil && *req.Status != 0 && *req.Status != 1 {
+		return nil, errors.New("分类状态只能为0或1")
+	}
+
+	var count int
+	db.Model(&models.VulnCategory{}).Where("name = ? AND id <> ?", req.Name, id).Count(&count)
+	if count > 0 {
+		return nil, errors.New("分类名称已存在")
+	}
+	db.Model(&models.VulnCategory{}).Where("code = ? AND id <> ?", req.Code, id).Count(&count)
+	if count > 0 {
+		return nil, errors.New("分类代码已存在")
+	}
+
+	if req.ParentID == nil || *req.ParentID == 0 {
+		req.ParentID = nil
+		return nil, nil
+	}
+	var parent models.VulnCategory
+	if err := db.Where("id = ?", *req.ParentID).First(&parent).Error; err != nil {
+		return nil, errors.New("上级分类不存在")
+	}
+	if parent.Level >= vulnCategoryMaxDepth {
+		return nil, fmt.Errorf("分类最多%d级", vulnCategoryMaxDepth)
+	}
+	return &parent, nil
+}
+
+// CreateVulnCategory 创建漏洞分类，未填写OWASP类别时沿用上级分类的类别
+func (s *VulnCategoryService) CreateVulnCategory(req *VulnCategoryRequest) (*models.VulnCategory, error) {
+	db := Init.GetDB()
+
+	parent, err := validateVulnCategoryRequest(db, req, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	category := models.VulnCategory{
+		Name:        req.Name,
+		Code:        req.Code,
+		Description: req.Description,
+		CWEID:       req.CWEID,
+		OWASP:       strings.TrimSpace(req.OWASP),
+		Aliases:     strings.TrimSpace(req.Aliases),
+		ParentID:    req.ParentID,
+		Level:       1,
+		Sort:        req.Sort,
+		Status:      1,
+	}
+	if parent != nil {
+		category.Level = parent.Level + 1
+		if category.OWASP == "" {
+			category.OWASP = parent.OWASP
+		}
+	}
+	if err := db.Create(&category).Error; err != nil {
+		return nil, errors.New("创建漏洞分类失败")
+	}
+
+	// GORM不会写入零值，禁用状态需要单独更新
+	if req.Status != nil && *req.Status == 0 {
+		db.Model(&category).Update("status", 0)
+	}
+
+	return &category, nil
+}
+
+// UpdateVulnCategory 更新漏洞分类，调整上级分类时同步更新下级分类的层级
+func (s *VulnCategoryService) UpdateVulnCategory(id uint, req *VulnCategoryRequest) (*models.VulnCategory, error) {
+	db := Init.GetDB()
+
+	var category models.VulnCategory
+	if err := db.Where("id = ?", id).First(&category).Error; err != nil {
+		return nil, errors.New("漏洞分类不存在")
+	}
+	parent, err := validateVulnCategoryRequest(db, req, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 上级分类不能是自身或下级分类
+	level := 1
+	if parent != nil {
+		for _, descendantID := range vulnCategoryDescendantIDs(db, id) {
+			if descendantID == parent.ID {
+				return nil, errors.New("上级分类不能是自身或下级分类")
+			}
+		}
+		level = parent.Level + 1
+	}
+
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"code":        req.Code,
+		"description": req.Description,
+		"cwe_id":      req.CWEID,
+		"owasp":       strings.TrimSpace(req.OWASP),
+		"aliases":     strings.TrimSpace(req.Aliases),
+		"parent_id":   req.ParentID,
+		"level":       level,
+		"sort":        req.Sort,
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+	if err := db.Model(&category).Updates(updates).Error; err != nil {
+		return nil, errors.New("更新漏洞分类失败")
+	}
+	if level != category.Level {
+		updateVulnCategoryLevels(db, id, level, 1)
+	}
+
+	db.Where("id = ?", id).First(&category)
+	return &category, nil
+}
+
+// updateVulnCategoryLevels 按上级分类的层级更新下级分类的层级
+func updateVulnCategoryLevels(db *gorm.DB, parentID uint, parentLevel int, depth int) {
+	if depth > vulnCategoryMaxDepth {
+		return
+	}
+	var children []models.VulnCategory
+	db.Select("id").Where("parent_id = ?", parentID).Find(&children)
+	for _, child := range children {
+		db.Model(&models.VulnCategory{}).Where("id = ?", child.ID).Update("level", parentLevel+1)
+		updateVulnCategoryLevels(db, child.ID, parentLevel+1, depth+1)
+	}
+}
+
+// DeleteVulnCategory 删除漏洞分类，有下级分类或已有漏洞归入的分类不能删除
+func (s *VulnCategoryService) DeleteVulnCategory(id uint) error {
+	db := Init.GetDB()
+
+	var category models.VulnCategory
+	if err := db.Where("id = ?", id).First(&category).Error; err != nil {
+		return errors.New("漏洞分类不存在")
+	}
+
+	var count int
+	db.Model(&models.VulnCategory{}).Where("parent_id = ?", id).Count(&count)
+	if count > 0 {
+		return errors.New("请先删除下级分类")
+	}
+	db.Model(&models.Vulnerability{}).Where("category_id = ?", id).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("有%d个漏洞归入该分类，请先调整漏洞分类或禁用该分类", count)
+	}
+
+	if err := db.Delete(&category).Error; err != nil {
+		return errors.New("删除漏洞分类失败")
+	}
+	return nil
+}
+
+// vulnCategoryDescendantIDs 获取分类及其全部下级分类的ID
+func vulnCategoryDescendantIDs(db *gorm.DB, id uint) []uint {
+	ids := []uint{id}
+	parents := []uint{id}
+	for depth := 0; depth < vulnCategoryMaxDepth && len(parents) > 0; depth++ {
+		var children []models.VulnCategory
+		db.Select("id").Where("parent_id IN (?)", parents).Find(&children)
+		parents = nil
+		for _, child := range children {
+			ids = append(ids, child.ID)
+			parents = append(parents, child.ID)
+		}
+	}
+	return ids
+}
+
+// normalizeCategoryKey 统一大小写并去除空白和连接符，使SQL注入、sql 注入、SQL-Injection等写法可以匹配
+func normalizeCategoryKey(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
+
+// asciiCategoryWords 将文本中的英文和数字按单词切分后以空格连接，首尾加空格便于按整词匹配
+func asciiCategoryWords(value string) string {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return " " + strings.Join(words, " ") + " "
+}
+
+// isASCIIText 判断文本是否只包含ASCII字符
+func isASCIIText(value string) bool {
+	for _, r := range value {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// vulnCategoryKeyword 用于在漏洞类型中查找的分类关键词
+type vulnCategoryKeyword struct {
+	key        string // 中文关键词为规范化后的文本，英文关键词为按单词切分后的文本
+	ascii      bool
+	length     int
+	categoryID uint
+}
+
+// vulnCategoryMatcher 漏洞类型到分类的匹配器
+type vulnCategoryMatcher struct {
+	exact    map[string]uint
+	cwe      map[string]uint
+	keywords []vulnCategoryKeyword
+}
+
+// loadVulnCategoryMatcher 加载启用的分类，层级更深的分类更具体，名称或别名冲突时优先匹配
+func loadVulnCategoryMatcher(db *gorm.DB) *vulnCategoryMatcher {
+	var categories []models.VulnCategory
+	db.Where("status = ?", 1).Order("level DESC, sort ASC, id ASC").Find(&categories)
+	return newVulnCategoryMatcher(categories)
+}
+
+// newVulnCategoryMatcher 按分类的名称、代码、CWE编号和别名创建匹配器，排在前面的分类优先匹配
+func newVulnCategoryMatcher(categories []models.VulnCategory) *vulnCategoryMatcher {
+	matcher := &vulnCategoryMatcher{exact: map[string]uint{}, cwe: map[string]uint{}}
+	for _, category := range categories {
+		names := []string{category.Name, category.Code}
+		names = append(names, splitWorkflowList(strings.ReplaceAll(category.Aliases, "，", ","))...)
+		if category.CWEID != "" {
+			names = append(names, category.CWEID)
+			if _, ok := matcher.cwe[category.CWEID]; !ok {
+				matcher.cwe[category.CWEID] = category.ID
+			}
+		}
+		for _, name := range names {
+			key := normalizeCategoryKey(name)
+			if key == "" {
+				continue
+			}
+			if _, ok := matcher.exact[key]; !ok {
+				matcher.exact[key] = category.ID
+			}
+			keyword := vulnCategoryKeyword{key: key, length: len([]rune(key)), categoryID: category.ID}
+			if isASCIIText(name) {
+				keyword.ascii = true
+				keyword.key = asciiCategoryWords(name)
+			}
+			if keyword.length >= 2 {
+				matcher.keywords = append(matcher.keywords, keyword)
+			}
+		}
+	}
+
+	// 关键词越长越具体，如“远程命令执行”优先于“命令执行”
+	sort.SliceStable(matcher.keywords, func(i, j int) bool {
+		return matcher.keywords[i].length > matcher.keywords[j].length
+	})
+	return matcher
+}
+
+// match 按漏洞类型精确匹配分类名称、代码、CWE编号或别名，其次按漏洞的CWE编号匹配，
+// 最后在漏洞类型中查找分类关键词，未匹配时返回nil
+func (m *vulnCategoryMatcher) match(vulnType, cweIDs string) *uint {
+	key := normalizeCategoryKey(vulnType)
+	if id, ok := m.exact[key]; ok && key != "" {
+		return &id
+	}
+	for _, cweID := range splitWorkflowList(strings.ToUpper(cweIDs)) {
+		if id, ok := m.cwe[cweID]; ok {
+			return &id
+		}
+	}
+	if key == "" {
+		return nil
+	}
+	words := asciiCategoryWords(vulnType)
+	for _, keyword := range m.keywords {
+		if keyword.ascii && strings.Contains(words, keyword.key) || !keyword.ascii && strings.Contains(key, keyword.key) {
+			id := keyword.categoryID
+			return &id
+		}
+	}
+	return nil
+}
+
+// matchVulnCategory 根据漏洞类型和CWE编号匹配漏洞分类
+func matchVulnCategory(db *gorm.DB, vulnType, cweIDs string) *uint {
+	return loadVulnCategoryMatcher(db).match(vulnType, cweIDs)
+}
+
+// checkVulnCategory 校验手动指定的漏洞分类
+func checkVulnCategory(db *gorm.DB, categoryID uint) error {
+	var category models.VulnCategory
+	if err := db.Where("id = ?", categoryID).First(&category).Error; err != nil {
+		return errors.New("漏洞分类不存在")
+	}
+	if category.Status != 1 {
+		return errors.New("漏洞分类已禁用")
+	}
+	return nil
+}
+
+// classifyUncategorizedVulns 为尚未归类的漏洞按漏洞类型和CWE编号匹配分类，返回归类的漏洞数
+func classifyUncategorizedVulns(db *gorm.DB) int {
+	matcher := loadVulnCategoryMatcher(db)
+	classified := 0
+	lastID := uint(0)
+	for {
+		var vulns []models.Vulnerability
+		if err := db.Select("id, vuln_type, cwe_id").Where("category_id IS NULL AND id > ?", lastID).
+			Order("id").Limit(500).Find(&vulns).Error; err != nil {
+			fmt.Printf("查询未归类的漏洞失败: %v\n", err)
+			return classified
+		}
+		if len(vulns) == 0 {
+			return classified
+		}
+		lastID = vulns[len(vulns)-1].ID
+
+		for _, vuln := range vulns {
+			categoryID := matcher.match(vuln.VulnType, vuln.CWEID)
+			if categoryID == nil {
+				continue
+			}
+			if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).UpdateColumn("category_id", *categoryID).Error; err != nil {
+				fmt.Printf("更新漏洞分类失败 (漏洞ID: %d): %v\n", vuln.ID, err)
+				continue
+			}
+			classified++
+		}
+	}
+}
+
+// ClassifyVulns 为尚未归类的漏洞重新匹配分类，用于调整分类别名后
+func (s *VulnCategoryService) ClassifyVulns() int {
+	return classifyUncategorizedVulns(Init.GetDB())
+}
+
+// BackfillVulnCategories 为尚未归类的历史漏洞匹配分类，归类失败不影响系统启动
+func BackfillVulnCategories() error {
+	if count := classifyUncategorizedVulns(Init.GetDB()); count > 0 {
+		fmt.Printf("已为%d个历史漏洞匹配漏洞分类\n", count)
+	}
+	return nil
+}
+
+// vulnCategoryStats 统计查询范围内的漏洞按分类的分布，数量逐级汇总到上级分类，
+// 只返回有漏洞的分类，同级按合计数量从多到少排序，未归类的漏洞放在最后
+func vulnCategoryStats(db *gorm.DB, query *gorm.DB) []*VulnCategoryStat {
+	stats := []*VulnCategoryStat{}
+
+	var rows []struct {
+		CategoryID *uint
+		Status     string
+		Count      int64
+	}
+	if err := query.Select("category_id, status, COUNT(*) as count").Group("category_id, status").Scan(&rows).Error; err != nil {
+		return stats
+	}
+
+	var categories []models.VulnCategory
+	db.Find(&categories)
+	nodes := map[uint]*VulnCategoryStat{}
+	parents := map[uint]uint{}
+	for _, category := range categories {
+		nodes[category.ID] = &VulnCategoryStat{
+			CategoryID: category.ID,
+			Name:       category.Name,
+			Code:       category.Code,
+			CWEID:      category.CWEID,
+			OWASP:      category.OWASP,
+			Level:      category.Level,
+		}
+		if category.ParentID != nil {
+			parents[category.ID] = *category.ParentID
+		}
+	}
+
+	uncategorized := &VulnCategoryStat{Name: "未分类", Code: "uncategorized"}
+	for _, row := range rows {
+		open := int64(0)
+		if !isVulnClosedStatus(row.Status) {
+			open = row.Count
+		}
+		node := uncategorized
+		if row.CategoryID != nil && nodes[*row.CategoryID] != nil {
+			node = nodes[*row.CategoryID]
+		}
+		node.Count += row.Count
+		if node == uncategorized {
+			node.Total += row.Count
+			node.Open += open
+			continue
+		}
+		id := node.CategoryID
+		for depth := 0; depth < vulnCategoryMaxDepth; depth++ {
+			nodes[id].Total += row.Count
+			nodes[id].Open += open
+			parentID, ok := parents[id]
+			if !ok || nodes[parentID] == nil {
+				break
+			}
+			id = parentID
+		}
+	}
+
+	// 组装有漏洞的分类树
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if node.Total == 0 {
+			continue
+		}
+		if parent := nodes[parents[category.ID]]; parent != nil && parent.Total > 0 && parents[category.ID] != category.ID {
+			parent.Children = append(parent.Children, node)
+		} else {
+			stats = append(stats, node)
+		}
+	}
+	sortVulnCategoryStats(stats, 1)
+	if uncategorized.Total > 0 {
+		stats = append(stats, uncategorized)
+	}
+	return stats
+}
+
+// sortVulnCategoryStats 同级分类按合计数量从多到少排序
+func sortVulnCategoryStats(stats []*VulnCategoryStat, depth int) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Total > stats[j].Total
+	})
+	if depth >= vulnCategoryMaxDepth {
+		return
+	}
+	for _, stat := range stats {
+		sortVulnCategoryStats(stat.Children, depth+1)
+	}
+}
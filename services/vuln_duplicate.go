@@ -0,0 +1,443 @@
+// 漏洞重复检测服务包
+// 该包根据资产、规范化后的漏洞地址、漏洞类型和CVE编号计算漏洞指纹，
+// 在提交和导入漏洞时识别疑似重复的漏洞和已完成漏洞的回归，并支持将重复漏洞合并
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// duplicateSimilarityThreshold 相似度达到该值时视为疑似重复
+const duplicateSimilarityThreshold = 60
+
+// maxDuplicateCandidates 最多返回的疑似重复漏洞数量
+const maxDuplicateCandidates = 10
+
+// variablePathSegment 匹配路径中的数字ID、UUID、长哈希等可变片段，规范化时替换为占位符
+var variablePathSegment = regexp.MustCompile(`^([0-9]+|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{24,})$`)
+
+// VulnDuplicateCheckRequest 提交漏洞前检查重复的请求
+type VulnDuplicateCheckRequest struct {
+	AssetID  uint   `json:"asset_id" binding:"required"`
+	VulnURL  string `json:"vuln_url"`
+	VulnType string `json:"vuln_type"`
+	CVEID    string `json:"cve_id"`
+	Title    string `json:"title"`
+}
+
+// VulnDuplicateCandidate 疑似重复的漏洞
+type VulnDuplicateCandidate struct {
+	VulnID      uint       `json:"vuln_id"`
+	Title       string     `json:"title"`
+	VulnURL     string     `json:"vuln_url"`
+	VulnType    string     `json:"vuln_type"`
+	CVEID       string     `json:"cve_id"`
+	Severity    string     `json:"severity"`
+	Status      string     `json:"status"`
+	ProjectID   uint       `json:"project_id"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Similarity  int        `json:"similarity"` // 相似度，0-100
+	Exact       bool       `json:"exact"`      // 指纹完全一致
+	Regression  bool       `json:"regression"` // 指纹一致且原漏洞已完成，即漏洞复现
+	Reasons     []string   `json:"reasons"`    // 相似原因
+}
+
+// VulnMergeRequest 合并漏洞请求
+type VulnMergeRequest struct {
+	TargetID uint `json:"target_id" binding:"required"` // 合并到的漏洞ID
+}
+
+// normalizeVulnURL 规范化漏洞地址：忽略协议、默认端口、查询参数、锚点和大小写，路径中的ID替换为占位符
+func normalizeVulnURL(raw string) string {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(strings.TrimPrefix(raw, "http://"), "/")
+	}
+
+	host := u.Hostname()
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	segments := []string{}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "" {
+			continue
+		}
+		if variablePathSegment.MatchString(segment) {
+			segment = "{id}"
+		}
+		segments = append(segments, segment)
+	}
+	return host + "/" + strings.Join(segments, "/")
+}
+
+// normalizeVulnType 规范化漏洞类型，忽略大小写、空格和连接符
+func normalizeVulnType(vulnType string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(vulnType)))
+}
+
+// VulnFingerprint 计算漏洞指纹
+func VulnFingerprint(assetID uint, vulnURL, vulnType, cveID string) string {
+	key := fmt.Sprintf("%d|%s|%s|%s", assetID, normalizeVulnURL(vulnURL), normalizeVulnType(vulnType), strings.ToUpper(strings.TrimSpace(cveID)))
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// titleSimilarity 按字符二元组计算标题相似度（Dice系数），同时适用于中英文标题
+func titleSimilarity(a, b string) float64 {
+	bigrams := func(s string) map[string]int {
+		runes := []rune(strings.ToLower(strings.Join(strings.Fields(s), "")))
+		result := map[string]int{}
+		for i := 0; i+1 < len(runes); i++ {
+			result[string(runes[i:i+2])]++
+		}
+		return result
+	}
+
+	x, y := bigrams(a), bigrams(b)
+	total := 0
+	for _, n := range x {
+		total += n
+	}
+	for _, n := range y {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	common := 0
+	for k, n := range x {
+		if m := y[k]; m < n {
+			common += m
+		} else {
+			common += n
+		}
+	}
+	return float64(2*common) / float64(total)
+}
+
+// urlSimilarity 按相同的路径前缀计算规范化地址的相似度
+func urlSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	x, y := strings.Split(a, "/"), strings.Split(b, "/")
+	if x[0] != y[0] {
+		// 主机不同
+		return 0
+	}
+	common := 0
+	for i := 0; i < len(x) && i < len(y) && x[i] == y[i]; i++ {
+		common++
+	}
+	longest := len(x)
+	if len(y) > longest {
+		longest = len(y)
+	}
+	return float64(common) / float64(longest)
+}
+
+// vulnSimilarity 计算同一资产下两个漏洞的相似度
+// 指纹一致记100分；否则漏洞地址最高40分、漏洞类型25分、CVE编号25分、标题最高10分，CVE编号不同的不视为重复
+func vulnSimilarity(vuln, other *models.Vulnerability) (int, []string) {
+	cveA := strings.ToUpper(strings.TrimSpace(vuln.CVEID))
+	cveB := strings.ToUpper(strings.TrimSpace(other.CVEID))
+	if cveA != "" && cveB != "" && cveA != cveB {
+		return 0, nil
+	}
+
+	if vuln.Fingerprint != "" && vuln.Fingerprint == other.Fingerprint {
+		return 100, []string{"漏洞指纹一致"}
+	}
+
+	score := 0.0
+	reasons := []string{}
+	if similarity := urlSimilarity(normalizeVulnURL(vuln.VulnURL), normalizeVulnURL(other.VulnURL)); similarity > 0 {
+		score += 40 * similarity
+		if similarity == 1 {
+			reasons = append(reasons, "漏洞地址相同")
+		} else {
+			reasons = append(reasons, "漏洞地址相近")
+		}
+	}
+	if typeA := normalizeVulnType(vuln.VulnType); typeA != "" && typeA == normalizeVulnType(other.VulnType) {
+		score += 25
+		reasons = append(reasons, "漏洞类型相同")
+	}
+	if cveA != "" && cveA == cveB {
+		score += 25
+		reasons = append(reasons, "CVE编号相同")
+	}
+	if similarity := titleSimilarity(vuln.Title, other.Title); similarity > 0 {
+		score += 10 * similarity
+		if similarity >= 0.8 {
+			reasons = append(reasons, "标题相似")
+		}
+	}
+	return int(score + 0.5), reasons
+}
+
+// findDuplicateCandidates 查找同一资产下与漏洞相似的漏洞，按相似度从高到低排序
+func findDuplicateCandidates(db *gorm.DB, vuln *models.Vulnerability) []VulnDuplicateCandidate {
+	var others []models.Vulnerability
+	if err := db.Where("asset_id = ? AND id <> ?", vuln.AssetID, vuln.ID).Find(&others).Error; err != nil {
+		fmt.Printf("查询疑似重复漏洞失败: %v\n", err)
+		return nil
+	}
+
+	candidates := []VulnDuplicateCandidate{}
+	for i := range others {
+		other := &others[i]
+		if other.Fingerprint == "" {
+			other.Fingerprint = VulnFingerprint(other.AssetID, other.VulnURL, other.VulnType, other.CVEID)
+		}
+		similarity, reasons := vulnSimilarity(vuln, other)
+		if similarity < duplicateSimilarityThreshold {
+			continue
+		}
+
+		exact := similarity == 100
+		candidates = append(candidates, VulnDuplicateCandidate{
+			VulnID:      other.ID,
+			Title:       other.Title,
+			VulnURL:     other.VulnURL,
+			VulnType:    other.VulnType,
+			CVEID:       other.CVEID,
+			Severity:    other.Severity,
+			Status:      other.Status,
+			ProjectID:   other.ProjectID,
+			CompletedAt: other.CompletedAt,
+			Similarity:  similarity,
+			Exact:       exact,
+			Regression:  exact && other.Status == VulnStatusCompleted,
+			Reasons:     reasons,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Similarity != candidates[j].Similarity {
+			return candidates[i].Similarity > candidates[j].Similarity
+		}
+		return candidates[i].VulnID > candidates[j].VulnID
+	})
+	if len(candidates) > maxDuplicateCandidates {
+		candidates = candidates[:maxDuplicateCandidates]
+	}
+	return candidates
+}
+
+// filterAccessibleCandidates 过滤掉当前用户无权查看的漏洞
+func (s *VulnService) filterAccessibleCandidates(db *gorm.DB, candidates []VulnDuplicateCandidate, userID uint, userRole string) []VulnDuplicateCandidate {
+	result := []VulnDuplicateCandidate{}
+	for _, candidate := range candidates {
+		var vuln models.Vulnerability
+		if err := db.Where("id = ?", candidate.VulnID).First(&vuln).Error; err != nil {
+			continue
+		}
+		if s.canAccessVuln(db, &vuln, userID, userRole) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// CheckDuplicates 提交漏洞前检查疑似重复的漏洞
+func (s *VulnService) CheckDuplicates(req *VulnDuplicateCheckRequest, userID uint, userRole string) ([]VulnDuplicateCandidate, error) {
+	db := Init.GetDB()
+
+	vuln := models.Vulnerability{
+		Title:    req.Title,
+		VulnURL:  req.VulnURL,
+		VulnType: req.VulnType,
+		CVEID:    req.CVEID,
+		AssetID:  req.AssetID,
+	}
+	vuln.Fingerprint = VulnFingerprint(vuln.AssetID, vuln.VulnURL, vuln.VulnType, vuln.CVEID)
+
+	return s.filterAccessibleCandidates(db, findDuplicateCandidates(db, &vuln), userID, userRole), nil
+}
+
+// GetVulnDuplicates 获取漏洞的疑似重复漏洞
+func (s *VulnService) GetVulnDuplicates(vulnID uint, userID uint, userRole string) ([]VulnDuplicateCandidate, error) {
+	db := Init.GetDB()
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+	if vuln.Fingerprint == "" {
+		vuln.Fingerprint = VulnFingerprint(vuln.AssetID, vuln.VulnURL, vuln.VulnType, vuln.CVEID)
+	}
+
+	return s.filterAccessibleCandidates(db, findDuplicateCandidates(db, &vuln), userID, userRole), nil
+}
+
+// detectDuplicates 新漏洞创建后检测重复和回归，结果记录到时间线
+// 与已完成漏洞指纹一致且没有未关闭的重复漏洞时，标记为回归漏洞并关联原漏洞
+func (s *VulnService) detectDuplicates(db *gorm.DB, vuln *models.Vulnerability, userID uint) []VulnDuplicateCandidate {
+	candidates := findDuplicateCandidates(db, vuln)
+	if len(candidates) == 0 {
+		return candidates
+	}
+
+	var regression *VulnDuplicateCandidate
+	openExact := false
+	duplicates := []string{}
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Regression {
+			// 多个已完成的原漏洞时关联最近完成的一个
+			if regression == nil || (candidate.CompletedAt != nil && (regression.CompletedAt == nil || candidate.CompletedAt.After(*regression.CompletedAt))) {
+				regression = candidate
+			}
+			continue
+		}
+		if candidate.Exact {
+			openExact = true
+		}
+		if len(duplicates) < 5 {
+			duplicates = append(duplicates, fmt.Sprintf("#%d（相似度%d%%）", candidate.VulnID, candidate.Similarity))
+		}
+	}
+
+	if len(duplicates) > 0 {
+		s.addTimeline(vuln.ID, userID, "duplicate_suspected", "疑似与以下漏洞重复："+strings.Join(duplicates, "、"))
+	}
+
+	// 存在指纹一致的其他漏洞时属于重复提交，不视为回归
+	if regression != nil && !openExact {
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).Update("regression_of_id", regression.VulnID).Error; err != nil {
+			fmt.Printf("标记回归漏洞失败 (漏洞ID: %d): %v\n", vuln.ID, err)
+			return candidates
+		}
+		vuln.RegressionOfID = &regression.VulnID
+
+		completedAt := ""
+		if regression.CompletedAt != nil {
+			completedAt = "，原漏洞已于" + regression.CompletedAt.Format("2006-01-02") + "修复完成"
+		}
+		s.addTimeline(vuln.ID, userID, "regression", fmt.Sprintf("漏洞复现：与已完成的漏洞#%d指纹一致%s", regression.VulnID, completedAt))
+		s.addTimeline(regression.VulnID, userID, "regression", fmt.Sprintf("漏洞再次出现，已关联回归漏洞#%d", vuln.ID))
+	}
+
+	return candidates
+}
+
+// MergeVuln 将重复漏洞合并到目标漏洞
+// 评论、时间线、附件、漏洞关系和内容中引用的图片迁移到目标漏洞，重复漏洞记录合并目标后软删除
+func (s *VulnService) MergeVuln(sourceID, targetID uint, userID uint, userRole string) (*models.Vulnerability, error) {
+	if sourceID == targetID {
+		return nil, errors.New("不能将漏洞合并到自身")
+	}
+
+	db := Init.GetDB()
+
+	var source, target models.Vulnerability
+	if err := db.Where("id = ?", sourceID).First(&source).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if err := db.Where("id = ?", targetID).First(&target).Error; err != nil {
+		return nil, errors.New("合并目标漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &source, userID, userRole) || !s.canAccessVuln(db, &target, userID, userRole) {
+		return nil, errors.New("无权限合并此漏洞")
+	}
+
+	tx := db.Begin()
+	for _, model := range []interface{}{&models.VulnComment{}, &models.VulnTimeline{}, &models.VulnAttachment{}} {
+		if err := tx.Model(model).Where("vuln_id = ?", sourceID).Update("vuln_id", targetID).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.New("迁移漏洞记录失败")
+		}
+	}
+	if err := moveVulnRelations(tx, sourceID, targetID); err != nil {
+		tx.Rollback()
+		return nil, errors.New("迁移漏洞关系失败")
+	}
+	if err := tx.Model(&models.FileStorage{}).Where("ref_type = ? AND ref_id = ?", "vuln", sourceID).Update("ref_id", targetID).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("迁移漏洞图片失败")
+	}
+	if err := tx.Model(&models.Vulnerability{}).Where("id = ?", sourceID).Update("duplicate_of_id", targetID).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("合并漏洞失败")
+	}
+	if err := tx.Delete(&source).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("合并漏洞失败")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("合并漏洞失败")
+	}
+
+	title := []rune(source.Title)
+	if len(title) > 60 {
+		title = append(title[:60], []rune("...")...)
+	}
+	s.addTimeline(targetID, userID, "merged", fmt.Sprintf("重复漏洞#%d「%s」已合并到本漏洞，评论、时间线和附件已迁移", sourceID, string(title)))
+
+	// 更新项目统计信息
+	projectService := &ProjectService{}
+	projectIDs := []uint{target.ProjectID}
+	if source.ProjectID != target.ProjectID {
+		projectIDs = append(projectIDs, source.ProjectID)
+	}
+	for _, projectID := range projectIDs {
+		if projectID == 0 {
+			continue
+		}
+		if err := projectService.UpdateProjectStats(projectID); err != nil {
+			fmt.Printf("更新项目统计失败 (项目ID: %d): %v\n", projectID, err)
+		}
+	}
+
+	return s.GetVulnByID(targetID, userID, userRole)
+}
+
+// BackfillVulnFingerprints 为历史漏洞补算指纹，补算失败不影响系统启动
+func BackfillVulnFingerprints() error {
+	db := Init.GetDB()
+
+	var vulns []models.Vulnerability
+	if err := db.Select("id, asset_id, vuln_url, vuln_type, cve_id").Where("fingerprint = ? OR fingerprint IS NULL", "").Find(&vulns).Error; err != nil {
+		return fmt.Errorf("查询待补算指纹的漏洞失败: %v", err)
+	}
+
+	for _, vuln := range vulns {
+		fingerprint := VulnFingerprint(vuln.AssetID, vuln.VulnURL, vuln.VulnType, vuln.CVEID)
+		if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).UpdateColumn("fingerprint", fingerprint).Error; err != nil {
+			return fmt.Errorf("补算漏洞指纹失败 (漏洞ID: %d): %v", vuln.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,636 @@
+// 漏洞导入导出服务包
+// 该包按漏洞列表的筛选条件和当前用户的可见范围导出Excel或CSV，生成漏洞导入模板，
+// 并从Excel或CSV批量导入漏洞：资产、项目和指派人可填写名称或ID，逐行校验并报告错误
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxVulnExportRows 单次导出的最大漏洞数量
+const maxVulnExportRows = 10000
+
+// maxVulnImportRows 单次导入的最大行数
+const maxVulnImportRows = 2000
+
+// vulnSheetColumn 漏洞表格的列，导入时按标题行匹配列，列顺序可以调整
+type vulnSheetColumn struct {
+	Key      string
+	Header   string
+	Required bool
+	Width    float64
+}
+
+// vulnSheetColumns 导入模板和导出文件共用的列，导出的文件可以直接修改后重新导入
+var vulnSheetColumns = []vulnSheetColumn{
+	{Key: "title", Header: "标题", Required: true, Width: 30},
+	{Key: "vuln_url", Header: "漏洞地址", Required: true, Width: 30},
+	{Key: "vuln_type", Header: "漏洞类型", Required: true, Width: 15},
+	{Key: "severity", Header: "严重程度", Width: 10},
+	{Key: "cvss_vector", Header: "CVSS向量", Width: 25},
+	{Key: "cvss_score", Header: "CVSS评分", Width: 10},
+	{Key: "asset", Header: "资产", Required: true, Width: 20},
+	{Key: "project", Header: "项目", Width: 20},
+	{Key: "assignee", Header: "指派人", Required: true, Width: 12},
+	{Key: "cve_id", Header: "CVE编号", Width: 18},
+	{Key: "cnnvd_id", Header: "CNNVD编号", Width: 18},
+	{Key: "source", Header: "来源", Width: 12},
+	{Key: "fix_deadline", Header: "修复截止时间", Width: 14},
+	{Key: "tags", Header: "标签", Width: 15},
+	{Key: "description", Header: "描述", Width: 40},
+	{Key: "poc", Header: "POC", Width: 40},
+	{Key: "fix_suggestion", Header: "修复建议", Width: 40}, // 未填写时使用匹配的知识库文章
+	{Key: "solution", Header: "解决方案", Width: 40},
+	{Key: "references", Header: "参考链接", Width: 30},
+}
+
+// vulnExportExtraColumns 仅导出的列，导入时忽略
+var vulnExportExtraColumns = []vulnSheetColumn{
+	{Key: "status", Header: "状态", Width: 10},
+	{Key: "category", Header: "漏洞分类", Width: 15},
+	{Key: "risk_level", Header: "风险优先级", Width: 12},
+	{Key: "risk_score", Header: "风险评分", Width: 10},
+	{Key: "reporter", Header: "提交人", Width: 12},
+	{Key: "submitted_at", Header: "提交时间", Width: 20},
+	{Key: "fixed_at", Header: "修复时间", Width: 20},
+	{Key: "completed_at", Header: "完成时间", Width: 20},
+}
+
+// VulnExportRequest 漏洞导出请求，筛选条件与漏洞列表一致，不分页
+type VulnExportRequest struct {
+	VulnListRequest `binding:"-"`
+	VulnIDs         []uint `json:"vuln_ids"` // 指定导出的漏洞，为空时导出筛选条件匹配的全部漏洞
+	Format          string `json:"format"`   // xlsx或csv，默认xlsx
+}
+
+// VulnImportResult 漏洞导入结果
+type VulnImportResult struct {
+	DryRun       bool     `json:"dry_run"`
+	SuccessCount int      `json:"success_count"`
+	FailureCount int      `json:"failure_count"`
+	Errors       []string `json:"errors"`
+	VulnIDs      []uint   `json:"vuln_ids"`
+}
+
+// formatOptionalTime 格式化可为空的时间
+func formatOptionalTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// ExportVulns 按漏洞ID或筛选条件导出漏洞，可见范围与漏洞列表一致，format为xlsx或csv
+func (s *VulnService) ExportVulns(req *VulnExportRequest) ([]byte, error) {
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = "xlsx"
+	}
+	if format != "xlsx" && format != "csv" {
+		return nil, errors.New("导出格式只支持xlsx和csv")
+	}
+
+	db := Init.GetDB()
+	query, ok := s.vulnListQuery(db, &req.VulnListRequest)
+	if !ok {
+		return nil, errors.New("没有找到要导出的漏洞")
+	}
+	if len(req.VulnIDs) > 0 {
+		query = query.Where("id IN (?)", req.VulnIDs)
+	}
+
+	var total int
+	query.Count(&total)
+	if total == 0 {
+		return nil, errors.New("没有找到要导出的漏洞")
+	}
+	if total > maxVulnExportRows {
+		return nil, fmt.Errorf("单次最多导出%d个漏洞，请缩小筛选范围", maxVulnExportRows)
+	}
+
+	var vulns []models.Vulnerability
+	if err := query.Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Category").
+		Order(vulnListOrder(&req.VulnListRequest)).Find(&vulns).Error; err != nil {
+		return nil, errors.New("查询漏洞失败")
+	}
+
+	columns := append([]vulnSheetColumn{{Key: "id", Header: "ID", Width: 8}}, vulnSheetColumns...)
+	columns = append(columns, vulnExportExtraColumns...)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+
+	rows := make([][]string, 0, len(vulns))
+	for i := range vulns {
+		vuln := &vulns[i]
+		values := map[string]string{
+			"id":             strconv.FormatUint(uint64(vuln.ID), 10),
+			"title":          vuln.Title,
+			"vuln_url":       vuln.VulnURL,
+			"vuln_type":      vuln.VulnType,
+			"severity":       severityLabel(vuln.Severity),
+			"cvss_vector":    vuln.CVSSVector,
+			"asset":          vuln.Asset.Name,
+			"project":        vuln.Project.Name,
+			"cve_id":         vuln.CVEID,
+			"cnnvd_id":       vuln.CNNVDID,
+			"source":         vuln.Source,
+			"fix_deadline":   formatOptionalTime(vuln.FixDeadline, "2006-01-02"),
+			"tags":           vuln.Tags,
+			"description":    vuln.Description,
+			"poc":            vuln.POC,
+			"fix_suggestion": vuln.FixSuggestion,
+			"solution":       vuln.Solution,
+			"references":     vuln.References,
+			"status":         vulnStatusLabel(vuln.Status),
+			"reporter":       displayName(&vuln.Reporter),
+			"submitted_at":   vuln.SubmittedAt.Format("2006-01-02 15:04:05"),
+			"risk_level":     vuln.RiskLevel,
+			"risk_score":     strconv.FormatFloat(vuln.RiskScore, 'f', 1, 64),
+			"fixed_at":       formatOptionalTime(vuln.FixedAt, "2006-01-02 15:04:05"),
+			"completed_at":   formatOptionalTime(vuln.CompletedAt, "2006-01-02 15:04:05"),
+		}
+		if vuln.Category != nil {
+			values["category"] = vuln.Category.Name
+		}
+		if vuln.CVSSScore > 0 {
+			values["cvss_score"] = strconv.FormatFloat(vuln.CVSSScore, 'f', 1, 64)
+		}
+		if vuln.Assignee != nil {
+			values["assignee"] = displayName(vuln.Assignee)
+		}
+
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			row[j] = values[column.Key]
+		}
+		rows = append(rows, row)
+	}
+
+	if format == "csv" {
+		buffer := &bytes.Buffer{}
+		buffer.WriteString("\xef\xbb\xbf") // 写入BOM，避免Excel打开中文乱码
+		writer := csv.NewWriter(buffer)
+		writer.Write(headers)
+		writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return nil, errors.New("生成CSV文件失败")
+		}
+		return buffer.Bytes(), nil
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "漏洞列表"
+	f.SetSheetName("Sheet1", sheetName)
+	writeVulnSheetHeader(f, sheetName, columns, "#E6E6FA")
+	for i, row := range rows {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, errors.New("生成Excel文件失败")
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeVulnSheetHeader 写入标题行并设置样式和列宽
+func writeVulnSheetHeader(f *excelize.File, sheetName string, columns []vulnSheetColumn, color string) {
+	for i, column := range columns {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, column.Header)
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheetName, col, col, column.Width)
+	}
+
+	style, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold: true,
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{color},
+			Pattern: 1,
+		},
+	})
+	if err == nil {
+		f.SetRowStyle(sheetName, 1, 1, style)
+	}
+}
+
+// GenerateVulnImportTemplate 生成漏洞导入Excel模板
+func (s *VulnService) GenerateVulnImportTemplate() ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "漏洞导入模板"
+	f.SetSheetName("Sheet1", sheetName)
+
+	columns := make([]vulnSheetColumn, len(vulnSheetColumns))
+	copy(columns, vulnSheetColumns)
+	for i := range columns {
+		if columns[i].Required {
+			columns[i].Header += "*"
+		}
+	}
+	writeVulnSheetHeader(f, sheetName, columns, "#4472C4")
+
+	// 添加示例数据
+	exampleData := [][]interface{}{
+		{"用户登录接口存在SQL注入", "https://example.com/api/login", "SQL注入", "high", "", "", "Web服务器01", "", "zhangsan", "", "", "内部测试", "", "web,登录", "登录接口username参数未过滤，可通过布尔盲注获取数据库信息", "username=admin' and 1=1--", "使用参数化查询", "", ""},
+		{"Apache HTTP Server路径穿越", "https://example.com/cgi-bin/", "路径穿越", "", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N", "", "12", "1", "李四", "CVE-2021-41773", "", "扫描器", "2030-12-31", "", "", "", "升级Apache至2.4.51及以上版本", "", "https://httpd.apache.org/security/vulnerabilities_24.html"},
+	}
+	for i, row := range exampleData {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+
+	// 添加说明工作表
+	instructionSheet := "导入说明"
+	f.NewSheet(instructionSheet)
+	instructions := []string{
+		"漏洞批量导入说明",
+		"",
+		"1. 必填字段（标记*的列）：",
+		"   - 标题、漏洞地址、漏洞类型、修复建议：不能为空",
+		"   - 资产：填写资产ID或资产名称；资产名称在多个项目中重复时需同时填写项目",
+		"   - 指派人：填写用户ID、用户名或姓名",
+		"   - 严重程度和CVSS向量至少填写一项，填写CVSS向量时按向量计算严重程度",
+		"",
+		"2. 可选字段：",
+		"   - 严重程度：critical/严重、high/高危、medium/中危、low/低危、info/信息",
+		"   - CVSS向量：支持CVSS:3.0、CVSS:3.1、CVSS:4.0",
+		"   - CVSS评分：0.0-10.0，未填写CVSS向量时按评分计算严重程度",
+		"   - 项目：填写项目ID或项目名称，未填写时使用资产所属项目",
+		"   - 修复截止时间：YYYY-MM-DD格式，未填写时审核通过后按SLA策略计算",
+		"   - 标签：多个标签用逗号分隔",
+		"",
+		"3. 注意事项：",
+		"   - 请不要修改标题行，列顺序可以调整，多余的列会被忽略",
+		"   - 导出的漏洞文件可以修改后直接导入，ID、状态等列会被忽略",
+		"   - 支持.xlsx和.csv格式，导入时会跳过空行",
+		"   - 导入的漏洞按项目工作流进入初始状态，与手动提交一致",
+		"   - 建议先预览导入（dry_run），确认无误后再正式导入",
+	}
+	for i, line := range instructions {
+		f.SetCellValue(instructionSheet, fmt.Sprintf("A%d", i+1), line)
+	}
+	f.SetColWidth(instructionSheet, "A", "A", 80)
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, errors.New("生成Excel模板失败")
+	}
+	return buffer.Bytes(), nil
+}
+
+// readVulnSheet 读取上传的Excel或CSV文件的所有行
+func readVulnSheet(file *multipart.FileHeader) ([][]string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("无法打开上传的文件")
+	}
+	defer src.Close()
+
+	if strings.HasSuffix(strings.ToLower(file.Filename), ".csv") {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return nil, errors.New("读取上传的文件失败")
+		}
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, errors.New("无法解析CSV文件，请确保文件格式正确")
+		}
+		return rows, nil
+	}
+
+	f, err := excelize.OpenReader(src)
+	if err != nil {
+		return nil, errors.New("无法解析Excel文件，请确保文件格式正确")
+	}
+	defer f.Close()
+
+	sheetName := f.GetSheetName(0)
+	if sheetName == "" {
+		return nil, errors.New("Excel文件中没有找到工作表")
+	}
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return nil, errors.New("读取Excel数据失败")
+	}
+	return rows, nil
+}
+
+// vulnImportResolver 解析导入行中的资产、项目和指派人，缓存查询结果
+type vulnImportResolver struct {
+	db        *gorm.DB
+	userID    uint
+	userRole  string
+	projects  map[string]*models.Project
+	users     map[string]*models.User
+	access    map[uint]bool
+	assetByID map[uint]*models.Asset
+}
+
+// project 按ID或名称查找项目
+func (r *vulnImportResolver) project(value string) (*models.Project, error) {
+	if project, ok := r.projects[value]; ok {
+		return project, nil
+	}
+
+	var projects []models.Project
+	if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+		r.db.Where("id = ?", id).Find(&projects)
+	}
+	if len(projects) == 0 {
+		r.db.Where("name = ?", value).Find(&projects)
+	}
+	switch len(projects) {
+	case 0:
+		return nil, fmt.Errorf("项目 '%s' 不存在", value)
+	case 1:
+		r.projects[value] = &projects[0]
+		return &projects[0], nil
+	}
+	return nil, fmt.Errorf("项目名称 '%s' 不唯一，请填写项目ID", value)
+}
+
+// asset 按ID或名称查找资产，指定项目时只在项目内查找
+func (r *vulnImportResolver) asset(value string, project *models.Project) (*models.Asset, error) {
+	var assets []models.Asset
+	if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+		if asset, ok := r.assetByID[uint(id)]; ok {
+			assets = []models.Asset{*asset}
+		} else {
+			r.db.Where("id = ?", id).Find(&assets)
+		}
+	}
+	if len(assets) == 0 {
+		query := r.db.Where("name = ?", value)
+		if project != nil {
+			query = query.Where("project_id = ?", project.ID)
+		}
+		query.Find(&assets)
+	}
+
+	switch len(assets) {
+	case 0:
+		if project != nil {
+			return nil, fmt.Errorf("项目 '%s' 中不存在资产 '%s'", project.Name, value)
+		}
+		return nil, fmt.Errorf("资产 '%s' 不存在", value)
+	case 1:
+		r.assetByID[assets[0].ID] = &assets[0]
+		return &assets[0], nil
+	}
+	return nil, fmt.Errorf("资产名称 '%s' 在多个项目中存在，请填写项目或资产ID", value)
+}
+
+// user 按ID、用户名或姓名查找启用的用户
+func (r *vulnImportResolver) user(value string) (*models.User, error) {
+	if user, ok := r.users[value]; ok {
+		return user, nil
+	}
+
+	var users []models.User
+	if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+		r.db.Where("id = ? AND status = ?", id, 1).Find(&users)
+	}
+	if len(users) == 0 {
+		r.db.Where("username = ? AND status = ?", value, 1).Find(&users)
+	}
+	if len(users) == 0 {
+		r.db.Where("real_name = ? AND status = ?", value, 1).Find(&users)
+	}
+	switch len(users) {
+	case 0:
+		return nil, fmt.Errorf("指派人 '%s' 不存在或已禁用", value)
+	case 1:
+		r.users[value] = &users[0]
+		return &users[0], nil
+	}
+	return nil, fmt.Errorf("指派人姓名 '%s' 不唯一，请填写用户名", value)
+}
+
+// canAccess 判断当前用户是否可以向项目提交漏洞
+func (r *vulnImportResolver) canAccess(project *models.Project) bool {
+	if allowed, ok := r.access[project.ID]; ok {
+		return allowed
+	}
+	allowed := canSubmitToProject(r.db, project, r.userID, r.userRole)
+	r.access[project.ID] = allowed
+	return allowed
+}
+
+// canSubmitToProject 判断用户是否可以向项目提交漏洞：超级管理员、项目负责人或项目成员
+func canSubmitToProject(db *gorm.DB, project *models.Project, userID uint, userRole string) bool {
+	if userRole == "super_admin" || project.OwnerID == userID {
+		return true
+	}
+	var count int
+	db.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", project.ID, userID).Count(&count)
+	return count > 0
+}
+
+// parseVulnSeverity 解析严重程度，支持代码和中文名称
+func parseVulnSeverity(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for _, option := range slaSeverities {
+		if strings.EqualFold(value, option.Code) || value == option.Label {
+			return option.Code, true
+		}
+	}
+	return "", false
+}
+
+// buildVulnImportRequest 校验一行数据并转换为创建漏洞请求
+func (r *vulnImportResolver) buildVulnImportRequest(values map[string]string) (*VulnCreateRequest, error) {
+	for _, column := range vulnSheetColumns {
+		if column.Required && values[column.Key] == "" {
+			return nil, fmt.Errorf("%s不能为空", column.Header)
+		}
+	}
+
+	req := &VulnCreateRequest{
+		Title:         values["title"],
+		VulnURL:       values["vuln_url"],
+		VulnType:      values["vuln_type"],
+		CVSSVector:    values["cvss_vector"],
+		CVEID:         strings.ToUpper(values["cve_id"]),
+		CNNVDID:       values["cnnvd_id"],
+		POC:           values["poc"],
+		Solution:      values["solution"],
+		Source:        values["source"],
+		FixDeadline:   values["fix_deadline"],
+		Tags:          values["tags"],
+		Description:   values["description"],
+		FixSuggestion: values["fix_suggestion"],
+		References:    values["references"],
+	}
+	if len([]rune(req.Title)) > 255 {
+		return nil, errors.New("标题不能超过255个字符")
+	}
+	if values["severity"] != "" {
+		severity, ok := parseVulnSeverity(values["severity"])
+		if !ok {
+			return nil, fmt.Errorf("无效的严重程度 '%s'", values["severity"])
+		}
+		req.Severity = severity
+	}
+	if values["cvss_score"] != "" {
+		score, err := strconv.ParseFloat(values["cvss_score"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的CVSS评分 '%s'", values["cvss_score"])
+		}
+		req.CVSSScore = score
+	}
+	if req.Severity == "" && req.CVSSVector == "" && req.CVSSScore == 0 {
+		return nil, errors.New("请填写严重程度或CVSS向量")
+	}
+	if req.FixSuggestion == "" && matchKnowledgeArticle(r.db, req.VulnType, nil, "") == nil {
+		return nil, errors.New("修复建议不能为空，没有匹配漏洞类型的知识库文章")
+	}
+	if req.FixDeadline != "" {
+		if _, err := time.Parse("2006-01-02", req.FixDeadline); err != nil {
+			return nil, errors.New("修复截止时间格式错误，请使用YYYY-MM-DD格式")
+		}
+	}
+
+	var project *models.Project
+	if values["project"] != "" {
+		var err error
+		if project, err = r.project(values["project"]); err != nil {
+			return nil, err
+		}
+	}
+	asset, err := r.asset(values["asset"], project)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		if project, err = r.project(strconv.FormatUint(uint64(asset.ProjectID), 10)); err != nil {
+			return nil, errors.New("资产未关联项目，请填写项目")
+		}
+	}
+	if asset.ProjectID != project.ID {
+		return nil, fmt.Errorf("资产 '%s' 不属于项目 '%s'", asset.Name, project.Name)
+	}
+	if !r.canAccess(project) {
+		return nil, fmt.Errorf("无权限向项目 '%s' 提交漏洞", project.Name)
+	}
+	if project.EndDate != nil && time.Now().After(*project.EndDate) {
+		return nil, fmt.Errorf("项目 '%s' 已过期，无法添加漏洞", project.Name)
+	}
+
+	assignee, err := r.user(values["assignee"])
+	if err != nil {
+		return nil, err
+	}
+
+	req.AssetID = asset.ID
+	req.ProjectID = project.ID
+	req.AssigneeID = assignee.ID
+	return req, nil
+}
+
+// ImportVulns 从Excel或CSV文件批量导入漏洞，dryRun为true时只校验不创建
+func (s *VulnService) ImportVulns(file *multipart.FileHeader, dryRun bool, userID uint, userRole string) (*VulnImportResult, error) {
+	rows, err := readVulnSheet(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("文件中没有数据行（除标题行外）")
+	}
+	if len(rows)-1 > maxVulnImportRows {
+		return nil, fmt.Errorf("单次最多导入%d行", maxVulnImportRows)
+	}
+
+	// 按标题行匹配列，忽略必填标记和多余的列
+	columnIndex := map[string]int{}
+	for i, header := range rows[0] {
+		header = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(header, "\xef\xbb\xbf")), "*")
+		for _, column := range vulnSheetColumns {
+			if header == column.Header {
+				columnIndex[column.Key] = i
+			}
+		}
+	}
+	for _, column := range vulnSheetColumns {
+		if _, ok := columnIndex[column.Key]; column.Required && !ok {
+			return nil, fmt.Errorf("缺少必填列 '%s'，请使用导入模板", column.Header)
+		}
+	}
+
+	resolver := &vulnImportResolver{
+		db:        Init.GetDB(),
+		userID:    userID,
+		userRole:  userRole,
+		projects:  map[string]*models.Project{},
+		users:     map[string]*models.User{},
+		access:    map[uint]bool{},
+		assetByID: map[uint]*models.Asset{},
+	}
+	result := &VulnImportResult{
+		DryRun:  dryRun,
+		Errors:  []string{},
+		VulnIDs: []uint{},
+	}
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2 // 实际行号（从2开始）
+
+		values := map[string]string{}
+		empty := true
+		for key, index := range columnIndex {
+			if index < len(row) {
+				values[key] = strings.TrimSpace(row[index])
+				if values[key] != "" {
+					empty = false
+				}
+			}
+		}
+		if empty {
+			continue
+		}
+
+		req, err := resolver.buildVulnImportRequest(values)
+		if err == nil && !dryRun {
+			var vuln *models.Vulnerability
+			if vuln, err = s.CreateVuln(req, userID); err == nil {
+				result.VulnIDs = append(result.VulnIDs, vuln.ID)
+			}
+		}
+		if err != nil {
+			result.FailureCount++
+			result.Errors = append(result.Errors, fmt.Sprintf("第%d行：%s", rowNum, err.Error()))
+			continue
+		}
+		result.SuccessCount++
+	}
+
+	return result, nil
+}
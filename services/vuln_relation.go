@@ -0,0 +1,456 @@
+// 漏洞关系服务包
+// 该包管理漏洞之间的重复、引起、阻塞和父子关系：同一根因影响多个资产时（例如30台服务器使用同一个
+// 过期组件），可以把发现拆分为根因漏洞下的子漏洞，关闭根因漏洞时可选择将状态同步到子漏洞
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 漏洞关系类型，关系方向为：源漏洞 <关系类型> 目标漏洞
+const (
+	VulnRelationDuplicateOf = "duplicate_of" // 源漏洞与目标漏洞重复
+	VulnRelationCausedBy    = "caused_by"    // 源漏洞由目标漏洞引起
+	VulnRelationBlocks      = "blocks"       // 源漏洞阻塞目标漏洞的修复
+	VulnRelationChildOf     = "child_of"     // 源漏洞是目标漏洞的子漏洞
+	VulnRelationParentOf    = "parent_of"    // 源漏洞是目标漏洞的父漏洞，仅用于请求，保存时转换为child_of
+)
+
+// vulnRelationType 漏洞关系类型定义，Forward和Backward分别为源漏洞和目标漏洞时间线中的描述
+type vulnRelationType struct {
+	Forward  string
+	Backward string
+}
+
+var vulnRelationTypes = map[string]vulnRelationType{
+	VulnRelationDuplicateOf: {Forward: "本漏洞与漏洞#%d重复", Backward: "漏洞#%d与本漏洞重复"},
+	VulnRelationCausedBy:    {Forward: "本漏洞由漏洞#%d引起", Backward: "漏洞#%d由本漏洞引起"},
+	VulnRelationBlocks:      {Forward: "本漏洞阻塞漏洞#%d的修复", Backward: "本漏洞的修复被漏洞#%d阻塞"},
+	VulnRelationChildOf:     {Forward: "本漏洞是漏洞#%d的子漏洞", Backward: "漏洞#%d是本漏洞的子漏洞"},
+}
+
+// 关系图的最大展开层数和最大节点数
+const (
+	maxVulnRelationDepth = 3
+	maxVulnRelationNodes = 200
+)
+
+// vulnClosedStatuses 关闭状态，父漏洞进入这些状态时可将状态同步到子漏洞
+var vulnClosedStatuses = []string{VulnStatusCompleted, VulnStatusIgnored, "closed"}
+
+// VulnRelationRequest 添加漏洞关系请求
+type VulnRelationRequest struct {
+	TargetID uint   `json:"target_id" binding:"required"`
+	Type     string `json:"type" binding:"required"` // duplicate_of、caused_by、blocks、child_of、parent_of
+	Comment  string `json:"comment" binding:"max=255"`
+}
+
+// VulnSplitItem 拆分出的子漏洞，未填写的字段沿用原漏洞
+type VulnSplitItem struct {
+	AssetID    uint   `json:"asset_id" binding:"required"`
+	Title      string `json:"title"`
+	VulnURL    string `json:"vuln_url"`
+	AssigneeID *uint  `json:"assignee_id"`
+}
+
+// VulnSplitRequest 拆分漏洞请求
+type VulnSplitRequest struct {
+	Items []VulnSplitItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// joinVulnIDs 拼接漏洞编号用于时间线描述，数量较多时只列出前20个
+func joinVulnIDs(ids []string) string {
+	if len(ids) > 20 {
+		return strings.Join(ids[:20], "、") + fmt.Sprintf("等%d个", len(ids))
+	}
+	return strings.Join(ids, "、")
+}
+
+// isVulnClosedStatus 判断状态是否为关闭状态
+func isVulnClosedStatus(status string) bool {
+	return contains(vulnClosedStatuses, normalizeVulnStatus(status))
+}
+
+// vulnParentID 获取漏洞的父漏洞ID，没有父漏洞时返回0
+func vulnParentID(db *gorm.DB, vulnID uint) uint {
+	var relation models.VulnRelation
+	if err := db.Where("source_id = ? AND type = ?", vulnID, VulnRelationChildOf).First(&relation).Error; err != nil {
+		return 0
+	}
+	return relation.TargetID
+}
+
+// CreateVulnRelation 添加漏洞关系
+func (s *VulnService) CreateVulnRelation(vulnID uint, req *VulnRelationRequest, userID uint, userRole string) (*models.VulnRelation, error) {
+	relationType := req.Type
+	sourceID, targetID := vulnID, req.TargetID
+	if relationType == VulnRelationParentOf {
+		relationType = VulnRelationChildOf
+		sourceID, targetID = targetID, sourceID
+	}
+	if _, ok := vulnRelationTypes[relationType]; !ok {
+		return nil, errors.New("无效的漏洞关系类型")
+	}
+	if sourceID == targetID {
+		return nil, errors.New("不能关联漏洞自身")
+	}
+
+	db := Init.GetDB()
+
+	var source, target models.Vulnerability
+	if err := db.Where("id = ?", sourceID).First(&source).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if err := db.Where("id = ?", targetID).First(&target).Error; err != nil {
+		return nil, errors.New("关联的漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &source, userID, userRole) || !s.canAccessVuln(db, &target, userID, userRole) {
+		return nil, errors.New("无权限关联此漏洞")
+	}
+
+	var count int64
+	db.Model(&models.VulnRelation{}).
+		Where("type = ? AND ((source_id = ? AND target_id = ?) OR (source_id = ? AND target_id = ?))", relationType, sourceID, targetID, targetID, sourceID).
+		Count(&count)
+	if count > 0 {
+		return nil, errors.New("漏洞关系已存在")
+	}
+
+	if relationType == VulnRelationChildOf {
+		if vulnParentID(db, sourceID) != 0 {
+			return nil, errors.New("漏洞已有父漏洞，请先移除原有的父子关系")
+		}
+		// 父漏洞不能是子漏洞的后代，避免形成循环
+		for ancestor, depth := targetID, 0; ancestor != 0 && depth < maxVulnRelationNodes; depth++ {
+			if ancestor == sourceID {
+				return nil, errors.New("父子关系不能形成循环")
+			}
+			ancestor = vulnParentID(db, ancestor)
+		}
+	}
+
+	relation := models.VulnRelation{
+		SourceID:  sourceID,
+		TargetID:  targetID,
+		Type:      relationType,
+		Comment:   strings.TrimSpace(req.Comment),
+		CreatedBy: userID,
+	}
+	if err := db.Create(&relation).Error; err != nil {
+		return nil, errors.New("添加漏洞关系失败")
+	}
+
+	definition := vulnRelationTypes[relationType]
+	s.addTimeline(sourceID, userID, "relation_added", "添加关系："+fmt.Sprintf(definition.Forward, targetID))
+	s.addTimeline(targetID, userID, "relation_added", "添加关系："+fmt.Sprintf(definition.Backward, sourceID))
+
+	return &relation, nil
+}
+
+// DeleteVulnRelation 移除漏洞关系
+func (s *VulnService) DeleteVulnRelation(vulnID, relationID uint, userID uint, userRole string) error {
+	db := Init.GetDB()
+
+	var relation models.VulnRelation
+	if err := db.Where("id = ? AND (source_id = ? OR target_id = ?)", relationID, vulnID, vulnID).First(&relation).Error; err != nil {
+		return errors.New("漏洞关系不存在")
+	}
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return errors.New("漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &vuln, userID, userRole) {
+		return errors.New("无权限修改此漏洞")
+	}
+
+	if err := db.Delete(&relation).Error; err != nil {
+		return errors.New("移除漏洞关系失败")
+	}
+
+	definition := vulnRelationTypes[relation.Type]
+	s.addTimeline(relation.SourceID, userID, "relation_removed", "移除关系："+fmt.Sprintf(definition.Forward, relation.TargetID))
+	s.addTimeline(relation.TargetID, userID, "relation_removed", "移除关系："+fmt.Sprintf(definition.Backward, relation.SourceID))
+
+	return nil
+}
+
+// buildVulnRelationGraph 从漏洞出发展开关系图，无权查看的漏洞只返回编号和状态
+func (s *VulnService) buildVulnRelationGraph(db *gorm.DB, vulnID uint, userID uint, userRole string) *models.VulnRelationGraph {
+	graph := &models.VulnRelationGraph{Nodes: []models.VulnRelationNode{}, Edges: []models.VulnRelation{}}
+
+	visited := map[uint]bool{vulnID: true}
+	frontier := []uint{vulnID}
+	edges := []models.VulnRelation{}
+	seen := map[string]bool{}
+	for depth := 0; depth < maxVulnRelationDepth && len(frontier) > 0 && len(visited) < maxVulnRelationNodes; depth++ {
+		var relations []models.VulnRelation
+		if err := db.Preload("Creator").Where("source_id IN (?) OR target_id IN (?)", frontier, frontier).Order("id").Find(&relations).Error; err != nil {
+			fmt.Printf("查询漏洞关系失败: %v\n", err)
+			break
+		}
+
+		next := []uint{}
+		for _, relation := range relations {
+			// 合并漏洞后可能出现重复的关系，只保留一条
+			key := fmt.Sprintf("%d-%s-%d", relation.SourceID, relation.Type, relation.TargetID)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			edges = append(edges, relation)
+			for _, id := range []uint{relation.SourceID, relation.TargetID} {
+				if !visited[id] {
+					visited[id] = true
+					next = append(next, id)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	if len(edges) == 0 {
+		return graph
+	}
+
+	ids := make([]uint, 0, len(visited))
+	for id := range visited {
+		ids = append(ids, id)
+	}
+	var vulns []models.Vulnerability
+	db.Where("id IN (?)", ids).Order("id").Find(&vulns)
+
+	exists := map[uint]bool{}
+	for i := range vulns {
+		vuln := &vulns[i]
+		exists[vuln.ID] = true
+		node := models.VulnRelationNode{
+			ID:        vuln.ID,
+			Status:    vuln.Status,
+			Severity:  vuln.Severity,
+			AssetID:   vuln.AssetID,
+			ProjectID: vuln.ProjectID,
+		}
+		if vuln.ID == vulnID || s.canAccessVuln(db, vuln, userID, userRole) {
+			node.Title = vuln.Title
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// 关联漏洞已删除的关系不返回
+	for _, edge := range edges {
+		if exists[edge.SourceID] && exists[edge.TargetID] {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	return graph
+}
+
+// GetVulnRelations 获取漏洞关系图
+func (s *VulnService) GetVulnRelations(vulnID uint, userID uint, userRole string) (*models.VulnRelationGraph, error) {
+	db := Init.GetDB()
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	return s.buildVulnRelationGraph(db, vulnID, userID, userRole), nil
+}
+
+// moveVulnRelations 合并漏洞时将重复漏洞的关系迁移到目标漏洞，迁移后指向自身的关系删除
+func moveVulnRelations(tx *gorm.DB, sourceID, targetID uint) error {
+	if err := tx.Model(&models.VulnRelation{}).Where("source_id = ?", sourceID).Update("source_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.VulnRelation{}).Where("target_id = ?", sourceID).Update("target_id", targetID).Error; err != nil {
+		return err
+	}
+	return tx.Where("source_id = target_id").Delete(&models.VulnRelation{}).Error
+}
+
+// SplitVuln 将影响多个资产的漏洞拆分为子漏洞
+// 子漏洞复制原漏洞的内容、状态和SLA计时，按所属资产重新计算CVSS环境评分，并作为子漏洞关联到原漏洞
+func (s *VulnService) SplitVuln(vulnID uint, req *VulnSplitRequest, userID uint, userRole string) ([]models.Vulnerability, error) {
+	db := Init.GetDB()
+
+	var parent models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&parent).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &parent, userID, userRole) {
+		return nil, errors.New("无权限拆分此漏洞")
+	}
+	if isVulnClosedStatus(parent.Status) {
+		return nil, errors.New("已关闭的漏洞不能拆分")
+	}
+
+	// 先校验全部子漏洞，避免部分创建
+	assets := make([]models.Asset, len(req.Items))
+	for i, item := range req.Items {
+		if err := db.Where("id = ?", item.AssetID).First(&assets[i]).Error; err != nil {
+			return nil, fmt.Errorf("第%d个子漏洞的资产不存在", i+1)
+		}
+		if item.AssigneeID != nil {
+			var assignee models.User
+			if err := db.Where("id = ?", *item.AssigneeID).First(&assignee).Error; err != nil {
+				return nil, fmt.Errorf("第%d个子漏洞的指派人不存在", i+1)
+			}
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	children := []models.Vulnerability{}
+	tx := db.Begin()
+	for i, item := range req.Items {
+		child := parent
+		child.ID = 0
+		child.AssetID = item.AssetID
+		child.SubmittedAt = now
+		child.CreatedAt = time.Time{}
+		child.UpdatedAt = time.Time{}
+		child.Attachments = nil
+		child.Comments = nil
+		child.Timeline = nil
+		child.DuplicateOfID = nil
+		child.RegressionOfID = nil
+		if item.Title != "" {
+			child.Title = item.Title
+		}
+		if item.VulnURL != "" {
+			child.VulnURL = item.VulnURL
+		}
+		if item.AssigneeID != nil {
+			child.AssigneeID = item.AssigneeID
+			child.AssignedAt = &now
+		}
+
+		// 按子漏洞所属资产重新计算CVSS环境评分
+		derivedSeverity, err := s.applyCVSS(&child, &assets[i])
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		s.resolveSeverity(&child, derivedSeverity, "", userRole) // 未指定严重程度时不会返回错误
+		applyVulnRisk(&child, &assets[i])
+		child.Fingerprint = VulnFingerprint(child.AssetID, child.VulnURL, child.VulnType, child.CVEID)
+
+		if err := tx.Create(&child).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.New("创建子漏洞失败")
+		}
+		if err := tx.Create(&models.VulnRelation{
+			SourceID:  child.ID,
+			TargetID:  parent.ID,
+			Type:      VulnRelationChildOf,
+			Comment:   "漏洞拆分",
+			CreatedBy: userID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.New("关联子漏洞失败")
+		}
+		children = append(children, child)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("拆分漏洞失败")
+	}
+
+	ids := []string{}
+	projectService := &ProjectService{}
+	notificationService := &NotificationService{}
+	webhookService := &WebhookService{}
+	var project models.Project
+	db.Where("id = ?", parent.ProjectID).First(&project)
+	for i := range children {
+		child := &children[i]
+		ids = append(ids, fmt.Sprintf("#%d", child.ID))
+		s.addTimeline(child.ID, userID, "created", fmt.Sprintf("由漏洞#%d拆分创建", parent.ID))
+
+		if child.AssigneeID != nil {
+			notificationService.NotifyVulnAssigned(child, *child.AssigneeID, project.Name)
+		}
+		webhookService.Emit(WebhookVulnCreated, NewWebhookVulnData(child, userID))
+	}
+	s.addTimeline(parent.ID, userID, "split", "拆分出子漏洞："+joinVulnIDs(ids))
+
+	if parent.ProjectID != 0 {
+		if err := projectService.UpdateProjectStats(parent.ProjectID); err != nil {
+			fmt.Printf("更新项目统计失败 (项目ID: %d): %v\n", parent.ProjectID, err)
+		}
+	}
+
+	return children, nil
+}
+
+// cascadeToChildren 父漏洞关闭后将关闭状态同步到未关闭的子漏洞，子漏洞的子漏洞同样同步
+// 同步不校验状态流转，子漏洞所属工作流中没有该状态时跳过，同步结果记录到父漏洞时间线；
+// 已忽略需要每个漏洞单独经过风险接受审批，不向子漏洞同步
+func (s *VulnService) cascadeToChildren(db *gorm.DB, parent *models.Vulnerability, actor *models.User, depth int) {
+	if depth >= maxVulnRelationDepth || isSystemOnlyVulnStatus(parent.Status) {
+		return
+	}
+
+	var childIDs []uint
+	db.Model(&models.VulnRelation{}).Where("target_id = ? AND type = ?", parent.ID, VulnRelationChildOf).Pluck("source_id", &childIDs)
+	if len(childIDs) == 0 {
+		return
+	}
+
+	var children []models.Vulnerability
+	db.Where("id IN (?)", childIDs).Find(&children)
+
+	closed := []string{}
+	failed := []string{}
+	projectIDs := map[uint]bool{}
+	for i := range children {
+		child := &children[i]
+		if isVulnClosedStatus(child.Status) {
+			continue
+		}
+
+		oldStatus := child.Status
+		reason := fmt.Sprintf("父漏洞#%d已关闭", parent.ID)
+		change, err := s.applySystemStatus(db, child, parent.Status, actor, reason)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("#%d", child.ID))
+			continue
+		}
+		if err := db.Save(child).Error; err != nil {
+			failed = append(failed, fmt.Sprintf("#%d", child.ID))
+			continue
+		}
+
+		s.recordStatusEffects(db, change)
+		s.recordStatusChange(child.ID, actor.ID, oldStatus, child.Status, reason)
+		closed = append(closed, fmt.Sprintf("#%d", child.ID))
+		projectIDs[child.ProjectID] = true
+		s.cascadeToChildren(db, child, actor, depth+1)
+	}
+
+	if len(closed) > 0 {
+		s.addTimeline(parent.ID, actor.ID, "cascade", "状态已同步到子漏洞："+joinVulnIDs(closed))
+	}
+	if len(failed) > 0 {
+		s.addTimeline(parent.ID, actor.ID, "cascade", fmt.Sprintf("以下子漏洞未能同步为「%s」状态：%s", vulnStatusLabel(parent.Status), joinVulnIDs(failed)))
+	}
+
+	projectService := &ProjectService{}
+	for projectID := range projectIDs {
+		if projectID == 0 {
+			continue
+		}
+		if err := projectService.UpdateProjectStats(projectID); err != nil {
+			fmt.Printf("更新项目统计失败 (项目ID: %d): %v\n", projectID, err)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// 漏洞风险优先级服务包
+// 该包综合严重程度或CVSS评分、CISA KEV、EPSS利用概率、资产重要性、资产所属环境和互联网暴露面，
+// 为漏洞计算0-100的风险优先级评分并保存评分明细。漏洞、CVE情报或资产变化时重新计算，
+// 用于漏洞列表的排序筛选和仪表板的优先修复列表
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 风险优先级
+const (
+	RiskLevelP1 = "P1" // 评分70及以上，立即修复
+	RiskLevelP2 = "P2" // 评分50-70，优先修复
+	RiskLevelP3 = "P3" // 评分30-50，计划修复
+	RiskLevelP4 = "P4" // 评分30以下，择机修复
+)
+
+// vulnRiskLevels 风险优先级可选项
+var vulnRiskLevels = []VulnWorkflowOption{
+	{Code: RiskLevelP1, Label: "立即修复"},
+	{Code: RiskLevelP2, Label: "优先修复"},
+	{Code: RiskLevelP3, Label: "计划修复"},
+	{Code: RiskLevelP4, Label: "择机修复"},
+}
+
+// 各因子满分，合计100分
+const (
+	riskSeverityMax    = 40.0 // 严重程度/CVSS评分
+	riskKEVMax         = 20.0 // 列入CISA KEV
+	riskEPSSMax        = 15.0 // EPSS百分位
+	riskImportanceMax  = 10.0 // 资产重要性
+	riskEnvironmentMax = 10.0 // 资产所属环境，生产环境最高
+	riskExposureMax    = 5.0  // 资产配置了域名，暴露在互联网
+)
+
+// 没有CVSS评分时按严重程度给分
+var riskSeverityPoints = map[string]float64{
+	"critical": 38,
+	"high":     30,
+	"medium":   20,
+	"low":      10,
+	"info":     2,
+}
+
+var riskImportancePoints = map[string]float64{
+	"extremely_high": 10,
+	"high":           7,
+	"medium":         4,
+	"low":            1,
+}
+
+var riskEnvironmentPoints = map[string]float64{
+	"production":        10,
+	"disaster_recovery": 8,
+	"pre_production":    6,
+	"staging":           4,
+	"testing":           2,
+	"development":       1,
+}
+
+// 资产未设置重要性或环境时按中间值计分
+const (
+	riskDefaultImportancePoints  = 4.0
+	riskDefaultEnvironmentPoints = 5.0
+)
+
+// optionLabel 获取可选项的显示名称，未找到时返回代码本身
+func optionLabel(options []VulnWorkflowOption, code string) string {
+	for _, option := range options {
+		if option.Code == code {
+			return option.Label
+		}
+	}
+	return code
+}
+
+// vulnRiskLevel 根据评分确定风险优先级
+func vulnRiskLevel(score float64) string {
+	switch {
+	case score >= 70:
+		return RiskLevelP1
+	case score >= 50:
+		return RiskLevelP2
+	case score >= 30:
+		return RiskLevelP3
+	default:
+		return RiskLevelP4
+	}
+}
+
+// roundRiskPoints 保留一位小数
+func roundRiskPoints(points float64) float64 {
+	return math.Round(points*10) / 10
+}
+
+// calculateVulnRisk 计算漏洞的风险优先级评分和各因子明细
+func calculateVulnRisk(vuln *models.Vulnerability, asset *models.Asset) (float64, []models.VulnRiskFactor) {
+	factors := []models.VulnRiskFactor{}
+
+	// 严重程度：有CVSS评分时按评分折算，否则按严重程度给分
+	severity := models.VulnRiskFactor{Code: "severity", Label: "严重程度", MaxPoints: riskSeverityMax}
+	if vuln.CVSSScore > 0 {
+		severity.Points = vuln.CVSSScore / 10 * riskSeverityMax
+		severity.Value = fmt.Sprintf("CVSS %.1f", vuln.CVSSScore)
+	} else {
+		severity.Points = riskSeverityPoints[vuln.Severity]
+		severity.Value = severityLabel(vuln.Severity)
+	}
+	factors = append(factors, severity)
+
+	kev := models.VulnRiskFactor{Code: "kev", Label: "已知被利用", Value: "未列入CISA KEV", MaxPoints: riskKEVMax}
+	if vuln.InKEV {
+		kev.Points = riskKEVMax
+		kev.Value = "已列入CISA KEV"
+	}
+	factors = append(factors, kev)
+
+	// EPSS按百分位计分，百分位反映该漏洞相对其他CVE被利用的可能性
+	epss := models.VulnRiskFactor{Code: "epss", Label: "利用概率", Value: "无EPSS评分", MaxPoints: riskEPSSMax}
+	if vuln.EPSSScore > 0 {
+		epss.Points = vuln.EPSSPercentile * riskEPSSMax
+		epss.Value = fmt.Sprintf("EPSS %.2f%%，百分位 %.0f%%", vuln.EPSSScore*100, vuln.EPSSPercentile*100)
+	}
+	factors = append(factors, epss)
+
+	importance := models.VulnRiskFactor{Code: "importance", Label: "资产重要性", Points: riskDefaultImportancePoints, Value: "未设置", MaxPoints: riskImportanceMax}
+	environment := models.VulnRiskFactor{Code: "environment", Label: "资产环境", Points: riskDefaultEnvironmentPoints, Value: "未设置", MaxPoints: riskEnvironmentMax}
+	exposure := models.VulnRiskFactor{Code: "exposure", Label: "互联网暴露", Value: "资产未配置域名", MaxPoints: riskExposureMax}
+	if asset != nil {
+		if points, ok := riskImportancePoints[asset.Importance]; ok {
+			importance.Points = points
+			importance.Value = optionLabel(slaAssetImportances, asset.Importance)
+		}
+		if points, ok := riskEnvironmentPoints[asset.Environment]; ok {
+			environment.Points = points
+			environment.Value = optionLabel(slaAssetEnvironments, asset.Environment)
+		}
+		if asset.Domain != "" {
+			exposure.Points = riskExposureMax
+			exposure.Value = "资产域名 " + asset.Domain
+		}
+	}
+	factors = append(factors, importance, environment, exposure)
+
+	score := 0.0
+	for i := range factors {
+		factors[i].Points = roundRiskPoints(factors[i].Points)
+		score += factors[i].Points
+	}
+	return roundRiskPoints(math.Min(score, 100)), factors
+}
+
+// applyVulnRisk 计算并设置漏洞的风险优先级评分、优先级和评分明细，在保存漏洞前调用
+func applyVulnRisk(vuln *models.Vulnerability, asset *models.Asset) {
+	score, factors := calculateVulnRisk(vuln, asset)
+	vuln.RiskScore = score
+	vuln.RiskLevel = vulnRiskLevel(score)
+	data, _ := json.Marshal(factors)
+	vuln.RiskFactors = string(data)
+}
+
+// vulnRiskBreakdown 解析保存的评分明细
+func vulnRiskBreakdown(vuln *models.Vulnerability) []models.VulnRiskFactor {
+	factors := []models.VulnRiskFactor{}
+	if vuln.RiskFactors != "" {
+		json.Unmarshal([]byte(vuln.RiskFactors), &factors)
+	}
+	return factors
+}
+
+// refreshVulnRisk 重新计算满足条件的漏洞的风险优先级评分，用于CVE情报或资产属性变化后，
+// 只更新评分字段，不改变漏洞的更新时间，返回评分有变化的漏洞数
+func refreshVulnRisk(db *gorm.DB, where string, args ...interface{}) int {
+	updated := 0
+	lastID := uint(0)
+	for {
+		var vulns []models.Vulnerability
+		if err := db.Select("id, asset_id, severity, cvss_score, in_kev, epss_score, epss_percentile, risk_score, risk_level, risk_factors").
+			Where(where, args...).Where("id > ?", lastID).Order("id").Limit(500).Find(&vulns).Error; err != nil {
+			fmt.Printf("查询需要重新计算风险优先级的漏洞失败: %v\n", err)
+			return updated
+		}
+		if len(vulns) == 0 {
+			return updated
+		}
+		lastID = vulns[len(vulns)-1].ID
+
+		assetIDs := []uint{}
+		for _, vuln := range vulns {
+			assetIDs = append(assetIDs, vuln.AssetID)
+		}
+		var assets []models.Asset
+		db.Where("id IN (?)", assetIDs).Find(&assets)
+		assetMap := map[uint]*models.Asset{}
+		for i := range assets {
+			assetMap[assets[i].ID] = &assets[i]
+		}
+
+		for i := range vulns {
+			vuln := &vulns[i]
+			oldScore, oldLevel, oldFactors := vuln.RiskScore, vuln.RiskLevel, vuln.RiskFactors
+			applyVulnRisk(vuln, assetMap[vuln.AssetID])
+			if vuln.RiskScore == oldScore && vuln.RiskLevel == oldLevel && vuln.RiskFactors == oldFactors {
+				continue
+			}
+			if err := db.Model(&models.Vulnerability{}).Where("id = ?", vuln.ID).UpdateColumns(map[string]interface{}{
+				"risk_score":   vuln.RiskScore,
+				"risk_level":   vuln.RiskLevel,
+				"risk_factors": vuln.RiskFactors,
+			}).Error; err != nil {
+				fmt.Printf("更新漏洞风险优先级失败 (漏洞ID: %d): %v\n", vuln.ID, err)
+				continue
+			}
+			updated++
+		}
+	}
+}
+
+// RecalculateVulnRisk 重新计算全部漏洞的风险优先级评分
+func (s *VulnService) RecalculateVulnRisk() int {
+	return refreshVulnRisk(Init.GetDB(), "1 = 1")
+}
+
+// BackfillVulnRiskScores 为尚未计算风险优先级的历史漏洞补算评分，补算失败不影响系统启动
+func BackfillVulnRiskScores() error {
+	db := Init.GetDB()
+	if count := refreshVulnRisk(db, "risk_level = '' OR risk_level IS NULL"); count > 0 {
+		fmt.Printf("已为%d个历史漏洞补算风险优先级评分\n", count)
+	}
+	return nil
+}
+
+// GetVulnRiskLevels 获取风险优先级可选项
+func (s *VulnService) GetVulnRiskLevels() []VulnWorkflowOption {
+	return vulnRiskLevels
+}
@@ -0,0 +1,775 @@
+// 漏洞状态机服务包
+// 该包集中定义漏洞状态、允许的状态流转、每个流转所需的权限以及进入状态时自动设置的时间戳，
+// 审核、修复、复测、编辑等所有漏洞状态变更都通过ChangeStatus完成。
+// 管理员可以按项目类型在数据库中配置工作流，未配置时使用系统内置流程
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 漏洞状态
+const (
+	VulnStatusPending   = "pending"   // 待审核
+	VulnStatusConfirmed = "confirmed" // 已确认
+	VulnStatusRejected  = "rejected"  // 已驳回
+	VulnStatusUnfixed   = "unfixed"   // 未修复
+	VulnStatusFixing    = "fixing"    // 修复中
+	VulnStatusFixed     = "fixed"     // 已修复
+	VulnStatusRetesting = "retesting" // 复测中
+	VulnStatusCompleted = "completed" // 已完成
+	VulnStatusIgnored   = "ignored"   // 已忽略
+)
+
+// 状态流转错误类型
+const (
+	TransitionInvalidStatus   = "invalid_status"         // 目标状态不存在
+	TransitionNotAllowed      = "transition_not_allowed" // 当前状态不允许流转到目标状态
+	TransitionForbidden       = "permission_denied"      // 操作人没有执行该流转的权限
+	TransitionMissingFields   = "missing_fields"         // 缺少流转要求的必填项
+	TransitionAlreadyApproved = "already_approved"       // 操作人已审批过该流转
+	TransitionPendingApproval = "pending_approval"       // 已记录审批，审批人数未达到要求
+)
+
+// TransitionContext 状态流转上下文
+type TransitionContext struct {
+	DB     *gorm.DB
+	Vuln   *models.Vulnerability
+	Actor  *models.User // 操作人，需预加载Role.Permissions
+	From   string
+	To     string
+	Reason string // 驳回、忽略等操作填写的原因
+	Now    time.Time
+}
+
+// VulnState 漏洞状态定义
+type VulnState struct {
+	Status    string `json:"status"`
+	Label     string `json:"label"`
+	IsInitial bool   `json:"is_initial"`
+	// OnEnter 进入该状态时执行，用于设置时间戳和处理人
+	OnEnter func(ctx *TransitionContext) `json:"-"`
+}
+
+// VulnTransition 漏洞状态流转定义
+// 系统角色、项目角色、权限三项条件需要同时满足，超级管理员不受限制
+type VulnTransition struct {
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	Name              string   `json:"name"`                // 操作名称
+	Permission        string   `json:"permission"`          // 执行该流转所需的权限
+	AllowProjectOwner bool     `json:"allow_project_owner"` // 项目负责人没有该权限也可执行
+	Roles             []string `json:"roles"`               // 允许的系统角色，为空表示不限制
+	ProjectRoles      []string `json:"project_roles"`       // 允许的项目角色，满足其一即可，为空表示不限制
+	RequiredFields    []string `json:"required_fields"`     // 必填项
+	AutoActions       []string `json:"auto_actions"`        // 流转时自动执行的动作，在目标状态的OnEnter之后执行
+	RequiredApprovals int      `json:"required_approvals"`  // 需要的审批人数，大于1时需要多人分别执行
+}
+
+// TransitionError 状态流转失败的结构化错误
+type TransitionError struct {
+	Code              string   `json:"code"`                         // 错误类型
+	From              string   `json:"from"`                         // 当前状态
+	To                string   `json:"to"`                           // 目标状态
+	Permission        string   `json:"permission,omitempty"`         // 缺少的权限
+	Missing           []string `json:"missing,omitempty"`            // 缺少的必填项
+	Approvals         int      `json:"approvals,omitempty"`          // 已审批人数
+	RequiredApprovals int      `json:"required_approvals,omitempty"` // 需要的审批人数
+	Allowed           []string `json:"allowed"`                      // 当前状态允许流转到的状态
+	Message           string   `json:"message"`
+}
+
+func (e *TransitionError) Error() string {
+	return e.Message
+}
+
+// VulnWorkflowOption 工作流配置项（项目角色、必填项、自动动作）
+type VulnWorkflowOption struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+// 项目角色
+var vulnProjectRoles = []VulnWorkflowOption{
+	{Code: "owner", Label: "项目负责人"},
+	{Code: "member", Label: "项目成员"},
+	{Code: "reporter", Label: "漏洞提交人"},
+	{Code: "assignee", Label: "漏洞指派人"},
+}
+
+// 流转必填项
+var vulnRequiredFields = []VulnWorkflowOption{
+	{Code: "comment", Label: "操作说明"},
+	{Code: "assignee", Label: "指派人"},
+	{Code: "fix_deadline", Label: "修复截止时间"},
+	{Code: "fix_suggestion", Label: "修复建议"},
+}
+
+// vulnAutoAction 流转时自动执行的动作
+type vulnAutoAction struct {
+	VulnWorkflowOption
+	Run func(ctx *TransitionContext)
+}
+
+var vulnAutoActions = []vulnAutoAction{
+	{VulnWorkflowOption{Code: "set_assigned_at", Label: "设置指派时间"}, func(ctx *TransitionContext) {
+		ctx.Vuln.AssignedAt = &ctx.Now
+	}},
+	{VulnWorkflowOption{Code: "assign_to_actor", Label: "指派给操作人"}, func(ctx *TransitionContext) {
+		actorID := ctx.Actor.ID
+		ctx.Vuln.AssigneeID = &actorID
+		ctx.Vuln.AssignedAt = &ctx.Now
+	}},
+	{VulnWorkflowOption{Code: "assign_to_reporter", Label: "指派给漏洞提交人"}, func(ctx *TransitionContext) {
+		reporterID := ctx.Vuln.ReporterID
+		ctx.Vuln.AssigneeID = &reporterID
+		ctx.Vuln.AssignedAt = &ctx.Now
+	}},
+	{VulnWorkflowOption{Code: "set_fix_started_at", Label: "设置开始修复时间"}, func(ctx *TransitionContext) {
+		ctx.Vuln.FixStartedAt = &ctx.Now
+	}},
+	{VulnWorkflowOption{Code: "set_fixed_at", Label: "设置修复时间和修复人"}, func(ctx *TransitionContext) {
+		ctx.Vuln.FixedAt = &ctx.Now
+		ctx.Vuln.FixedBy = &ctx.Actor.ID
+	}},
+	{VulnWorkflowOption{Code: "set_retest_at", Label: "设置复测时间和复测人"}, func(ctx *TransitionContext) {
+		ctx.Vuln.RetestAt = &ctx.Now
+		ctx.Vuln.RetesterID = &ctx.Actor.ID
+	}},
+	{VulnWorkflowOption{Code: "set_completed_at", Label: "设置完成时间"}, func(ctx *TransitionContext) {
+		ctx.Vuln.CompletedAt = &ctx.Now
+	}},
+	{VulnWorkflowOption{Code: "set_ignored_at", Label: "设置忽略时间"}, func(ctx *TransitionContext) {
+		ctx.Vuln.IgnoredAt = &ctx.Now
+	}},
+	{VulnWorkflowOption{Code: "mark_resubmitted", Label: "记录重新提交并清除驳回信息"}, func(ctx *TransitionContext) {
+		ctx.Vuln.ResubmittedAt = &ctx.Now
+		ctx.Vuln.ResubmittedBy = &ctx.Actor.ID
+		ctx.Vuln.RejectedAt = nil
+		ctx.Vuln.RejectedBy = nil
+		ctx.Vuln.RejectReason = ""
+	}},
+}
+
+// findVulnAutoAction 查找自动动作
+func findVulnAutoAction(code string) *vulnAutoAction {
+	for i := range vulnAutoActions {
+		if vulnAutoActions[i].Code == code {
+			return &vulnAutoActions[i]
+		}
+	}
+	return nil
+}
+
+// vulnStates 系统内置的漏洞状态，数据库工作流中同名状态同样会执行这里的OnEnter
+var vulnStates = []VulnState{
+	{Status: VulnStatusPending, Label: "待审核", IsInitial: true},
+	{Status: VulnStatusConfirmed, Label: "已确认"},
+	{Status: VulnStatusRejected, Label: "已驳回", OnEnter: func(ctx *TransitionContext) {
+		ctx.Vuln.RejectedAt = &ctx.Now
+		ctx.Vuln.RejectedBy = &ctx.Actor.ID
+		ctx.Vuln.RejectReason = ctx.Reason
+	}},
+	{Status: VulnStatusUnfixed, Label: "未修复"},
+	{Status: VulnStatusFixing, Label: "修复中", OnEnter: func(ctx *TransitionContext) {
+		ctx.Vuln.FixStartedAt = &ctx.Now
+		ctx.Vuln.FixedBy = &ctx.Actor.ID
+	}},
+	{Status: VulnStatusFixed, Label: "已修复", OnEnter: func(ctx *TransitionContext) {
+		ctx.Vuln.FixedAt = &ctx.Now
+		ctx.Vuln.FixedBy = &ctx.Actor.ID
+	}},
+	{Status: VulnStatusRetesting, Label: "复测中", OnEnter: func(ctx *TransitionContext) {
+		ctx.Vuln.RetestAt = &ctx.Now
+		ctx.Vuln.RetesterID = &ctx.Actor.ID
+	}},
+	{Status: VulnStatusCompleted, Label: "已完成", OnEnter: func(ctx *TransitionContext) {
+		if ctx.Vuln.RetestAt == nil {
+			ctx.Vuln.RetestAt = &ctx.Now
+		}
+		ctx.Vuln.CompletedAt = &ctx.Now
+		ctx.Vuln.RetesterID = &ctx.Actor.ID
+	}},
+	{Status: VulnStatusIgnored, Label: "已忽略", OnEnter: func(ctx *TransitionContext) {
+		ctx.Vuln.IgnoredAt = &ctx.Now
+	}},
+}
+
+// 复测只能由漏洞提交人或项目负责人执行
+var retesterRoles = []string{"reporter", "owner"}
+
+// vulnTransitions 系统内置的漏洞状态流转，未列出的流转一律拒绝
+// 漏洞只能通过风险接受审批进入已忽略状态，见systemOnlyVulnStatuses
+var vulnTransitions = []VulnTransition{
+	// 审核
+	{From: VulnStatusPending, To: VulnStatusConfirmed, Name: "审核通过", Permission: "vuln:audit"},
+	{From: VulnStatusPending, To: VulnStatusRejected, Name: "驳回", Permission: "vuln:change_status"},
+	{From: VulnStatusPending, To: VulnStatusUnfixed, Name: "确认待修复", Permission: "vuln:change_status"},
+	{From: VulnStatusPending, To: VulnStatusFixing, Name: "开始修复", Permission: "vuln:fix"},
+	{From: VulnStatusPending, To: VulnStatusFixed, Name: "标记已修复", Permission: "vuln:fix"},
+
+	// 修复
+	{From: VulnStatusConfirmed, To: VulnStatusUnfixed, Name: "确认待修复", Permission: "vuln:change_status"},
+	{From: VulnStatusConfirmed, To: VulnStatusFixing, Name: "开始修复", Permission: "vuln:fix"},
+	{From: VulnStatusConfirmed, To: VulnStatusFixed, Name: "标记已修复", Permission: "vuln:fix"},
+	{From: VulnStatusConfirmed, To: VulnStatusRejected, Name: "驳回", Permission: "vuln:change_status"},
+	{From: VulnStatusUnfixed, To: VulnStatusFixing, Name: "开始修复", Permission: "vuln:fix"},
+	{From: VulnStatusUnfixed, To: VulnStatusFixed, Name: "标记已修复", Permission: "vuln:fix"},
+	{From: VulnStatusUnfixed, To: VulnStatusRejected, Name: "驳回", Permission: "vuln:change_status"},
+	{From: VulnStatusFixing, To: VulnStatusUnfixed, Name: "暂停修复", Permission: "vuln:fix"},
+	{From: VulnStatusFixing, To: VulnStatusFixed, Name: "标记已修复", Permission: "vuln:fix"},
+	{From: VulnStatusFixing, To: VulnStatusRejected, Name: "驳回", Permission: "vuln:change_status"},
+
+	// 复测
+	{From: VulnStatusFixed, To: VulnStatusRetesting, Name: "开始复测", Permission: "vuln:retest", AllowProjectOwner: true, ProjectRoles: retesterRoles},
+	{From: VulnStatusFixed, To: VulnStatusCompleted, Name: "复测通过", Permission: "vuln:retest", AllowProjectOwner: true, ProjectRoles: retesterRoles},
+	{From: VulnStatusFixed, To: VulnStatusUnfixed, Name: "复测不通过", Permission: "vuln:retest", AllowProjectOwner: true, ProjectRoles: retesterRoles},
+	{From: VulnStatusRetesting, To: VulnStatusCompleted, Name: "复测通过", Permission: "vuln:retest", AllowProjectOwner: true, ProjectRoles: retesterRoles},
+	{From: VulnStatusRetesting, To: VulnStatusUnfixed, Name: "复测不通过", Permission: "vuln:retest", AllowProjectOwner: true, ProjectRoles: retesterRoles},
+	{From: VulnStatusCompleted, To: VulnStatusUnfixed, Name: "重新打开", Permission: "vuln:retest", AllowProjectOwner: true, ProjectRoles: retesterRoles},
+
+	// 驳回后由提交人重新提交、忽略后重新激活
+	{From: VulnStatusRejected, To: VulnStatusUnfixed, Name: "重新提交", Permission: "vuln:create", ProjectRoles: []string{"reporter"}, AutoActions: []string{"mark_resubmitted"}},
+	{From: VulnStatusIgnored, To: VulnStatusUnfixed, Name: "重新激活", Permission: "vuln:assign"},
+}
+
+// systemOnlyVulnStatuses 只能由系统流程通过applySystemStatus进入的状态
+// 已忽略需要经过风险接受审批，记录理由和有效期，不能通过状态流转直接设置
+var systemOnlyVulnStatuses = []string{VulnStatusIgnored}
+
+// isSystemOnlyVulnStatus 判断状态是否只能由系统流程进入
+func isSystemOnlyVulnStatus(status string) bool {
+	return contains(systemOnlyVulnStatuses, status)
+}
+
+// legacyVulnStatuses 历史版本使用的状态，按对应的新状态参与流转
+var legacyVulnStatuses = map[string]string{
+	"closed":   VulnStatusCompleted,
+	"reopened": VulnStatusUnfixed,
+}
+
+// normalizeVulnStatus 将历史状态转换为状态机中的状态
+func normalizeVulnStatus(status string) string {
+	if normalized, ok := legacyVulnStatuses[status]; ok {
+		return normalized
+	}
+	return status
+}
+
+// findVulnState 查找系统内置状态定义
+func findVulnState(status string) *VulnState {
+	for i := range vulnStates {
+		if vulnStates[i].Status == status {
+			return &vulnStates[i]
+		}
+	}
+	return nil
+}
+
+// vulnStatusLabel 获取漏洞状态的中文名称，自定义工作流中的状态取工作流中配置的名称
+func vulnStatusLabel(status string) string {
+	if state := findVulnState(status); state != nil {
+		return state.Label
+	}
+	switch status {
+	case "closed":
+		return "已关闭"
+	case "reopened":
+		return "重新打开"
+	}
+
+	var state models.WorkflowState
+	if err := Init.GetDB().Where("status = ? AND label <> ''", status).First(&state).Error; err == nil {
+		return state.Label
+	}
+	return status
+}
+
+// VulnWorkflow 漏洞工作流定义
+type VulnWorkflow struct {
+	ID          uint             `json:"id"`           // 数据库工作流ID，系统内置流程为0
+	Name        string           `json:"name"`         // 工作流名称
+	ProjectType string           `json:"project_type"` // 适用的项目类型
+	Initial     string           `json:"initial"`      // 新提交漏洞的初始状态
+	States      []VulnState      `json:"states"`
+	Transitions []VulnTransition `json:"transitions"`
+}
+
+// builtinVulnWorkflow 系统内置流程
+func builtinVulnWorkflow() *VulnWorkflow {
+	return &VulnWorkflow{
+		Name:        "系统内置流程",
+		Initial:     VulnStatusPending,
+		States:      vulnStates,
+		Transitions: vulnTransitions,
+	}
+}
+
+// state 查找工作流中的状态
+func (w *VulnWorkflow) state(status string) *VulnState {
+	for i := range w.States {
+		if w.States[i].Status == status {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// transition 查找工作流中的状态流转
+func (w *VulnWorkflow) transition(from, to string) *VulnTransition {
+	for i := range w.Transitions {
+		if w.Transitions[i].From == from && w.Transitions[i].To == to {
+			return &w.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// allowed 获取当前状态允许流转到的状态
+func (w *VulnWorkflow) allowed(from string) []string {
+	allowed := []string{}
+	for _, t := range w.Transitions {
+		if t.From == from && !isSystemOnlyVulnStatus(t.To) {
+			allowed = append(allowed, t.To)
+		}
+	}
+	return allowed
+}
+
+// label 获取工作流中状态的名称
+func (w *VulnWorkflow) label(status string) string {
+	if state := w.state(status); state != nil && state.Label != "" {
+		return state.Label
+	}
+	return vulnStatusLabel(status)
+}
+
+// splitWorkflowList 拆分逗号分隔的配置项
+func splitWorkflowList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newVulnWorkflow 将数据库中的工作流转换为状态机定义
+func newVulnWorkflow(workflow *models.Workflow) *VulnWorkflow {
+	wf := &VulnWorkflow{
+		ID:          workflow.ID,
+		Name:        workflow.Name,
+		ProjectType: workflow.ProjectType,
+		States:      []VulnState{},
+		Transitions: []VulnTransition{},
+	}
+	for _, s := range workflow.States {
+		state := VulnState{Status: s.Status, Label: s.Label, IsInitial: s.IsInitial}
+		if builtin := findVulnState(s.Status); builtin != nil {
+			state.OnEnter = builtin.OnEnter
+			if state.Label == "" {
+				state.Label = builtin.Label
+			}
+		}
+		if state.IsInitial {
+			wf.Initial = state.Status
+		}
+		wf.States = append(wf.States, state)
+	}
+	if wf.Initial == "" && len(wf.States) > 0 {
+		wf.Initial = wf.States[0].Status
+	}
+	for _, t := range workflow.Transitions {
+		wf.Transitions = append(wf.Transitions, VulnTransition{
+			From:              t.FromStatus,
+			To:                t.ToStatus,
+			Name:              t.Name,
+			Permission:        t.Permission,
+			Roles:             splitWorkflowList(t.Roles),
+			ProjectRoles:      splitWorkflowList(t.ProjectRoles),
+			RequiredFields:    splitWorkflowList(t.RequiredFields),
+			AutoActions:       splitWorkflowList(t.AutoActions),
+			RequiredApprovals: t.RequiredApprovals,
+		})
+	}
+	return wf
+}
+
+// resolveVulnWorkflow 获取项目使用的工作流：项目类型的专属工作流 > 默认工作流 > 系统内置流程
+func resolveVulnWorkflow(db *gorm.DB, projectID uint) *VulnWorkflow {
+	projectType := ""
+	if projectID != 0 {
+		var project models.Project
+		if err := db.Select("id, type").Where("id = ?", projectID).First(&project).Error; err == nil {
+			projectType = project.Type
+		}
+	}
+
+	query := func(projectType string) *models.Workflow {
+		var workflow models.Workflow
+		err := preloadWorkflow(db).Where("project_type = ? AND is_active = ?", projectType, true).First(&workflow).Error
+		if err != nil || len(workflow.States) == 0 {
+			return nil
+		}
+		return &workflow
+	}
+
+	if projectType != "" {
+		if workflow := query(projectType); workflow != nil {
+			return newVulnWorkflow(workflow)
+		}
+	}
+	if workflow := query(""); workflow != nil {
+		return newVulnWorkflow(workflow)
+	}
+	return builtinVulnWorkflow()
+}
+
+// isVulnProjectOwner 判断用户是否为漏洞所属项目的负责人
+func isVulnProjectOwner(db *gorm.DB, vuln *models.Vulnerability, userID uint) bool {
+	if vuln.ProjectID == 0 {
+		return false
+	}
+	var count int64
+	db.Model(&models.Project{}).Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).Count(&count)
+	return count > 0
+}
+
+// hasVulnProjectRole 判断操作人是否具有任一项目角色
+func hasVulnProjectRole(ctx *TransitionContext, roles []string) bool {
+	for _, role := range roles {
+		switch role {
+		case "owner":
+			if isVulnProjectOwner(ctx.DB, ctx.Vuln, ctx.Actor.ID) {
+				return true
+			}
+		case "member":
+			var count int64
+			ctx.DB.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", ctx.Vuln.ProjectID, ctx.Actor.ID).Count(&count)
+			if count > 0 {
+				return true
+			}
+		case "reporter":
+			if ctx.Vuln.ReporterID == ctx.Actor.ID {
+				return true
+			}
+		case "assignee":
+			if ctx.Vuln.AssigneeID != nil && *ctx.Vuln.AssigneeID == ctx.Actor.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// projectRoleLabels 获取项目角色名称，用于错误提示
+func projectRoleLabels(roles []string) string {
+	labels := []string{}
+	for _, role := range roles {
+		for _, option := range vulnProjectRoles {
+			if option.Code == role {
+				labels = append(labels, option.Label)
+			}
+		}
+	}
+	return strings.Join(labels, "、")
+}
+
+// checkTransitionPermission 校验操作人是否可以执行流转，返回拒绝原因
+func checkTransitionPermission(ctx *TransitionContext, t *VulnTransition) string {
+	if ctx.Actor.Role.Code == "super_admin" {
+		return ""
+	}
+	if len(t.Roles) > 0 && !contains(t.Roles, ctx.Actor.Role.Code) {
+		return fmt.Sprintf("当前角色无权限执行「%s」操作", t.Name)
+	}
+	if len(t.ProjectRoles) > 0 && !hasVulnProjectRole(ctx, t.ProjectRoles) {
+		return fmt.Sprintf("只有%s可以执行「%s」操作", projectRoleLabels(t.ProjectRoles), t.Name)
+	}
+	if t.Permission != "" && !ctx.Actor.HasPermission(t.Permission) &&
+		!(t.AllowProjectOwner && isVulnProjectOwner(ctx.DB, ctx.Vuln, ctx.Actor.ID)) {
+		return fmt.Sprintf("无权限执行「%s」操作", t.Name)
+	}
+	return ""
+}
+
+// missingTransitionFields 获取流转缺少的必填项
+func missingTransitionFields(ctx *TransitionContext, t *VulnTransition) []string {
+	missing := []string{}
+	for _, field := range t.RequiredFields {
+		filled := true
+		switch field {
+		case "comment":
+			filled = strings.TrimSpace(ctx.Reason) != ""
+		case "assignee":
+			filled = ctx.Vuln.AssigneeID != nil && *ctx.Vuln.AssigneeID != 0
+		case "fix_deadline":
+			filled = ctx.Vuln.FixDeadline != nil
+		case "fix_suggestion":
+			filled = strings.TrimSpace(ctx.Vuln.FixSuggestion) != ""
+		}
+		if !filled {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// requiredFieldLabels 获取必填项名称，用于错误提示
+func requiredFieldLabels(fields []string) string {
+	labels := []string{}
+	for _, field := range fields {
+		for _, option := range vulnRequiredFields {
+			if option.Code == field {
+				labels = append(labels, option.Label)
+			}
+		}
+	}
+	return strings.Join(labels, "、")
+}
+
+// loadVulnActor 加载操作人及其权限
+func loadVulnActor(db *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := db.Preload("Role.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	return &user, nil
+}
+
+// StatusChange 已应用到漏洞对象上的状态变更，漏洞保存成功后由recordStatusEffects记录附带的时间线和审批清理
+type StatusChange struct {
+	VulnID  uint
+	ActorID uint
+	SLANote string // SLA计时变化说明
+}
+
+// recordStatusEffects 记录SLA计时变化并作废之前的审批记录，必须在漏洞保存（或事务提交）成功后调用
+func (s *VulnService) recordStatusEffects(db *gorm.DB, change *StatusChange) {
+	if change.SLANote != "" {
+		s.addTimeline(change.VulnID, change.ActorID, "sla", change.SLANote)
+	}
+	db.Where("vuln_id = ?", change.VulnID).Delete(&models.WorkflowApproval{})
+}
+
+// ChangeStatus 变更漏洞状态：按漏洞所属项目的工作流校验流转、操作人权限、必填项和审批人数，
+// 然后设置新状态并执行进入状态的动作和流转的自动动作，同时更新SLA计时。
+// 校验通过时只修改传入的漏洞对象，由调用方保存后调用recordStatusEffects；
+// 多人审批人数不足时直接记录本次审批和时间线，并返回TransitionPendingApproval错误。
+// reason为驳回、忽略等操作填写的原因
+func (s *VulnService) ChangeStatus(db *gorm.DB, vuln *models.Vulnerability, to string, actor *models.User, reason string) (*StatusChange, error) {
+	wf := resolveVulnWorkflow(db, vuln.ProjectID)
+	from := normalizeVulnStatus(vuln.Status)
+
+	state := wf.state(to)
+	if state == nil {
+		return nil, &TransitionError{
+			Code:    TransitionInvalidStatus,
+			From:    from,
+			To:      to,
+			Allowed: wf.allowed(from),
+			Message: fmt.Sprintf("无效的漏洞状态: %s", to),
+		}
+	}
+
+	if isSystemOnlyVulnStatus(to) {
+		return nil, &TransitionError{
+			Code:    TransitionNotAllowed,
+			From:    from,
+			To:      to,
+			Allowed: wf.allowed(from),
+			Message: fmt.Sprintf("漏洞不能直接变更为「%s」，请提交风险接受申请并由审批人批准", state.Label),
+		}
+	}
+
+	transition := wf.transition(from, to)
+	if transition == nil {
+		return nil, &TransitionError{
+			Code:    TransitionNotAllowed,
+			From:    from,
+			To:      to,
+			Allowed: wf.allowed(from),
+			Message: fmt.Sprintf("漏洞状态不能从「%s」变更为「%s」", wf.label(from), state.Label),
+		}
+	}
+
+	ctx := &TransitionContext{
+		DB:     db,
+		Vuln:   vuln,
+		Actor:  actor,
+		From:   from,
+		To:     to,
+		Reason: reason,
+		Now:    time.Now().Truncate(time.Second),
+	}
+
+	if msg := checkTransitionPermission(ctx, transition); msg != "" {
+		return nil, &TransitionError{
+			Code:       TransitionForbidden,
+			From:       from,
+			To:         to,
+			Permission: transition.Permission,
+			Allowed:    wf.allowed(from),
+			Message:    msg,
+		}
+	}
+
+	if missing := missingTransitionFields(ctx, transition); len(missing) > 0 {
+		return nil, &TransitionError{
+			Code:    TransitionMissingFields,
+			From:    from,
+			To:      to,
+			Missing: missing,
+			Allowed: wf.allowed(from),
+			Message: fmt.Sprintf("执行「%s」操作需要填写：%s", transition.Name, requiredFieldLabels(missing)),
+		}
+	}
+
+	// 需要多人审批的流转，审批人数不足时只记录审批
+	if transition.RequiredApprovals > 1 {
+		var approvals []models.WorkflowApproval
+		db.Where("vuln_id = ? AND from_status = ? AND to_status = ?", vuln.ID, from, to).Find(&approvals)
+		for _, approval := range approvals {
+			if approval.UserID == actor.ID {
+				return nil, &TransitionError{
+					Code:              TransitionAlreadyApproved,
+					From:              from,
+					To:                to,
+					Approvals:         len(approvals),
+					RequiredApprovals: transition.RequiredApprovals,
+					Allowed:           wf.allowed(from),
+					Message:           fmt.Sprintf("您已审批过「%s」操作，需要其他用户审批", transition.Name),
+				}
+			}
+		}
+		if len(approvals)+1 < transition.RequiredApprovals {
+			db.Create(&models.WorkflowApproval{
+				VulnID:     vuln.ID,
+				FromStatus: from,
+				ToStatus:   to,
+				UserID:     actor.ID,
+				Comment:    reason,
+			})
+			count := len(approvals) + 1
+			s.addTimeline(vuln.ID, actor.ID, "transition_approved", fmt.Sprintf("审批「%s」（%d/%d）", transition.Name, count, transition.RequiredApprovals))
+			return nil, &TransitionError{
+				Code:              TransitionPendingApproval,
+				From:              from,
+				To:                to,
+				Approvals:         count,
+				RequiredApprovals: transition.RequiredApprovals,
+				Allowed:           wf.allowed(from),
+				Message:           fmt.Sprintf("已记录审批（%d/%d），还需要%d人审批", count, transition.RequiredApprovals, transition.RequiredApprovals-count),
+			}
+		}
+	}
+
+	vuln.Status = to
+	if state.OnEnter != nil {
+		state.OnEnter(ctx)
+	}
+	for _, code := range transition.AutoActions {
+		if action := findVulnAutoAction(code); action != nil {
+			action.Run(ctx)
+		}
+	}
+	return s.trackSLA(db, vuln, to, actor, ctx.Now), nil
+}
+
+// applySystemStatus 由系统流程（如风险接受审批、到期恢复）直接变更漏洞状态
+// 目标状态必须存在于漏洞所属项目的工作流中，不校验流转和操作人权限，由调用方负责业务校验和保存，保存后调用recordStatusEffects
+func (s *VulnService) applySystemStatus(db *gorm.DB, vuln *models.Vulnerability, to string, actor *models.User, reason string) (*StatusChange, error) {
+	wf := resolveVulnWorkflow(db, vuln.ProjectID)
+	state := wf.state(to)
+	if state == nil {
+		return nil, fmt.Errorf("漏洞所属项目的工作流中没有「%s」状态", vulnStatusLabel(to))
+	}
+
+	ctx := &TransitionContext{
+		DB:     db,
+		Vuln:   vuln,
+		Actor:  actor,
+		From:   normalizeVulnStatus(vuln.Status),
+		To:     to,
+		Reason: reason,
+		Now:    time.Now().Truncate(time.Second),
+	}
+	vuln.Status = to
+	if state.OnEnter != nil {
+		state.OnEnter(ctx)
+	}
+	return s.trackSLA(db, vuln, to, actor, ctx.Now), nil
+}
+
+// trackSLA 更新漏洞的SLA计时，返回待记录的状态变更
+func (s *VulnService) trackSLA(db *gorm.DB, vuln *models.Vulnerability, to string, actor *models.User, now time.Time) *StatusChange {
+	return &StatusChange{
+		VulnID:  vuln.ID,
+		ActorID: actor.ID,
+		SLANote: trackVulnSLA(db, vuln, to, now),
+	}
+}
+
+// initialVulnStatus 获取新提交漏洞的初始状态
+func initialVulnStatus(db *gorm.DB, projectID uint) string {
+	return resolveVulnWorkflow(db, projectID).Initial
+}
+
+// recordStatusChange 记录状态变更时间线，note为附加说明
+func (s *VulnService) recordStatusChange(vulnID, userID uint, from, to, note string) {
+	description := fmt.Sprintf("状态从 %s 变更为 %s", vulnStatusLabel(from), vulnStatusLabel(to))
+	if note != "" {
+		description += "，" + note
+	}
+	s.addTimeline(vulnID, userID, "status_changed", description)
+}
+
+// GetVulnWorkflow 获取项目使用的漏洞工作流，projectID为0时返回默认工作流
+func (s *VulnService) GetVulnWorkflow(projectID uint) *VulnWorkflow {
+	return resolveVulnWorkflow(Init.GetDB(), projectID)
+}
+
+// GetAvailableTransitions 获取当前用户对漏洞可执行的状态流转
+func (s *VulnService) GetAvailableTransitions(vulnID, userID uint, userRole string) ([]VulnTransition, error) {
+	db := Init.GetDB()
+
+	var vuln models.Vulnerability
+	if err := db.Where("id = ?", vulnID).First(&vuln).Error; err != nil {
+		return nil, errors.New("漏洞不存在")
+	}
+	if !s.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	actor, err := loadVulnActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	wf := resolveVulnWorkflow(db, vuln.ProjectID)
+	from := normalizeVulnStatus(vuln.Status)
+	ctx := &TransitionContext{DB: db, Vuln: &vuln, Actor: actor, From: from, Now: time.Now()}
+
+	available := []VulnTransition{}
+	for i := range wf.Transitions {
+		t := &wf.Transitions[i]
+		if t.From != from || isSystemOnlyVulnStatus(t.To) {
+			continue
+		}
+		ctx.To = t.To
+		if checkTransitionPermission(ctx, t) != "" {
+			continue
+		}
+		available = append(available, *t)
+	}
+
+	return available, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"vulnmain/models"
+)
+
+// 与InitDefaultData中分配的角色权限保持一致
+var (
+	testSecurityEngineerPermissions = []string{"vuln:view", "vuln:create", "vuln:edit", "vuln:assign", "vuln:retest", "vuln:change_status", "risk:view", "risk:approve"}
+	testDevEngineerPermissions      = []string{"vuln:view", "vuln:edit", "vuln:fix", "vuln:change_status"}
+)
+
+// testActor 构造带角色权限的操作人
+func testActor(id uint, role string, permissions []string) *models.User {
+	user := &models.User{Role: models.Role{Code: role}}
+	user.ID = id
+	for _, code := range permissions {
+		user.Role.Permissions = append(user.Role.Permissions, models.Permission{Code: code})
+	}
+	return user
+}
+
+func TestBuiltinWorkflowAllowed(t *testing.T) {
+	tests := []struct {
+		from string
+		want []string
+	}{
+		{VulnStatusPending, []string{VulnStatusConfirmed, VulnStatusRejected, VulnStatusUnfixed, VulnStatusFixing, VulnStatusFixed}},
+		{VulnStatusConfirmed, []string{VulnStatusUnfixed, VulnStatusFixing, VulnStatusFixed, VulnStatusRejected}},
+		{VulnStatusUnfixed, []string{VulnStatusFixing, VulnStatusFixed, VulnStatusRejected}},
+		{VulnStatusFixing, []string{VulnStatusUnfixed, VulnStatusFixed, VulnStatusRejected}},
+		{VulnStatusFixed, []string{VulnStatusRetesting, VulnStatusCompleted, VulnStatusUnfixed}},
+		{VulnStatusRetesting, []string{VulnStatusCompleted, VulnStatusUnfixed}},
+		{VulnStatusCompleted, []string{VulnStatusUnfixed}},
+		{VulnStatusRejected, []string{VulnStatusUnfixed}},
+		{VulnStatusIgnored, []string{VulnStatusUnfixed}},
+		{"unknown", []string{}},
+	}
+
+	wf := builtinVulnWorkflow()
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			if got := wf.allowed(tt.from); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allowed(%s) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinWorkflowIgnoredIsSystemOnly(t *testing.T) {
+	wf := builtinVulnWorkflow()
+	if wf.state(VulnStatusIgnored) == nil {
+		t.Fatal("ignored state missing from builtin workflow")
+	}
+	if !isSystemOnlyVulnStatus(VulnStatusIgnored) {
+		t.Error("ignored should only be reachable through risk acceptance approval")
+	}
+	for _, transition := range wf.Transitions {
+		if transition.To == VulnStatusIgnored {
+			t.Errorf("builtin workflow allows %s -> ignored", transition.From)
+		}
+	}
+}
+
+func TestBuiltinWorkflowLegacyStatuses(t *testing.T) {
+	wf := builtinVulnWorkflow()
+	if wf.transition(normalizeVulnStatus("closed"), VulnStatusUnfixed) == nil {
+		t.Error("closed vulnerabilities should be reopenable as completed")
+	}
+	if normalizeVulnStatus("reopened") != VulnStatusUnfixed {
+		t.Error("reopened should be treated as unfixed")
+	}
+}
+
+func TestCheckTransitionPermission(t *testing.T) {
+	const reporterID, otherID = 10, 20
+
+	tests := []struct {
+		name        string
+		from, to    string
+		actor       *models.User
+		wantAllowed bool
+	}{
+		{"security engineer confirms without audit permission", VulnStatusPending, VulnStatusConfirmed, testActor(otherID, "security_engineer", testSecurityEngineerPermissions), false},
+		{"auditor confirms", VulnStatusPending, VulnStatusConfirmed, testActor(otherID, "auditor", []string{"vuln:audit"}), true},
+		{"security engineer rejects", VulnStatusPending, VulnStatusRejected, testActor(otherID, "security_engineer", testSecurityEngineerPermissions), true},
+		{"dev engineer starts fixing", VulnStatusUnfixed, VulnStatusFixing, testActor(otherID, "dev_engineer", testDevEngineerPermissions), true},
+		{"dev engineer marks fixed", VulnStatusFixing, VulnStatusFixed, testActor(otherID, "dev_engineer", testDevEngineerPermissions), true},
+		{"security engineer cannot mark fixed", VulnStatusFixing, VulnStatusFixed, testActor(otherID, "security_engineer", testSecurityEngineerPermissions), false},
+		{"reporter retests", VulnStatusFixed, VulnStatusCompleted, testActor(reporterID, "security_engineer", testSecurityEngineerPermissions), true},
+		{"other security engineer cannot retest", VulnStatusFixed, VulnStatusCompleted, testActor(otherID, "security_engineer", testSecurityEngineerPermissions), false},
+		{"reporter without retest permission", VulnStatusRetesting, VulnStatusCompleted, testActor(reporterID, "dev_engineer", testDevEngineerPermissions), false},
+		{"reporter resubmits rejected", VulnStatusRejected, VulnStatusUnfixed, testActor(reporterID, "security_engineer", testSecurityEngineerPermissions), true},
+		{"non-reporter cannot resubmit", VulnStatusRejected, VulnStatusUnfixed, testActor(otherID, "security_engineer", testSecurityEngineerPermissions), false},
+		{"security engineer reactivates ignored", VulnStatusIgnored, VulnStatusUnfixed, testActor(otherID, "security_engineer", testSecurityEngineerPermissions), true},
+		{"dev engineer cannot reactivate ignored", VulnStatusIgnored, VulnStatusUnfixed, testActor(otherID, "dev_engineer", testDevEngineerPermissions), false},
+		{"super admin bypasses project roles", VulnStatusRejected, VulnStatusUnfixed, testActor(otherID, "super_admin", nil), true},
+		{"super admin bypasses permissions", VulnStatusFixed, VulnStatusCompleted, testActor(otherID, "super_admin", nil), true},
+	}
+
+	wf := builtinVulnWorkflow()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition := wf.transition(tt.from, tt.to)
+			if transition == nil {
+				t.Fatalf("transition %s -> %s not defined", tt.from, tt.to)
+			}
+			ctx := &TransitionContext{
+				Vuln:  &models.Vulnerability{ReporterID: reporterID},
+				Actor: tt.actor,
+				From:  tt.from,
+				To:    tt.to,
+			}
+			msg := checkTransitionPermission(ctx, transition)
+			if (msg == "") != tt.wantAllowed {
+				t.Errorf("checkTransitionPermission() = %q, wantAllowed %v", msg, tt.wantAllowed)
+			}
+		})
+	}
+}
@@ -3,72 +3,89 @@ package services
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 	Init "vulnmain/Init"
 	"vulnmain/models"
+	"vulnmain/utils"
+
+	"github.com/jinzhu/gorm"
 )
 
 type VulnService struct{}
 
 type VulnCreateRequest struct {
-	Title         string `json:"title" binding:"required"`
-	VulnURL       string `json:"vuln_url" binding:"required"`
-	Description   string `json:"description"`
-	VulnType      string `json:"vuln_type" binding:"required"`
-	Severity      string `json:"severity" binding:"required"`
-	Source        string `json:"source"`
-	CVEID         string `json:"cve_id"`
-	CNNVDID       string `json:"cnnvd_id"`
+	Title         string  `json:"title"` // 使用漏洞模板时可以不填
+	VulnURL       string  `json:"vuln_url" binding:"required"`
+	Description   string  `json:"description"`
+	VulnType      string  `json:"vuln_type"` // 使用漏洞模板时可以不填
+	Severity      string  `json:"severity"`
+	Source        string  `json:"source"`
+	CVEID         string  `json:"cve_id"`
+	CNNVDID       string  `json:"cnnvd_id"`
 	CVSSScore     float64 `json:"cvss_score"`
-	POC           string `json:"poc"`
-	Solution      string `json:"solution"`
-	FixSuggestion string `json:"fix_suggestion" binding:"required"`
-	References    string `json:"references"`
-	AssetID       uint   `json:"asset_id" binding:"required"`
-	ProjectID     uint   `json:"project_id" binding:"required"`
-	AssigneeID    uint   `json:"assignee_id" binding:"required"`
-	FixDeadline   string `json:"fix_deadline" binding:"required"`
-	Tags          string `json:"tags"`
+	CVSSVector    string  `json:"cvss_vector"`
+	POC           string  `json:"poc"`
+	Solution      string  `json:"solution"`
+	FixSuggestion string  `json:"fix_suggestion"` // 未填写时使用匹配的知识库文章的修复建议
+	References    string  `json:"references"`
+	AssetID       uint    `json:"asset_id" binding:"required"`
+	ProjectID     uint    `json:"project_id" binding:"required"`
+	AssigneeID    uint    `json:"assignee_id" binding:"required"`
+	FixDeadline   string  `json:"fix_deadline"` // 可选，审核通过时按SLA策略重新计算
+	Tags          string  `json:"tags"`
+	CategoryID    *uint   `json:"category_id"` // 漏洞分类，为空时按漏洞类型和CWE编号自动归类
+	TemplateID    *uint   `json:"template_id"` // 漏洞模板，未填写的字段使用模板内容，并替换占位符
 }
 
 type VulnUpdateRequest struct {
-	Title           string `json:"title"`
-	VulnURL         string `json:"vuln_url"`
-	Description     string `json:"description"`
-	VulnType        string `json:"vuln_type"`
-	Severity        string `json:"severity"`
-	Status          string `json:"status"`
-	CVEID           string `json:"cve_id"`
-	FixSuggestion   string `json:"fix_suggestion"`
-	AssetID         *uint  `json:"asset_id"`
-	FixDeadline     string `json:"fix_deadline"`
-	POC             string `json:"poc"`
-	Solution        string `json:"solution"`
-	References      string `json:"references"`
-	AssigneeID      *uint  `json:"assignee_id"`
-	Tags            string `json:"tags"`
-	RejectReason    string `json:"reject_reason"`
-	Comment         string `json:"comment"`
-	ResubmittedAt   string `json:"resubmitted_at"`
-	ResubmittedBy   *uint  `json:"resubmitted_by"`
+	Title           string   `json:"title"`
+	VulnURL         string   `json:"vuln_url"`
+	Description     string   `json:"description"`
+	VulnType        string   `json:"vuln_type"`
+	Severity        string   `json:"severity"`
+	Status          string   `json:"status"`
+	Source          string   `json:"source"`
+	CVEID           string   `json:"cve_id"`
+	CNNVDID         string   `json:"cnnvd_id"`
+	CVSSScore       *float64 `json:"cvss_score"`
+	CVSSVector      *string  `json:"cvss_vector"`
+	FixSuggestion   string   `json:"fix_suggestion"`
+	AssetID         *uint    `json:"asset_id"`
+	FixDeadline     string   `json:"fix_deadline"`
+	POC             string   `json:"poc"`
+	Solution        string   `json:"solution"`
+	References      string   `json:"references"`
+	AssigneeID      *uint    `json:"assignee_id"`
+	Tags            string   `json:"tags"`
+	RejectReason    string   `json:"reject_reason"`
+	Comment         string   `json:"comment"`
+	ResubmittedAt   string   `json:"resubmitted_at"`
+	ResubmittedBy   *uint    `json:"resubmitted_by"`
+	CascadeChildren bool     `json:"cascade_children"` // 关闭漏洞时是否将状态同步到子漏洞
+	CategoryID      *uint    `json:"category_id"`      // 漏洞分类，传0时按漏洞类型和CWE编号重新自动归类
 }
 
 type VulnListRequest struct {
-	Page       int    `form:"page" binding:"min=1"`
-	PageSize   int    `form:"page_size" binding:"min=1,max=100"`
-	Keyword    string `form:"keyword"`
-	Title      string `form:"title"`
-	VulnType   string `form:"vuln_type"`
-	Severity   string `form:"severity"`
-	Status     string `form:"status"`
-	Source     string `form:"source"`
-	AssetID    *uint  `form:"asset_id"`
-	ProjectID  *uint  `form:"project_id"`
-	ReporterID *uint  `form:"reporter_id"`
-	AssigneeID *uint  `form:"assignee_id"`
+	Page         int      `form:"page" json:"page" binding:"min=1"`
+	PageSize     int      `form:"page_size" json:"page_size" binding:"min=1,max=100"`
+	Keyword      string   `form:"keyword" json:"keyword"`
+	Title        string   `form:"title" json:"title"`
+	VulnType     string   `form:"vuln_type" json:"vuln_type"`
+	Severity     string   `form:"severity" json:"severity"`
+	Status       string   `form:"status" json:"status"`
+	Source       string   `form:"source" json:"source"`
+	AssetID      *uint    `form:"asset_id" json:"asset_id"`
+	ProjectID    *uint    `form:"project_id" json:"project_id"`
+	ReporterID   *uint    `form:"reporter_id" json:"reporter_id"`
+	AssigneeID   *uint    `form:"assignee_id" json:"assignee_id"`
+	CategoryID   *uint    `form:"category_id" json:"category_id"`       // 漏洞分类，包含下级分类的漏洞
+	RiskLevel    string   `form:"risk_level" json:"risk_level"`         // 风险优先级：P1、P2、P3、P4
+	MinRiskScore *float64 `form:"min_risk_score" json:"min_risk_score"` // 最低风险优先级评分
+	SortBy       string   `form:"sort_by" json:"sort_by"`               // 排序字段：created_at（默认）、risk_score
 	// 权限控制字段
-	CurrentUserID   uint   `form:"-"`
-	CurrentUserRole string `form:"-"`
+	CurrentUserID   uint   `form:"-" json:"-"`
+	CurrentUserRole string `form:"-" json:"-"`
 }
 
 type VulnListResponse struct {
@@ -86,6 +103,7 @@ type AuditRequest struct {
 	Comment    string  `json:"comment"`
 	Severity   string  `json:"severity"`
 	CvssScore  float64 `json:"cvss_score"`
+	CvssVector string  `json:"cvss_vector"`
 	AssigneeID *uint   `json:"assignee_id"`
 }
 
@@ -99,6 +117,21 @@ func (s *VulnService) CreateVuln(req *VulnCreateRequest, reporterID uint) (*mode
 		return nil, errors.New("资产不存在")
 	}
 
+	// 使用漏洞模板时补充未填写的字段并替换占位符
+	var template *models.FindingTemplate
+	if req.TemplateID != nil && *req.TemplateID != 0 {
+		var err error
+		if template, err = getVisibleFindingTemplate(db, *req.TemplateID, reporterID); err != nil {
+			return nil, err
+		}
+		// 模板图片先关联到模板，保存漏洞时再复制给漏洞，避免原图片被关联到第一个使用模板的漏洞
+		bindFindingTemplateImages(db, template, template.OwnerID)
+		applyFindingTemplate(db, req, template, &asset, reporterID)
+	}
+	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.VulnType) == "" {
+		return nil, errors.New("漏洞标题和漏洞类型不能为空")
+	}
+
 	// 验证项目是否存在且未过期
 	if req.ProjectID != 0 {
 		var project models.Project
@@ -118,47 +151,136 @@ func (s *VulnService) CreateVuln(req *VulnCreateRequest, reporterID uint) (*mode
 		return nil, errors.New("指定的分配人不存在")
 	}
 
-	// 解析修复截止时间
-	fixDeadline, err := time.Parse("2006-01-02", req.FixDeadline)
-	if err != nil {
-		return nil, errors.New("修复截止时间格式错误，请使用YYYY-MM-DD格式")
+	// 解析修复截止时间，未填写时由审核通过时匹配的SLA策略计算
+	var fixDeadline *time.Time
+	if req.FixDeadline != "" {
+		deadline, err := time.Parse("2006-01-02", req.FixDeadline)
+		if err != nil {
+			return nil, errors.New("修复截止时间格式错误，请使用YYYY-MM-DD格式")
+		}
+
+		// 验证截止时间不能是过去的日期
+		if deadline.Before(time.Now().Truncate(24 * time.Hour)) {
+			return nil, errors.New("修复截止时间不能是过去的日期")
+		}
+		fixDeadline = &deadline
 	}
 
-	// 验证截止时间不能是过去的日期
-	if fixDeadline.Before(time.Now().Truncate(24 * time.Hour)) {
-		return nil, errors.New("修复截止时间不能是过去的日期")
+	// 验证CVSS评分范围
+	if req.CVSSScore < 0 || req.CVSSScore > 10 {
+		return nil, errors.New("CVSS评分必须在0.0-10.0之间")
+	}
+
+	// 获取提交人角色，用于判断是否允许覆盖CVSS推导的严重程度
+	var reporter models.User
+	if err := db.Preload("Role").Where("id = ?", reporterID).First(&reporter).Error; err != nil {
+		return nil, errors.New("用户不存在")
 	}
 
 	vuln := models.Vulnerability{
 		Title:         req.Title,
 		VulnURL:       req.VulnURL,
-		Description:   req.Description,
+		Description:   NormalizeFileURLs(req.Description),
 		VulnType:      req.VulnType,
-		Severity:      req.Severity,
-		Status:        "pending",
+		Status:        initialVulnStatus(db, req.ProjectID), // 初始状态由项目使用的工作流决定
+		Source:        req.Source,
 		CVEID:         req.CVEID,
-		FixSuggestion: req.FixSuggestion,
+		CNNVDID:       req.CNNVDID,
+		CVSSScore:     req.CVSSScore,
+		CVSSVector:    req.CVSSVector,
+		POC:           NormalizeFileURLs(req.POC),
+		Solution:      NormalizeFileURLs(req.Solution),
+		References:    req.References,
+		FixSuggestion: NormalizeFileURLs(req.FixSuggestion),
 		ProjectID:     req.ProjectID,
 		AssetID:       req.AssetID,
 		ReporterID:    reporterID,
 		AssigneeID:    &req.AssigneeID,
-		FixDeadline:   &fixDeadline,
+		FixDeadline:   fixDeadline,
 		SubmittedAt:   time.Now().Truncate(time.Second), // 设置提交时间，精确到秒
 		Tags:          req.Tags,
 	}
 
+	// 根据本地CVE情报补充空白的描述、CWE、CVSS向量和参考链接，以及KEV和EPSS信息
+	enrichNotes := enrichVulnFromCVE(db, &vuln)
+
+	// 未指定漏洞分类时按漏洞类型和CWE编号自动归类
+	if req.CategoryID != nil && *req.CategoryID != 0 {
+		if err := checkVulnCategory(db, *req.CategoryID); err != nil {
+			return nil, err
+		}
+		vuln.CategoryID = req.CategoryID
+	} else {
+		vuln.CategoryID = matchVulnCategory(db, vuln.VulnType, vuln.CWEID)
+	}
+
+	// 未填写修复建议时使用匹配的知识库文章预填
+	var knowledge *models.KnowledgeArticle
+	if strings.TrimSpace(vuln.FixSuggestion) == "" {
+		knowledge = matchKnowledgeArticle(db, vuln.VulnType, vuln.CategoryID, vuln.CWEID)
+		if knowledge == nil {
+			return nil, errors.New("请填写修复建议")
+		}
+		vuln.FixSuggestion = knowledge.Remediation
+	}
+
+	// 根据CVSS向量或评分推导严重程度
+	derivedSeverity, err := s.applyCVSS(&vuln, &asset)
+	if err != nil {
+		return nil, err
+	}
+	overrideNote, err := s.resolveSeverity(&vuln, derivedSeverity, req.Severity, reporter.Role.Code)
+	if err != nil {
+		return nil, err
+	}
+	if vuln.Severity == "" {
+		return nil, errors.New("请填写严重程度或CVSS向量")
+	}
+
+	// 计算风险优先级评分
+	applyVulnRisk(&vuln, &asset)
+
+	// 计算漏洞指纹，用于重复和回归检测
+	vuln.Fingerprint = VulnFingerprint(vuln.AssetID, vuln.VulnURL, vuln.VulnType, vuln.CVEID)
+
+	// 工作流没有审核环节时，漏洞创建即开始SLA计时
+	slaNote := trackVulnSLA(db, &vuln, vuln.Status, vuln.SubmittedAt)
+
 	if err := db.Create(&vuln).Error; err != nil {
 		return nil, errors.New("创建漏洞失败")
 	}
 
+	// 关联内容中引用的图片，图片访问权限跟随漏洞；从知识库文章预填的图片复制为漏洞自己的文件
+	fileService := &FileService{}
+	fileService.BindVulnImages(&vuln, reporterID, reporter.Role.Code)
+
 	// 创建时间线记录
 	s.addTimeline(vuln.ID, reporterID, "created", "漏洞已创建")
+	if template != nil {
+		s.addTimeline(vuln.ID, reporterID, "template", fmt.Sprintf("使用漏洞模板《%s》创建", template.Name))
+		recordFindingTemplateUsage(db, template.ID, vuln.ID, reporterID)
+	}
+	if len(enrichNotes) > 0 {
+		s.addTimeline(vuln.ID, reporterID, "enriched", cveEnrichNote(enrichNotes))
+	}
+	if knowledge != nil {
+		s.addTimeline(vuln.ID, reporterID, "knowledge", fmt.Sprintf("修复建议来自知识库文章《%s》(版本%d)", knowledge.Title, knowledge.Version))
+	}
+	if overrideNote != "" {
+		s.addTimeline(vuln.ID, reporterID, "severity_override", overrideNote)
+	}
 
 	// 创建分配记录（AssigneeID现在是必填的）
 	s.addTimeline(vuln.ID, reporterID, "assigned", "漏洞已分配")
+	if slaNote != "" {
+		s.addTimeline(vuln.ID, reporterID, "sla", slaNote)
+	}
+
+	// 检测疑似重复漏洞和已完成漏洞的回归，结果记录到时间线
+	s.detectDuplicates(db, &vuln, reporterID)
 
 	// 重新查询漏洞信息(包含关联数据)
-	db.Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Rejector").Preload("Resubmitter").Where("id = ?", vuln.ID).First(&vuln)
+	db.Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Rejector").Preload("Resubmitter").Preload("Category").Where("id = ?", vuln.ID).First(&vuln)
 
 	// 更新项目统计信息
 	if req.ProjectID != 0 {
@@ -169,26 +291,16 @@ func (s *VulnService) CreateVuln(req *VulnCreateRequest, reporterID uint) (*mode
 		}
 	}
 
-	// 发送邮件通知给指派的研发工程师
-	go func() {
-		var assignee models.User
-		if err := db.First(&assignee, req.AssigneeID).Error; err == nil && assignee.Email != "" {
-				assigneeName := assignee.RealName
-				if assigneeName == "" {
-					assigneeName = assignee.Username
-				}
+	// 按通知偏好通知指派的研发工程师（站内通知、邮件、个人Webhook）
+	notificationService := &NotificationService{}
+	notificationService.NotifyVulnAssigned(&vuln, req.AssigneeID, vuln.Project.Name)
 
-				projectName := ""
-				if vuln.ProjectID != 0 {
-					projectName = vuln.Project.Name
-				}
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	webhookService.Emit(WebhookVulnCreated, NewWebhookVulnData(&vuln, reporterID))
+	webhookService.Emit(WebhookVulnAssigned, NewWebhookVulnData(&vuln, reporterID))
 
-				if err := SendVulnAssignedNotification(req.Title, projectName, assigneeName, assignee.Email, req.Severity); err != nil {
-					// 记录邮件发送失败的日志，但不影响漏洞创建
-					fmt.Printf("发送漏洞分派通知邮件失败: %v\n", err)
-				}
-			}
-		}()
+	fileService.SignVulnContent(reporterID, reporter.Role.Code, &vuln)
 
 	return &vuln, nil
 }
@@ -199,12 +311,36 @@ func (s *VulnService) GetVulnByID(vulnID uint, userID uint, userRole string) (*m
 
 	var vuln models.Vulnerability
 	if err := db.Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").
-		Preload("Fixer").Preload("Retester").Preload("Rejector").Preload("Resubmitter").Preload("Attachments").Preload("Comments.User").
+		Preload("Fixer").Preload("Retester").Preload("Rejector").Preload("Resubmitter").Preload("Category").Preload("Attachments").Preload("Comments.User").
 		Preload("Timeline.User").Where("id = ?", vulnID).First(&vuln).Error; err != nil {
 		return nil, errors.New("漏洞不存在")
 	}
 
 	// 基于角色的权限控制
+	if !s.canAccessVuln(db, &vuln, userID, userRole) {
+		return nil, errors.New("漏洞不存在")
+	}
+
+	// 漏洞关系图
+	vuln.Relations = s.buildVulnRelationGraph(db, vuln.ID, userID, userRole)
+
+	// 风险优先级评分明细
+	vuln.RiskBreakdown = vulnRiskBreakdown(&vuln)
+
+	// 匹配的知识库修复指南
+	vuln.Knowledge = vulnKnowledgeRef(db, &vuln)
+
+	// 为Markdown中的图片生成短时效签名链接
+	fileService := &FileService{}
+	fileService.SignVulnContent(userID, userRole, &vuln)
+
+	return &vuln, nil
+}
+
+// canAccessVuln 判断用户是否有权查看漏洞
+// 超级管理员可查看全部漏洞，安全工程师可查看自己提交的漏洞，研发工程师可查看分配给自己的漏洞，
+// 项目负责人和项目成员可查看项目内的漏洞
+func (s *VulnService) canAccessVuln(db *gorm.DB, vuln *models.Vulnerability, userID uint, userRole string) bool {
 	canAccess := false
 	switch userRole {
 	case "super_admin":
@@ -248,11 +384,7 @@ func (s *VulnService) GetVulnByID(vulnID uint, userID uint, userRole string) (*m
 		}
 	}
 
-	if !canAccess {
-		return nil, errors.New("漏洞不存在")
-	}
-
-	return &vuln, nil
+	return canAccess
 }
 
 // UpdateVuln 更新漏洞信息
@@ -266,8 +398,14 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 
 	// 保存原始状态，用于邮件通知
 	oldStatus := vuln.Status
+	oldCVEID := vuln.CVEID
+	oldVulnType := vuln.VulnType
+
+	if err := s.checkVulnEditPermission(db, &vuln, userID, userRole); err != nil {
+		return nil, err
+	}
 
-	// 基于角色的权限控制和字段更新
+	// 基于角色的字段更新
 	switch userRole {
 	case "super_admin":
 		// 超级管理员可以更新所有字段
@@ -283,30 +421,36 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 		if req.VulnType != "" {
 			vuln.VulnType = req.VulnType
 		}
-		if req.Severity != "" {
-			vuln.Severity = req.Severity
-		}
-		if req.Status != "" {
-			// 检查复测相关状态变更的权限
-			if req.Status == "retesting" || req.Status == "completed" {
-				// 复测状态变更需要特殊权限检查
-				canRetest := false
-
-				// 超级管理员可以进行任何复测操作
-				canRetest = true
-
-				if !canRetest {
-					return nil, errors.New("无权限进行复测操作")
-				}
-			}
-			vuln.Status = req.Status
+		if req.Source != "" {
+			vuln.Source = req.Source
 		}
 		if req.CVEID != "" {
 			vuln.CVEID = req.CVEID
 		}
+		if req.CNNVDID != "" {
+			vuln.CNNVDID = req.CNNVDID
+		}
+		if req.CVSSScore != nil {
+			if *req.CVSSScore < 0 || *req.CVSSScore > 10 {
+				return nil, errors.New("CVSS评分必须在0.0-10.0之间")
+			}
+			vuln.CVSSScore = *req.CVSSScore
+		}
+		if req.CVSSVector != nil {
+			vuln.CVSSVector = strings.TrimSpace(*req.CVSSVector)
+		}
 		if req.FixSuggestion != "" {
 			vuln.FixSuggestion = req.FixSuggestion
 		}
+		if req.POC != "" {
+			vuln.POC = req.POC
+		}
+		if req.Solution != "" {
+			vuln.Solution = req.Solution
+		}
+		if req.References != "" {
+			vuln.References = req.References
+		}
 		if req.AssetID != nil {
 			vuln.AssetID = *req.AssetID
 		}
@@ -320,20 +464,6 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 		}
 
 	case "security_engineer":
-		// 安全工程师只能更新自己创建的漏洞或项目内的漏洞
-		if vuln.ReporterID != userID {
-			// 检查是否是项目负责人
-			var project models.Project
-			if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err != nil {
-				// 如果不是项目负责人，检查是否是项目成员
-				var memberCount int64
-				db.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", vuln.ProjectID, userID).Count(&memberCount)
-				if memberCount == 0 {
-					return nil, errors.New("无权限编辑此漏洞")
-				}
-			}
-		}
-
 		// 安全工程师可以更新大部分字段
 		if req.Title != "" {
 			vuln.Title = req.Title
@@ -347,37 +477,36 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 		if req.VulnType != "" {
 			vuln.VulnType = req.VulnType
 		}
-		if req.Severity != "" {
-			vuln.Severity = req.Severity
-		}
-		if req.Status != "" {
-			// 检查复测相关状态变更的权限
-			if req.Status == "retesting" || req.Status == "completed" {
-				canRetest := false
-
-				// 安全工程师可以复测自己提交的漏洞
-				if vuln.ReporterID == userID {
-					canRetest = true
-				}
-
-				// 安全工程师作为项目负责人可以复测项目内的漏洞
-				var project models.Project
-				if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err == nil {
-					canRetest = true
-				}
-
-				if !canRetest {
-					return nil, errors.New("无权限进行复测操作")
-				}
-			}
-			vuln.Status = req.Status
+		if req.Source != "" {
+			vuln.Source = req.Source
 		}
 		if req.CVEID != "" {
 			vuln.CVEID = req.CVEID
 		}
+		if req.CNNVDID != "" {
+			vuln.CNNVDID = req.CNNVDID
+		}
+		if req.CVSSScore != nil {
+			if *req.CVSSScore < 0 || *req.CVSSScore > 10 {
+				return nil, errors.New("CVSS评分必须在0.0-10.0之间")
+			}
+			vuln.CVSSScore = *req.CVSSScore
+		}
+		if req.CVSSVector != nil {
+			vuln.CVSSVector = strings.TrimSpace(*req.CVSSVector)
+		}
 		if req.FixSuggestion != "" {
 			vuln.FixSuggestion = req.FixSuggestion
 		}
+		if req.POC != "" {
+			vuln.POC = req.POC
+		}
+		if req.Solution != "" {
+			vuln.Solution = req.Solution
+		}
+		if req.References != "" {
+			vuln.References = req.References
+		}
 		if req.AssetID != nil {
 			vuln.AssetID = *req.AssetID
 		}
@@ -390,102 +519,68 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 			vuln.Tags = req.Tags
 		}
 
-		// 处理重新提交逻辑
-		if req.ResubmittedAt != "" && req.ResubmittedBy != nil {
-			if resubmittedTime, err := time.Parse(time.RFC3339, req.ResubmittedAt); err == nil {
-				vuln.ResubmittedAt = &resubmittedTime
-				vuln.ResubmittedBy = req.ResubmittedBy
-
-				// 清除驳回相关信息
-				vuln.RejectedAt = nil
-				vuln.RejectedBy = nil
-				vuln.RejectReason = ""
+	case "dev_engineer":
+		// 研发工程师只能更新状态，状态变更在下方统一通过状态机处理
+	default:
+		return nil, errors.New("无权限编辑漏洞")
+	}
 
-				// 注意：不在这里记录时间线，状态变更的时间线记录会在后面统一处理
-			}
+	// 状态变更统一通过状态机校验流转、权限并设置时间戳（重新提交、驳回等信息由状态机处理）
+	statusChanged := req.Status != "" && req.Status != oldStatus
+	var statusChange *StatusChange
+	if statusChanged {
+		actor, err := loadVulnActor(db, userID)
+		if err != nil {
+			return nil, err
 		}
-
-	case "dev_engineer":
-		// 研发工程师只能更新分配给自己的漏洞的状态
-		if vuln.AssigneeID == nil || *vuln.AssigneeID != userID {
-			// 检查是否是项目负责人
-			var project models.Project
-			if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err != nil {
-				// 如果不是项目负责人，检查是否是项目成员
-				var memberCount int64
-				db.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", vuln.ProjectID, userID).Count(&memberCount)
-				if memberCount == 0 {
-					return nil, errors.New("无权限编辑此漏洞")
-				}
-			}
+		reason := req.RejectReason
+		if reason == "" {
+			reason = req.Comment
 		}
+		if statusChange, err = s.ChangeStatus(db, &vuln, req.Status, actor, reason); err != nil {
+			return nil, err
+		}
+	}
 
-		// 研发工程师只能更新状态
-		if req.Status != "" {
-			// 检查复测相关状态变更的权限
-			if req.Status == "retesting" || req.Status == "completed" {
-				canRetest := false
+	// CVE编号变更时根据本地CVE情报补充空白字段，并同步KEV和EPSS信息
+	var enrichNotes []string
+	if vuln.CVEID != oldCVEID {
+		enrichNotes = enrichVulnFromCVE(db, &vuln)
+	}
 
-				// 研发工程师作为项目负责人可以复测项目内的漏洞
-				var project models.Project
-				if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err == nil {
-					canRetest = true
-				}
+	// 重新计算CVSS评分并推导严重程度（资产变更会影响环境评分）
+	severityNote := ""
+	if userRole == "super_admin" || userRole == "security_engineer" {
+		var asset models.Asset
+		db.Where("id = ?", vuln.AssetID).First(&asset)
 
-				if !canRetest {
-					return nil, errors.New("无权限进行复测操作")
-				}
-			} else {
-				// 验证非复测状态是否有效
-				validStatuses := []string{"unfixed", "fixing", "fixed", "rejected"}
-				isValidStatus := false
-				for _, status := range validStatuses {
-					if req.Status == status {
-						isValidStatus = true
-						break
-					}
-				}
-				if !isValidStatus {
-					return nil, errors.New("研发工程师只能将状态设置为: 未修复、修复中、已修复、驳回")
-				}
-			}
+		derivedSeverity, err := s.applyCVSS(&vuln, &asset)
+		if err != nil {
+			return nil, err
+		}
+		severityNote, err = s.resolveSeverity(&vuln, derivedSeverity, req.Severity, userRole)
+		if err != nil {
+			return nil, err
+		}
 
-			// 记录状态变更时间
-			now := time.Now().Truncate(time.Second)
-
-			// 更新状态
-			vuln.Status = req.Status
-
-			// 根据状态变更设置相应的时间戳
-			if req.Status == "fixing" && oldStatus != "fixing" {
-				vuln.FixStartedAt = &now
-				vuln.FixedBy = &userID
-			} else if req.Status == "fixed" && oldStatus != "fixed" {
-				vuln.FixedAt = &now
-				vuln.FixedBy = &userID
-			} else if req.Status == "retesting" && oldStatus != "retesting" {
-				vuln.RetestAt = &now
-				vuln.RetesterID = &userID
-			} else if req.Status == "completed" && oldStatus != "completed" {
-				vuln.CompletedAt = &now
-				vuln.RetesterID = &userID
-			} else if req.Status == "rejected" && oldStatus != "rejected" {
-				vuln.RejectedAt = &now
-				vuln.RejectedBy = &userID
-				// 如果有驳回原因，保存到备注字段
-				if req.RejectReason != "" {
-					vuln.RejectReason = req.RejectReason
-				} else if req.Comment != "" {
-					vuln.RejectReason = req.Comment
-				}
+		// 严重程度、CVE情报或资产变化后重新计算风险优先级评分
+		applyVulnRisk(&vuln, &asset)
+
+		// 手动调整漏洞分类，或漏洞类型、CWE编号变化后重新自动归类
+		if req.CategoryID != nil && *req.CategoryID != 0 {
+			if err := checkVulnCategory(db, *req.CategoryID); err != nil {
+				return nil, err
+			}
+			vuln.CategoryID = req.CategoryID
+		} else if req.CategoryID != nil || vuln.CategoryID == nil || vuln.VulnType != oldVulnType || len(enrichNotes) > 0 {
+			if categoryID := matchVulnCategory(db, vuln.VulnType, vuln.CWEID); categoryID != nil || req.CategoryID != nil {
+				vuln.CategoryID = categoryID
 			}
 		}
-
-	default:
-		return nil, errors.New("无权限编辑漏洞")
 	}
 
 	// 处理分配人变更（仅管理员和安全工程师可以修改）
+	reassigned := false
 	if (userRole == "super_admin" || userRole == "security_engineer") && req.AssigneeID != nil && (vuln.AssigneeID == nil || *vuln.AssigneeID != *req.AssigneeID) {
 		if *req.AssigneeID != 0 {
 			// 验证分配人是否存在
@@ -494,6 +589,7 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 				return nil, errors.New("指定的分配人不存在")
 			}
 			vuln.AssigneeID = req.AssigneeID
+			reassigned = true
 			s.addTimeline(vulnID, userID, "assigned", "漏洞重新分配")
 		} else {
 			vuln.AssigneeID = nil
@@ -501,60 +597,75 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 		}
 	}
 
+	// 去除图片链接中的签名参数，只保存稳定的文件地址
+	vuln.Description = NormalizeFileURLs(vuln.Description)
+	vuln.POC = NormalizeFileURLs(vuln.POC)
+	vuln.Solution = NormalizeFileURLs(vuln.Solution)
+	vuln.FixSuggestion = NormalizeFileURLs(vuln.FixSuggestion)
+
+	// 资产、地址、类型或CVE编号变更后重新计算指纹
+	vuln.Fingerprint = VulnFingerprint(vuln.AssetID, vuln.VulnURL, vuln.VulnType, vuln.CVEID)
+
 	if err := db.Save(&vuln).Error; err != nil {
 		return nil, errors.New("更新漏洞失败")
 	}
 
-	// 简化的时间线记录逻辑：只要状态发生变更就记录
-	if req.Status != "" && oldStatus != req.Status {
-		// 状态标签映射
-		statusLabels := map[string]string{
-			"pending":    "待处理",
-			"unfixed":    "未修复",
-			"fixing":     "修复中",
-			"fixed":      "已修复",
-			"retesting":  "复测中",
-			"completed":  "已完成",
-			"rejected":   "已驳回",
-			"ignored":    "已忽略",
-		}
-
-		oldLabel := statusLabels[oldStatus]
-		newLabel := statusLabels[req.Status]
-		if oldLabel == "" {
-			oldLabel = oldStatus
-		}
-		if newLabel == "" {
-			newLabel = req.Status
-		}
+	// 关联内容中新引用的图片，图片访问权限跟随漏洞
+	fileService := &FileService{}
+	fileService.BindVulnImages(&vuln, userID, userRole)
 
-		// 构建描述信息
-		description := fmt.Sprintf("状态从 %s 变更为 %s", oldLabel, newLabel)
+	if len(enrichNotes) > 0 {
+		s.addTimeline(vulnID, userID, "enriched", cveEnrichNote(enrichNotes))
+	}
+	if severityNote != "" {
+		s.addTimeline(vulnID, userID, "severity_override", severityNote)
+	}
 
-		// 如果是驳回操作，添加驳回原因
-		if req.Status == "rejected" && req.RejectReason != "" {
-			description += fmt.Sprintf("，驳回原因：%s", req.RejectReason)
+	// 记录状态变更时间线
+	if statusChanged {
+		note := ""
+		switch {
+		case oldStatus == VulnStatusRejected && req.Status == VulnStatusUnfixed:
+			note = "重新提交"
+		case req.Status == VulnStatusRejected && vuln.RejectReason != "":
+			note = fmt.Sprintf("驳回原因：%s", vuln.RejectReason)
 		}
+		s.recordStatusEffects(db, statusChange)
+		s.recordStatusChange(vulnID, userID, oldStatus, req.Status, note)
 
-		// 如果是重新提交操作，添加重新提交标识
-		if req.ResubmittedAt != "" && req.ResubmittedBy != nil {
-			description = fmt.Sprintf("重新提交：%s", description)
+		// 关闭父漏洞时按需将状态同步到子漏洞
+		if req.CascadeChildren && isVulnClosedStatus(vuln.Status) {
+			if actor, err := loadVulnActor(db, userID); err == nil {
+				s.cascadeToChildren(db, &vuln, actor, 0)
+			}
 		}
-
-		// 记录状态变更时间线
-		s.addTimeline(vulnID, userID, "status_changed", description)
 	}
 
 	// 重新查询漏洞信息
-	db.Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Rejector").Preload("Resubmitter").Where("id = ?", vuln.ID).First(&vuln)
+	db.Preload("Asset").Preload("Project").Preload("Project.Owner").Preload("Reporter").Preload("Assignee").Preload("Rejector").Preload("Resubmitter").Preload("Category").Where("id = ?", vuln.ID).First(&vuln)
+
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	if reassigned {
+		webhookService.Emit(WebhookVulnAssigned, NewWebhookVulnData(&vuln, userID))
+	}
+	if statusChanged {
+		data := NewWebhookVulnData(&vuln, userID)
+		data.OldStatus = oldStatus
+		switch req.Status {
+		case "fixed":
+			webhookService.Emit(WebhookVulnFixed, data)
+		case "rejected":
+			data.Comment = vuln.RejectReason
+			webhookService.Emit(WebhookVulnRejected, data)
+		}
+	}
 
-	// 发送状态变更邮件通知
-	if req.Status != "" && oldStatus != req.Status {
+	// 按通知偏好通知下一处理人（站内通知、邮件、个人Webhook）
+	if statusChanged {
 		go func() {
 			// 确定下一个处理人
 			var nextUser *models.User
-			var nextUserEmail string
-			var nextUserName string
 
 			switch req.Status {
 			case "fixed":
@@ -574,22 +685,9 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 				}
 			}
 
-			if nextUser != nil && nextUser.Email != "" {
-				nextUserEmail = nextUser.Email
-				nextUserName = nextUser.RealName
-				if nextUserName == "" {
-					nextUserName = nextUser.Username
-				}
-
-				projectName := ""
-				if vuln.ProjectID != 0 {
-					projectName = vuln.Project.Name
-				}
-
-				if err := SendVulnStatusChangedNotification(vuln.Title, projectName, oldStatus, req.Status, nextUserName, nextUserEmail); err != nil {
-					// 记录邮件发送失败的日志，但不影响漏洞更新
-					fmt.Printf("发送漏洞状态变更通知邮件失败: %v\n", err)
-				}
+			if nextUser != nil && nextUser.ID != 0 {
+				notificationService := &NotificationService{}
+				notificationService.NotifyVulnStatusChanged(&vuln, oldStatus, req.Status, nextUser.ID, vuln.Project.Name)
 			}
 		}()
 	}
@@ -603,9 +701,44 @@ func (s *VulnService) UpdateVuln(vulnID uint, req *VulnUpdateRequest, userID uin
 		}
 	}
 
+	fileService.SignVulnContent(userID, userRole, &vuln)
+
 	return &vuln, nil
 }
 
+// checkVulnEditPermission 校验用户是否可以编辑漏洞
+// 超级管理员可编辑全部漏洞；安全工程师可编辑自己提交的漏洞，研发工程师可编辑分配给自己的漏洞（只能变更状态），
+// 项目负责人和项目成员可编辑项目内的漏洞
+func (s *VulnService) checkVulnEditPermission(db *gorm.DB, vuln *models.Vulnerability, userID uint, userRole string) error {
+	switch userRole {
+	case "super_admin":
+		return nil
+	case "security_engineer":
+		if vuln.ReporterID == userID {
+			return nil
+		}
+	case "dev_engineer":
+		if vuln.AssigneeID != nil && *vuln.AssigneeID == userID {
+			return nil
+		}
+	default:
+		return errors.New("无权限编辑漏洞")
+	}
+
+	// 检查是否是项目负责人
+	var project models.Project
+	if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err == nil {
+		return nil
+	}
+	// 如果不是项目负责人，检查是否是项目成员
+	var memberCount int64
+	db.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", vuln.ProjectID, userID).Count(&memberCount)
+	if memberCount == 0 {
+		return errors.New("无权限编辑此漏洞")
+	}
+	return nil
+}
+
 // DeleteVuln 删除漏洞(软删除)
 func (s *VulnService) DeleteVuln(vulnID uint, userID uint) error {
 	db := Init.GetDB()
@@ -637,19 +770,9 @@ func (s *VulnService) DeleteVuln(vulnID uint, userID uint) error {
 	return nil
 }
 
-// GetVulnList 获取漏洞列表
-func (s *VulnService) GetVulnList(req *VulnListRequest) (*VulnListResponse, error) {
-	db := Init.GetDB()
-
-	// 设置默认值
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 {
-		req.PageSize = 10
-	}
-
-	query := db.Model(&models.Vulnerability{}).Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Rejector").Preload("Resubmitter")
+// vulnListQuery 按当前用户的可见范围和列表筛选条件构建漏洞查询，用户无权查看时返回false
+func (s *VulnService) vulnListQuery(db *gorm.DB, req *VulnListRequest) (*gorm.DB, bool) {
+	query := db.Model(&models.Vulnerability{})
 
 	// 基于角色的权限控制
 	// 如果是查询特定项目的漏洞，需要先检查用户是否有项目权限
@@ -675,13 +798,7 @@ func (s *VulnService) GetVulnList(req *VulnListRequest) (*VulnListResponse, erro
 
 			if !hasAccess {
 				// 用户既不是项目负责人也不是项目成员，不能查看项目漏洞
-				return &VulnListResponse{
-					Vulns:           []models.Vulnerability{},
-					Vulnerabilities: []models.Vulnerability{},
-					Total:           0,
-					Page:            req.Page,
-					PageSize:        req.PageSize,
-				}, nil
+				return nil, false
 			}
 		}
 		// 如果用户是项目负责人、项目成员或管理员，可以查看项目内所有漏洞，不添加额外的用户限制
@@ -698,21 +815,15 @@ func (s *VulnService) GetVulnList(req *VulnListRequest) (*VulnListResponse, erro
 			// 超级管理员能看到所有漏洞，不添加额外限制
 		default:
 			// 其他角色不能查看漏洞列表
-			return &VulnListResponse{
-				Vulns:           []models.Vulnerability{},
-				Vulnerabilities: []models.Vulnerability{},
-				Total:           0,
-				Page:            req.Page,
-				PageSize:        req.PageSize,
-			}, nil
+			return nil, false
 		}
 	}
 
 	// 添加过滤条件
 	if req.Keyword != "" {
-		// 支持关键词搜索，在标题、描述、CVE ID中搜索
-		query = query.Where("title LIKE ? OR description LIKE ? OR cve_id LIKE ?",
-			"%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+		// 支持关键词搜索，在标题、描述、CVE ID、CNNVD ID中搜索
+		query = query.Where("title LIKE ? OR description LIKE ? OR cve_id LIKE ? OR cnnvd_id LIKE ?",
+			"%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
 	}
 	if req.Title != "" {
 		query = query.Where("title LIKE ?", "%"+req.Title+"%")
@@ -741,6 +852,50 @@ func (s *VulnService) GetVulnList(req *VulnListRequest) (*VulnListResponse, erro
 	if req.AssigneeID != nil {
 		query = query.Where("assignee_id = ?", *req.AssigneeID)
 	}
+	if req.CategoryID != nil {
+		query = query.Where("category_id IN (?)", vulnCategoryDescendantIDs(db, *req.CategoryID))
+	}
+	if req.RiskLevel != "" {
+		query = query.Where("risk_level = ?", req.RiskLevel)
+	}
+	if req.MinRiskScore != nil {
+		query = query.Where("risk_score >= ?", *req.MinRiskScore)
+	}
+
+	return query, true
+}
+
+// vulnListOrder 漏洞列表排序，默认按创建时间倒序，按风险优先级排序时评分相同的漏洞按创建时间排序
+func vulnListOrder(req *VulnListRequest) string {
+	if req.SortBy == "risk_score" {
+		return "risk_score DESC, created_at DESC"
+	}
+	return "created_at DESC"
+}
+
+// GetVulnList 获取漏洞列表
+func (s *VulnService) GetVulnList(req *VulnListRequest) (*VulnListResponse, error) {
+	db := Init.GetDB()
+
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	query, ok := s.vulnListQuery(db, req)
+	if !ok {
+		return &VulnListResponse{
+			Vulns:           []models.Vulnerability{},
+			Vulnerabilities: []models.Vulnerability{},
+			Total:           0,
+			Page:            req.Page,
+			PageSize:        req.PageSize,
+		}, nil
+	}
+	query = query.Preload("Asset").Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Rejector").Preload("Resubmitter").Preload("Category")
 
 	// 获取总数
 	var total int64
@@ -749,10 +904,18 @@ func (s *VulnService) GetVulnList(req *VulnListRequest) (*VulnListResponse, erro
 	// 分页查询
 	var vulns []models.Vulnerability
 	offset := (req.Page - 1) * req.PageSize
-	if err := query.Offset(offset).Limit(req.PageSize).Order("created_at DESC").Find(&vulns).Error; err != nil {
+	if err := query.Offset(offset).Limit(req.PageSize).Order(vulnListOrder(req)).Find(&vulns).Error; err != nil {
 		return nil, errors.New("查询漏洞列表失败")
 	}
 
+	// 为Markdown中的图片生成短时效签名链接
+	vulnPtrs := make([]*models.Vulnerability, len(vulns))
+	for i := range vulns {
+		vulnPtrs[i] = &vulns[i]
+	}
+	fileService := &FileService{}
+	fileService.SignVulnContent(req.CurrentUserID, req.CurrentUserRole, vulnPtrs...)
+
 	// 计算总页数
 	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
 
@@ -776,60 +939,76 @@ func (s *VulnService) AuditVuln(vulnID uint, req *AuditRequest, userID uint) err
 		return errors.New("漏洞不存在")
 	}
 
-	if vuln.Status != "pending" {
-		return errors.New("只能审核待确认的漏洞")
+	if req.Status != VulnStatusConfirmed && req.Status != VulnStatusRejected {
+		return errors.New("无效的审核状态")
 	}
 
-	if req.Status != "confirmed" && req.Status != "rejected" {
-		return errors.New("无效的审核状态")
+	// 获取审核人及其权限，用于校验状态流转和判断是否允许覆盖CVSS推导的严重程度
+	auditor, err := loadVulnActor(db, userID)
+	if err != nil {
+		return err
 	}
 
-	oldStatus := vuln.Status
-	vuln.Status = req.Status
+	// 更新CVSS信息，向量优先于直接填写的评分。先于状态变更处理，审核通过时按审核后的严重程度匹配SLA策略
+	if req.CvssScore < 0 || req.CvssScore > 10 {
+		return errors.New("CVSS评分必须在0.0-10.0之间")
+	}
+	if req.CvssScore > 0 {
+		vuln.CVSSScore = req.CvssScore
+	}
+	if req.CvssVector != "" {
+		vuln.CVSSVector = strings.TrimSpace(req.CvssVector)
+	}
 
-	// 更新其他审核信息
-	if req.Severity != "" {
-		vuln.Severity = req.Severity
+	derivedSeverity, err := s.applyCVSS(&vuln, &vuln.Asset)
+	if err != nil {
+		return err
+	}
+	severityNote, err := s.resolveSeverity(&vuln, derivedSeverity, req.Severity, auditor.Role.Code)
+	if err != nil {
+		return err
 	}
+	applyVulnRisk(&vuln, &vuln.Asset)
 
 	if req.AssigneeID != nil {
 		vuln.AssigneeID = req.AssigneeID
 	}
 
-	if err := db.Save(&vuln).Error; err != nil {
-		return errors.New("审核漏洞失败")
+	oldStatus := vuln.Status
+	change, err := s.ChangeStatus(db, &vuln, req.Status, auditor, req.Comment)
+	if err != nil {
+		return err
 	}
 
-	// 统一的状态变更时间线记录
-	statusLabels := map[string]string{
-		"pending":    "待处理",
-		"confirmed":  "已确认",
-		"rejected":   "已拒绝",
-		"unfixed":    "未修复",
-		"fixing":     "修复中",
-		"fixed":      "已修复",
-		"retesting":  "复测中",
-		"completed":  "已完成",
-		"ignored":    "已忽略",
+	if err := db.Save(&vuln).Error; err != nil {
+		return errors.New("审核漏洞失败")
 	}
+	s.recordStatusEffects(db, change)
 
-	oldLabel := statusLabels[oldStatus]
-	newLabel := statusLabels[req.Status]
-	if oldLabel == "" {
-		oldLabel = oldStatus
-	}
-	if newLabel == "" {
-		newLabel = req.Status
+	if severityNote != "" {
+		s.addTimeline(vulnID, userID, "severity_override", severityNote)
 	}
 
-	description := fmt.Sprintf("状态从 %s 变更为 %s", oldLabel, newLabel)
-	s.addTimeline(vulnID, userID, "status_changed", description)
+	s.recordStatusChange(vulnID, userID, oldStatus, req.Status, "")
 
 	// 发送审核结果邮件通知
 	if nextUserID := s.getNextUserForStatus(&vuln, req.Status, req.AssigneeID); nextUserID != nil {
 		s.sendVulnNotification(&vuln, oldStatus, req.Status, *nextUserID)
 	}
 
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	data := NewWebhookVulnData(&vuln, userID)
+	data.OldStatus = oldStatus
+	webhookService.Emit(WebhookVulnAudited, data)
+	if req.Status == "rejected" {
+		data.Comment = req.Comment
+		webhookService.Emit(WebhookVulnRejected, data)
+	}
+	if req.AssigneeID != nil {
+		webhookService.Emit(WebhookVulnAssigned, NewWebhookVulnData(&vuln, userID))
+	}
+
 	return nil
 }
 
@@ -842,47 +1021,35 @@ func (s *VulnService) FixVuln(vulnID uint, userID uint) error {
 		return errors.New("漏洞不存在")
 	}
 
-	if vuln.Status != "confirmed" && vuln.Status != "fixing" {
-		return errors.New("只能修复已确认的漏洞")
+	actor, err := loadVulnActor(db, userID)
+	if err != nil {
+		return err
 	}
 
 	oldStatus := vuln.Status
-	vuln.Status = "fixed"
-	vuln.FixedBy = &userID
-	now := time.Now().Truncate(time.Second)
-	vuln.FixedAt = &now
+	change, err := s.ChangeStatus(db, &vuln, VulnStatusFixed, actor, "")
+	if err != nil {
+		return err
+	}
 
 	if err := db.Save(&vuln).Error; err != nil {
 		return errors.New("标记修复失败")
 	}
+	s.recordStatusEffects(db, change)
 
-	// 统一的状态变更时间线记录
-	statusLabels := map[string]string{
-		"pending":    "待处理",
-		"confirmed":  "已确认",
-		"unfixed":    "未修复",
-		"fixing":     "修复中",
-		"fixed":      "已修复",
-		"retesting":  "复测中",
-		"completed":  "已完成",
-		"rejected":   "已驳回",
-		"ignored":    "已忽略",
-	}
-
-	oldLabel := statusLabels[oldStatus]
-	newLabel := statusLabels["fixed"]
-	if oldLabel == "" {
-		oldLabel = oldStatus
-	}
-
-	description := fmt.Sprintf("状态从 %s 变更为 %s", oldLabel, newLabel)
-	s.addTimeline(vulnID, userID, "status_changed", description)
+	s.recordStatusChange(vulnID, userID, oldStatus, VulnStatusFixed, "")
 
 	// 发送修复完成邮件通知给安全工程师进行复测
-	if nextUserID := s.getNextUserForStatus(&vuln, "fixed", nil); nextUserID != nil {
-		s.sendVulnNotification(&vuln, oldStatus, "fixed", *nextUserID)
+	if nextUserID := s.getNextUserForStatus(&vuln, VulnStatusFixed, nil); nextUserID != nil {
+		s.sendVulnNotification(&vuln, oldStatus, VulnStatusFixed, *nextUserID)
 	}
 
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	data := NewWebhookVulnData(&vuln, userID)
+	data.OldStatus = oldStatus
+	webhookService.Emit(WebhookVulnFixed, data)
+
 	return nil
 }
 
@@ -895,101 +1062,49 @@ func (s *VulnService) RetestVuln(vulnID uint, result string, userID uint) error
 		return errors.New("漏洞不存在")
 	}
 
-	// 权限控制：检查用户是否有复测权限
-	var user models.User
-	if err := db.Preload("Role").Where("id = ?", userID).First(&user).Error; err != nil {
-		return errors.New("用户不存在")
-	}
-
-	userRole := user.Role.Code
-	canRetest := false
-
-	switch userRole {
-	case "super_admin":
-		// 超级管理员可以复测任何漏洞
-		canRetest = true
-	case "security_engineer":
-		// 安全工程师可以复测自己提交的漏洞
-		if vuln.ReporterID == userID {
-			canRetest = true
-		}
-		// 安全工程师作为项目负责人可以复测项目内的漏洞
-		var project models.Project
-		if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err == nil {
-			canRetest = true
-		}
-	case "dev_engineer":
-		// 研发工程师作为项目负责人可以复测项目内的漏洞
-		var project models.Project
-		if err := db.Where("id = ? AND owner_id = ?", vuln.ProjectID, userID).First(&project).Error; err == nil {
-			canRetest = true
-		}
-	}
-
-	if !canRetest {
-		return errors.New("无权限复测此漏洞")
-	}
-
-	if vuln.Status != "fixed" {
-		return errors.New("只能复测已修复的漏洞")
-	}
-
 	if result != "passed" && result != "failed" {
 		return errors.New("无效的复测结果")
 	}
 
-	oldStatus := vuln.Status
-	newStatus := ""
-	if result == "passed" {
-		vuln.Status = "closed"
-		newStatus = "closed"
-	} else {
-		vuln.Status = "reopened"
-		newStatus = "reopened"
+	// 复测权限（漏洞提交人或项目负责人）由状态机校验
+	actor, err := loadVulnActor(db, userID)
+	if err != nil {
+		return err
 	}
 
-	if err := db.Save(&vuln).Error; err != nil {
-		return errors.New("复测失败")
+	oldStatus := vuln.Status
+	newStatus := VulnStatusCompleted
+	if result == "failed" {
+		newStatus = VulnStatusUnfixed
 	}
-
-	// 统一的状态变更时间线记录
-	statusLabels := map[string]string{
-		"pending":    "待处理",
-		"confirmed":  "已确认",
-		"unfixed":    "未修复",
-		"fixing":     "修复中",
-		"fixed":      "已修复",
-		"retesting":  "复测中",
-		"completed":  "已完成",
-		"closed":     "已关闭",
-		"reopened":   "重新开放",
-		"rejected":   "已驳回",
-		"ignored":    "已忽略",
+	change, err := s.ChangeStatus(db, &vuln, newStatus, actor, "")
+	if err != nil {
+		return err
 	}
 
-	oldLabel := statusLabels[oldStatus]
-	newLabel := statusLabels[newStatus]
-	if oldLabel == "" {
-		oldLabel = oldStatus
-	}
-	if newLabel == "" {
-		newLabel = newStatus
+	if err := db.Save(&vuln).Error; err != nil {
+		return errors.New("复测失败")
 	}
+	s.recordStatusEffects(db, change)
 
-	description := fmt.Sprintf("状态从 %s 变更为 %s", oldLabel, newLabel)
-	if result == "passed" {
-		description += "，复测通过"
-	} else {
-		description += "，复测失败"
+	note := "复测通过"
+	if result == "failed" {
+		note = "复测失败"
 	}
-
-	s.addTimeline(vulnID, userID, "status_changed", description)
+	s.recordStatusChange(vulnID, userID, oldStatus, newStatus, note)
 
 	// 发送复测结果邮件通知
 	if nextUserID := s.getNextUserForStatus(&vuln, newStatus, nil); nextUserID != nil {
 		s.sendVulnNotification(&vuln, oldStatus, newStatus, *nextUserID)
 	}
 
+	// 推送出站Webhook
+	webhookService := &WebhookService{}
+	data := NewWebhookVulnData(&vuln, userID)
+	data.OldStatus = oldStatus
+	data.Result = result
+	webhookService.Emit(WebhookVulnRetested, data)
+
 	return nil
 }
 
@@ -1016,6 +1131,22 @@ func (s *VulnService) AddComment(vulnID uint, content string, userID uint) (*mod
 	// 重新查询评论信息(包含用户信息)
 	db.Preload("User").Where("id = ?", comment.ID).First(&comment)
 
+	// 推送给正在查看该漏洞的用户
+	publishRealtime(VulnTopic(vulnID), RealtimeComment, vulnID, comment)
+
+	// 按通知偏好通知漏洞参与人
+	go func() {
+		db.Preload("Project").Where("id = ?", vuln.ID).First(&vuln)
+		notificationService := &NotificationService{}
+		notificationService.NotifyVulnComment(&vuln, &comment, vuln.Project.Name)
+
+		// 推送出站Webhook
+		webhookService := &WebhookService{}
+		data := NewWebhookVulnData(&vuln, userID)
+		data.Comment = comment.Content
+		webhookService.Emit(WebhookVulnCommented, data)
+	}()
+
 	return &comment, nil
 }
 
@@ -1033,7 +1164,7 @@ func (s *VulnService) GetVulnStats() (map[string]interface{}, error) {
 	db.Model(&models.Vulnerability{}).Where("status = ?", "pending").Count(&pendingVulns)
 	db.Model(&models.Vulnerability{}).Where("status = ?", "confirmed").Count(&confirmedVulns)
 	db.Model(&models.Vulnerability{}).Where("status = ?", "fixed").Count(&fixedVulns)
-	db.Model(&models.Vulnerability{}).Where("status = ?", "closed").Count(&closedVulns)
+	db.Model(&models.Vulnerability{}).Where("status IN (?)", []string{VulnStatusCompleted, "closed"}).Count(&closedVulns)
 
 	// 按严重程度统计
 	var severityStats []struct {
@@ -1077,6 +1208,103 @@ func (s *VulnService) GetVulnTimeline(vulnID uint) ([]models.VulnTimeline, error
 	return timeline, nil
 }
 
+// cvssEnvironmentDefaults 根据资产重要性和所处环境推导CVSS环境指标默认值
+// 重要性决定CR/IR/AR的基础级别，测试、开发环境在此基础上降低一级
+func cvssEnvironmentDefaults(asset *models.Asset) map[string]string {
+	level := "M"
+	switch asset.Importance {
+	case "extremely_high", "high":
+		level = "H"
+	case "low":
+		level = "L"
+	}
+
+	switch asset.Environment {
+	case "testing", "development":
+		if level == "H" {
+			level = "M"
+		} else {
+			level = "L"
+		}
+	}
+
+	return map[string]string{
+		"CR": level,
+		"IR": level,
+		"AR": level,
+	}
+}
+
+// applyCVSS 根据漏洞的CVSS向量计算各项评分
+// 返回由评分推导的严重程度，既没有向量也没有评分时返回空字符串
+func (s *VulnService) applyCVSS(vuln *models.Vulnerability, asset *models.Asset) (string, error) {
+	if vuln.CVSSVector == "" {
+		vuln.CVSSVersion = ""
+		vuln.CVSSBaseScore = 0
+		vuln.CVSSTemporalScore = 0
+		vuln.CVSSEnvironmentalScore = 0
+		if vuln.CVSSScore > 0 {
+			return utils.CVSSSeverity(vuln.CVSSScore), nil
+		}
+		return "", nil
+	}
+
+	result, err := utils.CalculateCVSS(vuln.CVSSVector, cvssEnvironmentDefaults(asset))
+	if err != nil {
+		return "", err
+	}
+
+	vuln.CVSSVector = result.Vector
+	vuln.CVSSVersion = result.Version
+	vuln.CVSSBaseScore = result.BaseScore
+	vuln.CVSSTemporalScore = result.TemporalScore
+	vuln.CVSSEnvironmentalScore = result.EnvironmentalScore
+	vuln.CVSSScore = result.Score
+	return result.Severity, nil
+}
+
+// resolveSeverity 确定漏洞最终的严重程度
+// 有CVSS评分时以评分推导结果为准，仅超级管理员可以手动覆盖，其他用户提交不同的严重程度时返回错误；
+// 返回需要记录到时间线的覆盖说明
+func (s *VulnService) resolveSeverity(vuln *models.Vulnerability, derived, requested, userRole string) (string, error) {
+	if derived == "" {
+		if requested != "" {
+			vuln.Severity = requested
+		}
+		vuln.SeverityOverridden = false
+		return "", nil
+	}
+
+	if requested == "" || requested == derived {
+		// 未指定严重程度时保留已有的管理员覆盖
+		if requested == "" && vuln.SeverityOverridden {
+			return "", nil
+		}
+		vuln.Severity = derived
+		vuln.SeverityOverridden = false
+		return "", nil
+	}
+
+	// 提交的严重程度与已有的覆盖结果一致，无需重复记录
+	if vuln.SeverityOverridden && requested == vuln.Severity {
+		return "", nil
+	}
+
+	if userRole != "super_admin" {
+		// 编辑时原样提交了漏洞当前的严重程度，视为未要求修改，按新的评分推导
+		if vuln.Severity != "" && requested == vuln.Severity {
+			vuln.Severity = derived
+			vuln.SeverityOverridden = false
+			return "", nil
+		}
+		return "", fmt.Errorf("严重程度由CVSS评分确定为%s，只有管理员可以覆盖", severityLabel(derived))
+	}
+
+	vuln.Severity = requested
+	vuln.SeverityOverridden = true
+	return fmt.Sprintf("管理员将严重程度由 %s（CVSS %.1f）调整为 %s", derived, vuln.CVSSScore, requested), nil
+}
+
 // addTimeline 添加时间线记录
 func (s *VulnService) addTimeline(vulnID uint, userID uint, action string, description string) {
 	db := Init.GetDB()
@@ -1091,7 +1319,12 @@ func (s *VulnService) addTimeline(vulnID uint, userID uint, action string, descr
 	if err := db.Create(&timeline).Error; err != nil {
 		// 记录错误，但不影响主要业务流程
 		fmt.Printf("Failed to create timeline record: %v\n", err)
+		return
 	}
+
+	// 推送给正在查看该漏洞的用户
+	db.Preload("User").Where("id = ?", timeline.ID).First(&timeline)
+	publishRealtime(VulnTopic(vulnID), RealtimeTimeline, vulnID, timeline)
 }
 
 // sendVulnNotification 发送漏洞状态变更通知的统一方法
@@ -1099,13 +1332,8 @@ func (s *VulnService) sendVulnNotification(vuln *models.Vulnerability, oldStatus
 	db := Init.GetDB()
 
 	var nextUser models.User
-	if err := db.First(&nextUser, nextUserID).Error; err != nil || nextUser.Email == "" {
-		return // 用户不存在或没有邮箱，不发送通知
-	}
-
-	nextUserName := nextUser.RealName
-	if nextUserName == "" {
-		nextUserName = nextUser.Username
+	if err := db.First(&nextUser, nextUserID).Error; err != nil {
+		return // 用户不存在，不发送通知
 	}
 
 	projectName := ""
@@ -1113,12 +1341,9 @@ func (s *VulnService) sendVulnNotification(vuln *models.Vulnerability, oldStatus
 		projectName = vuln.Project.Name
 	}
 
-	go func() {
-		if err := SendVulnStatusChangedNotification(vuln.Title, projectName, oldStatus, newStatus, nextUserName, nextUser.Email); err != nil {
-			// 记录邮件发送失败的日志，但不影响业务操作
-			fmt.Printf("发送漏洞状态变更通知邮件失败: %v\n", err)
-		}
-	}()
+	// 按通知偏好发送站内通知、邮件和个人Webhook
+	notificationService := &NotificationService{}
+	notificationService.NotifyVulnStatusChanged(vuln, oldStatus, newStatus, nextUserID, projectName)
 }
 
 // getNextUserForStatus 根据状态变更确定下一个处理人
@@ -1142,7 +1367,7 @@ func (s *VulnService) getNextUserForStatus(vuln *models.Vulnerability, newStatus
 		if vuln.ReporterID != 0 {
 			return &vuln.ReporterID
 		}
-	case "closed":
+	case VulnStatusCompleted:
 		// 复测通过，通知项目负责人
 		if vuln.ProjectID != 0 && vuln.Project.OwnerID != 0 {
 			return &vuln.Project.OwnerID
@@ -1151,7 +1376,7 @@ func (s *VulnService) getNextUserForStatus(vuln *models.Vulnerability, newStatus
 		if vuln.ReporterID != 0 {
 			return &vuln.ReporterID
 		}
-	case "reopened":
+	case VulnStatusUnfixed:
 		// 复测失败，通知分配的研发工程师重新修复
 		if vuln.AssigneeID != nil {
 			return vuln.AssigneeID
@@ -1180,7 +1405,7 @@ func (s *VulnService) GetVulnsNearDeadline(daysLeft int) ([]models.Vulnerability
 		Preload("Reporter").
 		Where("fix_deadline >= ? AND fix_deadline < ?", startOfDay, endOfDay).
 		Where("status IN (?)", []string{"unfixed", "fixing"}). // 只查询未修复和修复中的漏洞
-		Where("assignee_id IS NOT NULL"). // 必须有指派人
+		Where("assignee_id IS NOT NULL").                      // 必须有指派人
 		Find(&vulns).Error
 
 	if err != nil {
@@ -1194,6 +1419,7 @@ func (s *VulnService) GetVulnsNearDeadline(daysLeft int) ([]models.Vulnerability
 func (s *VulnService) SendDeadlineReminders() error {
 	db := Init.GetDB()
 	today := time.Now().Truncate(24 * time.Hour)
+	notificationService := &NotificationService{}
 
 	// 分别查询1天、2天、3天后到期的漏洞
 	for _, days := range []int{1, 2, 3} {
@@ -1204,11 +1430,6 @@ func (s *VulnService) SendDeadlineReminders() error {
 		}
 
 		for _, vuln := range vulns {
-			// 检查是否有指派人和邮箱
-			if vuln.Assignee.Email == "" {
-				continue
-			}
-
 			// 检查今天是否已经发送过相同天数的提醒
 			var existingReminder models.VulnDeadlineReminder
 			err := db.Where("vuln_id = ? AND days_left = ? AND reminder_date = ?",
@@ -1219,11 +1440,6 @@ func (s *VulnService) SendDeadlineReminders() error {
 				continue
 			}
 
-			assigneeName := vuln.Assignee.RealName
-			if assigneeName == "" {
-				assigneeName = vuln.Assignee.Username
-			}
-
 			projectName := ""
 			if vuln.Project.Name != "" {
 				projectName = vuln.Project.Name
@@ -1231,42 +1447,35 @@ func (s *VulnService) SendDeadlineReminders() error {
 
 			deadline := vuln.FixDeadline.Format("2006-01-02")
 
-			// 发送提醒邮件
-			go func(v models.Vulnerability, name, project, dl string, d int) {
-				err := SendVulnDeadlineReminderNotification(
-					v.Title,
-					project,
-					name,
-					v.Assignee.Email,
-					v.Severity,
-					v.Status,
-					d,
-					dl,
-				)
-
-				// 记录发送结果
+			// 按通知偏好提醒指派人，并根据邮件投递结果记录提醒，避免当天重复提醒
+			vulnID, daysLeft := vuln.ID, days
+			notificationService.NotifyVulnDeadline(&vuln, *vuln.AssigneeID, projectName, days, deadline, func(recipient *models.User, status string, err error) {
 				reminder := models.VulnDeadlineReminder{
-					VulnID:        v.ID,
-					DaysLeft:      d,
+					VulnID:        vulnID,
+					DaysLeft:      daysLeft,
 					ReminderDate:  today,
 					SentAt:        time.Now(),
-					AssigneeID:    *v.AssigneeID,
-					AssigneeEmail: v.Assignee.Email,
-					Status:        "sent",
+					AssigneeID:    recipient.ID,
+					AssigneeEmail: recipient.Email,
+					Status:        status,
 				}
 
-				if err != nil {
-					fmt.Printf("发送漏洞截止时间提醒邮件失败 (漏洞ID: %d, %d天后到期): %v\n", v.ID, d, err)
-					reminder.Status = "failed"
-				} else {
-					fmt.Printf("成功发送漏洞截止时间提醒邮件 (漏洞ID: %d, %d天后到期) 给 %s\n", v.ID, d, v.Assignee.Email)
+				switch status {
+				case EmailSent:
+					fmt.Printf("成功发送漏洞截止时间提醒邮件 (漏洞ID: %d, %d天后到期) 给 %s\n", vulnID, daysLeft, recipient.Email)
+				case EmailFailed:
+					fmt.Printf("发送漏洞截止时间提醒邮件失败 (漏洞ID: %d, %d天后到期): %v\n", vulnID, daysLeft, err)
+				case EmailSkipped:
+					// 关闭邮件提醒或没有邮箱时只记录站内提醒
+					reminder.AssigneeEmail = ""
+					reminder.Status = "in_app"
 				}
 
 				// 保存提醒记录
 				if createErr := db.Create(&reminder).Error; createErr != nil {
 					fmt.Printf("保存漏洞截止时间提醒记录失败: %v\n", createErr)
 				}
-			}(vuln, assigneeName, projectName, deadline, days)
+			})
 		}
 	}
 
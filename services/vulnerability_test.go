@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"vulnmain/models"
+)
+
+func TestResolveSeverity(t *testing.T) {
+	tests := []struct {
+		name           string
+		current        string
+		overridden     bool
+		derived        string
+		requested      string
+		role           string
+		wantSeverity   string
+		wantOverridden bool
+		wantNote       bool
+		wantErr        bool
+	}{
+		{name: "no cvss uses requested", derived: "", requested: "high", role: "security_engineer", wantSeverity: "high"},
+		{name: "derived when not requested", derived: "critical", role: "security_engineer", wantSeverity: "critical"},
+		{name: "requested matches derived", derived: "medium", requested: "medium", role: "dev_engineer", wantSeverity: "medium"},
+		{name: "non-admin override rejected on create", derived: "critical", requested: "low", role: "security_engineer", wantErr: true},
+		{name: "non-admin override rejected on update", current: "critical", derived: "critical", requested: "low", role: "security_engineer", wantErr: true},
+		{name: "unchanged form value follows new score", current: "medium", derived: "high", requested: "medium", role: "security_engineer", wantSeverity: "high"},
+		{name: "admin override recorded", derived: "critical", requested: "low", role: "super_admin", wantSeverity: "low", wantOverridden: true, wantNote: true},
+		{name: "existing override kept", current: "low", overridden: true, derived: "critical", role: "security_engineer", wantSeverity: "low", wantOverridden: true},
+		{name: "existing override resubmitted", current: "low", overridden: true, derived: "critical", requested: "low", role: "security_engineer", wantSeverity: "low", wantOverridden: true},
+	}
+
+	s := &VulnService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vuln := &models.Vulnerability{Severity: tt.current, SeverityOverridden: tt.overridden}
+			note, err := s.resolveSeverity(vuln, tt.derived, tt.requested, tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSeverity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if vuln.Severity != tt.wantSeverity || vuln.SeverityOverridden != tt.wantOverridden {
+				t.Errorf("severity = %s (overridden %v), want %s (overridden %v)", vuln.Severity, vuln.SeverityOverridden, tt.wantSeverity, tt.wantOverridden)
+			}
+			if (note != "") != tt.wantNote {
+				t.Errorf("note = %q, wantNote %v", note, tt.wantNote)
+			}
+		})
+	}
+}
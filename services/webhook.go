@@ -0,0 +1,661 @@
+// 出站Webhook服务包
+// 该包负责管理Webhook订阅，并在漏洞、项目、资产等业务事件发生时推送带HMAC-SHA256签名的事件数据
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+)
+
+// WebhookService Webhook服务
+type WebhookService struct{}
+
+// Webhook事件类型
+const (
+	WebhookVulnCreated      = "vuln.created"       // 漏洞提交
+	WebhookVulnAudited      = "vuln.audited"       // 漏洞审核
+	WebhookVulnAssigned     = "vuln.assigned"      // 漏洞分派
+	WebhookVulnFixed        = "vuln.fixed"         // 漏洞修复
+	WebhookVulnRetested     = "vuln.retested"      // 漏洞复测
+	WebhookVulnRejected     = "vuln.rejected"      // 漏洞驳回
+	WebhookVulnCommented    = "vuln.commented"     // 漏洞评论
+	WebhookVulnRiskAccepted = "vuln.risk_accepted" // 漏洞风险接受已批准
+	WebhookVulnRiskExpired  = "vuln.risk_expired"  // 漏洞风险接受到期或撤销
+	WebhookVulnEscalated    = "vuln.escalated"     // 漏洞超期升级
+	WebhookProjectCreated   = "project.created"    // 项目创建
+	WebhookProjectUpdated   = "project.updated"    // 项目更新
+	WebhookProjectDeleted   = "project.deleted"    // 项目删除
+	WebhookAssetCreated     = "asset.created"      // 资产创建
+	WebhookAssetUpdated     = "asset.updated"      // 资产更新
+	WebhookAssetDeleted     = "asset.deleted"      // 资产删除
+	WebhookPing             = "ping"               // 测试推送
+)
+
+// Webhook投递状态
+const (
+	DeliveryPending = "pending" // 待投递或等待重试
+	DeliverySuccess = "success" // 投递成功
+	DeliveryFailed  = "failed"  // 超过最大重试次数，最终失败
+)
+
+// Webhook签名相关请求头
+const (
+	WebhookHeaderEvent     = "X-VulnMain-Event"     // 事件类型
+	WebhookHeaderDelivery  = "X-VulnMain-Delivery"  // 投递记录ID
+	WebhookHeaderTimestamp = "X-VulnMain-Timestamp" // 签名时间戳（秒）
+	WebhookHeaderSignature = "X-VulnMain-Signature" // 签名：sha256=HEX(HMAC-SHA256(secret, timestamp + "." + body))
+)
+
+// WebhookEventInfo 可订阅的事件
+type WebhookEventInfo struct {
+	Event string `json:"event"` // 事件类型
+	Label string `json:"label"` // 事件名称
+}
+
+// WebhookEvents 可订阅的事件列表
+var WebhookEvents = []WebhookEventInfo{
+	{Event: WebhookVulnCreated, Label: "漏洞提交"},
+	{Event: WebhookVulnAudited, Label: "漏洞审核"},
+	{Event: WebhookVulnAssigned, Label: "漏洞分派"},
+	{Event: WebhookVulnFixed, Label: "漏洞修复"},
+	{Event: WebhookVulnRetested, Label: "漏洞复测"},
+	{Event: WebhookVulnRejected, Label: "漏洞驳回"},
+	{Event: WebhookVulnCommented, Label: "漏洞评论"},
+	{Event: WebhookVulnRiskAccepted, Label: "风险接受批准"},
+	{Event: WebhookVulnRiskExpired, Label: "风险接受到期或撤销"},
+	{Event: WebhookVulnEscalated, Label: "漏洞超期升级"},
+	{Event: WebhookProjectCreated, Label: "项目创建"},
+	{Event: WebhookProjectUpdated, Label: "项目更新"},
+	{Event: WebhookProjectDeleted, Label: "项目删除"},
+	{Event: WebhookAssetCreated, Label: "资产创建"},
+	{Event: WebhookAssetUpdated, Label: "资产更新"},
+	{Event: WebhookAssetDeleted, Label: "资产删除"},
+}
+
+// WebhookPayload 推送的数据
+type WebhookPayload struct {
+	EventID   string      `json:"event_id"`  // 事件ID，同一事件重放时不变
+	Event     string      `json:"event"`     // 事件类型
+	Timestamp int64       `json:"timestamp"` // 事件发生时间（秒）
+	Data      interface{} `json:"data"`      // 事件数据
+}
+
+// WebhookVulnData 漏洞事件数据
+type WebhookVulnData struct {
+	ID          uint    `json:"id"`
+	Title       string  `json:"title"`
+	VulnType    string  `json:"vuln_type"`
+	Severity    string  `json:"severity"`
+	Status      string  `json:"status"`
+	OldStatus   string  `json:"old_status,omitempty"` // 状态变更前的状态
+	CVSSScore   float64 `json:"cvss_score"`
+	CVEID       string  `json:"cve_id,omitempty"`
+	ProjectID   uint    `json:"project_id"`
+	ProjectName string  `json:"project_name"`
+	AssetID     uint    `json:"asset_id"`
+	ReporterID  uint    `json:"reporter_id"`
+	AssigneeID  *uint   `json:"assignee_id"`
+	OperatorID  uint    `json:"operator_id"`       // 操作人ID
+	Result      string  `json:"result,omitempty"`  // 复测结果：passed、failed
+	Comment     string  `json:"comment,omitempty"` // 评论内容或驳回原因
+	Link        string  `json:"link"`              // 前端跳转地址
+}
+
+// WebhookProjectData 项目事件数据
+type WebhookProjectData struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Priority   string `json:"priority"`
+	Status     string `json:"status"`
+	OwnerID    uint   `json:"owner_id"`
+	OperatorID uint   `json:"operator_id"` // 操作人ID
+	Link       string `json:"link"`        // 前端跳转地址
+}
+
+// WebhookAssetData 资产事件数据
+type WebhookAssetData struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	IP          string `json:"ip"`
+	Domain      string `json:"domain"`
+	Environment string `json:"environment"`
+	Importance  string `json:"importance"`
+	ProjectID   uint   `json:"project_id"`
+	OperatorID  uint   `json:"operator_id"` // 操作人ID
+}
+
+// WebhookRequest 创建或更新Webhook订阅请求
+type WebhookRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required"`
+	Secret      string   `json:"secret"` // 为空时创建自动生成，更新时保持不变
+	Events      []string `json:"events" binding:"required"`
+	IsActive    *bool    `json:"is_active"`
+	Description string   `json:"description"`
+}
+
+// WebhookDeliveryListRequest 投递记录列表请求
+type WebhookDeliveryListRequest struct {
+	WebhookID uint   `form:"webhook_id"`
+	Event     string `form:"event"`
+	Status    string `form:"status"`
+	Page      int    `form:"page"`
+	PageSize  int    `form:"page_size"`
+}
+
+// WebhookDeliveryListResponse 投递记录列表响应
+type WebhookDeliveryListResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"page_size"`
+}
+
+// NewWebhookVulnData 根据漏洞生成事件数据
+func NewWebhookVulnData(vuln *models.Vulnerability, operatorID uint) WebhookVulnData {
+	return WebhookVulnData{
+		ID:          vuln.ID,
+		Title:       vuln.Title,
+		VulnType:    vuln.VulnType,
+		Severity:    vuln.Severity,
+		Status:      vuln.Status,
+		CVSSScore:   vuln.CVSSScore,
+		CVEID:       vuln.CVEID,
+		ProjectID:   vuln.ProjectID,
+		ProjectName: vuln.Project.Name,
+		AssetID:     vuln.AssetID,
+		ReporterID:  vuln.ReporterID,
+		AssigneeID:  vuln.AssigneeID,
+		OperatorID:  operatorID,
+		Link:        vulnLink(vuln.ProjectID, vuln.ID),
+	}
+}
+
+// NewWebhookProjectData 根据项目生成事件数据
+func NewWebhookProjectData(project *models.Project, operatorID uint) WebhookProjectData {
+	return WebhookProjectData{
+		ID:         project.ID,
+		Name:       project.Name,
+		Type:       project.Type,
+		Priority:   project.Priority,
+		Status:     project.Status,
+		OwnerID:    project.OwnerID,
+		OperatorID: operatorID,
+		Link:       projectLink(project.ID),
+	}
+}
+
+// NewWebhookAssetData 根据资产生成事件数据
+func NewWebhookAssetData(asset *models.Asset, operatorID uint) WebhookAssetData {
+	return WebhookAssetData{
+		ID:          asset.ID,
+		Name:        asset.Name,
+		Type:        asset.Type,
+		IP:          asset.IP,
+		Domain:      asset.Domain,
+		Environment: asset.Environment,
+		Importance:  asset.Importance,
+		ProjectID:   asset.ProjectID,
+		OperatorID:  operatorID,
+	}
+}
+
+// isWebhookEvent 判断是否为可订阅的事件
+func isWebhookEvent(event string) bool {
+	for _, info := range WebhookEvents {
+		if info.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribes 判断订阅是否包含指定事件
+func subscribes(webhook *models.Webhook, event string) bool {
+	for _, item := range strings.Split(webhook.Events, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || item == event {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHex 生成指定字节数的随机十六进制字符串
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// SignWebhookPayload 计算Webhook签名，接收方使用相同算法校验
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookRequest 校验订阅参数
+func validateWebhookRequest(req *WebhookRequest) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("推送地址格式错误，仅支持http和https")
+	}
+
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if event != "*" && !isWebhookEvent(event) {
+			return "", fmt.Errorf("不支持的事件类型: %s", event)
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return "", errors.New("请至少订阅一个事件")
+	}
+
+	return strings.Join(events, ","), nil
+}
+
+// CreateWebhook 创建Webhook订阅，未填写密钥时自动生成
+func (s *WebhookService) CreateWebhook(req *WebhookRequest, userID uint) (*models.Webhook, string, error) {
+	events, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = randomHex(32)
+	}
+
+	webhook := models.Webhook{
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      events,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+
+	db := Init.GetDB()
+	if err := db.Create(&webhook).Error; err != nil {
+		return nil, "", errors.New("创建Webhook失败")
+	}
+	// gorm会忽略布尔零值，停用状态需要单独更新
+	if !webhook.IsActive {
+		db.Model(&webhook).Update("is_active", false)
+	}
+
+	return &webhook, secret, nil
+}
+
+// UpdateWebhook 更新Webhook订阅，密钥为空时保持不变
+func (s *WebhookService) UpdateWebhook(id uint, req *WebhookRequest) (*models.Webhook, error) {
+	db := Init.GetDB()
+
+	var webhook models.Webhook
+	if err := db.Where("id = ?", id).First(&webhook).Error; err != nil {
+		return nil, errors.New("Webhook不存在")
+	}
+
+	events, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"url":         req.URL,
+		"events":      events,
+		"description": req.Description,
+	}
+	if req.Secret != "" {
+		updates["secret"] = req.Secret
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if err := db.Model(&webhook).Updates(updates).Error; err != nil {
+		return nil, errors.New("更新Webhook失败")
+	}
+
+	db.Where("id = ?", id).First(&webhook)
+	return &webhook, nil
+}
+
+// DeleteWebhook 删除Webhook订阅及其投递记录
+func (s *WebhookService) DeleteWebhook(id uint) error {
+	db := Init.GetDB()
+
+	var webhook models.Webhook
+	if err := db.Where("id = ?", id).First(&webhook).Error; err != nil {
+		return errors.New("Webhook不存在")
+	}
+
+	tx := db.Begin()
+	if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		tx.Rollback()
+		return errors.New("删除Webhook投递记录失败")
+	}
+	if err := tx.Delete(&webhook).Error; err != nil {
+		tx.Rollback()
+		return errors.New("删除Webhook失败")
+	}
+
+	return tx.Commit().Error
+}
+
+// GetWebhooks 获取全部Webhook订阅
+func (s *WebhookService) GetWebhooks() ([]models.Webhook, error) {
+	db := Init.GetDB()
+
+	var webhooks []models.Webhook
+	if err := db.Order("id DESC").Find(&webhooks).Error; err != nil {
+		return nil, errors.New("获取Webhook列表失败")
+	}
+
+	return webhooks, nil
+}
+
+// Emit 触发业务事件，异步推送给所有订阅了该事件的Webhook，推送失败不影响业务流程
+func (s *WebhookService) Emit(event string, data interface{}) {
+	payload := WebhookPayload{
+		EventID:   randomHex(16),
+		Event:     event,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("序列化Webhook数据失败 (事件: %s): %v\n", event, err)
+		return
+	}
+
+	go func() {
+		db := Init.GetDB()
+
+		var webhooks []models.Webhook
+		if err := db.Where("is_active = ?", true).Find(&webhooks).Error; err != nil {
+			fmt.Printf("查询Webhook订阅失败: %v\n", err)
+			return
+		}
+
+		for i := range webhooks {
+			if !subscribes(&webhooks[i], event) {
+				continue
+			}
+
+			delivery := models.WebhookDelivery{
+				WebhookID: webhooks[i].ID,
+				EventID:   payload.EventID,
+				Event:     event,
+				Payload:   string(body),
+				Status:    DeliveryPending,
+			}
+			if err := db.Create(&delivery).Error; err != nil {
+				fmt.Printf("创建Webhook投递记录失败 (WebhookID: %d): %v\n", webhooks[i].ID, err)
+				continue
+			}
+
+			s.deliver(&webhooks[i], &delivery)
+		}
+	}()
+}
+
+// retryDelay 计算第attempts次失败后的重试间隔，按指数退避翻倍
+func retryDelay(attempts int) time.Duration {
+	base := time.Duration(getIntConfig("webhook.retry_interval", 30)) * time.Second
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 16 {
+		attempts = 16
+	}
+	return base * time.Duration(1<<uint(attempts-1))
+}
+
+// deliver 执行一次投递并记录结果，失败时按指数退避安排下次重试
+func (s *WebhookService) deliver(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	db := Init.GetDB()
+
+	timestamp := time.Now().Unix()
+	body := []byte(delivery.Payload)
+
+	start := time.Now()
+	code, respBody, err := s.post(webhook, delivery, timestamp, body)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.ResponseBody = respBody
+	delivery.Duration = now.Sub(start).Milliseconds()
+	delivery.Error = ""
+
+	if err == nil && code >= 200 && code < 300 {
+		delivery.Status = DeliverySuccess
+		delivery.DeliveredAt = &now
+		delivery.NextRetryAt = nil
+	} else {
+		if err != nil {
+			delivery.Error = err.Error()
+			if len(delivery.Error) > 500 {
+				delivery.Error = delivery.Error[:500]
+			}
+		} else {
+			delivery.Error = fmt.Sprintf("HTTP %d", code)
+		}
+
+		if delivery.Attempts >= getIntConfig("webhook.max_attempts", 6) {
+			delivery.Status = DeliveryFailed
+			delivery.NextRetryAt = nil
+		} else {
+			delivery.Status = DeliveryPending
+			next := now.Add(retryDelay(delivery.Attempts))
+			delivery.NextRetryAt = &next
+		}
+	}
+
+	if err := db.Save(delivery).Error; err != nil {
+		fmt.Printf("保存Webhook投递记录失败 (ID: %d): %v\n", delivery.ID, err)
+	}
+}
+
+// post 发送带签名的推送请求，返回状态码和截断后的响应内容
+func (s *WebhookService) post(webhook *models.Webhook, delivery *models.WebhookDelivery, timestamp int64, body []byte) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VulnMain-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.Event)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	client := &http.Client{Timeout: time.Duration(getIntConfig("webhook.timeout", 10)) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	return resp.StatusCode, string(respBody), nil
+}
+
+// RetryDueDeliveries 重试到期的失败投递，由定时任务每分钟调用
+func (s *WebhookService) RetryDueDeliveries() error {
+	db := Init.GetDB()
+
+	var deliveries []models.WebhookDelivery
+	if err := db.Preload("Webhook").Where("status = ? AND next_retry_at IS NOT NULL AND next_retry_at <= ?", DeliveryPending, time.Now()).
+		Order("next_retry_at ASC").Limit(100).Find(&deliveries).Error; err != nil {
+		return fmt.Errorf("查询待重试的Webhook投递失败: %v", err)
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if delivery.Webhook.ID == 0 || !delivery.Webhook.IsActive {
+			// 订阅已删除或停用，不再重试
+			db.Model(delivery).Updates(map[string]interface{}{"status": DeliveryFailed, "next_retry_at": nil, "error": "Webhook已停用"})
+			continue
+		}
+		webhook := delivery.Webhook
+		s.deliver(&webhook, delivery)
+	}
+
+	return nil
+}
+
+// GetDeliveries 分页获取投递记录
+func (s *WebhookService) GetDeliveries(req *WebhookDeliveryListRequest) (*WebhookDeliveryListResponse, error) {
+	db := Init.GetDB()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := db.Model(&models.WebhookDelivery{})
+	if req.WebhookID != 0 {
+		query = query.Where("webhook_id = ?", req.WebhookID)
+	}
+	if req.Event != "" {
+		query = query.Where("event = ?", req.Event)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("获取投递记录失败")
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Preload("Webhook").Order("id DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&deliveries).Error; err != nil {
+		return nil, errors.New("获取投递记录失败")
+	}
+
+	return &WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}, nil
+}
+
+// GetDelivery 获取投递记录详情
+func (s *WebhookService) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	db := Init.GetDB()
+
+	var delivery models.WebhookDelivery
+	if err := db.Preload("Webhook").Where("id = ?", id).First(&delivery).Error; err != nil {
+		return nil, errors.New("投递记录不存在")
+	}
+
+	return &delivery, nil
+}
+
+// ReplayDelivery 重放投递记录，以相同的事件数据生成新的投递记录并立即推送
+func (s *WebhookService) ReplayDelivery(id uint) (*models.WebhookDelivery, error) {
+	db := Init.GetDB()
+
+	original, err := s.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if original.Webhook.ID == 0 {
+		return nil, errors.New("Webhook不存在")
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID: original.WebhookID,
+		EventID:   original.EventID,
+		Event:     original.Event,
+		Payload:   original.Payload,
+		Status:    DeliveryPending,
+		ReplayOf:  &original.ID,
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		return nil, errors.New("创建投递记录失败")
+	}
+
+	webhook := original.Webhook
+	s.deliver(&webhook, &delivery)
+
+	return &delivery, nil
+}
+
+// TestWebhook 发送测试事件，同步返回投递结果
+func (s *WebhookService) TestWebhook(id uint) (*models.WebhookDelivery, error) {
+	db := Init.GetDB()
+
+	var webhook models.Webhook
+	if err := db.Where("id = ?", id).First(&webhook).Error; err != nil {
+		return nil, errors.New("Webhook不存在")
+	}
+
+	payload := WebhookPayload{
+		EventID:   randomHex(16),
+		Event:     WebhookPing,
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"webhook_id": webhook.ID,
+			"message":    "这是一条来自VulnMain的测试推送",
+		},
+	}
+	body, _ := json.Marshal(payload)
+
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   payload.EventID,
+		Event:     WebhookPing,
+		Payload:   string(body),
+		Status:    DeliveryPending,
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		return nil, errors.New("创建投递记录失败")
+	}
+
+	s.deliver(&webhook, &delivery)
+
+	// 测试推送不参与自动重试
+	if delivery.Status == DeliveryPending {
+		delivery.Status = DeliveryFailed
+		delivery.NextRetryAt = nil
+		db.Model(&delivery).Updates(map[string]interface{}{"status": DeliveryFailed, "next_retry_at": nil})
+	}
+
+	return &delivery, nil
+}
@@ -7,13 +7,14 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 	"time"
 	Init "vulnmain/Init"
 	"vulnmain/models"
+	"vulnmain/utils"
 
 	"github.com/signintech/gopdf"
+	"github.com/spf13/viper"
 )
 
 // WeeklyReportService 周报服务
@@ -32,6 +33,10 @@ type WeeklyReportData struct {
 	ProjectVulnRanking       []ProjectWeeklyRanking    `json:"project_vuln_ranking"`       // 项目漏洞排名
 	SeverityStats            map[string]int64          `json:"severity_stats"`             // 严重程度统计
 	StatusStats              map[string]int64          `json:"status_stats"`               // 状态统计
+	HighRiskVulns            []WeeklyVulnItem          `json:"high_risk_vulns"`            // 本周新增严重/高危漏洞
+	SLABreached              int64                     `json:"sla_breached"`               // 本周新增超出SLA的漏洞数
+	SLACompliance            *SLAComplianceReport      `json:"sla_compliance"`             // 截至本周末的SLA达成率
+	CategoryStats            []*VulnCategoryStat       `json:"category_stats"`             // 本周新增漏洞按分类树逐级汇总的分布
 	GeneratedAt              time.Time                 `json:"generated_at"`               // 生成时间
 }
 
@@ -52,6 +57,18 @@ type ProjectWeeklyRanking struct {
 	OwnerName   string `json:"owner_name"`
 }
 
+// WeeklyVulnItem 周报漏洞明细
+type WeeklyVulnItem struct {
+	ID          uint    `json:"id"`
+	Title       string  `json:"title"`
+	Severity    string  `json:"severity"`
+	Source      string  `json:"source"`
+	CVEID       string  `json:"cve_id"`
+	CNNVDID     string  `json:"cnnvd_id"`
+	CVSSScore   float64 `json:"cvss_score"`
+	ProjectName string  `json:"project_name"`
+}
+
 // GenerateWeeklyReport 生成周报数据
 func (s *WeeklyReportService) GenerateWeeklyReport() (*WeeklyReportData, error) {
 	db := Init.GetDB()
@@ -156,6 +173,32 @@ func (s *WeeklyReportService) GenerateWeeklyReport() (*WeeklyReportData, error)
 		report.StatusStats[stat.Status] = stat.Count
 	}
 	
+	// 本周新增严重/高危漏洞明细（按CVSS评分排序）
+	var highRiskVulns []WeeklyVulnItem
+	db.Table("vulnerabilities").
+		Select("vulnerabilities.id, vulnerabilities.title, vulnerabilities.severity, vulnerabilities.source, vulnerabilities.cve_id, vulnerabilities.cnnvd_id, vulnerabilities.cvss_score, projects.name as project_name").
+		Joins("LEFT JOIN projects ON vulnerabilities.project_id = projects.id").
+		Where("vulnerabilities.submitted_at >= ? AND vulnerabilities.submitted_at <= ? AND vulnerabilities.severity IN ('critical', 'high') AND vulnerabilities.deleted_at IS NULL", weekStart, weekEnd).
+		Order("vulnerabilities.cvss_score DESC").
+		Limit(20).
+		Scan(&highRiskVulns)
+	report.HighRiskVulns = highRiskVulns
+
+	// SLA达成情况：本周新增超期数和截至本周末纳入SLA的漏洞的达成率
+	db.Model(&models.Vulnerability{}).
+		Where("sla_breached_at >= ? AND sla_breached_at <= ?", weekStart, weekEnd).
+		Count(&report.SLABreached)
+	slaService := &SLAService{}
+	slaCompliance, err := slaService.slaCompliance(db, &slaComplianceFilter{StartedTo: &weekEnd})
+	if err != nil {
+		return nil, err
+	}
+	report.SLACompliance = slaCompliance
+
+	// 本周新增漏洞的分类分布，逐级汇总到OWASP大类
+	report.CategoryStats = vulnCategoryStats(db, db.Model(&models.Vulnerability{}).
+		Where("submitted_at >= ? AND submitted_at <= ?", weekStart, weekEnd))
+	
 	return report, nil
 }
 
@@ -345,6 +388,68 @@ func (s *WeeklyReportService) GenerateWeeklyReportPDF(data *WeeklyReportData) ([
 	}
 	currentY += 20
 
+	// SLA达成情况
+	if data.SLACompliance != nil && data.SLACompliance.Overall.Total > 0 {
+		if currentY > 650 {
+			pdf.AddPage()
+			currentY = 40
+		}
+
+		pdf.SetFont(fontName, "", 14)
+		pdf.SetX(50)
+		pdf.SetY(currentY)
+		pdf.Cell(nil, "SLA达成情况")
+		currentY += 25
+
+		overall := data.SLACompliance.Overall
+		pdf.SetFont(fontName, "", 12)
+		pdf.SetX(60)
+		pdf.SetY(currentY)
+		pdf.Cell(nil, fmt.Sprintf("达成率: %.1f%%  纳入SLA: %d  超期: %d  本周新增超期: %d  平均修复耗时: %.1f小时",
+			overall.ComplianceRate, overall.Total, overall.Breached, data.SLABreached, overall.AvgRemediationHours))
+		currentY += 25
+
+		groups := []struct {
+			title string
+			items []SLAComplianceItem
+		}{
+			{"按项目（达成率由低到高）", data.SLACompliance.ByProject},
+			{"按团队（达成率由低到高）", data.SLACompliance.ByTeam},
+			{"按工程师（达成率由低到高）", data.SLACompliance.ByEngineer},
+		}
+		for _, group := range groups {
+			if currentY > 680 {
+				pdf.AddPage()
+				currentY = 40
+			}
+			pdf.SetFont(fontName, "", 11)
+			pdf.SetX(60)
+			pdf.SetY(currentY)
+			pdf.Cell(nil, group.title)
+			currentY += 15
+
+			pdf.SetFont(fontName, "", 10)
+			pdf.SetX(60)
+			pdf.SetY(currentY)
+			pdf.Cell(nil, "名称                      达成率    总数    超期    平均修复耗时")
+			currentY += 15
+
+			for i, item := range group.items {
+				if i >= 10 { // 只显示前10项
+					break
+				}
+				pdf.SetX(60)
+				pdf.SetY(currentY)
+				text := fmt.Sprintf("%-20s  %6.1f%%  %-6d  %-6d  %.1f小时",
+					item.Name, item.ComplianceRate, item.Total, item.Breached, item.AvgRemediationHours)
+				pdf.Cell(nil, text)
+				currentY += 12
+			}
+			currentY += 10
+		}
+		currentY += 10
+	}
+
 	// 严重程度统计
 	if len(data.SeverityStats) > 0 {
 		pdf.SetFont(fontName, "", 14)
@@ -380,6 +485,92 @@ func (s *WeeklyReportService) GenerateWeeklyReportPDF(data *WeeklyReportData) ([
 		currentY += 20
 	}
 
+	// 漏洞分类分布
+	if len(data.CategoryStats) > 0 {
+		if currentY > 650 {
+			pdf.AddPage()
+			currentY = 40
+		}
+
+		pdf.SetFont(fontName, "", 14)
+		pdf.SetX(50)
+		pdf.SetY(currentY)
+		pdf.Cell(nil, "漏洞分类分布（本周新增）")
+		currentY += 25
+
+		pdf.SetFont(fontName, "", 10)
+		// 表头
+		pdf.SetX(60)
+		pdf.SetY(currentY)
+		pdf.Cell(nil, "分类                                  数量      未关闭")
+		currentY += 15
+
+		// 数据行：第一级分类及其下前5个子分类
+		for _, stat := range data.CategoryStats {
+			lines := []string{fmt.Sprintf("%-32s  %-8d  %d", stat.Name, stat.Total, stat.Open)}
+			for i, child := range stat.Children {
+				if i >= 5 {
+					break
+				}
+				lines = append(lines, fmt.Sprintf("    %-28s  %-8d  %d", child.Name, child.Total, child.Open))
+			}
+			for _, line := range lines {
+				if currentY > 730 {
+					pdf.AddPage()
+					currentY = 40
+				}
+				pdf.SetX(60)
+				pdf.SetY(currentY)
+				pdf.Cell(nil, line)
+				currentY += 12
+			}
+		}
+		currentY += 20
+	}
+
+	// 本周新增严重/高危漏洞明细
+	if len(data.HighRiskVulns) > 0 {
+		if currentY > 650 {
+			pdf.AddPage()
+			currentY = 40
+		}
+
+		pdf.SetFont(fontName, "", 14)
+		pdf.SetX(50)
+		pdf.SetY(currentY)
+		pdf.Cell(nil, "本周新增严重/高危漏洞")
+		currentY += 25
+
+		pdf.SetFont(fontName, "", 10)
+		// 表头
+		pdf.SetX(60)
+		pdf.SetY(currentY)
+		pdf.Cell(nil, "CVSS    编号                  来源          项目                标题")
+		currentY += 15
+
+		// 数据行
+		for _, vuln := range data.HighRiskVulns {
+			if currentY > 730 {
+				pdf.AddPage()
+				currentY = 40
+			}
+			vulnNo := vuln.CVEID
+			if vulnNo == "" {
+				vulnNo = vuln.CNNVDID
+			}
+			if vulnNo == "" {
+				vulnNo = "-"
+			}
+			pdf.SetX(60)
+			pdf.SetY(currentY)
+			text := fmt.Sprintf("%-6.1f  %-20s  %-12s  %-18s  %s",
+				vuln.CVSSScore, vulnNo, vuln.Source, vuln.ProjectName, vuln.Title)
+			pdf.Cell(nil, text)
+			currentY += 12
+		}
+		currentY += 20
+	}
+
 	// 页脚
 	pdf.SetFont(fontName, "", 8)
 	pdf.SetX(50)
@@ -420,7 +611,7 @@ func (s *WeeklyReportService) SendWeeklyReport() error {
 	fileName := generateFileName(weekStartFormatted)
 
 	// 保存PDF文件
-	filePath, err := s.savePDFFile(pdfData, fileName)
+	storage, err := s.savePDFFile(pdfData, fileName)
 	if err != nil {
 		return fmt.Errorf("保存PDF文件失败: %v", err)
 	}
@@ -436,8 +627,9 @@ func (s *WeeklyReportService) SendWeeklyReport() error {
 		WeekStart:       data.WeekStart,
 		WeekEnd:         data.WeekEnd,
 		FileName:        fileName,
-		FilePath:        filePath,
+		FilePath:        storage.FilePath,
 		FileSize:        int64(len(pdfData)),
+		FileStorageID:   storage.ID,
 		TotalSubmitted:  data.TotalSubmitted,
 		TotalFixed:      data.TotalFixed,
 		TotalFixing:     data.TotalFixing,
@@ -454,10 +646,11 @@ func (s *WeeklyReportService) SendWeeklyReport() error {
 	if err := db.Create(weeklyReport).Error; err != nil {
 		return fmt.Errorf("保存周报记录失败: %v", err)
 	}
+	db.Model(storage).Update("ref_id", weeklyReport.ID)
 
 	// 发送邮件
 	subject := fmt.Sprintf("周报 - 漏洞管理系统 (%s - %s)", data.WeekStart, data.WeekEnd)
-	body := s.generateEmailBody(data)
+	body := s.generateEmailBody(data, s.signedDownloadURL(weeklyReport.ID))
 
 	err = SendEmailWithAttachment(adminEmail, subject, body, fileName, pdfData)
 	if err != nil {
@@ -488,25 +681,57 @@ func (s *WeeklyReportService) getAdminEmail() (string, error) {
 	return user.Email, nil
 }
 
+// signedDownloadURL 生成邮件中使用的周报签名下载链接
+// 需要在config.yml中配置server.external_url，未配置时返回空字符串
+func (s *WeeklyReportService) signedDownloadURL(reportID uint) string {
+	externalURL := strings.TrimRight(viper.GetString("server.external_url"), "/")
+	if externalURL == "" {
+		return ""
+	}
+
+	fileService := &FileService{}
+	return externalURL + fileService.SignedWeeklyReportURL(utils.NewResourceSigner(), reportID, fileService.EmailLinkExpire())
+}
+
 // generateEmailBody 生成邮件正文
-func (s *WeeklyReportService) generateEmailBody(data *WeeklyReportData) string {
+func (s *WeeklyReportService) generateEmailBody(data *WeeklyReportData, downloadURL string) string {
+	downloadLine := ""
+	if downloadURL != "" {
+		downloadLine = fmt.Sprintf("\n在线查看（链接短期有效）：%s\n", downloadURL)
+	}
+	slaLine := ""
+	if data.SLACompliance != nil && data.SLACompliance.Overall.Total > 0 {
+		slaLine = fmt.Sprintf("- SLA达成率：%.1f%%（本周新增超期 %d 个）\n", data.SLACompliance.Overall.ComplianceRate, data.SLABreached)
+	}
+	categoryLine := ""
+	if len(data.CategoryStats) > 0 {
+		items := []string{}
+		for i, stat := range data.CategoryStats {
+			if i >= 3 { // 只列出前3个分类
+				break
+			}
+			items = append(items, fmt.Sprintf("%s %d 个", stat.Name, stat.Total))
+		}
+		categoryLine = fmt.Sprintf("- 主要漏洞分类：%s\n", strings.Join(items, "、"))
+	}
+
 	return fmt.Sprintf(`
 亲爱的管理员，
 
 本周（%s - %s）漏洞管理系统周报已生成，详细信息请查看附件PDF。
-
+%s
 本周概览：
 - 新提交漏洞：%d 个
 - 已修复漏洞：%d 个
 - 修复中漏洞：%d 个
 - 待复测漏洞：%d 个
-
+%s%s
 此邮件由系统自动发送，请勿回复。
 
 漏洞管理系统
 %s
-`, data.WeekStart, data.WeekEnd, data.TotalSubmitted, data.TotalFixed, 
-   data.TotalFixing, data.TotalRetesting, data.GeneratedAt.Format("2006-01-02 15:04:05"))
+`, data.WeekStart, data.WeekEnd, downloadLine, data.TotalSubmitted, data.TotalFixed, 
+   data.TotalFixing, data.TotalRetesting, slaLine, categoryLine, data.GeneratedAt.Format("2006-01-02 15:04:05"))
 }
 
 // 辅助函数
@@ -529,12 +754,6 @@ func generateRandomString(length int) string {
 	return hex.EncodeToString(bytes)
 }
 
-// ensureWeeklyDir 确保weekly目录存在
-func ensureWeeklyDir() error {
-	weeklyDir := filepath.Join("uploads", "weekly")
-	return os.MkdirAll(weeklyDir, 0755)
-}
-
 // generateFileName 生成周报文件名
 func generateFileName(weekStart string) string {
 	// 格式：YYYYMMDD_weekly_随机字符串18位.pdf
@@ -542,20 +761,21 @@ func generateFileName(weekStart string) string {
 	return fmt.Sprintf("%s_weekly_%s.pdf", weekStart, randomStr)
 }
 
-// savePDFFile 保存PDF文件到uploads/weekly目录
-func (s *WeeklyReportService) savePDFFile(pdfData []byte, fileName string) (string, error) {
-	// 确保目录存在
-	if err := ensureWeeklyDir(); err != nil {
-		return "", fmt.Errorf("创建weekly目录失败: %v", err)
+// savePDFFile 保存PDF文件到配置的存储后端，对象键为weekly/<文件名>
+func (s *WeeklyReportService) savePDFFile(pdfData []byte, fileName string) (*models.FileStorage, error) {
+	storage := &models.FileStorage{
+		FileName: fileName,
+		FileSize: int64(len(pdfData)),
+		MimeType: "application/pdf",
+		UserID:   1, // 系统自动生成
+		Category: "weekly_report",
+		RefType:  "weekly_report",
 	}
 
-	// 构建完整文件路径
-	filePath := filepath.Join("uploads", "weekly", fileName)
-
-	// 写入文件
-	if err := os.WriteFile(filePath, pdfData, 0644); err != nil {
-		return "", fmt.Errorf("保存PDF文件失败: %v", err)
+	fileService := &FileService{}
+	if err := fileService.StoreFile("weekly/"+fileName, bytes.NewReader(pdfData), storage); err != nil {
+		return nil, fmt.Errorf("保存PDF文件失败: %v", err)
 	}
 
-	return filePath, nil
+	return storage, nil
 }
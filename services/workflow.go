@@ -0,0 +1,389 @@
+// 漏洞工作流配置服务包
+// 该包提供管理员按项目类型维护漏洞工作流的功能，工作流的执行由vuln_state.go中的状态机完成
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	Init "vulnmain/Init"
+	"vulnmain/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WorkflowService 漏洞工作流服务
+type WorkflowService struct{}
+
+// WorkflowStateRequest 工作流状态请求
+type WorkflowStateRequest struct {
+	Status    string `json:"status" binding:"required"`
+	Label     string `json:"label" binding:"required"`
+	IsInitial bool   `json:"is_initial"`
+}
+
+// WorkflowTransitionRequest 工作流状态流转请求
+type WorkflowTransitionRequest struct {
+	From              string   `json:"from" binding:"required"`
+	To                string   `json:"to" binding:"required"`
+	Name              string   `json:"name" binding:"required"`
+	Permission        string   `json:"permission"`
+	Roles             []string `json:"roles"`
+	ProjectRoles      []string `json:"project_roles"`
+	RequiredFields    []string `json:"required_fields"`
+	AutoActions       []string `json:"auto_actions"`
+	RequiredApprovals int      `json:"required_approvals"`
+}
+
+// WorkflowRequest 创建或更新工作流请求，状态和流转整体替换
+type WorkflowRequest struct {
+	Name        string                      `json:"name" binding:"required"`
+	Description string                      `json:"description"`
+	ProjectType string                      `json:"project_type"` // 为空表示默认工作流
+	IsActive    *bool                       `json:"is_active"`
+	States      []WorkflowStateRequest      `json:"states" binding:"required"`
+	Transitions []WorkflowTransitionRequest `json:"transitions"`
+}
+
+// WorkflowOptions 工作流配置可选项
+type WorkflowOptions struct {
+	ProjectTypes   []VulnWorkflowOption `json:"project_types"`
+	Roles          []VulnWorkflowOption `json:"roles"`
+	ProjectRoles   []VulnWorkflowOption `json:"project_roles"`
+	Permissions    []VulnWorkflowOption `json:"permissions"`
+	RequiredFields []VulnWorkflowOption `json:"required_fields"`
+	AutoActions    []VulnWorkflowOption `json:"auto_actions"`
+	Builtin        *VulnWorkflow        `json:"builtin"` // 系统内置流程，可作为新建工作流的模板
+}
+
+// 项目类型
+var workflowProjectTypes = []VulnWorkflowOption{
+	{Code: "web_project", Label: "网站项目"},
+	{Code: "api_interface", Label: "接口项目"},
+	{Code: "mobile_app", Label: "移动应用"},
+	{Code: "software_app", Label: "软件应用"},
+}
+
+// 状态代码只允许小写字母、数字和下划线
+var workflowStatusPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// GetWorkflowOptions 获取工作流配置可选项
+func (s *WorkflowService) GetWorkflowOptions() *WorkflowOptions {
+	db := Init.GetDB()
+
+	options := &WorkflowOptions{
+		ProjectTypes:   workflowProjectTypes,
+		Roles:          []VulnWorkflowOption{},
+		ProjectRoles:   vulnProjectRoles,
+		Permissions:    []VulnWorkflowOption{},
+		RequiredFields: vulnRequiredFields,
+		AutoActions:    []VulnWorkflowOption{},
+		Builtin:        builtinVulnWorkflow(),
+	}
+
+	var roles []models.Role
+	db.Order("id ASC").Find(&roles)
+	for _, role := range roles {
+		options.Roles = append(options.Roles, VulnWorkflowOption{Code: role.Code, Label: role.Name})
+	}
+
+	var permissions []models.Permission
+	db.Where("module = ?", "vuln").Order("id ASC").Find(&permissions)
+	for _, permission := range permissions {
+		options.Permissions = append(options.Permissions, VulnWorkflowOption{Code: permission.Code, Label: permission.Name})
+	}
+
+	for _, action := range vulnAutoActions {
+		options.AutoActions = append(options.AutoActions, action.VulnWorkflowOption)
+	}
+
+	return options
+}
+
+// GetWorkflows 获取全部工作流
+func (s *WorkflowService) GetWorkflows() ([]models.Workflow, error) {
+	db := Init.GetDB()
+
+	var workflows []models.Workflow
+	if err := preloadWorkflow(db).Order("project_type ASC, id ASC").Find(&workflows).Error; err != nil {
+		return nil, errors.New("获取工作流列表失败")
+	}
+
+	return workflows, nil
+}
+
+// GetWorkflow 获取工作流详情
+func (s *WorkflowService) GetWorkflow(id uint) (*models.Workflow, error) {
+	db := Init.GetDB()
+
+	var workflow models.Workflow
+	if err := preloadWorkflow(db).Where("id = ?", id).First(&workflow).Error; err != nil {
+		return nil, errors.New("工作流不存在")
+	}
+
+	return &workflow, nil
+}
+
+// CreateWorkflow 创建工作流
+func (s *WorkflowService) CreateWorkflow(req *WorkflowRequest, userID uint) (*models.Workflow, error) {
+	db := Init.GetDB()
+
+	isActive := req.IsActive == nil || *req.IsActive
+	if err := validateWorkflowRequest(db, req, isActive, 0); err != nil {
+		return nil, err
+	}
+
+	workflow := models.Workflow{
+		Name:        req.Name,
+		Description: req.Description,
+		ProjectType: req.ProjectType,
+		IsActive:    isActive,
+		CreatedBy:   userID,
+	}
+
+	tx := db.Begin()
+	if err := tx.Create(&workflow).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("创建工作流失败")
+	}
+	// gorm会忽略布尔零值，停用状态需要单独更新
+	if !workflow.IsActive {
+		tx.Model(&workflow).Update("is_active", false)
+	}
+	if err := saveWorkflowDefinition(tx, workflow.ID, req); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("创建工作流失败")
+	}
+
+	return s.GetWorkflow(workflow.ID)
+}
+
+// UpdateWorkflow 更新工作流，状态和流转整体替换
+func (s *WorkflowService) UpdateWorkflow(id uint, req *WorkflowRequest) (*models.Workflow, error) {
+	db := Init.GetDB()
+
+	var workflow models.Workflow
+	if err := db.Where("id = ?", id).First(&workflow).Error; err != nil {
+		return nil, errors.New("工作流不存在")
+	}
+
+	isActive := workflow.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	if err := validateWorkflowRequest(db, req, isActive, id); err != nil {
+		return nil, err
+	}
+
+	tx := db.Begin()
+	if err := tx.Model(&workflow).Updates(map[string]interface{}{
+		"name":         req.Name,
+		"description":  req.Description,
+		"project_type": req.ProjectType,
+		"is_active":    isActive,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("更新工作流失败")
+	}
+	if err := tx.Where("workflow_id = ?", id).Delete(&models.WorkflowState{}).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("更新工作流失败")
+	}
+	if err := tx.Where("workflow_id = ?", id).Delete(&models.WorkflowTransition{}).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("更新工作流失败")
+	}
+	if err := saveWorkflowDefinition(tx, id, req); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("更新工作流失败")
+	}
+
+	return s.GetWorkflow(id)
+}
+
+// DeleteWorkflow 删除工作流，删除后对应项目类型的漏洞改用默认工作流或系统内置流程
+func (s *WorkflowService) DeleteWorkflow(id uint) error {
+	db := Init.GetDB()
+
+	var workflow models.Workflow
+	if err := db.Where("id = ?", id).First(&workflow).Error; err != nil {
+		return errors.New("工作流不存在")
+	}
+
+	tx := db.Begin()
+	if err := tx.Where("workflow_id = ?", id).Delete(&models.WorkflowState{}).Error; err != nil {
+		tx.Rollback()
+		return errors.New("删除工作流失败")
+	}
+	if err := tx.Where("workflow_id = ?", id).Delete(&models.WorkflowTransition{}).Error; err != nil {
+		tx.Rollback()
+		return errors.New("删除工作流失败")
+	}
+	if err := tx.Delete(&workflow).Error; err != nil {
+		tx.Rollback()
+		return errors.New("删除工作流失败")
+	}
+
+	return tx.Commit().Error
+}
+
+// preloadWorkflow 预加载工作流的状态和流转
+func preloadWorkflow(db *gorm.DB) *gorm.DB {
+	return db.Preload("States", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).Preload("Transitions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	})
+}
+
+// saveWorkflowDefinition 保存工作流的状态和流转
+func saveWorkflowDefinition(tx *gorm.DB, workflowID uint, req *WorkflowRequest) error {
+	for i, st := range req.States {
+		state := models.WorkflowState{
+			WorkflowID: workflowID,
+			Status:     st.Status,
+			Label:      st.Label,
+			IsInitial:  st.IsInitial,
+			SortOrder:  i,
+		}
+		if err := tx.Create(&state).Error; err != nil {
+			return errors.New("保存工作流状态失败")
+		}
+	}
+	for i, t := range req.Transitions {
+		transition := models.WorkflowTransition{
+			WorkflowID:        workflowID,
+			FromStatus:        t.From,
+			ToStatus:          t.To,
+			Name:              t.Name,
+			Permission:        t.Permission,
+			Roles:             strings.Join(t.Roles, ","),
+			ProjectRoles:      strings.Join(t.ProjectRoles, ","),
+			RequiredFields:    strings.Join(t.RequiredFields, ","),
+			AutoActions:       strings.Join(t.AutoActions, ","),
+			RequiredApprovals: t.RequiredApprovals,
+			SortOrder:         i,
+		}
+		if err := tx.Create(&transition).Error; err != nil {
+			return errors.New("保存工作流流转失败")
+		}
+	}
+	return nil
+}
+
+// validateWorkflowRequest 校验工作流定义
+func validateWorkflowRequest(db *gorm.DB, req *WorkflowRequest, isActive bool, excludeID uint) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("工作流名称不能为空")
+	}
+
+	if req.ProjectType != "" && !isWorkflowOption(workflowProjectTypes, req.ProjectType) {
+		return fmt.Errorf("无效的项目类型: %s", req.ProjectType)
+	}
+
+	// 同一项目类型只能有一个启用的工作流
+	if isActive {
+		var count int64
+		db.Model(&models.Workflow{}).Where("project_type = ? AND is_active = ? AND id <> ?", req.ProjectType, true, excludeID).Count(&count)
+		if count > 0 {
+			if req.ProjectType == "" {
+				return errors.New("已存在启用的默认工作流")
+			}
+			return errors.New("该项目类型已存在启用的工作流")
+		}
+	}
+
+	// 校验状态
+	if len(req.States) == 0 {
+		return errors.New("工作流至少需要一个状态")
+	}
+	states := map[string]bool{}
+	initialCount := 0
+	for _, st := range req.States {
+		if !workflowStatusPattern.MatchString(st.Status) {
+			return fmt.Errorf("无效的状态代码: %s，只能包含小写字母、数字和下划线，且不超过20个字符", st.Status)
+		}
+		if states[st.Status] {
+			return fmt.Errorf("状态重复: %s", st.Status)
+		}
+		states[st.Status] = true
+		if st.IsInitial {
+			initialCount++
+		}
+	}
+	if initialCount != 1 {
+		return errors.New("工作流必须且只能有一个初始状态")
+	}
+
+	// 校验流转
+	var roleCodes []string
+	db.Model(&models.Role{}).Pluck("code", &roleCodes)
+	transitions := map[string]bool{}
+	for _, t := range req.Transitions {
+		if !states[t.From] || !states[t.To] {
+			return fmt.Errorf("流转「%s」引用了不存在的状态", t.Name)
+		}
+		if t.From == t.To {
+			return fmt.Errorf("流转「%s」的当前状态和目标状态不能相同", t.Name)
+		}
+		if isSystemOnlyVulnStatus(t.To) {
+			return fmt.Errorf("流转「%s」不能以「%s」为目标状态，该状态只能通过风险接受审批进入", t.Name, vulnStatusLabel(t.To))
+		}
+		key := t.From + "->" + t.To
+		if transitions[key] {
+			return fmt.Errorf("流转重复: %s -> %s", t.From, t.To)
+		}
+		transitions[key] = true
+
+		if t.Permission != "" {
+			var count int64
+			db.Model(&models.Permission{}).Where("code = ?", t.Permission).Count(&count)
+			if count == 0 {
+				return fmt.Errorf("流转「%s」的权限不存在: %s", t.Name, t.Permission)
+			}
+		}
+		for _, role := range t.Roles {
+			if !contains(roleCodes, role) {
+				return fmt.Errorf("流转「%s」的角色不存在: %s", t.Name, role)
+			}
+		}
+		for _, role := range t.ProjectRoles {
+			if !isWorkflowOption(vulnProjectRoles, role) {
+				return fmt.Errorf("流转「%s」的项目角色无效: %s", t.Name, role)
+			}
+		}
+		for _, field := range t.RequiredFields {
+			if !isWorkflowOption(vulnRequiredFields, field) {
+				return fmt.Errorf("流转「%s」的必填项无效: %s", t.Name, field)
+			}
+		}
+		for _, action := range t.AutoActions {
+			if findVulnAutoAction(action) == nil {
+				return fmt.Errorf("流转「%s」的自动动作无效: %s", t.Name, action)
+			}
+		}
+		if t.RequiredApprovals < 0 {
+			return fmt.Errorf("流转「%s」的审批人数不能为负数", t.Name)
+		}
+	}
+
+	return nil
+}
+
+// isWorkflowOption 判断代码是否在可选项中
+func isWorkflowOption(options []VulnWorkflowOption, code string) bool {
+	for _, option := range options {
+		if option.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,642 @@
+// CVSS评分工具包
+// 该包提供CVSS v3.1 / v4.0 向量解析、校验及基础、时间、环境评分计算功能
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CVSSResult CVSS评分计算结果
+type CVSSResult struct {
+	Version            string  `json:"version"`             // CVSS版本：3.0、3.1、4.0
+	Vector             string  `json:"vector"`              // 规范化后的向量字符串
+	BaseScore          float64 `json:"base_score"`          // 基础评分
+	TemporalScore      float64 `json:"temporal_score"`      // 时间评分（v4.0为威胁评分）
+	EnvironmentalScore float64 `json:"environmental_score"` // 环境评分
+	Score              float64 `json:"score"`               // 最终评分，取环境评分
+	Severity           string  `json:"severity"`            // 根据最终评分推导的严重程度
+}
+
+// cvss31Metrics CVSS v3.x 各指标允许的取值，Required表示基础指标必填
+var cvss31Metrics = []struct {
+	Name     string
+	Values   []string
+	Required bool
+}{
+	{"AV", []string{"N", "A", "L", "P"}, true},
+	{"AC", []string{"L", "H"}, true},
+	{"PR", []string{"N", "L", "H"}, true},
+	{"UI", []string{"N", "R"}, true},
+	{"S", []string{"U", "C"}, true},
+	{"C", []string{"H", "L", "N"}, true},
+	{"I", []string{"H", "L", "N"}, true},
+	{"A", []string{"H", "L", "N"}, true},
+	{"E", []string{"X", "H", "F", "P", "U"}, false},
+	{"RL", []string{"X", "U", "W", "T", "O"}, false},
+	{"RC", []string{"X", "C", "R", "U"}, false},
+	{"CR", []string{"X", "H", "M", "L"}, false},
+	{"IR", []string{"X", "H", "M", "L"}, false},
+	{"AR", []string{"X", "H", "M", "L"}, false},
+	{"MAV", []string{"X", "N", "A", "L", "P"}, false},
+	{"MAC", []string{"X", "L", "H"}, false},
+	{"MPR", []string{"X", "N", "L", "H"}, false},
+	{"MUI", []string{"X", "N", "R"}, false},
+	{"MS", []string{"X", "U", "C"}, false},
+	{"MC", []string{"X", "H", "L", "N"}, false},
+	{"MI", []string{"X", "H", "L", "N"}, false},
+	{"MA", []string{"X", "H", "L", "N"}, false},
+}
+
+// cvss40Metrics CVSS v4.0 各指标允许的取值，Required表示基础指标必填
+var cvss40Metrics = []struct {
+	Name     string
+	Values   []string
+	Required bool
+}{
+	{"AV", []string{"N", "A", "L", "P"}, true},
+	{"AC", []string{"L", "H"}, true},
+	{"AT", []string{"N", "P"}, true},
+	{"PR", []string{"N", "L", "H"}, true},
+	{"UI", []string{"N", "P", "A"}, true},
+	{"VC", []string{"H", "L", "N"}, true},
+	{"VI", []string{"H", "L", "N"}, true},
+	{"VA", []string{"H", "L", "N"}, true},
+	{"SC", []string{"H", "L", "N"}, true},
+	{"SI", []string{"H", "L", "N"}, true},
+	{"SA", []string{"H", "L", "N"}, true},
+	{"E", []string{"X", "A", "P", "U"}, false},
+	{"CR", []string{"X", "H", "M", "L"}, false},
+	{"IR", []string{"X", "H", "M", "L"}, false},
+	{"AR", []string{"X", "H", "M", "L"}, false},
+	{"MAV", []string{"X", "N", "A", "L", "P"}, false},
+	{"MAC", []string{"X", "L", "H"}, false},
+	{"MAT", []string{"X", "N", "P"}, false},
+	{"MPR", []string{"X", "N", "L", "H"}, false},
+	{"MUI", []string{"X", "N", "P", "A"}, false},
+	{"MVC", []string{"X", "H", "L", "N"}, false},
+	{"MVI", []string{"X", "H", "L", "N"}, false},
+	{"MVA", []string{"X", "H", "L", "N"}, false},
+	{"MSC", []string{"X", "H", "L", "N"}, false},
+	{"MSI", []string{"X", "S", "H", "L", "N"}, false},
+	{"MSA", []string{"X", "S", "H", "L", "N"}, false},
+	{"S", []string{"X", "N", "P"}, false},
+	{"AU", []string{"X", "N", "Y"}, false},
+	{"R", []string{"X", "A", "U", "I"}, false},
+	{"V", []string{"X", "D", "C"}, false},
+	{"RE", []string{"X", "L", "M", "H"}, false},
+	{"U", []string{"X", "Clear", "Green", "Amber", "Red"}, false},
+}
+
+// CVSSSeverity 根据CVSS评分推导严重程度
+// 0.0为info，0.1-3.9为low，4.0-6.9为medium，7.0-8.9为high，9.0-10.0为critical
+func CVSSSeverity(score float64) string {
+	switch {
+	case score >= 9.0:
+		return "critical"
+	case score >= 7.0:
+		return "high"
+	case score >= 4.0:
+		return "medium"
+	case score > 0:
+		return "low"
+	default:
+		return "info"
+	}
+}
+
+// CalculateCVSS 校验CVSS向量并计算基础、时间和环境评分
+// defaults用于补充向量中未指定（或为X）的环境指标，如根据资产重要性推导的CR/IR/AR
+func CalculateCVSS(vector string, defaults map[string]string) (*CVSSResult, error) {
+	vector = strings.TrimSpace(vector)
+	if vector == "" {
+		return nil, errors.New("CVSS向量不能为空")
+	}
+
+	parts := strings.Split(vector, "/")
+	prefix := parts[0]
+
+	var version string
+	switch prefix {
+	case "CVSS:3.0":
+		version = "3.0"
+	case "CVSS:3.1":
+		version = "3.1"
+	case "CVSS:4.0":
+		version = "4.0"
+	default:
+		return nil, errors.New("不支持的CVSS版本，仅支持CVSS:3.0、CVSS:3.1和CVSS:4.0")
+	}
+
+	metrics, err := parseCVSSMetrics(parts[1:], version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 补充环境指标默认值，仅在向量未指定时生效
+	for key, value := range defaults {
+		if current, ok := metrics[key]; !ok || current == "X" {
+			metrics[key] = value
+		}
+	}
+
+	result := &CVSSResult{
+		Version: version,
+		Vector:  vector,
+	}
+
+	if version == "4.0" {
+		result.BaseScore = cvss40Score(cvss40Subset(metrics, false, false))
+		result.TemporalScore = cvss40Score(cvss40Subset(metrics, true, false))
+		result.EnvironmentalScore = cvss40Score(metrics)
+	} else {
+		result.BaseScore, result.TemporalScore, result.EnvironmentalScore = cvss31Scores(metrics)
+	}
+
+	result.Score = result.EnvironmentalScore
+	result.Severity = CVSSSeverity(result.Score)
+	return result, nil
+}
+
+// parseCVSSMetrics 解析并校验向量中的指标
+func parseCVSSMetrics(parts []string, version string) (map[string]string, error) {
+	definitions := cvss31Metrics
+	if version == "4.0" {
+		definitions = cvss40Metrics
+	}
+
+	allowed := make(map[string][]string, len(definitions))
+	for _, def := range definitions {
+		allowed[def.Name] = def.Values
+	}
+
+	metrics := make(map[string]string)
+	for _, part := range parts {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("CVSS向量格式错误: %s", part)
+		}
+
+		values, ok := allowed[kv[0]]
+		if !ok {
+			return nil, fmt.Errorf("未知的CVSS指标: %s", kv[0])
+		}
+		if _, exists := metrics[kv[0]]; exists {
+			return nil, fmt.Errorf("CVSS指标重复: %s", kv[0])
+		}
+
+		valid := false
+		for _, v := range values {
+			if v == kv[1] {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("CVSS指标%s的取值无效: %s", kv[0], kv[1])
+		}
+
+		metrics[kv[0]] = kv[1]
+	}
+
+	for _, def := range definitions {
+		if _, ok := metrics[def.Name]; def.Required && !ok {
+			return nil, fmt.Errorf("缺少CVSS基础指标: %s", def.Name)
+		}
+	}
+
+	return metrics, nil
+}
+
+// cvssRoundUp CVSS v3.1规范中的向上取整到一位小数
+func cvssRoundUp(value float64) float64 {
+	intInput := int64(math.Round(value * 100000))
+	if intInput%10000 == 0 {
+		return float64(intInput) / 100000.0
+	}
+	return (math.Floor(float64(intInput)/10000) + 1) / 10.0
+}
+
+// cvss31Scores 计算CVSS v3.x的基础、时间和环境评分
+func cvss31Scores(m map[string]string) (float64, float64, float64) {
+	av := map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}
+	ac := map[string]float64{"L": 0.77, "H": 0.44}
+	ui := map[string]float64{"N": 0.85, "R": 0.62}
+	cia := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	exploit := map[string]float64{"X": 1, "H": 1, "F": 0.97, "P": 0.94, "U": 0.91}
+	remediation := map[string]float64{"X": 1, "U": 1, "W": 0.97, "T": 0.96, "O": 0.95}
+	confidence := map[string]float64{"X": 1, "C": 1, "R": 0.96, "U": 0.92}
+	requirement := map[string]float64{"X": 1, "H": 1.5, "M": 1, "L": 0.5}
+
+	pr := func(value string, changed bool) float64 {
+		switch value {
+		case "N":
+			return 0.85
+		case "L":
+			if changed {
+				return 0.68
+			}
+			return 0.62
+		default:
+			if changed {
+				return 0.5
+			}
+			return 0.27
+		}
+	}
+
+	get := func(metric string) string {
+		if value, ok := m[metric]; ok {
+			return value
+		}
+		return "X"
+	}
+
+	// 修改后的指标，未指定时取对应的基础指标
+	modified := func(metric string) string {
+		if value := get("M" + metric); value != "X" {
+			return value
+		}
+		return m[metric]
+	}
+
+	// 基础评分
+	changed := m["S"] == "C"
+	iss := 1 - (1-cia[m["C"]])*(1-cia[m["I"]])*(1-cia[m["A"]])
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	exploitability := 8.22 * av[m["AV"]] * ac[m["AC"]] * pr(m["PR"], changed) * ui[m["UI"]]
+
+	var base float64
+	if impact > 0 {
+		if changed {
+			base = cvssRoundUp(math.Min(1.08*(impact+exploitability), 10))
+		} else {
+			base = cvssRoundUp(math.Min(impact+exploitability, 10))
+		}
+	}
+
+	// 时间评分
+	temporalFactor := exploit[get("E")] * remediation[get("RL")] * confidence[get("RC")]
+	temporal := cvssRoundUp(base * temporalFactor)
+
+	// 环境评分
+	modifiedChanged := modified("S") == "C"
+	miss := math.Min(1-
+		(1-requirement[get("CR")]*cia[modified("C")])*
+			(1-requirement[get("IR")]*cia[modified("I")])*
+			(1-requirement[get("AR")]*cia[modified("A")]), 0.915)
+	var modifiedImpact float64
+	if modifiedChanged {
+		modifiedImpact = 7.52*(miss-0.029) - 3.25*math.Pow(miss*0.9731-0.02, 13)
+	} else {
+		modifiedImpact = 6.42 * miss
+	}
+	modifiedExploitability := 8.22 * av[modified("AV")] * ac[modified("AC")] * pr(modified("PR"), modifiedChanged) * ui[modified("UI")]
+
+	var environmental float64
+	if modifiedImpact > 0 {
+		if modifiedChanged {
+			environmental = cvssRoundUp(cvssRoundUp(math.Min(1.08*(modifiedImpact+modifiedExploitability), 10)) * temporalFactor)
+		} else {
+			environmental = cvssRoundUp(cvssRoundUp(math.Min(modifiedImpact+modifiedExploitability, 10)) * temporalFactor)
+		}
+	}
+
+	return base, temporal, environmental
+}
+
+// cvss40Subset 截取CVSS v4.0向量中参与计算的指标
+// withThreat为true时保留威胁指标E，withEnv为true时保留环境指标
+func cvss40Subset(m map[string]string, withThreat, withEnv bool) map[string]string {
+	subset := make(map[string]string)
+	for _, def := range cvss40Metrics {
+		value, ok := m[def.Name]
+		if !ok {
+			continue
+		}
+		switch {
+		case def.Required:
+			subset[def.Name] = value
+		case def.Name == "E":
+			if withThreat {
+				subset[def.Name] = value
+			}
+		case withEnv:
+			subset[def.Name] = value
+		}
+	}
+	return subset
+}
+
+// cvss40Lookup CVSS v4.0 宏向量评分表（EQ1-EQ6）
+var cvss40Lookup = map[string]float64{
+	"000000": 10, "000001": 9.9, "000010": 9.8, "000011": 9.5, "000020": 9.5, "000021": 9.2,
+	"000100": 10, "000101": 9.6, "000110": 9.3, "000111": 8.7, "000120": 9.1, "000121": 8.1,
+	"000200": 9.3, "000201": 9, "000210": 8.9, "000211": 8, "000220": 8.1, "000221": 6.8,
+	"001000": 9.8, "001001": 9.5, "001010": 9.5, "001011": 9.2, "001020": 9, "001021": 8.4,
+	"001100": 9.3, "001101": 9.2, "001110": 8.9, "001111": 8.1, "001120": 8.1, "001121": 6.5,
+	"001200": 8.8, "001201": 8, "001210": 7.8, "001211": 7, "001220": 6.9, "001221": 4.8,
+	"002001": 9.2, "002011": 8.2, "002021": 7.2, "002101": 7.9, "002111": 6.9, "002121": 5,
+	"002201": 6.9, "002211": 5.5, "002221": 2.7,
+	"010000": 9.9, "010001": 9.7, "010010": 9.5, "010011": 9.2, "010020": 9.2, "010021": 8.5,
+	"010100": 9.5, "010101": 9.1, "010110": 9, "010111": 8.3, "010120": 8.4, "010121": 7.1,
+	"010200": 9.2, "010201": 8.1, "010210": 8.2, "010211": 7.1, "010220": 7.2, "010221": 5.3,
+	"011000": 9.5, "011001": 9.3, "011010": 9.2, "011011": 8.5, "011020": 8.5, "011021": 7.3,
+	"011100": 9.2, "011101": 8.2, "011110": 8, "011111": 7.2, "011120": 7, "011121": 5.9,
+	"011200": 8.4, "011201": 7, "011210": 7.1, "011211": 5.2, "011220": 5, "011221": 3,
+	"012001": 8.6, "012011": 7.5, "012021": 5.2, "012101": 7.1, "012111": 5.2, "012121": 2.9,
+	"012201": 6.3, "012211": 2.9, "012221": 1.7,
+	"100000": 9.8, "100001": 9.5, "100010": 9.4, "100011": 8.7, "100020": 9.1, "100021": 8.1,
+	"100100": 9.4, "100101": 8.9, "100110": 8.6, "100111": 7.4, "100120": 7.7, "100121": 6.4,
+	"100200": 8.7, "100201": 7.5, "100210": 7.4, "100211": 6.3, "100220": 6.3, "100221": 4.9,
+	"101000": 9.4, "101001": 8.9, "101010": 8.8, "101011": 7.7, "101020": 7.6, "101021": 6.7,
+	"101100": 8.6, "101101": 7.6, "101110": 7.4, "101111": 5.8, "101120": 5.9, "101121": 5,
+	"101200": 7.2, "101201": 5.7, "101210": 5.7, "101211": 5.2, "101220": 5.2, "101221": 2.5,
+	"102001": 8.3, "102011": 7, "102021": 5.4, "102101": 6.5, "102111": 5.8, "102121": 2.6,
+	"102201": 5.3, "102211": 2.1, "102221": 1.3,
+	"110000": 9.5, "110001": 9, "110010": 8.8, "110011": 7.6, "110020": 7.6, "110021": 7,
+	"110100": 9, "110101": 7.7, "110110": 7.5, "110111": 6.2, "110120": 6.1, "110121": 5.3,
+	"110200": 7.7, "110201": 6.6, "110210": 6.8, "110211": 5.9, "110220": 5.2, "110221": 3,
+	"111000": 8.9, "111001": 7.8, "111010": 7.6, "111011": 6.7, "111020": 6.2, "111021": 5.8,
+	"111100": 7.4, "111101": 5.9, "111110": 5.7, "111111": 5.7, "111120": 4.7, "111121": 2.3,
+	"111200": 6.1, "111201": 5.2, "111210": 5.7, "111211": 2.9, "111220": 2.4, "111221": 1.6,
+	"112001": 7.1, "112011": 5.9, "112021": 3, "112101": 5.8, "112111": 2.6, "112121": 1.5,
+	"112201": 2.3, "112211": 1.3, "112221": 0.6,
+	"200000": 9.3, "200001": 8.7, "200010": 8.6, "200011": 7.2, "200020": 7.5, "200021": 5.8,
+	"200100": 8.6, "200101": 7.4, "200110": 7.4, "200111": 6.1, "200120": 5.6, "200121": 3.4,
+	"200200": 7, "200201": 5.4, "200210": 5.2, "200211": 4, "200220": 4, "200221": 2.2,
+	"201000": 8.5, "201001": 7.5, "201010": 7.4, "201011": 5.5, "201020": 6.2, "201021": 5.1,
+	"201100": 7.2, "201101": 5.7, "201110": 5.5, "201111": 4.1, "201120": 4.6, "201121": 1.9,
+	"201200": 5.3, "201201": 3.6, "201210": 3.4, "201211": 1.9, "201220": 1.9, "201221": 0.8,
+	"202001": 6.4, "202011": 5.1, "202021": 2, "202101": 4.7, "202111": 2.1, "202121": 1.1,
+	"202201": 2.4, "202211": 0.9, "202221": 0.4,
+	"210000": 8.8, "210001": 7.5, "210010": 7.3, "210011": 5.3, "210020": 6, "210021": 5,
+	"210100": 7.3, "210101": 5.5, "210110": 5.9, "210111": 4, "210120": 4.1, "210121": 2,
+	"210200": 5.4, "210201": 4.3, "210210": 4.5, "210211": 2.2, "210220": 2, "210221": 1.1,
+	"211000": 7.5, "211001": 5.5, "211010": 5.8, "211011": 4.5, "211020": 4, "211021": 2.1,
+	"211100": 6.1, "211101": 5.1, "211110": 4.8, "211111": 1.8, "211120": 2, "211121": 0.9,
+	"211200": 4.6, "211201": 1.8, "211210": 1.7, "211211": 0.7, "211220": 0.8, "211221": 0.2,
+	"212001": 5.3, "212011": 2.4, "212021": 1.4, "212101": 2.4, "212111": 1.2, "212121": 0.5,
+	"212201": 1, "212211": 0.3, "212221": 0.1,
+}
+
+// cvss40MaxComposed 各等价类中严重程度最高的指标组合
+var cvss40MaxComposed = struct {
+	EQ1 map[int][]string
+	EQ2 map[int][]string
+	EQ3 map[int]map[int][]string
+	EQ4 map[int][]string
+	EQ5 map[int][]string
+}{
+	EQ1: map[int][]string{
+		0: {"AV:N/PR:N/UI:N/"},
+		1: {"AV:A/PR:N/UI:N/", "AV:N/PR:L/UI:N/", "AV:N/PR:N/UI:P/"},
+		2: {"AV:P/PR:N/UI:N/", "AV:A/PR:L/UI:P/"},
+	},
+	EQ2: map[int][]string{
+		0: {"AC:L/AT:N/"},
+		1: {"AC:H/AT:N/", "AC:L/AT:P/"},
+	},
+	EQ3: map[int]map[int][]string{
+		0: {
+			0: {"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H/"},
+			1: {"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H/", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M/"},
+		},
+		1: {
+			0: {"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H/", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H/"},
+			1: {"VC:L/VI:H/VA:L/CR:H/IR:M/AR:H/", "VC:L/VI:H/VA:H/CR:H/IR:M/AR:M/", "VC:H/VI:L/VA:H/CR:M/IR:H/AR:M/", "VC:H/VI:L/VA:L/CR:M/IR:H/AR:H/", "VC:L/VI:L/VA:H/CR:H/IR:H/AR:M/"},
+		},
+		2: {
+			1: {"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H/"},
+		},
+	},
+	EQ4: map[int][]string{
+		0: {"SC:H/SI:S/SA:S/"},
+		1: {"SC:H/SI:H/SA:H/"},
+		2: {"SC:L/SI:L/SA:L/"},
+	},
+	EQ5: map[int][]string{
+		0: {"E:A/"},
+		1: {"E:P/"},
+		2: {"E:U/"},
+	},
+}
+
+// cvss40MaxSeverity 各等价类内部的最大严重程度距离（以0.1为步长）
+var cvss40MaxSeverity = struct {
+	EQ1    map[int]float64
+	EQ2    map[int]float64
+	EQ3EQ6 map[int]map[int]float64
+	EQ4    map[int]float64
+}{
+	EQ1:    map[int]float64{0: 1, 1: 4, 2: 5},
+	EQ2:    map[int]float64{0: 1, 1: 2},
+	EQ3EQ6: map[int]map[int]float64{0: {0: 7, 1: 6}, 1: {0: 8, 1: 8}, 2: {1: 10}},
+	EQ4:    map[int]float64{0: 6, 1: 5, 2: 4},
+}
+
+// cvss40Levels 各指标取值对应的严重程度距离
+var cvss40Levels = map[string]map[string]float64{
+	"AV": {"N": 0.0, "A": 0.1, "L": 0.2, "P": 0.3},
+	"PR": {"N": 0.0, "L": 0.1, "H": 0.2},
+	"UI": {"N": 0.0, "P": 0.1, "A": 0.2},
+	"AC": {"L": 0.0, "H": 0.1},
+	"AT": {"N": 0.0, "P": 0.1},
+	"VC": {"H": 0.0, "L": 0.1, "N": 0.2},
+	"VI": {"H": 0.0, "L": 0.1, "N": 0.2},
+	"VA": {"H": 0.0, "L": 0.1, "N": 0.2},
+	"SC": {"H": 0.1, "L": 0.2, "N": 0.3},
+	"SI": {"S": 0.0, "H": 0.1, "L": 0.2, "N": 0.3},
+	"SA": {"S": 0.0, "H": 0.1, "L": 0.2, "N": 0.3},
+	"CR": {"H": 0.0, "M": 0.1, "L": 0.2},
+	"IR": {"H": 0.0, "M": 0.1, "L": 0.2},
+	"AR": {"H": 0.0, "M": 0.1, "L": 0.2},
+	"E":  {"U": 0.2, "P": 0.1, "A": 0.0},
+}
+
+// cvss40Score 按照CVSS v4.0规范计算评分
+func cvss40Score(m map[string]string) float64 {
+	// 取指标的有效值：优先使用修改后的环境指标，E和CR/IR/AR未指定时取最严重值
+	get := func(metric string) string {
+		if value, ok := m["M"+metric]; ok && value != "X" {
+			return value
+		}
+		value, ok := m[metric]
+		if !ok || value == "X" {
+			switch metric {
+			case "E":
+				return "A"
+			case "CR", "IR", "AR":
+				return "H"
+			}
+		}
+		return value
+	}
+
+	// 所有影响指标均为N时评分为0
+	if get("VC") == "N" && get("VI") == "N" && get("VA") == "N" &&
+		get("SC") == "N" && get("SI") == "N" && get("SA") == "N" {
+		return 0
+	}
+
+	// 计算宏向量
+	eq1, eq2, eq3, eq4, eq5, eq6 := 0, 0, 0, 0, 0, 0
+
+	av, pr, ui := get("AV"), get("PR"), get("UI")
+	switch {
+	case av == "N" && pr == "N" && ui == "N":
+		eq1 = 0
+	case (av == "N" || pr == "N" || ui == "N") && av != "P":
+		eq1 = 1
+	default:
+		eq1 = 2
+	}
+
+	if !(get("AC") == "L" && get("AT") == "N") {
+		eq2 = 1
+	}
+
+	vc, vi, va := get("VC"), get("VI"), get("VA")
+	switch {
+	case vc == "H" && vi == "H":
+		eq3 = 0
+	case vc == "H" || vi == "H" || va == "H":
+		eq3 = 1
+	default:
+		eq3 = 2
+	}
+
+	switch {
+	case get("SI") == "S" || get("SA") == "S":
+		eq4 = 0
+	case get("SC") == "H" || get("SI") == "H" || get("SA") == "H":
+		eq4 = 1
+	default:
+		eq4 = 2
+	}
+
+	switch get("E") {
+	case "A":
+		eq5 = 0
+	case "P":
+		eq5 = 1
+	default:
+		eq5 = 2
+	}
+
+	if !((get("CR") == "H" && vc == "H") || (get("IR") == "H" && vi == "H") || (get("AR") == "H" && va == "H")) {
+		eq6 = 1
+	}
+
+	lookup := func(e1, e2, e3, e4, e5, e6 int) (float64, bool) {
+		value, ok := cvss40Lookup[fmt.Sprintf("%d%d%d%d%d%d", e1, e2, e3, e4, e5, e6)]
+		return value, ok
+	}
+
+	value, ok := lookup(eq1, eq2, eq3, eq4, eq5, eq6)
+	if !ok {
+		return 0
+	}
+
+	// 各等价类下一个更低宏向量的评分
+	scoreEQ1, okEQ1 := lookup(eq1+1, eq2, eq3, eq4, eq5, eq6)
+	scoreEQ2, okEQ2 := lookup(eq1, eq2+1, eq3, eq4, eq5, eq6)
+
+	var scoreEQ3EQ6 float64
+	var okEQ3EQ6 bool
+	switch {
+	case eq3 == 0 && eq6 == 0:
+		left, okLeft := lookup(eq1, eq2, eq3, eq4, eq5, eq6+1)
+		right, okRight := lookup(eq1, eq2, eq3+1, eq4, eq5, eq6)
+		switch {
+		case okLeft && (!okRight || left > right):
+			scoreEQ3EQ6, okEQ3EQ6 = left, true
+		case okRight:
+			scoreEQ3EQ6, okEQ3EQ6 = right, true
+		}
+	case eq3 == 1 && eq6 == 0:
+		scoreEQ3EQ6, okEQ3EQ6 = lookup(eq1, eq2, eq3, eq4, eq5, eq6+1)
+	case eq6 == 1 && eq3 < 2:
+		scoreEQ3EQ6, okEQ3EQ6 = lookup(eq1, eq2, eq3+1, eq4, eq5, eq6)
+	}
+
+	scoreEQ4, okEQ4 := lookup(eq1, eq2, eq3, eq4+1, eq5, eq6)
+	_, okEQ5 := lookup(eq1, eq2, eq3, eq4, eq5+1, eq6)
+
+	// 查找当前向量所属的最高严重程度组合
+	var maxVectors []string
+	for _, v1 := range cvss40MaxComposed.EQ1[eq1] {
+		for _, v2 := range cvss40MaxComposed.EQ2[eq2] {
+			for _, v3 := range cvss40MaxComposed.EQ3[eq3][eq6] {
+				for _, v4 := range cvss40MaxComposed.EQ4[eq4] {
+					for _, v5 := range cvss40MaxComposed.EQ5[eq5] {
+						maxVectors = append(maxVectors, v1+v2+v3+v4+v5)
+					}
+				}
+			}
+		}
+	}
+
+	distance := make(map[string]float64)
+	for _, maxVector := range maxVectors {
+		maxMetrics := make(map[string]string)
+		for _, part := range strings.Split(strings.TrimSuffix(maxVector, "/"), "/") {
+			kv := strings.SplitN(part, ":", 2)
+			maxMetrics[kv[0]] = kv[1]
+		}
+
+		valid := true
+		for metric, levels := range cvss40Levels {
+			distance[metric] = levels[get(metric)] - levels[maxMetrics[metric]]
+			if distance[metric] < 0 {
+				valid = false
+			}
+		}
+		if valid {
+			break
+		}
+	}
+
+	const step = 0.1
+	currentEQ1 := distance["AV"] + distance["PR"] + distance["UI"]
+	currentEQ2 := distance["AC"] + distance["AT"]
+	currentEQ3EQ6 := distance["VC"] + distance["VI"] + distance["VA"] + distance["CR"] + distance["IR"] + distance["AR"]
+	currentEQ4 := distance["SC"] + distance["SI"] + distance["SA"]
+
+	existingLower := 0
+	normalized := 0.0
+	if okEQ1 {
+		existingLower++
+		normalized += (value - scoreEQ1) * (currentEQ1 / (cvss40MaxSeverity.EQ1[eq1] * step))
+	}
+	if okEQ2 {
+		existingLower++
+		normalized += (value - scoreEQ2) * (currentEQ2 / (cvss40MaxSeverity.EQ2[eq2] * step))
+	}
+	if okEQ3EQ6 {
+		existingLower++
+		normalized += (value - scoreEQ3EQ6) * (currentEQ3EQ6 / (cvss40MaxSeverity.EQ3EQ6[eq3][eq6] * step))
+	}
+	if okEQ4 {
+		existingLower++
+		normalized += (value - scoreEQ4) * (currentEQ4 / (cvss40MaxSeverity.EQ4[eq4] * step))
+	}
+	if okEQ5 {
+		// EQ5内部没有严重程度差异，仅参与平均值计算
+		existingLower++
+	}
+
+	if existingLower > 0 {
+		value -= normalized / float64(existingLower)
+	}
+
+	value = math.Max(0, math.Min(10, value))
+	return math.Round((value+1e-6)*10) / 10
+}
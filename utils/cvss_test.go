@@ -0,0 +1,110 @@
+package utils
+
+import "testing"
+
+func TestCalculateCVSS(t *testing.T) {
+	tests := []struct {
+		vector        string
+		base          float64
+		temporal      float64
+		environmental float64
+		severity      string
+	}{
+		// CVSS v3.x，取自FIRST规范示例和NVD公布的评分
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, 9.8, 9.8, "critical"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0, 10.0, 10.0, "critical"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, 6.1, 6.1, "medium"},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, 7.8, 7.8, "high"},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9, 5.9, 5.9, "medium"},
+		{"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 1.6, 1.6, 1.6, "low"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0.0, 0.0, 0.0, "info"},
+		{"CVSS:3.0/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 8.8, 8.8, 8.8, "high"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:U/RL:O/RC:U", 9.8, 7.8, 7.8, "high"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/CR:L/IR:L/AR:L", 9.8, 9.8, 8.0, "high"},
+
+		// CVSS v4.0，取自FIRST计算器
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.3, 9.3, 9.3, "critical"},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", 10.0, 10.0, 10.0, "critical"},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.5, 8.5, 8.5, "high"},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", 0.0, 0.0, 0.0, "info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			result, err := CalculateCVSS(tt.vector, nil)
+			if err != nil {
+				t.Fatalf("CalculateCVSS() error = %v", err)
+			}
+			if result.BaseScore != tt.base || result.TemporalScore != tt.temporal || result.EnvironmentalScore != tt.environmental {
+				t.Errorf("scores = %.1f/%.1f/%.1f, want %.1f/%.1f/%.1f",
+					result.BaseScore, result.TemporalScore, result.EnvironmentalScore, tt.base, tt.temporal, tt.environmental)
+			}
+			if result.Score != tt.environmental || result.Severity != tt.severity {
+				t.Errorf("score = %.1f (%s), want %.1f (%s)", result.Score, result.Severity, tt.environmental, tt.severity)
+			}
+		})
+	}
+}
+
+func TestCalculateCVSSDefaults(t *testing.T) {
+	// 资产重要性推导的环境指标只在向量未指定时生效
+	defaults := map[string]string{"CR": "L", "IR": "L", "AR": "L"}
+
+	result, err := CalculateCVSS("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", defaults)
+	if err != nil {
+		t.Fatalf("CalculateCVSS() error = %v", err)
+	}
+	if result.EnvironmentalScore != 8.0 {
+		t.Errorf("environmental score = %.1f, want 8.0", result.EnvironmentalScore)
+	}
+
+	result, err = CalculateCVSS("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/CR:H/IR:H/AR:H", defaults)
+	if err != nil {
+		t.Fatalf("CalculateCVSS() error = %v", err)
+	}
+	if result.EnvironmentalScore != 9.8 {
+		t.Errorf("environmental score with explicit requirements = %.1f, want 9.8", result.EnvironmentalScore)
+	}
+}
+
+func TestCalculateCVSSInvalid(t *testing.T) {
+	for _, vector := range []string{
+		"",
+		"AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"CVSS:2.0/AV:N/AC:L/Au:N/C:P/I:P/A:P",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"CVSS:3.1/AV:N/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/FOO:X",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A",
+		"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H",
+		"CVSS:4.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+	} {
+		if _, err := CalculateCVSS(vector, nil); err == nil {
+			t.Errorf("CalculateCVSS(%q) = nil error, want error", vector)
+		}
+	}
+}
+
+func TestCVSSSeverity(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "info"},
+		{0.1, "low"},
+		{3.9, "low"},
+		{4.0, "medium"},
+		{6.9, "medium"},
+		{7.0, "high"},
+		{8.9, "high"},
+		{9.0, "critical"},
+		{10.0, "critical"},
+	}
+
+	for _, tt := range tests {
+		if got := CVSSSeverity(tt.score); got != tt.want {
+			t.Errorf("CVSSSeverity(%.1f) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
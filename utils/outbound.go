@@ -0,0 +1,98 @@
+// 出站请求工具包
+// 该包为用户自行填写的推送地址提供出站请求限制，拒绝访问回环、内网和链路本地地址，防止服务端请求伪造
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress 目标地址不是公网地址
+var ErrNonPublicAddress = errors.New("不允许访问回环、内网或链路本地地址")
+
+// sharedAddressSpace 运营商级NAT地址段（100.64.0.0/10），同样不可从公网访问
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP 判断IP是否为可访问的公网地址
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (ip4[0] == 0 || sharedAddressSpace.Contains(ip4)) {
+		return false
+	}
+	return true
+}
+
+// ValidatePublicURL 校验地址为http/https协议且主机解析到的全部IP均为公网地址
+// 保存地址时调用以便及时提示，发送请求时仍由PublicHTTPClient在建立连接时校验，防止DNS解析结果变化
+func ValidatePublicURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("地址格式错误，仅支持http和https")
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(context.Background(), "ip", u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("无法解析主机: %s", u.Hostname())
+	}
+	for _, ip := range ips {
+		if !IsPublicIP(ip) {
+			return ErrNonPublicAddress
+		}
+	}
+	return nil
+}
+
+// publicDialControl 在建立连接前校验实际连接的IP，重定向后的连接同样经过该校验
+func publicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(net.ParseIP(host)) {
+		return ErrNonPublicAddress
+	}
+	return nil
+}
+
+// NewPublicHTTPClient 创建只能访问公网地址的HTTP客户端
+// 不使用环境变量中的代理，否则连接校验的是代理地址；重定向只允许http/https且最多5次
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: publicDialControl,
+	}
+	transport := &http.Transport{
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("重定向次数过多")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("重定向地址仅支持http和https")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"::", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"8.8.8.8:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"192.168.0.10:8080", true},
+		{"169.254.169.254:80", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := publicDialControl("tcp", tt.address, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("publicDialControl(%s) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePublicURLRejectsLiteralAddresses(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1/hook",
+		"http://[::1]:8080/hook",
+		"https://10.1.2.3/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"ftp://example.com/hook",
+		"not a url",
+	} {
+		if err := ValidatePublicURL(raw); err == nil {
+			t.Errorf("ValidatePublicURL(%q) = nil, want error", raw)
+		}
+	}
+}
+
+func TestPublicHTTPClientRejectsLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp, err := NewPublicHTTPClient(5 * time.Second).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to loopback server succeeded, want error")
+	}
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("error = %v, want ErrNonPublicAddress", err)
+	}
+}
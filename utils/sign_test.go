@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResourceSignerVerify(t *testing.T) {
+	signer := &ResourceSigner{secret: []byte("test-secret")}
+
+	query, err := url.ParseQuery(signer.Query("file:12", time.Hour))
+	if err != nil {
+		t.Fatalf("parse query: %v", err)
+	}
+	expires, signature := query.Get("expires"), query.Get("signature")
+
+	tampered := []byte(signature)
+	if tampered[0] == '0' {
+		tampered[0] = '1'
+	} else {
+		tampered[0] = '0'
+	}
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	expiredExpires := strconv.FormatInt(expired, 10)
+	expiredSignature := signer.sign("file:12", expired)
+
+	tests := []struct {
+		name      string
+		signer    *ResourceSigner
+		resource  string
+		expires   string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", signer, "file:12", expires, signature, false},
+		{"other resource", signer, "file:13", expires, signature, true},
+		{"other resource type", signer, "weekly_report:12", expires, signature, true},
+		{"other secret", &ResourceSigner{secret: []byte("other")}, "file:12", expires, signature, true},
+		{"tampered signature", signer, "file:12", expires, string(tampered), true},
+		{"extended expiry", signer, "file:12", strconv.FormatInt(time.Now().Add(48*time.Hour).Unix(), 10), signature, true},
+		{"expired", signer, "file:12", expiredExpires, expiredSignature, true},
+		{"empty signature", signer, "file:12", expires, "", true},
+		{"invalid expires", signer, "file:12", "abc", signature, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.resource, tt.expires, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamTicket(t *testing.T) {
+	signer := &ResourceSigner{secret: []byte("test-secret")}
+
+	ticket, _ := signer.StreamTicket(7, time.Minute)
+	userID, err := signer.VerifyStreamTicket(ticket)
+	if err != nil || userID != 7 {
+		t.Fatalf("VerifyStreamTicket(%q) = %d, %v, want 7, nil", ticket, userID, err)
+	}
+
+	expired, _ := signer.StreamTicket(7, -time.Minute)
+	foreign, _ := (&ResourceSigner{secret: []byte("other")}).StreamTicket(7, time.Minute)
+	parts := strings.SplitN(ticket, ".", 3)
+
+	tests := []struct {
+		name   string
+		ticket string
+	}{
+		{"expired", expired},
+		{"other user", "8." + parts[1] + "." + parts[2]},
+		{"file signature reused", "12." + parts[1] + "." + signer.sign("file:12", mustParseInt(t, parts[1]))},
+		{"other secret", foreign},
+		{"jwt-like token", "header.payload.signature"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := signer.VerifyStreamTicket(tt.ticket); err == nil {
+				t.Errorf("VerifyStreamTicket(%q) = nil, want error", tt.ticket)
+			}
+		})
+	}
+}
+
+func mustParseInt(t *testing.T, s string) int64 {
+	t.Helper()
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return v
+}
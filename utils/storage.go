@@ -0,0 +1,205 @@
+// 文件存储工具包
+// 该包定义上传文件和报表的存储后端接口，支持本地磁盘、S3兼容对象存储（MinIO等）和WebDAV，
+// 通过config.yml中的storage配置选择默认后端
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// 存储后端名称
+const (
+	StorageLocal  = "local"
+	StorageS3     = "s3"
+	StorageWebDAV = "webdav"
+)
+
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("文件不存在")
+
+// Storage 文件存储后端接口
+// key为相对路径形式的对象键，如 vuln-images/xxx.png、weekly/xxx.pdf
+type Storage interface {
+	// Name 返回后端名称，记录到FileStorage.Backend
+	Name() string
+	// Put 写入对象，size为-1时表示大小未知
+	Put(key string, reader io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的ReadCloser
+	Get(key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(key string) error
+	// Exists 判断对象是否存在
+	Exists(key string) (bool, error)
+	// Location 返回对象位置描述，本地存储为文件路径，远程存储为URI
+	Location(key string) string
+}
+
+var (
+	storages     = make(map[string]Storage)
+	storagesLock sync.Mutex
+)
+
+// GetStorage 根据名称获取存储后端实例，实例按名称缓存
+func GetStorage(name string) (Storage, error) {
+	if name == "" {
+		name = StorageLocal
+	}
+
+	storagesLock.Lock()
+	defer storagesLock.Unlock()
+
+	if s, ok := storages[name]; ok {
+		return s, nil
+	}
+
+	var s Storage
+	var err error
+	switch name {
+	case StorageLocal:
+		root := viper.GetString("storage.local.root")
+		if root == "" {
+			root = "uploads"
+		}
+		s = &LocalStorage{Root: root}
+	case StorageS3:
+		s, err = newS3StorageFromConfig()
+	case StorageWebDAV:
+		s, err = newWebDAVStorageFromConfig()
+	default:
+		err = fmt.Errorf("不支持的存储后端: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	storages[name] = s
+	return s, nil
+}
+
+// DefaultStorage 获取config.yml中storage.backend指定的默认存储后端，未配置时使用本地存储
+func DefaultStorage() (Storage, error) {
+	return GetStorage(viper.GetString("storage.backend"))
+}
+
+// normalizeKey 规范化对象键，统一使用正斜杠并拒绝跳出根目录的路径
+func normalizeKey(key string) (string, error) {
+	key = strings.TrimLeft(filepath.ToSlash(filepath.Clean("/"+key)), "/")
+	if key == "" || key == "." {
+		return "", errors.New("文件键不能为空")
+	}
+	return key, nil
+}
+
+// LocalStorage 本地磁盘存储
+type LocalStorage struct {
+	Root string // 存储根目录，默认uploads
+}
+
+// Name 返回后端名称
+func (s *LocalStorage) Name() string {
+	return StorageLocal
+}
+
+// path 将对象键转换为本地文件路径
+func (s *LocalStorage) path(key string) (string, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(key)), nil
+}
+
+// Put 写入本地文件，先写临时文件再重命名，避免读到写了一半的文件
+func (s *LocalStorage) Put(key string, reader io.Reader, size int64, contentType string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("创建存储目录失败: %v", err)
+	}
+
+	tmpPath := filePath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+
+	if _, err := io.Copy(dst, reader); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("保存文件失败: %v", err)
+	}
+
+	return nil
+}
+
+// Get 读取本地文件
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return file, err
+}
+
+// Delete 删除本地文件
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Exists 判断本地文件是否存在
+func (s *LocalStorage) Exists(key string) (bool, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Location 返回本地文件路径
+func (s *LocalStorage) Location(key string) string {
+	filePath, err := s.path(key)
+	if err != nil {
+		return key
+	}
+	return filePath
+}
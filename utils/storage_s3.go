@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// S3Storage S3兼容对象存储，支持AWS S3、MinIO等
+// 请求使用AWS Signature Version 4签名，不依赖第三方SDK
+type S3Storage struct {
+	Endpoint  string // 服务地址，如 http://127.0.0.1:9000
+	Region    string // 区域，MinIO默认为us-east-1
+	Bucket    string // 存储桶名称
+	AccessKey string // 访问密钥ID
+	SecretKey string // 访问密钥
+	PathStyle bool   // 是否使用路径风格访问（MinIO需要开启）
+	Prefix    string // 对象键前缀，多个系统共用存储桶时使用
+
+	client *http.Client
+}
+
+// newS3StorageFromConfig 根据config.yml中的storage.s3配置创建S3存储
+func newS3StorageFromConfig() (*S3Storage, error) {
+	viper.SetDefault("storage.s3.region", "us-east-1")
+	viper.SetDefault("storage.s3.path_style", true)
+
+	s := &S3Storage{
+		Endpoint:  strings.TrimRight(viper.GetString("storage.s3.endpoint"), "/"),
+		Region:    viper.GetString("storage.s3.region"),
+		Bucket:    viper.GetString("storage.s3.bucket"),
+		AccessKey: viper.GetString("storage.s3.access_key"),
+		SecretKey: viper.GetString("storage.s3.secret_key"),
+		PathStyle: viper.GetBool("storage.s3.path_style"),
+		Prefix:    strings.Trim(viper.GetString("storage.s3.prefix"), "/"),
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+
+	if s.Endpoint == "" || s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+		return nil, errors.New("S3存储配置不完整，请检查storage.s3的endpoint、bucket、access_key、secret_key")
+	}
+	if _, err := url.Parse(s.Endpoint); err != nil {
+		return nil, fmt.Errorf("S3服务地址格式错误: %v", err)
+	}
+
+	return s, nil
+}
+
+// Name 返回后端名称
+func (s *S3Storage) Name() string {
+	return StorageS3
+}
+
+// objectKey 生成带前缀的对象键
+func (s *S3Storage) objectKey(key string) (string, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.Prefix != "" {
+		key = s.Prefix + "/" + key
+	}
+	return key, nil
+}
+
+// objectURL 生成对象访问地址
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.PathStyle {
+		u.Path = "/" + s.Bucket + "/" + objectKey
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+		u.Path = "/" + objectKey
+	}
+	// 使用与签名一致的路径编码
+	u.RawPath = s3URIEncode(u.Path)
+	return u, nil
+}
+
+// s3URIEncode 按S3签名规则对路径进行编码，保留非保留字符和斜杠
+func s3URIEncode(path string) string {
+	var builder strings.Builder
+	for _, b := range []byte(path) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || b == '/' {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return builder.String()
+}
+
+// hmacSHA256 计算HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sign 为请求添加AWS Signature Version 4签名头
+// 请求体不参与签名（UNSIGNED-PAYLOAD），便于流式上传大文件
+func (s *S3Storage) sign(req *http.Request) {
+	s.signAt(req, time.Now().UTC(), "UNSIGNED-PAYLOAD")
+}
+
+// signAt 按指定时间和请求体哈希签名请求
+func (s *S3Storage) signAt(req *http.Request, now time.Time, payloadHash string) {
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest, signedHeaders := s3CanonicalRequest(req, payloadHash)
+	stringToSign := s.stringToSign(canonicalRequest, now)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, s.scope(now), signedHeaders, s.signature(stringToSign, now)))
+}
+
+// s3CanonicalRequest 生成规范请求，返回规范请求和参与签名的请求头列表
+// 参与签名的请求头为host、content-type、range和所有x-amz-*请求头
+func s3CanonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	return canonicalRequest, signedHeaders
+}
+
+// scope 签名范围：日期/区域/服务/aws4_request
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.Region + "/s3/aws4_request"
+}
+
+// stringToSign 生成待签名字符串
+func (s *S3Storage) stringToSign(canonicalRequest string, now time.Time) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	return "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + s.scope(now) + "\n" + hex.EncodeToString(requestHash[:])
+}
+
+// signature 用由密钥逐级派生的签名密钥计算签名
+func (s *S3Storage) signature(stringToSign string, now time.Time) string {
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretKey), now.Format("20060102"))
+	signingKey = hmacSHA256(signingKey, s.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+// do 构造、签名并发送请求
+func (s *S3Storage) do(method string, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && size >= 0 {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req)
+
+	return s.client.Do(req)
+}
+
+// responseError 读取S3错误响应
+func responseError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s失败: HTTP %d %s", action, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// Put 上传对象
+func (s *S3Storage) Put(key string, reader io.Reader, size int64, contentType string) error {
+	// 大小未知时先读入内存，S3的PUT请求需要Content-Length
+	if size < 0 {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("读取文件失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+
+	resp, err := s.do(http.MethodPut, key, reader, size, contentType)
+	if err != nil {
+		return fmt.Errorf("上传对象失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError("上传对象", resp)
+	}
+	return nil
+}
+
+// Get 下载对象
+func (s *S3Storage) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("下载对象失败: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError("下载对象", resp)
+	}
+	return resp.Body, nil
+}
+
+// Delete 删除对象
+func (s *S3Storage) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return fmt.Errorf("删除对象失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError("删除对象", resp)
+	}
+	return nil
+}
+
+// Exists 判断对象是否存在
+func (s *S3Storage) Exists(key string) (bool, error) {
+	resp, err := s.do(http.MethodHead, key, nil, 0, "")
+	if err != nil {
+		return false, fmt.Errorf("查询对象失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("查询对象失败: HTTP %d", resp.StatusCode)
+	}
+}
+
+// Location 返回对象URI
+func (s *S3Storage) Location(key string) string {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return key
+	}
+	return "s3://" + s.Bucket + "/" + objectKey
+}
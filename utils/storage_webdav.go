@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// WebDAVStorage WebDAV存储，适用于NAS、Nextcloud等支持WebDAV协议的服务
+type WebDAVStorage struct {
+	BaseURL  string // WebDAV根地址，如 https://dav.example.com/remote.php/dav/files/vulnmain
+	Username string // 用户名
+	Password string // 密码
+
+	client *http.Client
+}
+
+// newWebDAVStorageFromConfig 根据config.yml中的storage.webdav配置创建WebDAV存储
+func newWebDAVStorageFromConfig() (*WebDAVStorage, error) {
+	s := &WebDAVStorage{
+		BaseURL:  strings.TrimRight(viper.GetString("storage.webdav.url"), "/"),
+		Username: viper.GetString("storage.webdav.username"),
+		Password: viper.GetString("storage.webdav.password"),
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}
+
+	if s.BaseURL == "" {
+		return nil, errors.New("WebDAV存储配置不完整，请检查storage.webdav.url")
+	}
+	if _, err := url.Parse(s.BaseURL); err != nil {
+		return nil, fmt.Errorf("WebDAV地址格式错误: %v", err)
+	}
+
+	return s, nil
+}
+
+// Name 返回后端名称
+func (s *WebDAVStorage) Name() string {
+	return StorageWebDAV
+}
+
+// objectURL 生成对象地址，逐段编码路径
+func (s *WebDAVStorage) objectURL(key string) (string, error) {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.BaseURL + "/" + strings.Join(segments, "/"), nil
+}
+
+// do 发送WebDAV请求
+func (s *WebDAVStorage) do(method string, target string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && size >= 0 {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	return s.client.Do(req)
+}
+
+// mkdirAll 逐级创建父目录，目录已存在时服务端返回405，忽略即可
+func (s *WebDAVStorage) mkdirAll(key string) error {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return err
+	}
+
+	segments := strings.Split(key, "/")
+	current := s.BaseURL
+	for _, segment := range segments[:len(segments)-1] {
+		current += "/" + url.PathEscape(segment)
+		resp, err := s.do("MKCOL", current+"/", nil, 0, "")
+		if err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("创建目录失败: HTTP %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// Put 上传文件
+func (s *WebDAVStorage) Put(key string, reader io.Reader, size int64, contentType string) error {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	if err := s.mkdirAll(key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodPut, target, reader, size, contentType)
+	if err != nil {
+		return fmt.Errorf("上传文件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return responseError("上传文件", resp)
+	}
+	return nil
+}
+
+// Get 下载文件
+func (s *WebDAVStorage) Get(key string) (io.ReadCloser, error) {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodGet, target, nil, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError("下载文件", resp)
+	}
+	return resp.Body, nil
+}
+
+// Delete 删除文件
+func (s *WebDAVStorage) Delete(key string) error {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodDelete, target, nil, 0, "")
+	if err != nil {
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError("删除文件", resp)
+	}
+	return nil
+}
+
+// Exists 判断文件是否存在
+func (s *WebDAVStorage) Exists(key string) (bool, error) {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.do(http.MethodHead, target, nil, 0, "")
+	if err != nil {
+		return false, fmt.Errorf("查询文件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("查询文件失败: HTTP %d", resp.StatusCode)
+	}
+}
+
+// Location 返回文件地址
+func (s *WebDAVStorage) Location(key string) string {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return key
+	}
+	return target
+}
@@ -532,10 +532,6 @@ export default function ProjectDetailPage() {
           Toast.error('请选择研发工程师');
           return;
         }
-        if (!values.fix_deadline) {
-          Toast.error('请选择修复期限');
-          return;
-        }
       }
 
       const vulnData: VulnCreateRequest | VulnUpdateRequest = {
@@ -585,10 +581,6 @@ export default function ProjectDetailPage() {
           Toast.error('请选择研发工程师');
           return;
         }
-        if (!vulnData.fix_deadline) {
-          Toast.error('修复期限不能为空');
-          return;
-        }
         await vulnApi.createVuln({
           ...vulnData,
           project_id: parseInt(projectId),
@@ -1929,10 +1921,9 @@ export default function ProjectDetailPage() {
           <Form.DatePicker
             field="fix_deadline"
             label="修复期限"
-            placeholder="请选择修复期限"
+            placeholder="可选，审核通过后按SLA策略计算"
             type="date"
             rules={[
-              { required: true, message: '请选择修复期限' },
               {
                 validator: (rule, value, callback) => {
                   if (!value) {
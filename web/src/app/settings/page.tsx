@@ -450,10 +450,15 @@ export default function SettingsPage() {
   };
 
   // 预览周报
-  const handlePreviewReport = async (fileName: string) => {
+  const handlePreviewReport = async (id: number) => {
     try {
-      // 构建后端API静态文件URL
-      const pdfUrl = resolveImageUrl(`/uploads/weekly/${fileName}`);
+      // 获取短时效签名链接，周报文件不再通过静态目录公开访问
+      const response = await weeklyReportApi.getWeeklyReportSignedUrl(id);
+      if (response.code !== 200 || !response.data) {
+        Toast.error(response.msg || '预览周报失败');
+        return;
+      }
+      const pdfUrl = resolveImageUrl(response.data.url);
 
       // 设置预览URL并打开模态框
       setPreviewPdfUrl(pdfUrl);
@@ -471,10 +476,15 @@ export default function SettingsPage() {
   };
 
   // 下载周报
-  const handleDownloadReport = async (fileName: string) => {
+  const handleDownloadReport = async (id: number, fileName: string) => {
     try {
-      // 构建后端API静态文件URL
-      const url = resolveImageUrl(`/uploads/weekly/${fileName}`);
+      // 获取短时效签名链接，周报文件不再通过静态目录公开访问
+      const response = await weeklyReportApi.getWeeklyReportSignedUrl(id);
+      if (response.code !== 200 || !response.data) {
+        Toast.error(response.msg || '下载周报失败');
+        return;
+      }
+      const url = resolveImageUrl(`${response.data.url}&download=1`);
       const a = document.createElement('a');
       a.href = url;
       a.download = fileName;
@@ -689,7 +699,7 @@ export default function SettingsPage() {
                           theme="borderless"
                           type="primary"
                           size="small"
-                          onClick={() => handlePreviewReport(record.file_name)}
+                          onClick={() => handlePreviewReport(record.id)}
                         >
                           预览
                         </Button>
@@ -697,7 +707,7 @@ export default function SettingsPage() {
                           theme="borderless"
                           type="secondary"
                           size="small"
-                          onClick={() => handleDownloadReport(record.file_name)}
+                          onClick={() => handleDownloadReport(record.id, record.file_name)}
                         >
                           下载
                         </Button>
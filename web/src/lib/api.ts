@@ -188,8 +188,134 @@ export const systemApi = {
     const response = await api.post('/system/email/test', data);
     return response.data;
   },
+
+  // 获取CVE情报库状态和导入记录
+  getCVEFeedStatus: async (): Promise<ApiResponse<CVEFeedStatus>> => {
+    const response = await api.get('/system/cve-feeds');
+    return response.data;
+  },
+
+  // 立即更新CVE情报：从内部镜像下载并导入数据源目录中变化的文件
+  refreshCVEFeeds: async (): Promise<ApiResponse<CVEFeedRefreshResult>> => {
+    const response = await api.post('/system/cve-feeds/refresh');
+    return response.data;
+  },
+
+  // 重新计算全部漏洞的风险优先级评分
+  recalculateRiskScores: async (): Promise<ApiResponse<{ updated: number }>> => {
+    const response = await api.post('/system/risk-scores/recalculate');
+    return response.data;
+  },
+
+  // 获取全部漏洞分类树（含已禁用）
+  getVulnCategories: async (): Promise<ApiResponse<VulnCategory[]>> => {
+    const response = await api.get('/system/vuln-categories');
+    return response.data;
+  },
+
+  // 创建漏洞分类
+  createVulnCategory: async (data: VulnCategoryRequest): Promise<ApiResponse<VulnCategory>> => {
+    const response = await api.post('/system/vuln-categories', data);
+    return response.data;
+  },
+
+  // 更新漏洞分类
+  updateVulnCategory: async (id: number, data: VulnCategoryRequest): Promise<ApiResponse<VulnCategory>> => {
+    const response = await api.put(`/system/vuln-categories/${id}`, data);
+    return response.data;
+  },
+
+  // 删除漏洞分类
+  deleteVulnCategory: async (id: number): Promise<ApiResponse<null>> => {
+    const response = await api.delete(`/system/vuln-categories/${id}`);
+    return response.data;
+  },
+
+  // 为未归类的漏洞重新匹配分类
+  classifyVulns: async (): Promise<ApiResponse<{ classified: number }>> => {
+    const response = await api.post('/system/vuln-categories/classify');
+    return response.data;
+  },
+
+  // 上传并导入NVD、KEV或EPSS数据源文件
+  uploadCVEFeed: async (file: File): Promise<ApiResponse<CVEFeedRefreshResult>> => {
+    const formData = new FormData();
+    formData.append('file', file);
+
+    const response = await api.post('/system/cve-feeds/upload', formData, {
+      headers: {
+        'Content-Type': undefined, // 让axios自动设置multipart/form-data
+      },
+    });
+    return response.data;
+  },
 };
 
+// CVE情报
+export interface CVEIntel {
+  cve_id: string;
+  record?: {
+    cve_id: string;
+    description: string;
+    cwe_ids: string;
+    cvss_vector: string;
+    cvss_score: number;
+    cvss_severity: string;
+    references: string;
+    published_at?: string;
+    last_modified_at?: string;
+  } | null;
+  kev?: {
+    cve_id: string;
+    vendor_project: string;
+    product: string;
+    vulnerability_name: string;
+    short_description: string;
+    required_action: string;
+    date_added?: string;
+    due_date?: string;
+    known_ransomware: boolean;
+  } | null;
+  epss?: {
+    cve_id: string;
+    score: number;
+    percentile: number;
+    score_date?: string;
+  } | null;
+}
+
+export interface CVEFeedImport {
+  id: number;
+  source: 'nvd' | 'kev' | 'epss';
+  file_name: string;
+  file_size: number;
+  file_mod_time: string;
+  status: 'success' | 'failed';
+  records: number;
+  error: string;
+  started_at: string;
+  finished_at?: string;
+}
+
+export interface CVEFeedStatus {
+  enabled: boolean;
+  directory: string;
+  mirror_url: string;
+  cve_count: number;
+  kev_count: number;
+  epss_count: number;
+  files: { name: string; source: string; size: number; mod_time: string }[];
+  imports: CVEFeedImport[];
+}
+
+export interface CVEFeedRefreshResult {
+  downloaded: string[];
+  imports: CVEFeedImport[];
+  skipped: string[];
+  errors: string[];
+  vulns_enriched: number;
+}
+
 // API 函数
 export const authApi = {
   // 用户登录
@@ -233,6 +359,80 @@ export interface DashboardData {
   dev_engineer_ranking?: EngineerRankingItem[];
   latest_vulns: VulnListItem[];
   current_user_vulns?: UserVulnStats;
+  top_risk_vulns: RiskVulnItem[];
+  category_stats: VulnCategoryStat[];
+}
+
+// 漏洞分类（第一级为OWASP Top 10类别，下级为CWE）
+export interface VulnCategory {
+  id: number;
+  name: string;
+  code: string;
+  description: string;
+  cwe_id: string;
+  owasp: string;
+  aliases: string;
+  parent_id?: number;
+  children: VulnCategory[];
+  level: number;
+  sort: number;
+  status: number;
+  created_at: string;
+  updated_at: string;
+}
+
+// 创建或更新漏洞分类请求
+export interface VulnCategoryRequest {
+  name: string;
+  code: string;
+  description?: string;
+  cwe_id?: string;
+  owasp?: string;
+  aliases?: string;
+  parent_id?: number;
+  sort?: number;
+  status?: number;
+}
+
+// 漏洞分类统计，total和open包含全部下级分类的漏洞，category_id为0表示未分类
+export interface VulnCategoryStat {
+  category_id: number;
+  name: string;
+  code: string;
+  cwe_id: string;
+  owasp: string;
+  level: number;
+  count: number;
+  total: number;
+  open: number;
+  children?: VulnCategoryStat[];
+}
+
+// 风险优先级评分因子
+export interface VulnRiskFactor {
+  code: 'severity' | 'kev' | 'epss' | 'importance' | 'environment' | 'exposure';
+  label: string;
+  value: string;
+  points: number;
+  max_points: number;
+}
+
+// 优先修复的漏洞
+export interface RiskVulnItem {
+  id: number;
+  title: string;
+  severity: string;
+  status: string;
+  risk_score: number;
+  risk_level: 'P1' | 'P2' | 'P3' | 'P4';
+  cve_id: string;
+  in_kev: boolean;
+  epss_score: number;
+  asset_name: string;
+  project_name: string;
+  assignee_name: string;
+  fix_deadline?: string;
+  factors: VulnRiskFactor[];
 }
 
 export interface EngineerRankingItem {
@@ -503,7 +703,7 @@ export const userApi = {
   },
 
   // 上传漏洞图片
-  uploadVulnImage: async (file: File): Promise<ApiResponse<{ image_url: string }>> => {
+  uploadVulnImage: async (file: File): Promise<ApiResponse<{ image_url: string; file_id: number }>> => {
     const formData = new FormData();
     formData.append('image', file);
 
@@ -576,6 +776,12 @@ export const weeklyReportApi = {
     const response = await api.post('/system/weekly-report/generate');
     return response.data;
   },
+
+  // 获取历史周报文件的短时效签名链接
+  getWeeklyReportSignedUrl: async (id: number): Promise<ApiResponse<{ url: string; expire_seconds: number }>> => {
+    const response = await api.get(`/system/weekly-report/file/${id}/signed-url`);
+    return response.data;
+  },
 };
 
 // 项目类型定义
@@ -744,9 +950,19 @@ export interface Vulnerability {
   vuln_url?: string;
   description: string;
   vuln_type: string;
+  category_id?: number;
+  category?: VulnCategory;
   severity: string;
   status: string;
   cve_id?: string;
+  cwe_id?: string;
+  in_kev?: boolean;
+  epss_score?: number;
+  epss_percentile?: number;
+  enriched_at?: string;
+  risk_score?: number;
+  risk_level?: 'P1' | 'P2' | 'P3' | 'P4';
+  risk_breakdown?: VulnRiskFactor[];
   fix_suggestion?: string;
   project_id: number;
   project: Project;
@@ -776,12 +992,129 @@ export interface Vulnerability {
   resubmitted_by?: number;
   resubmitter?: User;
   fix_deadline?: string;
+  sla_policy_id?: number;
+  sla_days?: number;
+  sla_started_at?: string;
+  sla_paused_at?: string;
+  sla_paused_seconds?: number;
+  sla_breached?: boolean;
+  sla_breached_at?: string;
+  remediation_seconds?: number;
+  fingerprint?: string;
+  duplicate_of_id?: number;
+  regression_of_id?: number;
+  relations?: VulnRelationGraph;
+  knowledge?: KnowledgeArticleRef;
   retest_result?: string;
   tags?: string;
   created_at: string;
   updated_at: string;
 }
 
+// 知识库文章类型定义
+export interface KnowledgeArticle {
+  id: number;
+  title: string;
+  category_id: number | null;
+  category?: VulnCategory;
+  cwe_id: string;
+  vuln_types: string;
+  description: string;
+  impact: string;
+  remediation: string;
+  fix_examples: string;
+  references: string;
+  tags: string;
+  status: 'draft' | 'published';
+  version: number;
+  author_id: number;
+  author?: User;
+  updated_by: number;
+  editor?: User;
+  created_at: string;
+  updated_at: string;
+}
+
+// 知识库文章历史版本
+export interface KnowledgeArticleVersion extends Omit<KnowledgeArticle, 'author_id' | 'author' | 'updated_by' | 'updated_at'> {
+  article_id: number;
+  change_note: string;
+  editor_id: number;
+}
+
+// 漏洞详情中关联的知识库文章
+export interface KnowledgeArticleRef {
+  id: number;
+  title: string;
+  version: number;
+}
+
+export interface KnowledgeArticleRequest {
+  title: string;
+  category_id?: number | null;
+  cwe_id?: string;
+  vuln_types?: string;
+  description?: string;
+  impact?: string;
+  remediation: string;
+  fix_examples?: string;
+  references?: string;
+  tags?: string;
+  status?: 'draft' | 'published';
+  change_note?: string;
+}
+
+export interface KnowledgeListParams {
+  page?: number;
+  page_size?: number;
+  keyword?: string;
+  category_id?: number;
+  cwe_id?: string;
+  status?: string;
+}
+
+// 漏洞关系类型定义
+export interface VulnRelation {
+  id: number;
+  source_id: number;
+  target_id: number;
+  type: 'duplicate_of' | 'caused_by' | 'blocks' | 'child_of';
+  comment: string;
+  created_by: number;
+  creator?: User;
+  created_at: string;
+}
+
+// 漏洞关系图类型定义
+export interface VulnRelationGraph {
+  nodes: {
+    id: number;
+    title: string;
+    status: string;
+    severity: string;
+    asset_id: number;
+    project_id: number;
+  }[];
+  edges: VulnRelation[];
+}
+
+// 疑似重复漏洞类型定义
+export interface VulnDuplicateCandidate {
+  vuln_id: number;
+  title: string;
+  vuln_url: string;
+  vuln_type: string;
+  cve_id: string;
+  severity: string;
+  status: string;
+  project_id: number;
+  completed_at?: string;
+  similarity: number;
+  exact: boolean;
+  regression: boolean;
+  reasons: string[];
+}
+
 // 漏洞时间线类型定义
 export interface VulnTimeline {
   id: number;
@@ -910,14 +1243,72 @@ export interface VulnCreateRequest {
   vuln_type: string;
   severity: string;
   cve_id?: string;
-  fix_suggestion: string; // 修复建议改为必填
+  fix_suggestion?: string; // 未填写时使用匹配的知识库文章的修复建议
   project_id: number;
   asset_id: number;
   assignee_id: number; // 指派给改为必填
-  fix_deadline: string; // 修复截止时间改为必填
+  fix_deadline?: string; // 修复截止时间可选，审核通过后按SLA策略计算
+  tags?: string;
+  category_id?: number; // 漏洞分类可选，未指定时按漏洞类型和CWE编号自动归类
+  template_id?: number; // 漏洞模板可选，未填写的字段使用模板内容
+}
+
+// 漏洞模板类型定义，文本字段支持{{asset.name}}、{{vuln_url}}等占位符
+export interface FindingTemplate {
+  id: number;
+  name: string;
+  scope: 'personal' | 'shared';
+  owner_id: number;
+  owner?: User;
+  title: string;
+  vuln_type: string;
+  category_id: number | null;
+  category?: VulnCategory;
+  severity: string;
+  cvss_vector: string;
+  cvss_score: number;
+  description: string;
+  poc: string;
+  solution: string;
+  fix_suggestion: string;
+  references: string;
+  tags: string;
+  source_vuln_id: number | null;
+  usage_count: number;
+  last_used_at: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface FindingTemplateRequest {
+  name: string;
+  scope?: 'personal' | 'shared';
+  title: string;
+  vuln_type: string;
+  category_id?: number | null;
+  severity?: string;
+  cvss_vector?: string;
+  cvss_score?: number;
+  description?: string;
+  poc?: string;
+  solution?: string;
+  fix_suggestion?: string;
+  references?: string;
   tags?: string;
 }
 
+// 漏洞模板使用统计
+export interface FindingTemplateStat {
+  template_id: number;
+  name: string;
+  scope: 'personal' | 'shared';
+  owner_name: string;
+  usage_count: number;
+  recent_count: number;
+  recent_users: number;
+  last_used_at: string | null;
+}
+
 // 漏洞更新请求类型
 export interface VulnUpdateRequest {
   title?: string;
@@ -938,6 +1329,8 @@ export interface VulnUpdateRequest {
   comment?: string;
   resubmitted_at?: string;
   resubmitted_by?: number;
+  cascade_children?: boolean;
+  category_id?: number; // 传0时按漏洞类型和CWE编号重新自动归类
 }
 
 // 资产创建请求类型
@@ -981,6 +1374,36 @@ export const vulnApi = {
     return response.data;
   },
 
+  // 获取漏洞列表，sort_by为risk_score时按风险优先级评分排序
+  getVulnList: async (params: {
+    page: number;
+    page_size: number;
+    keyword?: string;
+    severity?: string;
+    status?: string;
+    project_id?: number;
+    assignee_id?: number;
+    risk_level?: 'P1' | 'P2' | 'P3' | 'P4';
+    min_risk_score?: number;
+    sort_by?: 'created_at' | 'risk_score';
+    category_id?: number; // 包含下级分类的漏洞
+  }): Promise<ApiResponse<{ vulns: Vulnerability[]; total: number; page: number; page_size: number; total_pages: number }>> => {
+    const response = await api.get('/vulns', { params });
+    return response.data;
+  },
+
+  // 获取风险优先级可选项
+  getRiskLevels: async (): Promise<ApiResponse<{ code: string; label: string }[]>> => {
+    const response = await api.get('/vulns/risk-levels');
+    return response.data;
+  },
+
+  // 获取启用的漏洞分类树
+  getCategories: async (): Promise<ApiResponse<VulnCategory[]>> => {
+    const response = await api.get('/vulns/categories');
+    return response.data;
+  },
+
   // 获取漏洞详情
   getVuln: async (id: number): Promise<ApiResponse<Vulnerability>> => {
     const response = await api.get(`/vulns/${id}`);
@@ -1034,6 +1457,286 @@ export const vulnApi = {
     const response = await api.put(`/vulns/${id}/ignore`, { ignore_reason: reason });
     return response.data;
   },
+
+  // 提交前检查疑似重复漏洞
+  checkDuplicates: async (data: { asset_id: number; vuln_url?: string; vuln_type?: string; cve_id?: string; title?: string }): Promise<ApiResponse<VulnDuplicateCandidate[]>> => {
+    const response = await api.post('/vulns/duplicates/check', data);
+    return response.data;
+  },
+
+  // 获取疑似重复漏洞
+  getVulnDuplicates: async (id: number): Promise<ApiResponse<VulnDuplicateCandidate[]>> => {
+    const response = await api.get(`/vulns/${id}/duplicates`);
+    return response.data;
+  },
+
+  // 将重复漏洞合并到目标漏洞
+  mergeVuln: async (id: number, targetId: number): Promise<ApiResponse<Vulnerability>> => {
+    const response = await api.post(`/vulns/${id}/merge`, { target_id: targetId });
+    return response.data;
+  },
+
+  // 按漏洞ID或漏洞列表的筛选条件导出漏洞（Excel或CSV），vuln_ids为空时导出筛选结果
+  exportVulns: async (data: {
+    vuln_ids?: number[];
+    format?: 'xlsx' | 'csv';
+    [filter: string]: string | number | number[] | undefined;
+  }): Promise<Blob> => {
+    const response = await api.post('/vulns/export', data, {
+      responseType: 'blob',
+    });
+    return response.data;
+  },
+
+  // 下载漏洞导入模板
+  downloadImportTemplate: async (): Promise<Blob> => {
+    const response = await api.get('/vulns/import/template', {
+      responseType: 'blob',
+    });
+    return response.data;
+  },
+
+  // 从Excel或CSV批量导入漏洞，dryRun为true时只校验不创建
+  importVulns: async (file: File, dryRun = false): Promise<ApiResponse<{
+    dry_run: boolean;
+    success_count: number;
+    failure_count: number;
+    errors: string[];
+    vuln_ids: number[];
+  }>> => {
+    const formData = new FormData();
+    formData.append('file', file);
+    formData.append('dry_run', String(dryRun));
+
+    const response = await api.post('/vulns/import', formData, {
+      headers: {
+        'Content-Type': undefined, // 让axios自动设置multipart/form-data
+      },
+    });
+    return response.data;
+  },
+
+  // 导入扫描结果（Nessus、OpenVAS、Burp Suite、Nuclei、OWASP ZAP、SARIF），dry_run为true时仅预览
+  importScanResults: async (file: File, options: {
+    project_id: number;
+    format?: 'nessus' | 'openvas' | 'burp' | 'nuclei' | 'zap' | 'sarif';
+    dry_run?: boolean;
+    auto_create_assets?: boolean;
+    include_info?: boolean;
+    asset_id?: number;
+    assignee_id?: number;
+    tags?: string;
+  }): Promise<ApiResponse<{
+    format: string;
+    source: string;
+    dry_run: boolean;
+    total: number;
+    created: number;
+    duplicates: number;
+    skipped_info: number;
+    failed: number;
+    assets_created: number;
+    rows: {
+      row: number;
+      title: string;
+      host: string;
+      vuln_url: string;
+      severity: string;
+      cve_id: string;
+      asset_id: number;
+      asset_name: string;
+      asset_created: boolean;
+      status: 'created' | 'ready' | 'duplicate' | 'failed';
+      vuln_id?: number;
+      duplicate_of?: number;
+      message?: string;
+    }[];
+  }>> => {
+    const formData = new FormData();
+    formData.append('file', file);
+    Object.entries(options).forEach(([key, value]) => {
+      if (value !== undefined) {
+        formData.append(key, String(value));
+      }
+    });
+
+    const response = await api.post('/vulns/import/scan', formData, {
+      headers: {
+        'Content-Type': undefined, // 让axios自动设置multipart/form-data
+      },
+    });
+    return response.data;
+  },
+
+  // 查询本地CVE情报（NVD、CISA KEV、EPSS）
+  getCVEIntel: async (cveId: string): Promise<ApiResponse<CVEIntel>> => {
+    const response = await api.get(`/vulns/cve-intel/${encodeURIComponent(cveId)}`);
+    return response.data;
+  },
+
+  // 根据本地CVE情报补充漏洞信息，只填充空白字段
+  enrichVuln: async (id: number): Promise<ApiResponse<{ vuln: Vulnerability; notes: string[] }>> => {
+    const response = await api.post(`/vulns/${id}/enrich`);
+    return response.data;
+  },
+
+  // 批量操作漏洞，ids与filter二选一
+  bulkUpdateVulns: async (data: {
+    action: 'assign' | 'severity' | 'status' | 'add_tag' | 'deadline' | 'delete';
+    ids?: number[];
+    filter?: Record<string, string | number>;
+    assignee_id?: number;
+    severity?: string;
+    status?: string;
+    reason?: string;
+    cascade_children?: boolean;
+    tag?: string;
+    fix_deadline?: string;
+  }): Promise<ApiResponse<{
+    action: string;
+    total: number;
+    succeeded: number;
+    failed: number;
+    items: { vuln_id: number; title: string; success: boolean; error?: string }[];
+  }>> => {
+    const response = await api.post('/vulns/bulk', data);
+    return response.data;
+  },
+
+  // 获取漏洞关系图
+  getVulnRelations: async (id: number): Promise<ApiResponse<VulnRelationGraph>> => {
+    const response = await api.get(`/vulns/${id}/relations`);
+    return response.data;
+  },
+
+  // 添加漏洞关系
+  createVulnRelation: async (id: number, data: { target_id: number; type: string; comment?: string }): Promise<ApiResponse<VulnRelation>> => {
+    const response = await api.post(`/vulns/${id}/relations`, data);
+    return response.data;
+  },
+
+  // 移除漏洞关系
+  deleteVulnRelation: async (id: number, relationId: number): Promise<ApiResponse> => {
+    const response = await api.delete(`/vulns/${id}/relations/${relationId}`);
+    return response.data;
+  },
+
+  // 将漏洞拆分为多个资产上的子漏洞
+  splitVuln: async (id: number, items: { asset_id: number; title?: string; vuln_url?: string; assignee_id?: number }[]): Promise<ApiResponse<Vulnerability[]>> => {
+    const response = await api.post(`/vulns/${id}/split`, { items });
+    return response.data;
+  },
+
+  // 获取可用的漏洞模板，scope可选personal、shared、mine
+  getTemplates: async (params?: { page?: number; page_size?: number; keyword?: string; scope?: string; vuln_type?: string }): Promise<ApiResponse<{ templates: FindingTemplate[]; total: number; page: number; page_size: number }>> => {
+    const response = await api.get('/vulns/templates', { params });
+    return response.data;
+  },
+
+  // 获取模板支持的占位符
+  getTemplatePlaceholders: async (): Promise<ApiResponse<{ code: string; label: string }[]>> => {
+    const response = await api.get('/vulns/templates/placeholders');
+    return response.data;
+  },
+
+  // 获取模板使用统计
+  getTemplateStats: async (days = 30): Promise<ApiResponse<{ days: number; templates: FindingTemplateStat[]; total_usage: number; recent_usage: number }>> => {
+    const response = await api.get('/vulns/templates/stats', { params: { days } });
+    return response.data;
+  },
+
+  // 获取漏洞模板详情
+  getTemplate: async (id: number): Promise<ApiResponse<FindingTemplate>> => {
+    const response = await api.get(`/vulns/templates/${id}`);
+    return response.data;
+  },
+
+  // 创建漏洞模板
+  createTemplate: async (data: FindingTemplateRequest): Promise<ApiResponse<FindingTemplate>> => {
+    const response = await api.post('/vulns/templates', data);
+    return response.data;
+  },
+
+  // 修改漏洞模板
+  updateTemplate: async (id: number, data: FindingTemplateRequest): Promise<ApiResponse<FindingTemplate>> => {
+    const response = await api.put(`/vulns/templates/${id}`, data);
+    return response.data;
+  },
+
+  // 删除漏洞模板
+  deleteTemplate: async (id: number): Promise<ApiResponse<null>> => {
+    const response = await api.delete(`/vulns/templates/${id}`);
+    return response.data;
+  },
+
+  // 按资产和漏洞地址渲染模板，返回预填的提交漏洞表单
+  renderTemplate: async (id: number, data: { asset_id: number; vuln_url?: string; project_id?: number }): Promise<ApiResponse<VulnCreateRequest>> => {
+    const response = await api.post(`/vulns/templates/${id}/render`, data);
+    return response.data;
+  },
+
+  // 将漏洞转换为模板
+  saveAsTemplate: async (id: number, data: { name: string; scope?: 'personal' | 'shared' }): Promise<ApiResponse<FindingTemplate>> => {
+    const response = await api.post(`/vulns/${id}/template`, data);
+    return response.data;
+  },
+};
+
+// 知识库API
+export const knowledgeApi = {
+  // 获取知识库文章列表，关键词全文检索
+  getKnowledgeList: async (params?: KnowledgeListParams): Promise<ApiResponse<{ articles: KnowledgeArticle[]; total: number; page: number; page_size: number }>> => {
+    const response = await api.get('/knowledge', { params });
+    return response.data;
+  },
+
+  // 按漏洞类型、分类和CWE编号匹配文章，用于预填修复建议
+  matchKnowledge: async (params: { vuln_type?: string; category_id?: number; cwe_id?: string }): Promise<ApiResponse<KnowledgeArticle>> => {
+    const response = await api.get('/knowledge/match', { params });
+    return response.data;
+  },
+
+  // 获取文章详情
+  getKnowledge: async (id: number): Promise<ApiResponse<KnowledgeArticle>> => {
+    const response = await api.get(`/knowledge/${id}`);
+    return response.data;
+  },
+
+  // 创建文章
+  createKnowledge: async (data: KnowledgeArticleRequest): Promise<ApiResponse<KnowledgeArticle>> => {
+    const response = await api.post('/knowledge', data);
+    return response.data;
+  },
+
+  // 修改文章，保存为新版本
+  updateKnowledge: async (id: number, data: KnowledgeArticleRequest): Promise<ApiResponse<KnowledgeArticle>> => {
+    const response = await api.put(`/knowledge/${id}`, data);
+    return response.data;
+  },
+
+  // 删除文章
+  deleteKnowledge: async (id: number): Promise<ApiResponse<null>> => {
+    const response = await api.delete(`/knowledge/${id}`);
+    return response.data;
+  },
+
+  // 获取历史版本列表
+  getVersions: async (id: number): Promise<ApiResponse<KnowledgeArticleVersion[]>> => {
+    const response = await api.get(`/knowledge/${id}/versions`);
+    return response.data;
+  },
+
+  // 获取指定版本内容
+  getVersion: async (id: number, version: number): Promise<ApiResponse<KnowledgeArticleVersion>> => {
+    const response = await api.get(`/knowledge/${id}/versions/${version}`);
+    return response.data;
+  },
+
+  // 恢复到指定版本
+  restoreVersion: async (id: number, version: number): Promise<ApiResponse<KnowledgeArticle>> => {
+    const response = await api.post(`/knowledge/${id}/versions/${version}/restore`);
+    return response.data;
+  },
 };
 
 